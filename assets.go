@@ -0,0 +1,11 @@
+// Package llmrpg embeds the default world so the server can run with no external data files.
+package llmrpg
+
+import "embed"
+
+// DefaultWorld holds the bundled world: locations, themes, scenarios, endings, items,
+// NPCs, world events, abilities, perks, bestiary, loot tables, prompts, few-shot examples and lore.
+// Paths inside the FS mirror the repository layout (e.g. "data/locations/oakhaven_gate.json").
+//
+//go:embed data/locations data/themes data/scenarios data/endings data/items data/npcs data/events data/abilities data/perks data/bestiary data/loot data/prompts data/examples data/lore
+var DefaultWorld embed.FS
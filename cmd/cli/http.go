@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"llmrpg/internal/narrative"
+)
+
+// httpBackend plays against a running server.
+type httpBackend struct {
+	baseURL string
+	client  *http.Client
+	token   string // Session access token, from CreateSession or -token
+}
+
+func newHTTPBackend(baseURL string) *httpBackend {
+	return &httpBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 2 * time.Minute}, // Turns wait on the LLM
+	}
+}
+
+func (b *httpBackend) CreateSession(playerName, startLocationID string) (string, error) {
+	var created struct {
+		ID          string `json:"id"`
+		AccessToken string `json:"accessToken"`
+	}
+	err := b.post("/v1/create_session", map[string]string{
+		"playerName":      playerName,
+		"startLocationId": startLocationID,
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+	b.token = created.AccessToken
+	return created.ID, nil
+}
+
+func (b *httpBackend) Act(sessionID, input string) (*narrative.TurnEnvelope, error) {
+	var env narrative.TurnEnvelope
+	if err := b.post("/v1/action?sessionId="+url.QueryEscape(sessionID), map[string]string{"input": input}, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// post sends body as JSON and decodes a successful response into v.
+func (b *httpBackend) post(path string, body, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, b.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.token != "" {
+		req.Header.Set("X-Session-Token", b.token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"llmrpg"
+	"llmrpg/internal/character"
+	"llmrpg/internal/ids"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/narrative"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// localBackend runs the engine in-process, bypassing HTTP.
+type localBackend struct {
+	world    world.WorldSystem
+	sessions *session.InMemorySessionManager
+	engine   *narrative.NarrativeEngine
+}
+
+// newLocalBackend builds a minimal engine: the world from LOCATION_DATA_PATH/THEME_DATA_PATH
+// (or the embedded default world), the embedded system prompt, and the LLM provider
+// configured as for the server (LLM_PROVIDER, GEMINI_*/OPENAI_*).
+func newLocalBackend() (*localBackend, error) {
+	locFS, themeFS, err := localWorldFS()
+	if err != nil {
+		return nil, err
+	}
+	ws := world.NewInMemoryWorldSystem()
+	if err := ws.LoadWorldData(locFS, themeFS); err != nil {
+		return nil, fmt.Errorf("failed to load world data: %w", err)
+	}
+
+	adapter, err := localAdapter()
+	if err != nil {
+		return nil, err
+	}
+	if configurable, ok := adapter.(llm.SchemaConfigurable); ok && os.Getenv("LLM_RESPONSE_SCHEMA") != "false" {
+		configurable.SetResponseSchema(narrative.ActionResponseSchema())
+	}
+
+	systemPrompt, err := llm.LoadSystemPrompt(llmrpg.DefaultWorld, "data/prompts/system_prompt.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedded system prompt: %w", err)
+	}
+
+	sessions := session.NewInMemorySessionManager()
+	engine, err := narrative.NewNarrativeEngine(ws, adapter, narrative.NewSimpleActionExecutor(ws), sessions, systemPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create narrative engine: %w", err)
+	}
+	return &localBackend{world: ws, sessions: sessions, engine: engine}, nil
+}
+
+func localWorldFS() (fs.FS, fs.FS, error) {
+	locPath, themePath := os.Getenv("LOCATION_DATA_PATH"), os.Getenv("THEME_DATA_PATH")
+	if locPath != "" && themePath != "" {
+		return os.DirFS(locPath), os.DirFS(themePath), nil
+	}
+	locFS, err := fs.Sub(llmrpg.DefaultWorld, "data/locations")
+	if err != nil {
+		return nil, nil, err
+	}
+	themeFS, err := fs.Sub(llmrpg.DefaultWorld, "data/themes")
+	if err != nil {
+		return nil, nil, err
+	}
+	return locFS, themeFS, nil
+}
+
+// localAdapter supports the API-key providers; Vertex AI needs the server.
+func localAdapter() (llm.Adapter, error) {
+	httpClient, err := llm.NewHTTPClient(llm.DefaultHTTPClientConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build LLM HTTP client: %w", err)
+	}
+	switch provider := os.Getenv("LLM_PROVIDER"); provider {
+	case "", "gemini":
+		modelName := os.Getenv("GEMINI_MODEL_NAME")
+		if modelName == "" {
+			modelName = "gemini-1.5-flash-latest"
+		}
+		return llm.NewGeminiAdapter(modelName, httpClient), nil
+	case "openai":
+		baseURL := os.Getenv("OPENAI_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return llm.NewOpenAICompatibleAdapter(baseURL, os.Getenv("OPENAI_MODEL_NAME"), os.Getenv("OPENAI_API_KEY"), httpClient)
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER '%s' (expected 'gemini' or 'openai')", provider)
+	}
+}
+
+func (b *localBackend) CreateSession(playerName, startLocationID string) (string, error) {
+	if _, err := b.world.GetLocation(startLocationID); err != nil {
+		return "", fmt.Errorf("invalid start location ID '%s': %w", startLocationID, err)
+	}
+	player := character.NewCharacter(ids.New(), playerName, "", "")
+	sess, err := b.sessions.CreateNewSession(player, startLocationID)
+	if err != nil {
+		return "", err
+	}
+	return sess.ID, nil
+}
+
+func (b *localBackend) Act(sessionID, input string) (*narrative.TurnEnvelope, error) {
+	resp, err := b.engine.ProcessPlayerInput(context.Background(), sessionID, input)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := b.sessions.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return narrative.NewTurnEnvelope(sess, b.world, resp), nil
+}
@@ -0,0 +1,180 @@
+// Command cli is a terminal client for playing and debugging llmrpg.
+//
+// By default it talks to a running server over HTTP. With -local it builds the
+// engine in-process instead (embedded world, LLM provider from the same environment
+// variables as the server), which is handy for development without a server.
+//
+// Input is read line by line from stdin (or -script), so a file of player inputs
+// doubles as a scripted smoke test:
+//
+//	go run ./cmd/cli -server http://localhost:8080 -script smoke.txt -json
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"llmrpg/internal/narrative"
+)
+
+// backend creates sessions and plays turns, over HTTP or in-process.
+type backend interface {
+	CreateSession(playerName, startLocationID string) (string, error)
+	Act(sessionID, input string) (*narrative.TurnEnvelope, error)
+}
+
+func main() {
+	serverURL := flag.String("server", "http://localhost:8080", "Base URL of the llmrpg server")
+	local := flag.Bool("local", false, "Run the engine in-process instead of calling a server")
+	sessionID := flag.String("session", "", "Resume an existing session instead of creating one")
+	token := flag.String("token", "", "Access token of the session given with -session (server mode)")
+	playerName := flag.String("name", "Ash", "Character name for a new session")
+	startLocation := flag.String("start", "oakhaven_gate", "Start location ID for a new session")
+	scriptPath := flag.String("script", "", "Read player inputs from this file instead of stdin")
+	jsonOutput := flag.Bool("json", false, "Print each turn as a JSON line instead of rendered text")
+	flag.Parse()
+
+	_ = godotenv.Load() // Optional, as for the server
+
+	out := os.Stdout
+	var b backend
+	if *local {
+		os.Stdout = os.Stderr // Engine progress logs go to stderr, keeping stdout for turns (and -json parseable)
+		lb, err := newLocalBackend()
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		b = lb
+	} else {
+		hb := newHTTPBackend(*serverURL)
+		hb.token = *token
+		b = hb
+	}
+
+	input := io.Reader(os.Stdin)
+	if *scriptPath != "" {
+		f, err := os.Open(*scriptPath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open script: %v", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	if *sessionID == "" {
+		id, err := b.CreateSession(*playerName, *startLocation)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to create session: %v", err)
+		}
+		*sessionID = id
+		if hb, ok := b.(*httpBackend); ok && !*jsonOutput {
+			fmt.Fprintf(out, "Resume later with -session %s -token %s\n", id, hb.token)
+		}
+	}
+	if !*jsonOutput {
+		fmt.Fprintf(out, "Session %s. Type an action, a suggestion number, or /quit.\n", *sessionID)
+	}
+
+	if err := play(b, *sessionID, input, out, *jsonOutput, *scriptPath == ""); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+}
+
+// play reads inputs until EOF or /quit, rendering each turn to out. In interactive
+// mode failed turns are reported and play continues; in scripted mode they abort,
+// so a smoke test exits non-zero.
+func play(b backend, sessionID string, in io.Reader, out io.Writer, jsonOutput, interactive bool) error {
+	scanner := bufio.NewScanner(in)
+	var suggestions []string
+	for {
+		if interactive && !jsonOutput {
+			fmt.Fprint(out, "> ")
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue // Blank lines and comments (for scripts)
+		}
+		if line == "/quit" || line == "/exit" {
+			return nil
+		}
+		// A bare number picks one of the previous turn's suggestions
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(suggestions) {
+			line = suggestions[n-1]
+			if !jsonOutput {
+				fmt.Fprintf(out, "(%s)\n", line)
+			}
+		}
+
+		env, err := b.Act(sessionID, line)
+		if err != nil {
+			if !interactive {
+				return fmt.Errorf("turn failed for input %q: %w", line, err)
+			}
+			fmt.Fprintf(out, "Error: %v\n", err)
+			continue
+		}
+		suggestions = env.Suggestions
+		if jsonOutput {
+			if err := json.NewEncoder(out).Encode(env); err != nil {
+				return err
+			}
+			continue
+		}
+		render(out, env)
+	}
+}
+
+// render prints a turn in a readable form.
+func render(out io.Writer, env *narrative.TurnEnvelope) {
+	fmt.Fprintf(out, "\n[Turn %d — %s]\n", env.TurnNumber, locationLabel(env.State))
+	fmt.Fprintf(out, "%s\n", env.Narrative)
+	for _, roll := range env.Rolls {
+		line := fmt.Sprintf("  roll %s %s = %d", roll.Label, roll.Notation, roll.Total)
+		if roll.Success != nil {
+			outcome := "failure"
+			if *roll.Success {
+				outcome = "success"
+			}
+			line += fmt.Sprintf(" vs DC %d: %s", roll.Target, outcome)
+		}
+		fmt.Fprintln(out, line)
+	}
+	for _, event := range env.Events {
+		fmt.Fprintf(out, "  * %s %v\n", event.Type, event.Data)
+	}
+	for _, warning := range env.Warnings {
+		fmt.Fprintf(out, "  ! %s\n", warning)
+	}
+	if env.Ending != nil {
+		fmt.Fprintf(out, "\n=== %s ===\n%s\n", env.Ending.Name, env.Ending.Epilogue)
+		recap := env.Ending.Recap
+		fmt.Fprintf(out, "Turns: %d  Locations visited: %d  Items gained: %d  NPCs met: %d  Tokens: %d\n",
+			recap.TurnsTaken, len(recap.LocationsVisited), len(recap.ItemsGained), len(recap.NPCsMet), recap.TotalTokens)
+	}
+	if len(env.Suggestions) > 0 {
+		fmt.Fprintln(out, "Suggestions:")
+		for i, s := range env.Suggestions {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, s)
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+func locationLabel(state narrative.TurnStateSummary) string {
+	if state.LocationName != "" {
+		return state.LocationName
+	}
+	return state.LocationID
+}
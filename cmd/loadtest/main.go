@@ -0,0 +1,326 @@
+// Command loadtest drives simulated players against a running llmrpg server and
+// reports turn latency and error rates, for capacity planning.
+//
+// Each simulated session is created with /v1/create_session and then plays -turns turns
+// through /v1/action, cycling through the inputs in -inputs (one per line; blank lines
+// and # comments are skipped) or a built-in list. Run the server with
+// LLM_PROVIDER=mock to measure the turn pipeline without provider latency, or against a
+// live provider with -rate to stay under its quota:
+//
+//	LLM_PROVIDER=mock go run ./cmd/server &
+//	go run ./cmd/loadtest -sessions 50 -turns 20
+//	go run ./cmd/loadtest -sessions 10 -turns 5 -rate 2 -json
+//
+// The server's own RATE_LIMIT_PER_MINUTE applies per client address, so leave it
+// unset on the server under test. The command exits non-zero if any request failed.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultInputs is what each simulated player types when no -inputs file is given.
+var defaultInputs = []string{
+	"I look around.",
+	"I walk to the town square.",
+	"I ask a passerby for the latest news.",
+	"I check my pack.",
+	"I head back to the gate.",
+}
+
+// sample is the outcome of one request.
+type sample struct {
+	kind    string // "create" or "turn"
+	latency time.Duration
+	status  int   // HTTP status; 0 when the request didn't complete
+	err     error // Transport or decode failure, or a non-2xx status
+}
+
+// client plays sessions against the server under test.
+type client struct {
+	baseURL string
+	http    *http.Client
+	tokens  <-chan time.Time // Paces turns across all sessions; nil = unpaced
+}
+
+func main() {
+	serverURL := flag.String("server", "http://localhost:8080", "Base URL of the llmrpg server")
+	sessions := flag.Int("sessions", 10, "Simulated sessions, played concurrently")
+	turns := flag.Int("turns", 10, "Turns each session plays")
+	rate := flag.Float64("rate", 0, "Turns per second across all sessions (0 = as fast as possible)")
+	startLocation := flag.String("start", "oakhaven_gate", "Start location ID for the sessions")
+	inputsPath := flag.String("inputs", "", "File of player inputs to cycle through, one per line")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Per-request timeout")
+	jsonOutput := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	if *sessions < 1 || *turns < 0 {
+		log.Fatal("FATAL: -sessions must be at least 1 and -turns non-negative")
+	}
+	inputs := defaultInputs
+	if *inputsPath != "" {
+		loaded, err := loadInputs(*inputsPath)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		inputs = loaded
+	}
+
+	c := &client{
+		baseURL: strings.TrimRight(*serverURL, "/"),
+		http:    &http.Client{Timeout: *timeout},
+	}
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		c.tokens = ticker.C
+	}
+
+	samples := make(chan sample)
+	var wg sync.WaitGroup
+	started := time.Now()
+	for i := 0; i < *sessions; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			c.playSession(n, *startLocation, inputs, *turns, samples)
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	var collected []sample
+	for s := range samples {
+		collected = append(collected, s)
+	}
+	rep := newReport(collected, time.Since(started))
+
+	if *jsonOutput {
+		if err := json.NewEncoder(os.Stdout).Encode(rep); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+	} else {
+		rep.print(os.Stdout)
+	}
+	if rep.Create.Errors > 0 || rep.Turn.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// playSession creates one session and plays its turns, sending every request's outcome
+// to samples. A session that fails to be created plays no turns.
+func (c *client) playSession(n int, startLocationID string, inputs []string, turns int, samples chan<- sample) {
+	var created struct {
+		ID          string `json:"id"`
+		AccessToken string `json:"accessToken"`
+	}
+	s := c.post("create", "/v1/create_session", "", map[string]string{
+		"playerName":      fmt.Sprintf("Loadtest %d", n+1),
+		"startLocationId": startLocationID,
+	}, &created)
+	samples <- s
+	if s.err != nil {
+		return
+	}
+
+	path := "/v1/action?sessionId=" + url.QueryEscape(created.ID)
+	for t := 0; t < turns; t++ {
+		if c.tokens != nil {
+			<-c.tokens
+		}
+		input := inputs[(n+t)%len(inputs)] // Offset per session so they don't all send the same input at once
+		samples <- c.post("turn", path, created.AccessToken, map[string]string{"input": input}, nil)
+	}
+}
+
+// post sends body as JSON and times the request; a successful response is decoded into
+// v unless it's nil.
+func (c *client) post(kind, path, token string, body, v interface{}) sample {
+	s := sample{kind: kind}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		s.err = err
+		return s
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Session-Token", token)
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		s.latency = time.Since(start)
+		s.err = fmt.Errorf("request to %s failed: %w", path, err)
+		return s
+	}
+	defer resp.Body.Close()
+	s.status = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		s.latency = time.Since(start)
+		s.err = fmt.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+		return s
+	}
+	if v != nil {
+		err = json.NewDecoder(resp.Body).Decode(v)
+	} else {
+		_, err = io.Copy(io.Discard, resp.Body) // Time the whole response, not just the headers
+	}
+	s.latency = time.Since(start)
+	if err != nil {
+		s.err = fmt.Errorf("failed to read response from %s: %w", path, err)
+	}
+	return s
+}
+
+// loadInputs reads player inputs from a file, one per line.
+func loadInputs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inputs: %w", err)
+	}
+	defer f.Close()
+	var inputs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			inputs = append(inputs, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inputs: %w", err)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no inputs in %s", path)
+	}
+	return inputs, nil
+}
+
+// --- Report ---
+
+// report summarizes a run.
+type report struct {
+	Duration       string  `json:"duration"`
+	TurnsPerSecond float64 `json:"turnsPerSecond"` // Successful turns over the whole run
+	Create         stats   `json:"create"`
+	Turn           stats   `json:"turn"`
+}
+
+// stats summarizes one kind of request. Latencies are in milliseconds and cover
+// successful requests only.
+type stats struct {
+	Requests  int            `json:"requests"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"errorRate"`
+	ByStatus  map[string]int `json:"byStatus,omitempty"` // Failed requests by HTTP status ("transport" = no response)
+	P50Ms     float64        `json:"p50Ms"`
+	P95Ms     float64        `json:"p95Ms"`
+	P99Ms     float64        `json:"p99Ms"`
+	MaxMs     float64        `json:"maxMs"`
+	FirstErr  string         `json:"firstError,omitempty"`
+}
+
+func newReport(samples []sample, elapsed time.Duration) report {
+	rep := report{
+		Duration: elapsed.Round(time.Millisecond).String(),
+		Create:   summarize(samples, "create"),
+		Turn:     summarize(samples, "turn"),
+	}
+	if elapsed > 0 {
+		rep.TurnsPerSecond = float64(rep.Turn.Requests-rep.Turn.Errors) / elapsed.Seconds()
+	}
+	return rep
+}
+
+func summarize(samples []sample, kind string) stats {
+	var st stats
+	var latencies []time.Duration
+	for _, s := range samples {
+		if s.kind != kind {
+			continue
+		}
+		st.Requests++
+		if s.err == nil {
+			latencies = append(latencies, s.latency)
+			continue
+		}
+		st.Errors++
+		if st.FirstErr == "" {
+			st.FirstErr = s.err.Error()
+		}
+		if st.ByStatus == nil {
+			st.ByStatus = make(map[string]int)
+		}
+		key := "transport"
+		if s.status != 0 {
+			key = fmt.Sprint(s.status)
+		}
+		st.ByStatus[key]++
+	}
+	if st.Requests > 0 {
+		st.ErrorRate = float64(st.Errors) / float64(st.Requests)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	st.P50Ms = percentile(latencies, 50)
+	st.P95Ms = percentile(latencies, 95)
+	st.P99Ms = percentile(latencies, 99)
+	st.MaxMs = percentile(latencies, 100)
+	return st
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted latencies in milliseconds.
+func percentile(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1].Microseconds()) / 1000
+}
+
+func (rep report) print(out io.Writer) {
+	fmt.Fprintf(out, "Run took %s, %.2f successful turns/s\n", rep.Duration, rep.TurnsPerSecond)
+	for _, row := range []struct {
+		name string
+		st   stats
+	}{{"create_session", rep.Create}, {"action", rep.Turn}} {
+		st := row.st
+		fmt.Fprintf(out, "%-15s %5d requests  %5.1f%% errors  p50 %8.1fms  p95 %8.1fms  p99 %8.1fms  max %8.1fms\n",
+			row.name, st.Requests, st.ErrorRate*100, st.P50Ms, st.P95Ms, st.P99Ms, st.MaxMs)
+		statuses := make([]string, 0, len(st.ByStatus))
+		for status := range st.ByStatus {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(out, "    %s: %d\n", status, st.ByStatus[status])
+		}
+		if st.FirstErr != "" {
+			fmt.Fprintf(out, "    first error: %s\n", st.FirstErr)
+		}
+	}
+}
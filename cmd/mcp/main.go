@@ -0,0 +1,154 @@
+// Command mcp exposes llmrpg's game tools over the Model Context Protocol, so LLM
+// agents and IDE assistants can play and test sessions: start a session, act, move,
+// inspect the scene and check the inventory. It speaks MCP's stdio transport
+// (newline-delimited JSON-RPC 2.0 on stdin/stdout; logs go to stderr) and plays
+// against a running server over its /v1 API, like cmd/cli.
+//
+// Register it with an MCP client as a stdio server, e.g.:
+//
+//	{"command": "go", "args": ["run", "./cmd/mcp", "-server", "http://localhost:8080"]}
+//
+// Sessions started through the tools are remembered with their access tokens for the
+// life of the process; -session and -token make an existing session playable too.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"sync"
+)
+
+// protocolVersion is the newest MCP revision this server implements; it also accepts
+// clients that ask for an older one it knows.
+const protocolVersion = "2025-06-18"
+
+var supportedProtocolVersions = map[string]bool{"2025-06-18": true, "2025-03-26": true, "2024-11-05": true}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func main() {
+	serverURL := flag.String("server", "http://localhost:8080", "Base URL of the llmrpg server")
+	startLocation := flag.String("start", "oakhaven_gate", "Start location ID for sessions that don't choose one")
+	sessionID := flag.String("session", "", "Existing session to make playable")
+	token := flag.String("token", "", "Access token of the session given with -session")
+	flag.Parse()
+
+	g := newGame(*serverURL, *startLocation)
+	if *sessionID != "" {
+		g.remember(*sessionID, *token)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	var writeMu sync.Mutex
+	send := func(resp rpcResponse) {
+		resp.JSONRPC = "2.0"
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if err := out.Encode(resp); err != nil {
+			log.Fatalf("FATAL: Failed to write response: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64<<10), 4<<20)
+	var wg sync.WaitGroup
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			send(rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if req.ID == nil {
+			continue // Notifications (initialized, cancelled...) need no answer
+		}
+		// Turns wait on the LLM; handle each request on its own so pings still answer
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rpcErr := g.handle(req)
+			send(rpcResponse{ID: req.ID, Result: result, Error: rpcErr})
+		}()
+	}
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("FATAL: Failed to read stdin: %v", err)
+	}
+}
+
+// handle answers one request.
+func (g *game) handle(req rpcRequest) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "jsonrpc must be \"2.0\""}
+	}
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := protocolVersion
+		if supportedProtocolVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "llmrpg", "version": "1.0.0"},
+			"instructions":    "Play llmrpg sessions: start_session, then act or move each turn; inspect and inventory read the state without taking a turn.",
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": toolDefinitions}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		tool, ok := tools[params.Name]
+		if !ok {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		return tool(g, params.Arguments), nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
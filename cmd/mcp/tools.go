@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llmrpg/internal/api"
+	"llmrpg/internal/narrative"
+)
+
+// --- Tools ---
+
+// toolDefinition is a tool as tools/list describes it.
+type toolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"` // JSON Schema of the arguments
+}
+
+// toolResult is a tools/call result: a readable summary for the model, the same data
+// as JSON for programs, and whether the call failed.
+type toolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+type textContent struct {
+	Type string `json:"type"` // Always "text"
+	Text string `json:"text"`
+}
+
+func textResult(text string, structured interface{}) toolResult {
+	return toolResult{Content: []textContent{{Type: "text", Text: text}}, StructuredContent: structured}
+}
+
+// errorResult reports a failed call to the model, which can correct it and retry.
+func errorResult(err error) toolResult {
+	return toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}
+}
+
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+var sessionIDProperty = stringProperty("ID of a session from start_session")
+
+var toolDefinitions = []toolDefinition{
+	{
+		Name:        "start_session",
+		Description: "Create a new game session and return its ID and opening state. Against a server in DETERMINISTIC mode, pass a seed to reproduce another playthrough's dice.",
+		InputSchema: objectSchema([]string{"playerName"}, map[string]interface{}{
+			"playerName":      stringProperty("The player character's name"),
+			"startLocationId": stringProperty("Location to start at (default: the scenario's start, or the server's -start flag)"),
+			"className":       stringProperty("Character class (optional)"),
+			"originName":      stringProperty("Character origin (optional)"),
+			"scenarioId":      stringProperty("Guided opening to play first (optional)"),
+			"worldId":         stringProperty("Hosted world to play (default: the server's default world)"),
+			"seed":            stringProperty("Dice seed as a decimal string; only accepted by servers in DETERMINISTIC mode (default: random)"),
+		}),
+	},
+	{
+		Name:        "act",
+		Description: "Play one turn: the player character does or says input, in their own words. Returns the narration, dice rolls, events and suggested next actions.",
+		InputSchema: objectSchema([]string{"sessionId", "input"}, map[string]interface{}{
+			"sessionId": sessionIDProperty,
+			"input":     stringProperty("What the character does or says, e.g. \"I ask the guard about the road north\""),
+		}),
+	},
+	{
+		Name:        "move",
+		Description: "Play one turn walking to a neighbouring location. inspect lists the exits.",
+		InputSchema: objectSchema([]string{"sessionId", "destination"}, map[string]interface{}{
+			"sessionId":   sessionIDProperty,
+			"destination": stringProperty("Name or ID of an exit from the current location"),
+		}),
+	},
+	{
+		Name:        "inspect",
+		Description: "Describe the session's current scene without taking a turn: location, exits, health, combat, actions waiting for confirmation and whether the session has ended.",
+		InputSchema: objectSchema([]string{"sessionId"}, map[string]interface{}{"sessionId": sessionIDProperty}),
+	},
+	{
+		Name:        "inventory",
+		Description: "List what the player character carries and their condition, without taking a turn.",
+		InputSchema: objectSchema([]string{"sessionId"}, map[string]interface{}{"sessionId": sessionIDProperty}),
+	},
+}
+
+// tools maps tool names to their implementations.
+var tools = map[string]func(g *game, args json.RawMessage) toolResult{
+	"start_session": (*game).startSession,
+	"act":           (*game).act,
+	"move":          (*game).move,
+	"inspect":       (*game).inspect,
+	"inventory":     (*game).inventory,
+}
+
+// --- Game Client ---
+
+// game plays sessions against the server, keeping the access token of each session
+// it knows.
+type game struct {
+	baseURL       string
+	client        *http.Client
+	startLocation string // For sessions that name neither a start location nor a scenario
+
+	mu     sync.Mutex
+	tokens map[string]string // Session ID -> access token
+}
+
+func newGame(baseURL, startLocation string) *game {
+	return &game{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		startLocation: startLocation,
+		client:        &http.Client{Timeout: 2 * time.Minute}, // Turns wait on the LLM
+		tokens:        make(map[string]string),
+	}
+}
+
+func (g *game) remember(sessionID, token string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tokens[sessionID] = token
+}
+
+func (g *game) token(sessionID string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	token, ok := g.tokens[sessionID]
+	if !ok {
+		return "", fmt.Errorf("unknown session '%s': start one with start_session (or pass -session and -token)", sessionID)
+	}
+	return token, nil
+}
+
+func (g *game) startSession(args json.RawMessage) toolResult {
+	var req struct {
+		PlayerName      string `json:"playerName"`
+		StartLocationID string `json:"startLocationId,omitempty"`
+		ClassName       string `json:"className,omitempty"`
+		OriginName      string `json:"originName,omitempty"`
+		ScenarioID      string `json:"scenarioId,omitempty"`
+		WorldID         string `json:"worldId,omitempty"`
+		Seed            string `json:"seed,omitempty"`
+	}
+	if err := json.Unmarshal(args, &req); err != nil {
+		return errorResult(fmt.Errorf("invalid arguments: %w", err))
+	}
+	if req.StartLocationID == "" && req.ScenarioID == "" {
+		req.StartLocationID = g.startLocation
+	}
+	var created api.CreatedSession
+	if err := g.call(http.MethodPost, "/v1/create_session", "", req, &created); err != nil {
+		return errorResult(err)
+	}
+	g.remember(created.ID, created.AccessToken)
+	created.AccessToken = "" // Kept here; the model doesn't need it
+	return textResult(fmt.Sprintf("Started session %s.\n%s", created.ID, describeScene(&created.SessionState)), created.SessionState)
+}
+
+func (g *game) act(args json.RawMessage) toolResult {
+	var req struct {
+		SessionID string `json:"sessionId"`
+		Input     string `json:"input"`
+	}
+	if err := json.Unmarshal(args, &req); err != nil {
+		return errorResult(fmt.Errorf("invalid arguments: %w", err))
+	}
+	return g.playTurn(req.SessionID, req.Input)
+}
+
+func (g *game) move(args json.RawMessage) toolResult {
+	var req struct {
+		SessionID   string `json:"sessionId"`
+		Destination string `json:"destination"`
+	}
+	if err := json.Unmarshal(args, &req); err != nil {
+		return errorResult(fmt.Errorf("invalid arguments: %w", err))
+	}
+	if strings.TrimSpace(req.Destination) == "" {
+		return errorResult(fmt.Errorf("destination is required"))
+	}
+	return g.playTurn(req.SessionID, "I go to "+req.Destination)
+}
+
+func (g *game) playTurn(sessionID, input string) toolResult {
+	token, err := g.token(sessionID)
+	if err != nil {
+		return errorResult(err)
+	}
+	var env narrative.TurnEnvelope
+	if err := g.call(http.MethodPost, "/v1/action?sessionId="+url.QueryEscape(sessionID), token, map[string]string{"input": input}, &env); err != nil {
+		return errorResult(err)
+	}
+	return textResult(describeTurn(&env), env)
+}
+
+func (g *game) inspect(args json.RawMessage) toolResult {
+	state, err := g.state(args, "location,character,combat,pending,challenge,progress")
+	if err != nil {
+		return errorResult(err)
+	}
+	return textResult(describeScene(state), state)
+}
+
+func (g *game) inventory(args json.RawMessage) toolResult {
+	state, err := g.state(args, "character")
+	if err != nil {
+		return errorResult(err)
+	}
+	return textResult(describeInventory(state.Character), state.Character)
+}
+
+// state reads the sessionId argument's session, projected to include's sections.
+func (g *game) state(args json.RawMessage, include string) (*api.SessionState, error) {
+	var req struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	token, err := g.token(req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	var state api.SessionState
+	path := fmt.Sprintf("/v1/state?sessionId=%s&include=%s", url.QueryEscape(req.SessionID), include)
+	if err := g.call(http.MethodGet, path, token, nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// call sends body (if any) as JSON and decodes a successful response into v.
+func (g *game) call(method, path, token string, body, v interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, g.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Session-Token", token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// --- Summaries ---
+
+// describeTurn renders a turn for the model, like cmd/cli does for a terminal.
+func describeTurn(env *narrative.TurnEnvelope) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Turn %d at %s (HP %d/%d, %s).\n%s\n", env.TurnNumber, orID(env.State.LocationName, env.State.LocationID),
+		env.State.HP, env.State.MaxHP, env.State.Status, env.Narrative)
+	for _, roll := range env.Rolls {
+		fmt.Fprintf(&b, "Roll %s %s = %d", roll.Label, roll.Notation, roll.Total)
+		if roll.Success != nil {
+			outcome := "failure"
+			if *roll.Success {
+				outcome = "success"
+			}
+			fmt.Fprintf(&b, " vs DC %d: %s", roll.Target, outcome)
+		}
+		b.WriteString("\n")
+	}
+	for _, event := range env.Events {
+		fmt.Fprintf(&b, "Event: %s %v\n", event.Type, event.Data)
+	}
+	for _, warning := range env.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", warning)
+	}
+	for _, pending := range env.Pending {
+		fmt.Fprintf(&b, "Awaiting confirmation: %s %s\n", pending.Type, pending.Reason)
+	}
+	if env.Ending != nil {
+		fmt.Fprintf(&b, "The story has ended: %s. %s\n", env.Ending.Name, env.Ending.Epilogue)
+	}
+	if len(env.Suggestions) > 0 {
+		fmt.Fprintf(&b, "Suggestions: %s\n", strings.Join(env.Suggestions, "; "))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// describeScene renders a session's current scene for the model.
+func describeScene(state *api.SessionState) string {
+	var b strings.Builder
+	if loc := state.CurrentLocation; loc != nil {
+		fmt.Fprintf(&b, "Location: %s (%s). %s\n", loc.Name, loc.ID, loc.Description)
+		if len(loc.AdjacentIDs) > 0 {
+			fmt.Fprintf(&b, "Exits: %s\n", strings.Join(loc.AdjacentIDs, ", "))
+		}
+	} else {
+		fmt.Fprintf(&b, "Location: %s\n", state.CurrentLocationID)
+	}
+	if c := state.Character; c != nil {
+		fmt.Fprintf(&b, "%s: level %d, HP %d/%d, MP %d/%d\n", c.Name, c.Level, c.HP, c.MaxHP, c.MP, c.MaxMP)
+	}
+	if state.Combat != nil {
+		b.WriteString("In combat.\n")
+	}
+	if state.PendingChallenge != nil {
+		b.WriteString("A challenge is waiting to be resolved.\n")
+	}
+	for _, pending := range state.PendingActions {
+		fmt.Fprintf(&b, "Awaiting confirmation: %s %s\n", pending.Type, pending.Reason)
+	}
+	if state.Ending != nil {
+		fmt.Fprintf(&b, "The story has ended: %s.\n", state.Ending.Name)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// describeInventory renders a character's belongings and condition for the model.
+func describeInventory(c *api.Character) string {
+	if c == nil {
+		return "No character."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: HP %d/%d, MP %d/%d\n", c.Name, c.HP, c.MaxHP, c.MP, c.MaxMP)
+	if len(c.Inventory) == 0 {
+		b.WriteString("Carrying nothing.\n")
+	} else {
+		items := make([]string, 0, len(c.Inventory))
+		for id, count := range c.Inventory {
+			items = append(items, fmt.Sprintf("%s x%d", id, count))
+		}
+		sort.Strings(items)
+		fmt.Fprintf(&b, "Carrying: %s\n", strings.Join(items, ", "))
+	}
+	for _, effect := range c.Effects {
+		fmt.Fprintf(&b, "Effect: %s %s\n", effect.ID, effect.Description)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func orID(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
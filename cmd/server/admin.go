@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"llmrpg/internal/llm"
+	"llmrpg/internal/lore"
+	"llmrpg/internal/narrative"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// --- Admin Endpoints ---
+
+// maxWorldArchiveBytes caps uploaded world archives.
+const maxWorldArchiveBytes = 64 << 20
+
+// worldIDPattern restricts archive IDs to safe file names.
+var worldIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// adminMiddleware restricts a handler to requests carrying ADMIN_API_KEY, sent as
+// "Authorization: Bearer <key>". Admin endpoints are disabled when ADMIN_API_KEY is unset.
+func adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminKey := os.Getenv("ADMIN_API_KEY")
+		if adminKey == "" {
+			http.Error(w, "Admin API disabled (set ADMIN_API_KEY to enable)", http.StatusNotFound)
+			return
+		}
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminKey)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// openWorldArchive opens a .llmworld file, or an unpacked archive directory.
+// The returned close function must be called once the archive has been loaded.
+func openWorldArchive(path string) (*world.Archive, func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open world archive %s: %w", path, err)
+	}
+	if info.IsDir() {
+		archive, err := world.NewArchive(os.DirFS(path))
+		return archive, func() {}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open world archive %s: %w", path, err)
+	}
+	archive, err := world.OpenArchive(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return archive, func() { f.Close() }, nil
+}
+
+// archiveLore loads the archive's lore documents. Archives without a lore directory have none.
+func archiveLore(archive *world.Archive) ([]lore.Document, error) {
+	if _, err := fs.Stat(archive.FS, world.ArchiveLoreDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	loreFS, err := fs.Sub(archive.FS, world.ArchiveLoreDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", world.ArchiveLoreDir, err)
+	}
+	return lore.LoadDocuments(loreFS)
+}
+
+// worldArchiveDir is where uploaded archives are stored (WORLD_ARCHIVE_DIR, default data/worlds).
+func worldArchiveDir() string {
+	if dir := os.Getenv("WORLD_ARCHIVE_DIR"); dir != "" {
+		return dir
+	}
+	return "data/worlds"
+}
+
+// handleUploadWorld validates an uploaded .llmworld archive and stores it in the
+// archive directory. The body is either the raw zip or a multipart form with a "file" field.
+// Stored worlds can be served by pointing WORLD_ARCHIVE at them.
+func handleUploadWorld(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxWorldArchiveBytes)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	archive, err := world.OpenArchive(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loaded, err := archive.Validate()
+	if err == nil {
+		_, err = archiveLore(archive)
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": false, "error": err.Error()})
+		return
+	}
+	if !worldIDPattern.MatchString(archive.Manifest.ID) {
+		http.Error(w, fmt.Sprintf("Invalid world ID '%s': use letters, digits, '.', '_' and '-'", archive.Manifest.ID), http.StatusBadRequest)
+		return
+	}
+
+	dir := worldArchiveDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("ERROR [handleUploadWorld]: Failed to create archive directory %s: %v\n", dir, err)
+		http.Error(w, "Failed to store world archive.", http.StatusInternalServerError)
+		return
+	}
+	dest := filepath.Join(dir, archive.Manifest.ID+world.ArchiveExtension)
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		log.Printf("ERROR [handleUploadWorld]: Failed to write %s: %v\n", tmp, err)
+		http.Error(w, "Failed to store world archive.", http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		log.Printf("ERROR [handleUploadWorld]: Failed to store %s: %v\n", dest, err)
+		http.Error(w, "Failed to store world archive.", http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Stored world archive '%s' (%s) at %s\n", archive.Manifest.Name, archive.Manifest.ID, dest)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":     true,
+		"manifest":  archive.Manifest,
+		"locations": len(loaded.GetAllLocationIDs()),
+		"themes":    len(loaded.GetAllThemeIDs()),
+		"path":      dest,
+	})
+}
+
+// handleExportWorld downloads a hosted world (?worldId=, default the default world) as
+// a .llmworld archive. Query parameters id, name and startLocationId fill in the manifest.
+func handleExportWorld(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	manifest := world.Manifest{
+		ID:              query.Get("id"),
+		Name:            query.Get("name"),
+		Version:         query.Get("version"),
+		StartLocationID: query.Get("startLocationId"),
+		ContentRating:   string(hw.Rating),
+	}
+	if manifest.ID == "" {
+		manifest.ID = "world"
+	}
+	if !worldIDPattern.MatchString(manifest.ID) {
+		http.Error(w, fmt.Sprintf("Invalid world ID '%s'", manifest.ID), http.StatusBadRequest)
+		return
+	}
+	if manifest.Name == "" {
+		manifest.Name = manifest.ID
+	}
+	if manifest.StartLocationID == "" {
+		manifest.StartLocationID = hw.StartID
+	}
+	if manifest.StartLocationID == "" {
+		manifest.StartLocationID = "oakhaven_gate" // Same default as createDefaultSession
+	}
+	if _, err := hw.World.GetLocation(manifest.StartLocationID); errors.Is(err, world.ErrLocationNotFound) {
+		http.Error(w, fmt.Sprintf("Invalid start location ID '%s': %v", manifest.StartLocationID, err), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("ERROR [handleExportWorld]: %v\n", err)
+		http.Error(w, "Failed to export the world due to an internal error.", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := world.ExportArchive(&buf, manifest, hw.World, hw.Engine.SystemPrompt); err != nil {
+		log.Printf("ERROR [handleExportWorld]: %v\n", err)
+		http.Error(w, "Failed to export world archive.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, manifest.ID, world.ArchiveExtension))
+	w.Write(buf.Bytes())
+}
+
+// maxContentBytes caps a single location/theme definition sent to the editor endpoints.
+const maxContentBytes = 1 << 20
+
+// handleWorldLocation serves the world editor's location endpoint:
+// GET reads, PUT creates or replaces (?link=bidirectional also updates neighbours'
+// adjacentIds), DELETE removes the location and every adjacency pointing at it.
+// ?worldId= picks a hosted world.
+func handleWorldLocation(w http.ResponseWriter, r *http.Request) {
+	locationID := r.PathValue("id")
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	editor, ok := hw.World.(world.Editor)
+	if !ok {
+		http.Error(w, "World system does not support editing", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		loc, err := hw.World.GetLocation(locationID)
+		if err != nil {
+			writeEditorError(w, "handleWorldLocation", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loc)
+
+	case http.MethodPut:
+		mode, err := world.ParseLinkMode(r.URL.Query().Get("link"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var loc world.LocationNode
+		if !decodeContentBody(w, r, world.LocationSchema, &loc) {
+			return
+		}
+		if loc.ID == "" {
+			loc.ID = locationID
+		} else if loc.ID != locationID {
+			http.Error(w, fmt.Sprintf("Body ID '%s' does not match path ID '%s'", loc.ID, locationID), http.StatusBadRequest)
+			return
+		}
+		changed, err := editor.PutLocation(loc, mode)
+		if err != nil {
+			writeEditorError(w, "handleWorldLocation", err)
+			return
+		}
+		fmt.Printf("World editor: saved location '%s' (%d location(s) changed)\n", locationID, len(changed))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"location": changed[0],
+			"updated":  locationIDs(changed[1:]),
+		})
+
+	case http.MethodDelete:
+		// Refuse to pull the floor out from under active players in this world
+		for _, sessionID := range sessionManager.GetAllSessionIDs() {
+			if sess, err := sessionManager.GetSession(sessionID); err == nil && playsIn(sess.WorldID, hw) && sess.CurrentLocationID == locationID {
+				http.Error(w, fmt.Sprintf("Location '%s' is occupied by session %s", locationID, sessionID), http.StatusConflict)
+				return
+			}
+		}
+		if archive, ok := sessionManager.(interface{ ArchivedSessions() []session.Summary }); ok {
+			for _, summary := range archive.ArchivedSessions() {
+				if playsIn(summary.WorldID, hw) && summary.CurrentLocationID == locationID {
+					http.Error(w, fmt.Sprintf("Location '%s' is occupied by archived session %s", locationID, summary.ID), http.StatusConflict)
+					return
+				}
+			}
+		}
+		changed, err := editor.DeleteLocation(locationID)
+		if err != nil {
+			writeEditorError(w, "handleWorldLocation", err)
+			return
+		}
+		fmt.Printf("World editor: deleted location '%s'\n", locationID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"deleted": locationID,
+			"updated": locationIDs(changed),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWorldTheme serves the world editor's theme endpoint (GET, PUT, DELETE).
+// Themes still used by a location cannot be deleted. ?worldId= picks a hosted world.
+func handleWorldTheme(w http.ResponseWriter, r *http.Request) {
+	themeID := r.PathValue("id")
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	editor, ok := hw.World.(world.Editor)
+	if !ok {
+		http.Error(w, "World system does not support editing", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		theme, err := hw.World.GetTheme(themeID)
+		if err != nil {
+			writeEditorError(w, "handleWorldTheme", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(theme)
+
+	case http.MethodPut:
+		var theme world.ThemeDefinition
+		if !decodeContentBody(w, r, world.ThemeSchema, &theme) {
+			return
+		}
+		if theme.ID == "" {
+			theme.ID = themeID
+		} else if theme.ID != themeID {
+			http.Error(w, fmt.Sprintf("Body ID '%s' does not match path ID '%s'", theme.ID, themeID), http.StatusBadRequest)
+			return
+		}
+		if err := editor.PutTheme(theme); err != nil {
+			writeEditorError(w, "handleWorldTheme", err)
+			return
+		}
+		fmt.Printf("World editor: saved theme '%s'\n", themeID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(theme)
+
+	case http.MethodDelete:
+		if err := editor.DeleteTheme(themeID); err != nil {
+			writeEditorError(w, "handleWorldTheme", err)
+			return
+		}
+		fmt.Printf("World editor: deleted theme '%s'\n", themeID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"deleted": themeID})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeContentBody validates a JSON request body against a content schema and decodes it into v.
+// It writes a 400 response and returns false if the body is invalid.
+func decodeContentBody(w http.ResponseWriter, r *http.Request, schema *world.Schema, v interface{}) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxContentBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		return false
+	}
+	if err := world.LoadContent("request body", body, schema, v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeEditorError maps world editor errors to HTTP status codes.
+func writeEditorError(w http.ResponseWriter, handler string, err error) {
+	switch {
+	case errors.Is(err, world.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, world.ErrInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, world.ErrInvalidEdit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("ERROR [%s]: %v\n", handler, err)
+		http.Error(w, "Failed to apply world edit due to an internal error.", http.StatusInternalServerError)
+	}
+}
+
+func locationIDs(locs []*world.LocationNode) []string {
+	ids := make([]string, 0, len(locs))
+	for _, loc := range locs {
+		ids = append(ids, loc.ID)
+	}
+	return ids
+}
+
+// handleWorldGraph exports the location adjacency graph as JSON (default) or
+// Graphviz DOT (?format=dot). ?start= sets the location used for reachability checks;
+// ?worldId= picks a hosted world.
+func handleWorldGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	startID := r.URL.Query().Get("start")
+	if startID == "" {
+		startID = hw.StartID
+	}
+	if startID == "" {
+		startID = "oakhaven_gate" // Same default as createDefaultSession
+	}
+	graph := world.BuildGraph(hw.World, startID)
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(graph)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		io.WriteString(w, graph.DOT())
+	default:
+		http.Error(w, fmt.Sprintf("Unknown format '%s' (expected json or dot)", format), http.StatusBadRequest)
+	}
+}
+
+// handleWorldStats reports content statistics for the loaded world: size, theme usage,
+// connectivity, tags, and themes or images nothing uses (see world.ComputeStats).
+// ?worldId= picks a hosted world.
+func handleWorldStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	var assets fs.FS
+	if assetsRoot != "" {
+		assets = os.DirFS(assetsRoot)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(world.ComputeStats(hw.World, assets))
+}
+
+// handleWorldLint reports style issues in a world's locations (see world.Lint): GET
+// lists them, POST also applies the mechanical fixes and lists what remains.
+// ?start= sets the reachability origin (default the world's start), ?minWords= and
+// ?maxWords= the description limits, ?disable= a comma-separated list of rules to skip,
+// and ?worldId= picks a hosted world.
+func handleWorldLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	opts := world.LintOptions{StartID: query.Get("start")}
+	if opts.StartID == "" {
+		opts.StartID = hw.StartID
+	}
+	if opts.StartID == "" {
+		opts.StartID = "oakhaven_gate" // Same default as createDefaultSession
+	}
+	for name, limit := range map[string]*int{"minWords": &opts.MinWords, "maxWords": &opts.MaxWords} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, fmt.Sprintf("Invalid '%s' query parameter: %s", name, v), http.StatusBadRequest)
+				return
+			}
+			*limit = n
+		}
+	}
+	if v := query.Get("disable"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			rule, err := world.ParseLintRule(strings.TrimSpace(name))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			opts.Disable = append(opts.Disable, rule)
+		}
+	}
+
+	issues := world.Lint(hw.World, opts)
+	fixed := []string{}
+	if r.Method == http.MethodPost {
+		editor, ok := hw.World.(world.Editor)
+		if !ok {
+			http.Error(w, "World system does not support editing", http.StatusNotImplemented)
+			return
+		}
+		changed, err := world.FixLint(editor, issues)
+		if err != nil {
+			writeEditorError(w, "handleWorldLint", err)
+			return
+		}
+		fixed = locationIDs(changed)
+		if len(changed) > 0 {
+			fmt.Printf("World lint: fixed %d location(s): %s\n", len(changed), strings.Join(fixed, ", "))
+		}
+		issues = world.Lint(hw.World, opts)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issues": issues,
+		"fixed":  fixed, // Locations POST changed
+	})
+}
+
+// handleGenerateLocations drafts candidate locations from an author's brief
+// ({"brief": "...", "count": 3, "near": ["harbor"]}) with the model. Nothing is saved:
+// the author reviews the candidates and saves the ones they keep with PUT
+// /admin/world/locations/{id}. ?worldId= picks a hosted world.
+func handleGenerateLocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	var req narrative.DraftRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	drafts, err := locationDrafter.DraftLocations(r.Context(), hw.World, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, narrative.ErrInvalidDraftRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, llm.ErrLLMTimeout):
+			http.Error(w, "The model took too long to respond; please try again.", http.StatusGatewayTimeout)
+		case errors.Is(err, narrative.ErrNoDrafts):
+			http.Error(w, "The model's reply held no usable locations; please try again or reword the brief.", http.StatusBadGateway)
+		default:
+			log.Printf("ERROR [handleGenerateLocations]: %v\n", err)
+			http.Error(w, "Failed to draft locations due to an internal server error.", http.StatusInternalServerError)
+		}
+		return
+	}
+	fmt.Printf("World authoring: drafted %d location(s) for brief %q\n", len(drafts), req.Brief)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"candidates": drafts})
+}
+
+// handleAnalytics reports anonymized gameplay totals across all recorded sessions.
+// ?top=N limits the popular locations listed (default 10, 0 = all).
+func handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if analyticsRecorder == nil {
+		http.Error(w, "Analytics are disabled (set ANALYTICS_STORE)", http.StatusNotFound)
+		return
+	}
+
+	top := 10
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("Invalid top: %s", v), http.StatusBadRequest)
+			return
+		}
+		top = n
+	}
+
+	totals, err := analyticsRecorder.Totals(top)
+	if err != nil {
+		log.Printf("ERROR [handleAnalytics]: Failed to read analytics: %v\n", err)
+		http.Error(w, "Failed to read analytics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(totals)
+}
+
+// handleFeatureFlags lists every experimental behavior flag and whether it is enabled
+// on this deployment.
+func handleFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flags": featureFlags.List(),
+	})
+}
+
+// handleDryRun reports what a set of actions ({"actions": [...]}; empty = the session's
+// pending actions) would do to a session, without applying them.
+func handleDryRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID := r.PathValue("id")
+	sess, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+	hw, err := worldOf(sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Actions []llm.LLMAction `json:"actions"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	result, err := hw.Engine.DryRun(sessionID, req.Actions)
+	if err != nil {
+		if errors.Is(err, narrative.ErrNoPendingActions) {
+			http.Error(w, "No actions given and none are waiting for confirmation.", http.StatusBadRequest)
+			return
+		}
+		log.Printf("ERROR [handleDryRun Session: %s]: %v\n", sessionID, err)
+		http.Error(w, "Failed to dry-run the actions due to an internal server error.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handlePromptPreview returns the exact prompt the narrator would be sent if the
+// session played ?input=... as its next turn, without calling the LLM or changing
+// the session.
+func handlePromptPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID := r.PathValue("id")
+	sess, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+	hw, err := worldOf(sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	input := r.URL.Query().Get("input")
+	if input == "" {
+		http.Error(w, "Missing 'input' query parameter", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := hw.Engine.PreviewPrompt(r.Context(), sessionID, input)
+	if err != nil {
+		log.Printf("ERROR [handlePromptPreview Session: %s]: %v\n", sessionID, err)
+		http.Error(w, "Failed to build the prompt due to an internal server error.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+// --- Static Assets ---
+
+// assetHandler serves files under root (location art referenced by ImageID, theme
+// images, audio) with Cache-Control headers. Directory listings are not served.
+func assetHandler(root string, maxAgeSeconds int) http.Handler {
+	files := http.FileServerFS(noDirFS{os.DirFS(root)})
+	cacheControl := fmt.Sprintf("public, max-age=%d", maxAgeSeconds)
+	return http.StripPrefix("/assets/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("Access-Control-Allow-Origin", "*") // Art may be drawn on canvases by any frontend origin
+		files.ServeHTTP(w, r)
+	}))
+}
+
+// noDirFS hides directories so the file server can't list them.
+type noDirFS struct {
+	fs.FS
+}
+
+func (n noDirFS) Open(name string) (fs.File, error) {
+	f, err := n.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+	return f, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"llmrpg/internal/audience"
+	"llmrpg/internal/narrative"
+	"llmrpg/internal/session"
+)
+
+// --- Audience Votes ---
+// With TWITCH_AUDIENCE=true, a session's owner can hand its turns to a Twitch
+// channel's chat: each round, viewers vote among the last turn's suggestions by
+// typing an option's number (or "!vote 2", or its text) for AUDIENCE_VOTE_SECONDS
+// (default 30), and the winner is played as the session's next turn. The streamer can
+// still play through /action; that turn's suggestions open a fresh round.
+//
+//	POST   /session/{id}/audience        start (owner): {"channel": "...", "voteSeconds": 20, "options": [...]}
+//	GET    /session/{id}/audience        state (owner)
+//	DELETE /session/{id}/audience        stop (owner)
+//	GET    /session/{id}/audience/votes  state (public, for overlays)
+//
+// Chat is read anonymously unless TWITCH_NICK and TWITCH_OAUTH_TOKEN are set.
+
+// audienceChat reads Twitch chat; nil when audience votes are disabled.
+var audienceChat *audience.TwitchChat
+
+// audienceVoteWindow is how long each round stays open unless the request picks a window.
+var audienceVoteWindow = 30 * time.Second
+
+// maxAudienceOptions caps the options a first round can be opened with.
+const maxAudienceOptions = 9
+
+// audienceRun is a running audience mode and the function that stops it.
+type audienceRun struct {
+	mode   *audience.Mode
+	cancel context.CancelFunc
+}
+
+var (
+	audiencesMu sync.Mutex
+	audiences   = make(map[string]*audienceRun) // By session ID
+)
+
+// audienceRoutes returns the audience vote routes when TWITCH_AUDIENCE=true. control
+// guards starting, stopping and reading a session's mode (the owner's token); overlay
+// guards the read-only state that stream overlays poll.
+func audienceRoutes(control, overlay middleware) []route {
+	if on, _ := strconv.ParseBool(os.Getenv("TWITCH_AUDIENCE")); !on {
+		return nil
+	}
+	audienceChat = &audience.TwitchChat{
+		Addr:  os.Getenv("TWITCH_IRC_ADDR"),
+		Nick:  os.Getenv("TWITCH_NICK"),
+		Token: os.Getenv("TWITCH_OAUTH_TOKEN"),
+	}
+	if v := os.Getenv("AUDIENCE_VOTE_SECONDS"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil || n <= 0 {
+			log.Printf("Warning: Invalid AUDIENCE_VOTE_SECONDS '%s', using default %s", v, audienceVoteWindow)
+		} else {
+			audienceVoteWindow = time.Duration(n) * time.Second
+		}
+	}
+	fmt.Printf("Audience votes enabled through Twitch chat (%s rounds).\n", audienceVoteWindow)
+	return []route{
+		{"/session/{id}/audience", handleAudience, control},
+		{"/session/{id}/audience/votes", handleAudienceVotes, overlay},
+	}
+}
+
+// handleAudience starts (POST), reports (GET) or stops (DELETE) a session's audience mode.
+func handleAudience(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+		run, ok := runningAudience(sessionID)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Audience votes are not running for session %s", sessionID), http.StatusNotFound)
+			return
+		}
+		writeAudienceState(w, run.mode.State())
+	case http.MethodPost:
+		sess, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+			return
+		}
+		if sess.Completed() {
+			http.Error(w, "Session has reached an ending and accepts no further actions; see /state for the epilogue.", http.StatusConflict)
+			return
+		}
+		var requestBody struct {
+			Channel     string   `json:"channel"`
+			VoteSeconds int      `json:"voteSeconds"` // Optional; defaults to AUDIENCE_VOTE_SECONDS
+			Options     []string `json:"options"`     // Optional first round; otherwise the next /action's suggestions open it
+		}
+		if !decodeJSONBody(w, r, &requestBody) {
+			return
+		}
+		channel, err := audience.NormalizeChannel(requestBody.Channel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		window := audienceVoteWindow
+		if requestBody.VoteSeconds != 0 {
+			if requestBody.VoteSeconds < 5 || requestBody.VoteSeconds > 600 {
+				http.Error(w, "'voteSeconds' must be between 5 and 600", http.StatusBadRequest)
+				return
+			}
+			window = time.Duration(requestBody.VoteSeconds) * time.Second
+		}
+		var options []string
+		for _, option := range requestBody.Options {
+			if option = narrative.SanitizeInput(option); option == "" {
+				continue
+			}
+			if !checkTextLength(w, "options", option, maxInputChars) {
+				return
+			}
+			options = append(options, option)
+		}
+		if len(options) > maxAudienceOptions {
+			http.Error(w, fmt.Sprintf("Too many options: %d (limit %d)", len(options), maxAudienceOptions), http.StatusBadRequest)
+			return
+		}
+		run := startAudience(sessionID, channel, window)
+		run.mode.Offer(options)
+		w.WriteHeader(http.StatusCreated)
+		writeAudienceState(w, run.mode.State())
+	case http.MethodDelete:
+		if !stopAudience(sessionID) {
+			http.Error(w, fmt.Sprintf("Audience votes are not running for session %s", sessionID), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAudienceVotes reports the voting state without the session's token, for
+// overlays (browser sources can't send headers). It shows only what's on stream anyway.
+func handleAudienceVotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID := r.PathValue("id")
+	run, ok := runningAudience(sessionID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Audience votes are not running for session %s", sessionID), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeAudienceState(w, run.mode.State())
+}
+
+func writeAudienceState(w http.ResponseWriter, state audience.State) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		log.Printf("ERROR [audience Session: %s]: Failed to encode response: %v\n", state.SessionID, err)
+	}
+}
+
+// startAudience starts a session's audience mode on channel, replacing any running one.
+func startAudience(sessionID, channel string, window time.Duration) *audienceRun {
+	stopAudience(sessionID)
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &audienceRun{
+		mode:   audience.New(sessionID, channel, window, audienceSubmitter(sessionID)),
+		cancel: cancel,
+	}
+	audiencesMu.Lock()
+	audiences[sessionID] = run
+	audiencesMu.Unlock()
+
+	go audienceChat.Follow(ctx, channel, func(viewer, text string) { run.mode.Vote(viewer, text) })
+	go func() {
+		run.mode.Run(ctx)
+		cancel() // Also stops reading chat if the session ended
+		audiencesMu.Lock()
+		if audiences[sessionID] == run {
+			delete(audiences, sessionID)
+		}
+		audiencesMu.Unlock()
+		log.Printf("Audience votes for session %s on #%s stopped", sessionID, channel)
+	}()
+	log.Printf("Audience votes for session %s started on #%s (%s rounds)", sessionID, channel, window)
+	return run
+}
+
+// stopAudience stops a session's audience mode, reporting whether one was running.
+func stopAudience(sessionID string) bool {
+	audiencesMu.Lock()
+	run, ok := audiences[sessionID]
+	delete(audiences, sessionID)
+	audiencesMu.Unlock()
+	if ok {
+		run.cancel()
+	}
+	return ok
+}
+
+func runningAudience(sessionID string) (*audienceRun, bool) {
+	audiencesMu.Lock()
+	defer audiencesMu.Unlock()
+	run, ok := audiences[sessionID]
+	return run, ok
+}
+
+// offerAudience opens a fresh round on a turn's suggestions if the session's audience
+// mode is running; handleAction calls it after turns the streamer plays.
+func offerAudience(sessionID string, suggestions []string) {
+	if run, ok := runningAudience(sessionID); ok {
+		run.mode.Offer(suggestions)
+	}
+}
+
+// audienceSubmitter plays winning options as the session's turns, in the session's
+// world, under the same timeout as async turns.
+func audienceSubmitter(sessionID string) audience.SubmitFunc {
+	return func(ctx context.Context, input string) ([]string, error) {
+		sess, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", audience.ErrEnded, err)
+		}
+		hw, err := worldOf(sess)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", audience.ErrEnded, err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		response, err := hw.Engine.ProcessPlayerInput(ctx, sessionID, input)
+		if err != nil {
+			log.Printf("ERROR [audience Session: %s]: %v\n", sessionID, err)
+			if errors.Is(err, session.ErrSessionCompleted) || errors.Is(err, session.ErrSessionNotFound) {
+				return nil, fmt.Errorf("%w: %v", audience.ErrEnded, err)
+			}
+			return nil, err
+		}
+		if sess, err := sessionManager.GetSession(sessionID); err == nil && sess.Completed() {
+			return nil, audience.ErrEnded // The winning option reached an ending
+		}
+		return response.Suggestions, nil
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// --- Request Limits ---
+
+// maxRequestBodyBytes caps JSON request bodies (MAX_REQUEST_BODY_BYTES). Admin content
+// and world archives have their own, larger limits.
+var maxRequestBodyBytes int64 = 64 << 10
+
+// maxInputChars caps player input per turn, after sanitization (MAX_INPUT_CHARS).
+var maxInputChars = 1000
+
+// maxNameChars caps character names, classes and origins, which appear in every prompt.
+const maxNameChars = 64
+
+// loadRequestLimits reads the configurable limits from the environment.
+func loadRequestLimits() {
+	if v := os.Getenv("MAX_REQUEST_BODY_BYTES"); v != "" {
+		if n, convErr := strconv.ParseInt(v, 10, 64); convErr != nil || n <= 0 {
+			log.Printf("Warning: Invalid MAX_REQUEST_BODY_BYTES '%s', using default %d", v, maxRequestBodyBytes)
+		} else {
+			maxRequestBodyBytes = n
+		}
+	}
+	if v := os.Getenv("MAX_INPUT_CHARS"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil || n <= 0 {
+			log.Printf("Warning: Invalid MAX_INPUT_CHARS '%s', using default %d", v, maxInputChars)
+		} else {
+			maxInputChars = n
+		}
+	}
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into v. It writes a 413
+// (body too large) or 400 response and returns false on failure.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	return false
+}
+
+// checkTextLength writes a 400 response and returns false if text (already sanitized)
+// is longer than limit characters.
+func checkTextLength(w http.ResponseWriter, field, text string, limit int) bool {
+	if n := utf8.RuneCountInString(text); n > limit {
+		http.Error(w, fmt.Sprintf("'%s' is too long: %d characters (limit %d)", field, n, limit), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
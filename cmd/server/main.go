@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings" // Needed for handleUpdateLocation check in narrative/executor.go (imported there)
 	"time"
 
@@ -15,10 +22,22 @@ import (
 	"github.com/joho/godotenv"
 
 	// Import internal packages
+	"llmrpg"
+	"llmrpg/internal/analytics"
+	"llmrpg/internal/api"
 	"llmrpg/internal/character"
+	"llmrpg/internal/clock"
+	"llmrpg/internal/features"
+	"llmrpg/internal/history"
+	"llmrpg/internal/ids"
 	"llmrpg/internal/llm"
+	"llmrpg/internal/lore"
+	"llmrpg/internal/memory"
+	"llmrpg/internal/moderation"
 	"llmrpg/internal/narrative"
+	"llmrpg/internal/rating"
 	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
 	"llmrpg/internal/world"
 )
 
@@ -27,43 +46,30 @@ import (
 var worldSystem world.WorldSystem
 var sessionManager session.Manager
 var llmAdapter llm.Adapter
+var llmHTTPClient *http.Client // Shared by every LLM adapter
 var actionExecutor narrative.ActionExecutor
 var narrativeEngine *narrative.NarrativeEngine
-
-// --- CORS Middleware ---
-
-// corsMiddleware adds necessary CORS headers to allow requests from the frontend development server.
-// It wraps an existing http.HandlerFunc.
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Set allowed origin (adjust if your frontend runs on a different port)
-		// Using "*" is generally okay for local development but be more specific for production.
-		// Ensure your frontend origin (e.g., http://localhost:3000) is allowed.
-		allowedOrigin := os.Getenv("ALLOWED_ORIGIN")
-		if allowedOrigin == "" {
-			allowedOrigin = "http://localhost:3000" // Default frontend dev server
-		}
-		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-
-		// Set allowed methods
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-
-		// Set allowed headers that the frontend might send
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-
-		// Set credentials header if needed (e.g., for cookies, authorization headers)
-		// w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// Handle preflight OPTIONS requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK) // Respond OK to OPTIONS preflight
-			return                       // Don't call the next handler for OPTIONS
-		}
-
-		// Call the actual handler for other methods (GET, POST, etc.)
-		next(w, r)
-	}
-}
+var limitedAdapter *llm.LimitedAdapter
+var locationDrafter *narrative.LocationDrafter
+var memorySearcher *memory.Searcher
+var sessionStore session.Store // nil when persistence is disabled
+var scenarios map[string]*world.Scenario
+var endings map[string]*world.Ending
+var npcs map[string]*world.NPC
+var worldEvents map[string]*world.WorldEvent
+var items map[string]*world.Item
+var abilities map[string]*world.Ability
+var perks map[string]*world.Perk
+var loreLibrary *lore.Library
+var bestiary map[string]*world.Creature
+var lootTables map[string]*world.LootTable
+var survivalRules *world.SurvivalRules
+var analyticsRecorder *analytics.Recorder // nil when analytics are disabled
+var featureFlags *features.Flags
+var worldRating rating.Rating // Highest content rating sessions may choose
+var confirmActions bool       // Default for sessions that don't choose whether to confirm high-impact actions
+var assetsRoot string         // Static asset directory served at /assets/; "" when disabled
+var deterministic bool        // DETERMINISTIC=true: fixed clock, derived seeds, mock LLM by default
 
 // --- Main Function ---
 
@@ -83,180 +89,1901 @@ func main() {
 	// --- System Initialization ---
 	fmt.Println("Initializing systems...")
 
-	// Initialize World System
-	worldSystem = world.NewInMemoryWorldSystem()
-	locPath := os.Getenv("LOCATION_DATA_PATH")
-	themePath := os.Getenv("THEME_DATA_PATH")
-	if locPath == "" || themePath == "" {
-		log.Fatal("FATAL: LOCATION_DATA_PATH and THEME_DATA_PATH environment variables must be set (check .env or system env)")
+	// DETERMINISTIC=true pins the clock, session seeds and (by default) the LLM so
+	// end-to-end tests get byte-identical transcripts
+	deterministic = configureDeterministicMode()
+
+	// Initialize World System
+	// Load from a WORLD_ARCHIVE (.llmworld), from LOCATION_DATA_PATH/THEME_DATA_PATH,
+	// or fall back to the world embedded in the binary.
+	inMemoryWorld := world.NewInMemoryWorldSystem()
+	worldSystem = inMemoryWorld
+	locPath := os.Getenv("LOCATION_DATA_PATH")
+	themePath := os.Getenv("THEME_DATA_PATH")
+	archivePath := os.Getenv("WORLD_ARCHIVE")
+	var archive *archiveContent // Content of WORLD_ARCHIVE, if set
+	var archivePrompt string    // System prompt bundled with WORLD_ARCHIVE, if any
+	var archiveRating string
+	var locFS, themeFS fs.FS
+	if archivePath != "" {
+		var err error
+		if archive, err = loadArchiveContent(archivePath, worldSystem); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		archivePrompt = archive.Prompt
+		archiveRating = archive.Manifest.ContentRating
+	} else if locPath == "" && themePath == "" {
+		fmt.Println("LOCATION_DATA_PATH and THEME_DATA_PATH not set; using embedded default world.")
+		locFS, themeFS = embeddedFS("data/locations"), embeddedFS("data/themes")
+	} else {
+		if locPath == "" || themePath == "" {
+			log.Fatal("FATAL: LOCATION_DATA_PATH and THEME_DATA_PATH must be set together (or both unset to use the embedded world)")
+		}
+		fmt.Printf("Loading world data from directories: locations=%s, themes=%s\n", locPath, themePath)
+		locFS, themeFS = os.DirFS(locPath), os.DirFS(themePath)
+		// World editor changes are written back to these directories (disable with WORLD_EDITOR_WRITE_BACK=false)
+		if os.Getenv("WORLD_EDITOR_WRITE_BACK") != "false" {
+			inMemoryWorld.SetContentStore(world.NewDirContentStore(locPath, themePath))
+		}
+	}
+	if locFS != nil {
+		if err := worldSystem.LoadWorldData(locFS, themeFS); err != nil {
+			log.Fatalf("FATAL: Failed to load world data: %v", err)
+		}
+	}
+	fmt.Println("World system loaded.")
+
+	// Content rating (E, T or M): WORLD_CONTENT_RATING, else the archive's, else T
+	worldRating = rating.Default
+	ratingSpec := os.Getenv("WORLD_CONTENT_RATING")
+	if ratingSpec == "" {
+		ratingSpec = archiveRating
+	}
+	if ratingSpec != "" {
+		parsed, err := rating.Parse(ratingSpec)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		worldRating = parsed
+	}
+	fmt.Printf("World content rating: %s (%s).\n", worldRating, worldRating.Profile().Name)
+
+	// Scenarios (guided openings): SCENARIO_DATA_PATH, the archive's, or the embedded ones
+	// when playing the embedded world
+	var scenarioErr error
+	if scenarioPath := os.Getenv("SCENARIO_DATA_PATH"); scenarioPath != "" {
+		scenarios, scenarioErr = world.LoadScenarios(os.DirFS(scenarioPath), worldSystem)
+	} else if archivePath != "" {
+		scenarios = archive.Scenarios
+	} else if locPath == "" {
+		scenarios, scenarioErr = world.LoadScenarios(embeddedFS("data/scenarios"), worldSystem)
+	}
+	if scenarioErr != nil {
+		log.Fatalf("FATAL: Failed to load scenarios: %v", scenarioErr)
+	}
+	fmt.Printf("Loaded %d scenario(s).\n", len(scenarios))
+
+	// Endings, from the same kind of source as scenarios (ENDING_DATA_PATH overrides)
+	var endingErr error
+	if endingPath := os.Getenv("ENDING_DATA_PATH"); endingPath != "" {
+		endings, endingErr = world.LoadEndings(os.DirFS(endingPath), worldSystem)
+	} else if archivePath != "" {
+		endings = archive.Endings
+	} else if locPath == "" {
+		endings, endingErr = world.LoadEndings(embeddedFS("data/endings"), worldSystem)
+	}
+	if endingErr != nil {
+		log.Fatalf("FATAL: Failed to load endings: %v", endingErr)
+	}
+	fmt.Printf("Loaded %d ending(s).\n", len(endings))
+
+	// NPCs and their schedules, likewise (NPC_DATA_PATH overrides)
+	var npcErr error
+	if npcPath := os.Getenv("NPC_DATA_PATH"); npcPath != "" {
+		npcs, npcErr = world.LoadNPCs(os.DirFS(npcPath), worldSystem)
+	} else if archivePath != "" {
+		npcs = archive.NPCs
+	} else if locPath == "" {
+		npcs, npcErr = world.LoadNPCs(embeddedFS("data/npcs"), worldSystem)
+	}
+	if npcErr != nil {
+		log.Fatalf("FATAL: Failed to load NPCs: %v", npcErr)
+	}
+	fmt.Printf("Loaded %d NPC(s).\n", len(npcs))
+
+	// World events (caravans, festivals, raids), likewise (WORLD_EVENT_DATA_PATH overrides)
+	var worldEventErr error
+	if worldEventPath := os.Getenv("WORLD_EVENT_DATA_PATH"); worldEventPath != "" {
+		worldEvents, worldEventErr = world.LoadWorldEvents(os.DirFS(worldEventPath), worldSystem)
+	} else if archivePath != "" {
+		worldEvents = archive.WorldEvents
+	} else if locPath == "" {
+		worldEvents, worldEventErr = world.LoadWorldEvents(embeddedFS("data/events"), worldSystem)
+	}
+	if worldEventErr != nil {
+		log.Fatalf("FATAL: Failed to load world events: %v", worldEventErr)
+	}
+	fmt.Printf("Loaded %d world event(s).\n", len(worldEvents))
+
+	// Items, likewise (ITEM_DATA_PATH overrides)
+	var itemErr error
+	if itemPath := os.Getenv("ITEM_DATA_PATH"); itemPath != "" {
+		items, itemErr = world.LoadItems(os.DirFS(itemPath))
+	} else if archivePath != "" {
+		items = archive.Items
+	} else if locPath == "" {
+		items, itemErr = world.LoadItems(embeddedFS("data/items"))
+	}
+	if itemErr != nil {
+		log.Fatalf("FATAL: Failed to load items: %v", itemErr)
+	}
+	fmt.Printf("Loaded %d item(s).\n", len(items))
+	if err := world.CheckLocationContainers(worldSystem, items); err != nil {
+		log.Fatalf("FATAL: Invalid location containers: %v", err)
+	}
+
+	// Abilities, likewise (ABILITY_DATA_PATH overrides)
+	var abilityErr error
+	if abilityPath := os.Getenv("ABILITY_DATA_PATH"); abilityPath != "" {
+		abilities, abilityErr = world.LoadAbilities(os.DirFS(abilityPath))
+	} else if archivePath != "" {
+		abilities = archive.Abilities
+	} else if locPath == "" {
+		abilities, abilityErr = world.LoadAbilities(embeddedFS("data/abilities"))
+	}
+	if abilityErr != nil {
+		log.Fatalf("FATAL: Failed to load abilities: %v", abilityErr)
+	}
+	fmt.Printf("Loaded %d ability definition(s).\n", len(abilities))
+
+	// Perk catalog for level-ups, likewise (PERK_DATA_PATH overrides); perks may teach loaded abilities
+	var perkErr error
+	if perkPath := os.Getenv("PERK_DATA_PATH"); perkPath != "" {
+		perks, perkErr = world.LoadPerks(os.DirFS(perkPath), abilities)
+	} else if archivePath != "" {
+		perks = archive.Perks
+	} else if locPath == "" {
+		perks, perkErr = world.LoadPerks(embeddedFS("data/perks"), abilities)
+	}
+	if perkErr != nil {
+		log.Fatalf("FATAL: Failed to load perks: %v", perkErr)
+	}
+	fmt.Printf("Loaded %d perk(s).\n", len(perks))
+
+	// Shared loot tables, likewise (LOOT_DATA_PATH overrides); they must reference loaded items
+	var lootErr error
+	if lootPath := os.Getenv("LOOT_DATA_PATH"); lootPath != "" {
+		lootTables, lootErr = world.LoadLootTables(os.DirFS(lootPath), items)
+	} else if archivePath != "" {
+		lootTables = archive.LootTables
+	} else if locPath == "" {
+		lootTables, lootErr = world.LoadLootTables(embeddedFS("data/loot"), items)
+	}
+	if lootErr == nil {
+		lootErr = world.CheckLocationLoot(worldSystem, lootTables)
+	}
+	if lootErr != nil {
+		log.Fatalf("FATAL: Failed to load loot tables: %v", lootErr)
+	}
+	fmt.Printf("Loaded %d loot table(s).\n", len(lootTables))
+
+	// Bestiary, likewise (BESTIARY_DATA_PATH overrides); loot must reference loaded items and tables
+	var bestiaryErr error
+	if bestiaryPath := os.Getenv("BESTIARY_DATA_PATH"); bestiaryPath != "" {
+		bestiary, bestiaryErr = world.LoadBestiary(os.DirFS(bestiaryPath), items, lootTables)
+	} else if archivePath != "" {
+		bestiary = archive.Bestiary
+	} else if locPath == "" {
+		bestiary, bestiaryErr = world.LoadBestiary(embeddedFS("data/bestiary"), items, lootTables)
+	}
+	if bestiaryErr == nil {
+		bestiaryErr = world.CheckLocationEncounters(worldSystem, bestiary)
+	}
+	if bestiaryErr != nil {
+		log.Fatalf("FATAL: Failed to load bestiary: %v", bestiaryErr)
+	}
+	fmt.Printf("Loaded %d creature(s).\n", len(bestiary))
+
+	// World lore, likewise (LORE_DATA_PATH overrides): browsable at /lore and included in prompts by tag
+	var loreDocs []lore.Document
+	var loreErr error
+	if lorePath := os.Getenv("LORE_DATA_PATH"); lorePath != "" {
+		loreDocs, loreErr = lore.LoadDocuments(os.DirFS(lorePath))
+	} else if archivePath != "" {
+		loreDocs = archive.LoreDocs
+	} else if locPath == "" {
+		loreDocs, loreErr = lore.LoadDocuments(embeddedFS("data/lore"))
+	}
+	if loreErr != nil {
+		log.Fatalf("FATAL: Failed to load lore: %v", loreErr)
+	}
+	loreLibrary = lore.NewLibrary(loreDocs)
+	fmt.Printf("Loaded %d lore document(s).\n", loreLibrary.Len())
+
+	// Survival mode is opt-in per world: SURVIVAL_RULES_PATH (a single file), else the archive's
+	if survivalPath := os.Getenv("SURVIVAL_RULES_PATH"); survivalPath != "" {
+		rules, err := world.LoadSurvivalRulesFile(survivalPath, items)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to load survival rules: %v", err)
+		}
+		survivalRules = rules
+	} else if archivePath != "" {
+		survivalRules = archive.Survival
+	}
+	if survivalRules != nil {
+		fmt.Printf("Survival mode enabled (%d resource(s)).\n", len(survivalRules.Resources))
+	}
+
+	// Initialize Session Manager
+	inMemorySessions := session.NewInMemorySessionManager()
+	inMemorySessions.HistoryPolicy = loadHistoryPolicy()
+	sessionManager = inMemorySessions
+	fmt.Printf("Session manager initialized (recent window: %d, retained history: %d).\n", inMemorySessions.HistoryPolicy.RecentWindow, inMemorySessions.HistoryPolicy.MaxRetained)
+
+	// Optional persistence (SESSION_STORE: "file" or "blob"), restoring saved sessions on startup
+	sessionStore = newSessionStore()
+	archiveAfter := loadArchiveAfter()
+	loadSessionCaps(inMemorySessions)
+	if sessionStore != nil {
+		restoreSessions(inMemorySessions, archiveAfter)
+	}
+	if archiveAfter > 0 || (inMemorySessions.MaxSessions > 0 && sessionStore != nil) {
+		inMemorySessions.ColdStore = sessionStore
+	}
+	if archiveAfter > 0 {
+		go archiveIdleSessions(inMemorySessions, archiveAfter)
+		fmt.Printf("Sessions idle for %v are archived to the session store.\n", archiveAfter)
+	}
+
+	// Request size limits (MAX_REQUEST_BODY_BYTES, MAX_INPUT_CHARS)
+	loadRequestLimits()
+
+	// Optional tracing (OTEL_TRACES_EXPORTER: "otlp" or "console")
+	initTracing()
+
+	// Shared HTTP client (connection pooling, optional proxy and custom CA)
+	httpConfig := llm.DefaultHTTPClientConfig()
+	httpConfig.ProxyURL = os.Getenv("LLM_HTTP_PROXY")
+	httpConfig.CACertPath = os.Getenv("LLM_CA_CERT_PATH")
+	if v := os.Getenv("LLM_HTTP_TIMEOUT_SECONDS"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil {
+			log.Printf("Warning: Invalid LLM_HTTP_TIMEOUT_SECONDS '%s', using default: %v", v, convErr)
+		} else {
+			httpConfig.Timeout = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("LLM_MAX_CONNS_PER_HOST"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil {
+			log.Printf("Warning: Invalid LLM_MAX_CONNS_PER_HOST '%s', using default: %v", v, convErr)
+		} else {
+			httpConfig.MaxConnsPerHost = n
+		}
+	}
+	llmHTTPClient, err = llm.NewHTTPClient(httpConfig)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to build LLM HTTP client: %v", err)
+	}
+
+	// Initialize LLM Adapter (LLM_PROVIDER selects "gemini" or "openai"); worlds whose
+	// manifest picks a model get their own adapter (see worldAdapter)
+	llmAdapter = newNarratorAdapter(nil)
+
+	// Cap simultaneous LLM calls so a burst of players doesn't flood the provider
+	maxConcurrent := 8 // Default concurrency limit
+	if v := os.Getenv("LLM_MAX_CONCURRENCY"); v != "" {
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil {
+			log.Printf("Warning: Invalid LLM_MAX_CONCURRENCY '%s', using default %d: %v", v, maxConcurrent, convErr)
+		} else {
+			maxConcurrent = n
+		}
+	}
+	limitedAdapter = llm.NewLimitedAdapter(llmAdapter, maxConcurrent)
+	llmAdapter = limitedAdapter
+	fmt.Printf("LLM concurrency limit set to %d (0 = unlimited).\n", maxConcurrent)
+
+	// Initialize Action Executor
+	// Inject dependencies needed by the executor (currently just WorldSystem)
+	simpleExecutor := narrative.NewSimpleActionExecutor(worldSystem /*, inventorySystem, etc */)
+
+	// World-wide action allow-list (locations may override with "allowedActions")
+	var defaultAllowed []narrative.ActionType
+	if v := os.Getenv("ALLOWED_ACTIONS"); v != "" {
+		for _, a := range strings.Split(v, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				defaultAllowed = append(defaultAllowed, narrative.ActionType(a))
+			}
+		}
+	}
+	actionPolicy := narrative.NewActionPolicy(defaultAllowed)
+	simpleExecutor.Policy = actionPolicy
+	simpleExecutor.Scenarios = scenarios
+	simpleExecutor.Items = items
+	simpleExecutor.Bestiary = bestiary
+	simpleExecutor.Abilities = abilities
+	simpleExecutor.Perks = perks
+	simpleExecutor.WorldEvents = worldEvents
+	simpleExecutor.LootTables = lootTables
+	simpleExecutor.Survival = survivalRules
+
+	// Resting: REST_HOURS per rest, REST_SAFETY scales encounter chances by location tag
+	restPolicy := narrative.DefaultRestPolicy()
+	if v := os.Getenv("REST_HOURS"); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			restPolicy.Hours = hours
+		} else {
+			log.Printf("Warning: Invalid REST_HOURS '%s', using default %d", v, restPolicy.Hours)
+		}
+	}
+	if v := os.Getenv("REST_SAFETY"); v != "" {
+		if safety, err := narrative.ParseRestSafety(v); err == nil {
+			restPolicy.TagSafety = safety
+		} else {
+			log.Printf("Warning: %v; using default rest safety", err)
+		}
+	}
+	simpleExecutor.Rest = restPolicy
+
+	// Challenges: CHALLENGE_MINIGAMES lists kinds the frontend plays as minigames (e.g. "lockpicking,hacking");
+	// the rest are rolled by the server
+	challenges := narrative.DefaultChallenges()
+	if v := os.Getenv("CHALLENGE_MINIGAMES"); v != "" {
+		if err := narrative.ParseMinigames(v, challenges); err != nil {
+			log.Printf("Warning: Invalid CHALLENGE_MINIGAMES: %v; the server rolls all challenges", err)
+			challenges = narrative.DefaultChallenges()
+		}
+	}
+	simpleExecutor.Challenges = challenges
+	actionExecutor = simpleExecutor
+
+	// Confirmation mode: CONFIRM_ACTIONS=true makes new sessions hold high-impact actions
+	// (fights, theft, discarding quest items) until the player confirms them
+	confirmActions = os.Getenv("CONFIRM_ACTIONS") == "true"
+	fmt.Println("Action executor initialized.")
+
+	// Initialize Narrative Engine
+	// Load system prompt from file or use default
+	defaultPromptPath := "data/prompts/system_prompt.txt" // Default system prompt path
+	systemPromptPath := os.Getenv("SYSTEM_PROMPT_PATH")
+	var systemPrompt string
+	if systemPromptPath == "" && archivePrompt != "" {
+		systemPrompt = archivePrompt
+		fmt.Println("Using system prompt bundled with the world archive.")
+	} else {
+		if systemPromptPath == "" {
+			systemPromptPath = defaultPromptPath
+			fmt.Printf("Using default prompt path: %s\n", defaultPromptPath)
+		}
+		loaded, promptErr := llm.LoadSystemPrompt(os.DirFS(filepath.Dir(systemPromptPath)), filepath.Base(systemPromptPath))
+		if promptErr != nil {
+			// Fall back to the prompt embedded in the binary, then to a minimal prompt as last resort
+			if embedded, embedErr := llm.LoadSystemPrompt(llmrpg.DefaultWorld, defaultPromptPath); embedErr == nil {
+				systemPrompt = embedded
+				log.Printf("Warning: Failed to load system prompt from %s: %v. Using embedded default prompt.", systemPromptPath, promptErr)
+			} else {
+				systemPrompt = `You are the narrator for a text adventure game. Describe the world vividly and respond to player actions.`
+				log.Printf("Warning: Failed to load system prompt from %s: %v. Using minimal fallback.", systemPromptPath, promptErr)
+			}
+		} else {
+			systemPrompt = loaded
+			fmt.Printf("Loaded system prompt from %s (%d bytes)\n", systemPromptPath, len(systemPrompt))
+		}
+	}
+	primaryAdapter := llmAdapter
+	if archive != nil {
+		primaryAdapter = worldAdapter(archive.Manifest.Name, archive.Manifest.LLM)
+	}
+	narrativeEngine, err = narrative.NewNarrativeEngine(worldSystem, primaryAdapter, actionExecutor, sessionManager, systemPrompt)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to create narrative engine: %v", err)
+	}
+	narrativeEngine.ActionPolicy = actionPolicy
+	narrativeEngine.Scenarios = scenarios
+	narrativeEngine.Endings = endings
+	narrativeEngine.NPCs = npcs
+	narrativeEngine.WorldEvents = worldEvents
+	narrativeEngine.Items = items
+	narrativeEngine.Bestiary = bestiary
+	narrativeEngine.Abilities = abilities
+	narrativeEngine.Perks = perks
+	narrativeEngine.Survival = survivalRules
+	narrativeEngine.ContentRating = worldRating
+	if archive != nil {
+		narrativeEngine.PromptVariants = archive.Variants
+		logPromptVariants(archive.Manifest.Name, archive.Variants)
+	}
+
+	// Layout of recent events in prompts: HISTORY_FORMAT, else the archive's, else inline
+	historyFormat := os.Getenv("HISTORY_FORMAT")
+	if historyFormat == "" && archive != nil {
+		historyFormat = archive.Manifest.HistoryFormat
+	}
+	if narrativeEngine.HistoryFormat, err = llm.ParseHistoryFormat(historyFormat); err != nil {
+		log.Fatalf("FATAL: Invalid HISTORY_FORMAT: %v", err)
+	}
+	fmt.Printf("Prompt history format: %s\n", narrativeEngine.HistoryFormat)
+
+	// Experimental behavior flags (FEATURE_FLAGS="name=true,name=false"), listed at /admin/flags
+	flags, flagErr := features.Parse(os.Getenv("FEATURE_FLAGS"))
+	if flagErr != nil {
+		log.Printf("Warning: Invalid FEATURE_FLAGS entries ignored: %v", flagErr)
+	}
+	featureFlags = flags
+	narrativeEngine.Features = featureFlags
+
+	// Prompt injection guard (INJECTION_GUARD: off, flag (default) or block; INJECTION_THRESHOLD 0-1)
+	guard := &narrative.InjectionGuard{Mode: narrative.GuardFlag, Threshold: 0.5}
+	if v := os.Getenv("INJECTION_GUARD"); v != "" {
+		mode, parseErr := narrative.ParseGuardMode(v)
+		if parseErr != nil {
+			log.Fatalf("FATAL: %v", parseErr)
+		}
+		guard.Mode = mode
+	}
+	if v := os.Getenv("INJECTION_THRESHOLD"); v != "" {
+		if f, convErr := strconv.ParseFloat(v, 64); convErr != nil || f < 0 || f > 1 {
+			log.Printf("Warning: Invalid INJECTION_THRESHOLD '%s', using default %.2f", v, guard.Threshold)
+		} else {
+			guard.Threshold = f
+		}
+	}
+	narrativeEngine.Guard = guard
+	fmt.Printf("Prompt injection guard: %s (threshold %.2f).\n", guard.Mode, guard.Threshold)
+
+	// Optional content moderation (MODERATION_PROVIDER: "keywords" or "openai")
+	narrativeEngine.Moderation = newModerationPolicy(llmHTTPClient)
+
+	// Optional input triage by a cheap model (TRIAGE_MODEL and/or TRIAGE_PROVIDER)
+	narrativeEngine.Triage = newTriagePolicy()
+	locationDrafter = newLocationDrafter()
+
+	// DEBUG_MODE=true reports each turn's model calls (tokens, latency, model, finish
+	// reason) in its response, for frontend developers without access to the logs
+	if debugMode, _ := strconv.ParseBool(os.Getenv("DEBUG_MODE")); debugMode {
+		narrativeEngine.Debug = true
+		fmt.Println("Debug mode: turn responses include LLM call details.")
+	}
+
+	// Optional anonymized gameplay analytics (ANALYTICS_STORE: "memory" or "file")
+	analyticsRecorder = newAnalyticsRecorder()
+	narrativeEngine.Analytics = analyticsRecorder
+
+	// Turn events go out on the event bus; notifications (email, web push) subscribe to it
+	narrativeEngine.Events = eventBus
+	notifier = newNotifier()
+
+	// Autosave policy (AUTOSAVE_MODE: off, every_turn, every_n, events)
+	if sessionStore != nil {
+		autosaveMode := session.AutosaveEveryTurn // Default when persistence is enabled
+		if v := os.Getenv("AUTOSAVE_MODE"); v != "" {
+			parsed, parseErr := session.ParseAutosaveMode(v)
+			if parseErr != nil {
+				log.Fatalf("FATAL: %v", parseErr)
+			}
+			autosaveMode = parsed
+		}
+		everyN, _ := strconv.Atoi(os.Getenv("AUTOSAVE_EVERY_N"))
+		narrativeEngine.Autosaver = session.NewAutosaver(sessionStore, session.AutosavePolicy{Mode: autosaveMode, EveryN: everyN})
+		fmt.Printf("Autosave enabled (mode: %s).\n", autosaveMode)
+	}
+
+	// Long-term memory search (MEMORY_EMBEDDER: "keyword" default, "hashing", or "gemini")
+	var memoryEmbedder lore.Embedder
+	switch name := os.Getenv("MEMORY_EMBEDDER"); name {
+	case "", "keyword":
+		// nil embedder = keyword overlap search
+	case "hashing":
+		memoryEmbedder = lore.NewHashingEmbedder(0)
+	case "gemini":
+		memoryEmbedder = lore.NewGeminiEmbedder(os.Getenv("LORE_EMBEDDING_MODEL"), llmHTTPClient)
+	default:
+		log.Printf("Warning: Unknown MEMORY_EMBEDDER '%s', using keyword search.", name)
+	}
+	memorySearcher = memory.NewSearcher(memoryEmbedder)
+	narrativeEngine.MemorySearcher = memorySearcher
+	narrativeEngine.MemoryRecall = 3 // Default past events recalled per turn
+	if v := os.Getenv("MEMORY_RECALL"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil {
+			log.Printf("Warning: Invalid MEMORY_RECALL '%s', using default: %v", v, convErr)
+		} else {
+			narrativeEngine.MemoryRecall = n
+		}
+	}
+
+	// Lore is included in prompts by the tags a scene mentions (LORE_MENTIONS documents per turn)
+	narrativeEngine.Lore = loreLibrary
+	if v := os.Getenv("LORE_MENTIONS"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil {
+			log.Printf("Warning: Invalid LORE_MENTIONS '%s', using default %d: %v", v, narrative.DefaultLoreMentions, convErr)
+		} else {
+			narrativeEngine.LoreMentions = n
+		}
+	}
+	// and, with LORE_DATA_PATH or LORE_EMBEDDER set, indexed with location descriptions for retrieval (RAG)
+	if os.Getenv("LORE_DATA_PATH") != "" || os.Getenv("LORE_EMBEDDER") != "" {
+		narrativeEngine.LoreRetriever, narrativeEngine.LoreTopK = initLoreRetriever(loreLibrary.Documents("", ""), llmHTTPClient)
+	}
+	fmt.Println("Narrative engine initialized.")
+
+	// Load optional few-shot examples for the world (player input -> ideal JSON response)
+	if examplesPath := os.Getenv("FEW_SHOT_EXAMPLES_PATH"); examplesPath != "" {
+		examples, exErr := llm.LoadFewShotExamples(os.DirFS(examplesPath))
+		if exErr != nil {
+			log.Printf("Warning: Failed to load few-shot examples from %s: %v. Continuing without examples.", examplesPath, exErr)
+		} else {
+			narrativeEngine.Examples = examples
+			fmt.Printf("Loaded %d few-shot example(s) from %s\n", len(examples), examplesPath)
+		}
+	}
+
+	// Host the primary world (WORLD_ID, else the archive's ID, else "default"), plus any
+	// archives listed in WORLDS; each hosted world runs its async turns in the background
+	defaultWorldID = os.Getenv("WORLD_ID")
+	defaultWorld := &hostedWorld{Name: "Default", World: worldSystem, Engine: narrativeEngine, Scenarios: scenarios, Abilities: abilities, Perks: perks, Lore: loreLibrary, Rating: worldRating}
+	if archive != nil {
+		defaultWorld.Name, defaultWorld.StartID = archive.Manifest.Name, archive.Manifest.StartLocationID
+		if defaultWorldID == "" {
+			defaultWorldID = archive.Manifest.ID
+		}
+	}
+	if defaultWorldID == "" {
+		defaultWorldID = "default"
+	}
+	defaultWorld.ID = defaultWorldID
+	hostWorld(defaultWorld)
+	hostArchiveWorlds(os.Getenv("WORLDS"), simpleExecutor, narrativeEngine)
+	fmt.Printf("Hosting %d world(s); default: %s.\n", len(hostedWorlds), defaultWorldID)
+
+	// Attempt to Create a Default Session (for testing/convenience)
+	createDefaultSession()
+
+	// --- HTTP Server Setup ---
+	// Each route declares its middleware policy; every request also passes through
+	// panic recovery, request IDs and (with ACCESS_LOG=true) the access log.
+	corsCfg := loadCORSConfig()
+	cors := func(methods ...string) middleware { return corsMiddleware(corsCfg, methods...) }
+	limitTurns := rateLimitMiddleware(newTurnRateLimiter()) // Each turn request costs an LLM call
+	admin := fromFunc(adminMiddleware)
+	owner := fromFunc(sessionTokenMiddleware) // Requests must carry the session's access token
+	v1 := []route{
+		{"/action", handleAction, chain(cors("POST"), owner, limitTurns)},
+		{"/action/async", handleActionAsync, chain(cors("POST"), owner, limitTurns)},
+		{"/turn", handleGetTurn, cors("GET")}, // Turn IDs are opaque and only returned to the session's owner
+		{"/session/{id}/memory/search", handleMemorySearch, chain(cors("GET"), owner)},
+		{"/session/{id}/history", handleGetHistory, chain(cors("GET"), owner)},
+		{"/session/{id}/stats", handleGetStats, chain(cors("GET"), owner)},
+		{"/session/{id}/rewind", handleRewind, chain(cors("POST"), owner)},
+		{"/session/{id}/challenge", handleChallengeResult, chain(cors("POST"), owner, limitTurns)},
+		{"/session/{id}/actions/confirm", handleConfirmActions, chain(cors("GET", "POST"), owner, limitTurns)},
+		{"/session/{id}/perks", handlePerks, chain(cors("GET", "POST"), owner)},
+		{"/session/{id}/report", handleReportTurn, chain(cors("POST"), owner, limitTurns)}, // Regenerating costs an LLM call
+		{"/session/{id}/turns/{n}/regenerate", handleRegenerateTurn, chain(cors("POST"), owner, limitTurns)},
+		{"/state", handleGetState, chain(cors("GET"), owner)},
+		{"/create_session", handleCreateSession, cors("POST")},
+		{"/players/{id}/sessions", handleListPlayerSessions, cors("GET")},
+		{"/themes", handleGetThemes, cors("GET")},
+		{"/lore", handleListLore, cors("GET")},
+		{"/lore/{id}", handleGetLore, cors("GET")},
+		{"/health", handleHealthCheck, cors("GET")}, // Basic health check
+		{"/admin/worlds/upload", handleUploadWorld, chain(cors("POST"), admin)},
+		{"/admin/worlds/export", handleExportWorld, chain(cors("GET"), admin)},
+		{"/admin/world/locations/{id}", handleWorldLocation, chain(cors("GET", "PUT", "DELETE"), admin)},
+		{"/admin/world/themes/{id}", handleWorldTheme, chain(cors("GET", "PUT", "DELETE"), admin)},
+		{"/admin/world/graph", handleWorldGraph, chain(cors("GET"), admin)},
+		{"/admin/world/stats", handleWorldStats, chain(cors("GET"), admin)},
+		{"/admin/world/lint", handleWorldLint, chain(cors("GET", "POST"), admin)},
+		{"/admin/world/generate", handleGenerateLocations, chain(cors("POST"), admin)},
+		{"/admin/analytics", handleAnalytics, chain(cors("GET"), admin)},
+		{"/admin/flags", handleFeatureFlags, chain(cors("GET"), admin)},
+		{"/admin/sessions/{id}/dry-run", handleDryRun, chain(cors("POST"), admin)},
+		{"/admin/session/{id}/prompt_preview", handlePromptPreview, chain(cors("GET"), admin)},
+	}
+	v1 = append(v1, notificationRoutes(chain(cors("GET", "PUT"), owner), cors("GET"))...)        // NOTIFY_SMTP_ADDR or VAPID_PRIVATE_KEY
+	v1 = append(v1, audienceRoutes(chain(cors("GET", "POST", "DELETE"), owner), cors("GET"))...) // TWITCH_AUDIENCE=true
+	// Every route is served under /v1, and at its original unversioned path (deprecated)
+	// for clients from before versioning; a future /v2 is added here beside v1
+	mux := newRouter([]apiVersion{{prefix: "/v1", routes: v1}}, "/v1", loadUnversionedSunset())
+	for _, rt := range pprofRoutes(admin) { // PPROF=true adds /admin/debug/pprof/ (operator tooling, not versioned)
+		mux.Handle(rt.pattern, rt.policy(rt.handler))
+	}
+	startPprofListener() // PPROF_ADDR serves pprof on a separate, private listener
+	serverMiddleware := []middleware{recoverMiddleware, requestIDMiddleware}
+	if os.Getenv("ACCESS_LOG") == "true" {
+		serverMiddleware = append(serverMiddleware, accessLogMiddleware)
+	}
+
+	// Serve location art and other static assets (ASSETS_PATH, default data/assets)
+	assetsPath := os.Getenv("ASSETS_PATH")
+	if assetsPath == "" {
+		assetsPath = "data/assets"
+	}
+	if info, statErr := os.Stat(assetsPath); statErr == nil && info.IsDir() {
+		assetsMaxAge := 86400 // Default cache lifetime: one day
+		if v := os.Getenv("ASSETS_MAX_AGE_SECONDS"); v != "" {
+			if n, convErr := strconv.Atoi(v); convErr != nil || n < 0 {
+				log.Printf("Warning: Invalid ASSETS_MAX_AGE_SECONDS '%s', using default %d", v, assetsMaxAge)
+			} else {
+				assetsMaxAge = n
+			}
+		}
+		mux.Handle("/assets/", assetHandler(assetsPath, assetsMaxAge))
+		assetsRoot = assetsPath
+		fmt.Printf("Serving static assets from %s at /assets/ (max-age %ds).\n", assetsPath, assetsMaxAge)
+	} else {
+		fmt.Printf("Static assets disabled: %s is not a directory.\n", assetsPath)
+	}
+
+	// Determine port
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080" // Default port
+	}
+
+	fmt.Printf("Starting llmrpg server on port %s with CORS enabled for origin(s): %s...\n", port, strings.Join(corsCfg.origins, ", "))
+	// Start listening
+	log.Fatal(listenAndServe(":"+port, chain(serverMiddleware...)(mux)))
+}
+
+// --- Helper Functions ---
+
+// newNarratorAdapter builds a narrator adapter: the server's provider and model, or
+// those a world's manifest picks, with the response schema and the world's
+// generation parameters applied.
+func newNarratorAdapter(settings *world.ModelSettings) llm.Adapter {
+	var provider, model string
+	if settings != nil {
+		provider, model = settings.Provider, settings.Model
+	}
+	adapter := newProviderAdapter(llmHTTPClient, provider, model)
+
+	// Send a response schema so providers enforce the action format (disable with LLM_RESPONSE_SCHEMA=false
+	// for OpenAI-compatible servers that don't support json_schema)
+	if configurable, ok := adapter.(llm.SchemaConfigurable); ok && os.Getenv("LLM_RESPONSE_SCHEMA") != "false" {
+		configurable.SetResponseSchema(narrative.ActionResponseSchema())
+		fmt.Println("LLM response schema enforcement enabled.")
+	}
+	// Prompts go out as conversations (system, example and history turns, then the context);
+	// LLM_CHAT_MESSAGES=false flattens them into one user message for models without system instructions
+	if configurable, ok := adapter.(llm.ChatConfigurable); ok && os.Getenv("LLM_CHAT_MESSAGES") == "false" {
+		configurable.SetChatMessages(false)
+		fmt.Println("LLM chat messages disabled; prompts are sent as a single user message.")
+	}
+	// LLM_PROMPT_CACHE_SECONDS has the provider cache the stable prompt prefix (system prompt and
+	// examples) for that long: a Gemini context cache, or a cache breakpoint for OpenAI-compatible APIs
+	if configurable, ok := adapter.(llm.CacheConfigurable); ok {
+		if v := os.Getenv("LLM_PROMPT_CACHE_SECONDS"); v != "" {
+			if n, convErr := strconv.Atoi(v); convErr != nil || n <= 0 {
+				log.Printf("Warning: Invalid LLM_PROMPT_CACHE_SECONDS '%s', prompt caching disabled", v)
+			} else {
+				configurable.SetPromptCaching(time.Duration(n) * time.Second)
+				fmt.Printf("LLM prompt caching enabled (%ds).\n", n)
+			}
+		}
+	}
+	if configurable, ok := adapter.(llm.ParamsConfigurable); ok && settings != nil {
+		configurable.SetGenerationParams(llm.GenerationParams{
+			Temperature: settings.Temperature,
+			TopP:        settings.TopP,
+			MaxTokens:   settings.MaxTokens,
+		})
+	}
+	return adapter
+}
+
+// newProviderAdapter builds the LLM adapter for provider and model; empty values
+// fall back to LLM_PROVIDER and the provider's *_MODEL_NAME variable.
+// In deterministic mode an unset LLM_PROVIDER means "mock".
+func newProviderAdapter(httpClient *http.Client, provider, model string) llm.Adapter {
+	if provider == "" {
+		provider = os.Getenv("LLM_PROVIDER")
+	}
+	if provider == "" && deterministic {
+		provider = "mock"
+	}
+	switch provider {
+	case "", "gemini":
+		modelName := model
+		if modelName == "" {
+			modelName = os.Getenv("GEMINI_MODEL_NAME")
+		}
+		if modelName == "" {
+			modelName = "gemini-1.5-flash-latest" // Default model
+		}
+
+		var adapter llm.Adapter
+		// GEMINI_AUTH_MODE selects "apikey" (default, public API) or "vertex" (service account via Vertex AI)
+		switch authMode := os.Getenv("GEMINI_AUTH_MODE"); authMode {
+		case "", "apikey":
+			if os.Getenv("GEMINI_API_KEY") == "" {
+				// Decide if this is fatal or just a warning
+				log.Println("Warning: GEMINI_API_KEY environment variable not set (check .env or system env). LLM calls will fail.")
+				// log.Fatal("FATAL: GEMINI_API_KEY must be set")
+			}
+			adapter = llm.NewGeminiAdapter(modelName, httpClient) // Assumes NewGeminiAdapter doesn't immediately need the key
+		case "vertex":
+			credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+			if credentialsPath == "" {
+				log.Fatal("FATAL: GOOGLE_APPLICATION_CREDENTIALS must be set when GEMINI_AUTH_MODE=vertex")
+			}
+			tokenSource, tsErr := llm.NewServiceAccountTokenSource(credentialsPath, httpClient)
+			if tsErr != nil {
+				log.Fatalf("FATAL: Failed to load Vertex AI credentials: %v", tsErr)
+			}
+			region := os.Getenv("VERTEX_REGION")
+			if region == "" {
+				region = "us-central1" // Default Vertex AI region
+			}
+			vertexAdapter, vErr := llm.NewVertexGeminiAdapter(modelName, os.Getenv("VERTEX_PROJECT_ID"), region, tokenSource, httpClient)
+			if vErr != nil {
+				log.Fatalf("FATAL: Failed to create Vertex AI adapter: %v", vErr)
+			}
+			adapter = vertexAdapter
+			fmt.Printf("Using Vertex AI authentication (region: %s).\n", region)
+		default:
+			log.Fatalf("FATAL: Unknown GEMINI_AUTH_MODE '%s' (expected 'apikey' or 'vertex')", authMode)
+		}
+		fmt.Printf("LLM adapter initialized (Provider: gemini, Model: %s).\n", modelName)
+		return adapter
+
+	case "openai":
+		// Any chat-completions compatible endpoint: OpenAI, OpenRouter, Together, vLLM, ...
+		baseURL := os.Getenv("OPENAI_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1" // Default to OpenAI itself
+		}
+		modelName := model
+		if modelName == "" {
+			modelName = os.Getenv("OPENAI_MODEL_NAME")
+		}
+		adapter, err := llm.NewOpenAICompatibleAdapter(baseURL, modelName, os.Getenv("OPENAI_API_KEY"), httpClient)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to create OpenAI-compatible adapter: %v", err)
+		}
+		fmt.Printf("LLM adapter initialized (Provider: openai-compatible, Base URL: %s, Model: %s).\n", baseURL, modelName)
+		return adapter
+
+	case "mock":
+		// Canned responses from MOCK_LLM_SCRIPT (a JSON array), then an echo of the input
+		var script []llm.LLMResponse
+		if scriptPath := os.Getenv("MOCK_LLM_SCRIPT"); scriptPath != "" {
+			var err error
+			script, err = llm.LoadMockScript(scriptPath)
+			if err != nil {
+				log.Fatalf("FATAL: Failed to load mock LLM script: %v", err)
+			}
+		}
+		fmt.Printf("LLM adapter initialized (Provider: mock, %d scripted responses).\n", len(script))
+		return llm.NewMockAdapter(script)
+
+	default:
+		log.Fatalf("FATAL: Unknown LLM_PROVIDER '%s' (expected 'gemini', 'openai' or 'mock')", provider)
+		return nil
+	}
+}
+
+// configureDeterministicMode applies DETERMINISTIC=true: a stepped clock starting at
+// DETERMINISTIC_START (RFC3339, default 2000-01-01T00:00:00Z) that advances
+// DETERMINISTIC_STEP_MS per reading, sequential IDs, and session seeds derived from
+// DETERMINISTIC_SEED in creation order. Must run before the session manager and turn
+// tracker are built, since they take the default clock and ID generator.
+// Reports whether the mode is on.
+func configureDeterministicMode() bool {
+	on, _ := strconv.ParseBool(os.Getenv("DETERMINISTIC"))
+	if !on {
+		return false
+	}
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if v := os.Getenv("DETERMINISTIC_START"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err != nil {
+			log.Printf("Warning: Invalid DETERMINISTIC_START '%s', using default: %v", v, err)
+		} else {
+			start = t
+		}
+	}
+	step := time.Second
+	if v := os.Getenv("DETERMINISTIC_STEP_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			log.Printf("Warning: Invalid DETERMINISTIC_STEP_MS '%s', using default", v)
+		} else {
+			step = time.Duration(n) * time.Millisecond
+		}
+	}
+	seed := uint64(1)
+	if v := os.Getenv("DETERMINISTIC_SEED"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err != nil {
+			log.Printf("Warning: Invalid DETERMINISTIC_SEED '%s', using default: %v", v, err)
+		} else {
+			seed = n
+		}
+	}
+	clock.Set(clock.NewStepped(start, step))
+	ids.Set(ids.NewSequence())
+	session.SeedSessionsFrom(seed)
+	fmt.Printf("Deterministic mode: clock starts %s (step %s), session seeds from %d.\n", start.Format(time.RFC3339), step, seed)
+	return true
+}
+
+// loadHistoryPolicy reads the session history window/retention policy from the environment.
+func loadHistoryPolicy() history.Policy {
+	policy := history.DefaultPolicy()
+	if v := os.Getenv("HISTORY_RECENT_WINDOW"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			log.Printf("Warning: Invalid HISTORY_RECENT_WINDOW '%s', using default %d", v, policy.RecentWindow)
+		} else {
+			policy.RecentWindow = n
+		}
+	}
+	if v := os.Getenv("HISTORY_MAX_RETAINED"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			log.Printf("Warning: Invalid HISTORY_MAX_RETAINED '%s', using default %d", v, policy.MaxRetained)
+		} else {
+			policy.MaxRetained = n
+		}
+	}
+	policy.IncludeNarration = os.Getenv("HISTORY_INCLUDE_NARRATION") == "true"
+	return policy
+}
+
+// embeddedFS returns the embedded default data rooted at dir (e.g. "data/locations").
+func embeddedFS(dir string) fs.FS {
+	sub, err := fs.Sub(llmrpg.DefaultWorld, dir)
+	if err != nil {
+		log.Fatalf("FATAL: Embedded data directory %s unavailable: %v", dir, err)
+	}
+	return sub
+}
+
+// initLoreRetriever indexes the world's lore documents plus location descriptions.
+// LORE_EMBEDDER selects "hashing" (default, local) or "gemini" (semantic, uses GEMINI_API_KEY).
+func initLoreRetriever(docs []lore.Document, httpClient *http.Client) (*lore.Retriever, int) {
+	var embedder lore.Embedder
+	switch name := os.Getenv("LORE_EMBEDDER"); name {
+	case "", "hashing":
+		embedder = lore.NewHashingEmbedder(0)
+	case "gemini":
+		embedder = lore.NewGeminiEmbedder(os.Getenv("LORE_EMBEDDING_MODEL"), httpClient)
+	default:
+		log.Printf("Warning: Unknown LORE_EMBEDDER '%s', lore retrieval disabled.", name)
+		return nil, 0
+	}
+
+	docs = append(docs, lore.DocumentsFromWorld(worldSystem)...)
+
+	retriever := lore.NewRetriever(embedder, lore.NewInMemoryVectorStore())
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := retriever.IndexDocuments(ctx, docs); err != nil {
+		log.Printf("Warning: Failed to index lore: %v. Lore retrieval disabled.", err)
+		return nil, 0
+	}
+
+	topK := 3 // Default lore chunks per turn
+	if v := os.Getenv("LORE_TOP_K"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil {
+			log.Printf("Warning: Invalid LORE_TOP_K '%s', using default %d: %v", v, topK, convErr)
+		} else {
+			topK = n
+		}
+	}
+	fmt.Printf("Lore retriever initialized (%d document(s), top-K %d).\n", len(docs), topK)
+	return retriever, topK
+}
+
+// newSessionStore builds the persistence backend selected by SESSION_STORE, or nil if disabled.
+// For backwards compatibility, setting only SESSION_STORE_PATH selects the file store.
+func newSessionStore() session.Store {
+	storeType := os.Getenv("SESSION_STORE")
+	if storeType == "" && os.Getenv("SESSION_STORE_PATH") != "" {
+		storeType = "file"
+	}
+
+	switch storeType {
+	case "", "none":
+		return nil
+	case "file":
+		storePath := os.Getenv("SESSION_STORE_PATH")
+		if storePath == "" {
+			storePath = "data/saves" // Default save directory
+		}
+		fileStore, err := session.NewFileStore(storePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open session store: %v", err)
+		}
+		fmt.Printf("Session persistence: file store at %s\n", storePath)
+		return fileStore
+	case "blob":
+		// S3-compatible object storage: AWS S3, GCS (HMAC interoperability keys), MinIO, R2...
+		blobStore, err := session.NewBlobStore(session.BlobStoreConfig{
+			Endpoint:     os.Getenv("BLOB_ENDPOINT"),
+			Region:       os.Getenv("BLOB_REGION"),
+			Bucket:       os.Getenv("BLOB_BUCKET"),
+			Prefix:       os.Getenv("BLOB_PREFIX"),
+			AccessKey:    os.Getenv("BLOB_ACCESS_KEY"),
+			SecretKey:    os.Getenv("BLOB_SECRET_KEY"),
+			PathStyle:    os.Getenv("BLOB_PATH_STYLE") == "true",
+			StorageClass: os.Getenv("BLOB_STORAGE_CLASS"),
+			Tagging:      os.Getenv("BLOB_TAGGING"),
+		}, nil)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to configure blob session store: %v", err)
+		}
+		fmt.Printf("Session persistence: blob store at %s/%s\n", os.Getenv("BLOB_ENDPOINT"), os.Getenv("BLOB_BUCKET"))
+		return blobStore
+	default:
+		log.Fatalf("FATAL: Unknown SESSION_STORE '%s' (expected 'file' or 'blob')", storeType)
+		return nil
+	}
+}
+
+// newModerationPolicy builds the moderation policy selected by MODERATION_PROVIDER, or nil if disabled.
+// MODERATION_INPUT_ACTION (default block) and MODERATION_OUTPUT_ACTION (default soften) choose
+// what happens to flagged text; verdicts go to MODERATION_AUDIT_PATH (JSON lines) or the log.
+func newModerationPolicy(httpClient *http.Client) *narrative.ModerationPolicy {
+	policy := &narrative.ModerationPolicy{InputAction: moderation.ActionBlock, OutputAction: moderation.ActionSoften}
+	switch provider := os.Getenv("MODERATION_PROVIDER"); provider {
+	case "", "none":
+		return nil
+	case "keywords":
+		path := os.Getenv("MODERATION_KEYWORDS_PATH")
+		if path == "" {
+			log.Fatalf("FATAL: MODERATION_PROVIDER=keywords requires MODERATION_KEYWORDS_PATH")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open moderation keyword list: %v", err)
+		}
+		defer f.Close()
+		keywords, err := moderation.LoadKeywords(f)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to load moderation keyword list %s: %v", path, err)
+		}
+		policy.Moderator = keywords
+	case "openai":
+		baseURL := os.Getenv("MODERATION_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		policy.Moderator = moderation.NewOpenAIModerator(baseURL, os.Getenv("MODERATION_MODEL"), os.Getenv("OPENAI_API_KEY"), httpClient)
+	default:
+		log.Fatalf("FATAL: Unknown MODERATION_PROVIDER '%s' (expected 'keywords' or 'openai')", provider)
+	}
+
+	for env, action := range map[string]*moderation.Action{"MODERATION_INPUT_ACTION": &policy.InputAction, "MODERATION_OUTPUT_ACTION": &policy.OutputAction} {
+		if v := os.Getenv(env); v != "" {
+			parsed, err := moderation.ParseAction(v)
+			if err != nil {
+				log.Fatalf("FATAL: Invalid %s: %v", env, err)
+			}
+			*action = parsed
+		}
+	}
+	if auditPath := os.Getenv("MODERATION_AUDIT_PATH"); auditPath != "" {
+		auditLog, err := moderation.NewFileAuditLog(auditPath)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		policy.Audit = auditLog
+	}
+	fmt.Printf("Moderation enabled (provider: %s, input: %s, output: %s).\n", os.Getenv("MODERATION_PROVIDER"), policy.InputAction, policy.OutputAction)
+	return policy
+}
+
+// newTriagePolicy builds the input triage policy, or nil if neither TRIAGE_PROVIDER nor
+// TRIAGE_MODEL is set. Unset, the provider is the narrator's; TRIAGE_SHORTCUTS lists the
+// input kinds answered without the narrator (default movement,question,chatter).
+func newTriagePolicy() *narrative.TriagePolicy {
+	provider, model := os.Getenv("TRIAGE_PROVIDER"), os.Getenv("TRIAGE_MODEL")
+	if provider == "" && model == "" {
+		return nil
+	}
+	temperature, maxTokens := 0.0, 64 // Labels are short and should be stable
+	adapter := newNarratorAdapter(&world.ModelSettings{Provider: provider, Model: model, Temperature: &temperature, MaxTokens: &maxTokens})
+	policy := &narrative.TriagePolicy{Adapter: limitedAdapter.Share(adapter), Shortcuts: narrative.DefaultTriageShortcuts}
+	if v, ok := os.LookupEnv("TRIAGE_SHORTCUTS"); ok {
+		policy.Shortcuts = []narrative.InputKind{}
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			kind, err := narrative.ParseInputKind(name)
+			if err != nil {
+				log.Fatalf("FATAL: Invalid TRIAGE_SHORTCUTS: %v", err)
+			}
+			policy.Shortcuts = append(policy.Shortcuts, kind)
+		}
+	}
+	fmt.Printf("Input triage enabled (shortcuts: %v).\n", policy.Shortcuts)
+	return policy
+}
+
+// newLocationDrafter builds the world authoring assistant. AUTHORING_PROVIDER and
+// AUTHORING_MODEL pick its model (unset, the narrator's provider and model).
+func newLocationDrafter() *narrative.LocationDrafter {
+	temperature, maxTokens := 0.9, 2048 // Varied drafts, and room for several of them
+	settings := &world.ModelSettings{Provider: os.Getenv("AUTHORING_PROVIDER"), Model: os.Getenv("AUTHORING_MODEL"), Temperature: &temperature, MaxTokens: &maxTokens}
+	return &narrative.LocationDrafter{Adapter: limitedAdapter.Share(newNarratorAdapter(settings))}
+}
+
+// initTracing enables span export as selected by OTEL_TRACES_EXPORTER, using the
+// standard OpenTelemetry variables for the collector endpoint, headers and service name.
+func initTracing() {
+	switch exporterType := os.Getenv("OTEL_TRACES_EXPORTER"); exporterType {
+	case "", "none":
+		return
+	case "console":
+		tracing.SetExporter(tracing.LogExporter{})
+		fmt.Println("Tracing: logging spans to the console")
+	case "otlp":
+		endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if endpoint == "" {
+			endpoint = "http://localhost:4318" // Default OTLP/HTTP collector port
+		}
+		headers := make(map[string]string)
+		for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		exporter, err := tracing.NewOTLPExporter(tracing.OTLPConfig{
+			Endpoint:    endpoint,
+			Headers:     headers,
+			ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		}, nil)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to configure OTLP trace exporter: %v", err)
+		}
+		tracing.SetExporter(exporter)
+		fmt.Printf("Tracing: exporting spans to %s\n", endpoint)
+	default:
+		log.Fatalf("FATAL: Unknown OTEL_TRACES_EXPORTER '%s' (expected 'otlp' or 'console')", exporterType)
+	}
+}
+
+// newAnalyticsRecorder builds the analytics recorder selected by ANALYTICS_STORE, or nil if disabled.
+func newAnalyticsRecorder() *analytics.Recorder {
+	var store analytics.Store
+	switch storeType := os.Getenv("ANALYTICS_STORE"); storeType {
+	case "", "none":
+		return nil
+	case "memory":
+		store = analytics.NewMemoryStore()
+		fmt.Println("Analytics: in-memory store")
+	case "file":
+		storePath := os.Getenv("ANALYTICS_STORE_PATH")
+		if storePath == "" {
+			storePath = "data/analytics" // Default analytics directory
+		}
+		fileStore, err := analytics.NewFileStore(storePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open analytics store: %v", err)
+		}
+		store = fileStore
+		fmt.Printf("Analytics: file store at %s\n", storePath)
+	default:
+		log.Fatalf("FATAL: Unknown ANALYTICS_STORE '%s' (expected 'memory' or 'file')", storeType)
+	}
+
+	salt := os.Getenv("ANALYTICS_SALT")
+	if salt == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			log.Fatalf("FATAL: Failed to generate analytics salt: %v", err)
+		}
+		salt = hex.EncodeToString(buf)
+		log.Printf("Warning: ANALYTICS_SALT not set, using a random salt. Sessions restored after a restart will be counted again.")
+	}
+	return analytics.NewRecorder(store, salt)
+}
+
+// loadArchiveAfter reads SESSION_ARCHIVE_AFTER_MINUTES: how long a session may sit idle
+// before it is moved to the session store and out of memory (0 = never). It needs a
+// session store to archive to.
+func loadArchiveAfter() time.Duration {
+	v := os.Getenv("SESSION_ARCHIVE_AFTER_MINUTES")
+	if v == "" {
+		return 0
+	}
+	minutes, err := strconv.Atoi(v)
+	if err != nil || minutes <= 0 {
+		log.Fatalf("FATAL: Invalid SESSION_ARCHIVE_AFTER_MINUTES '%s' (expected a positive number of minutes)", v)
+	}
+	if sessionStore == nil {
+		log.Fatal("FATAL: SESSION_ARCHIVE_AFTER_MINUTES needs a session store to archive to (set SESSION_STORE)")
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// loadSessionCaps reads MAX_SESSIONS, the most sessions kept in memory (0 = unlimited),
+// and SESSION_CAP_WARN_PERCENT, the share of it in use at which /health reports the
+// server as near capacity (default 80). With a session store, the least recently
+// active sessions are evicted to it to stay under the cap; without one, new sessions
+// are refused once it's reached.
+func loadSessionCaps(sm *session.InMemorySessionManager) {
+	if v := os.Getenv("MAX_SESSIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("FATAL: Invalid MAX_SESSIONS '%s' (expected a non-negative number)", v)
+		}
+		sm.MaxSessions = n
+	}
+	if v := os.Getenv("SESSION_CAP_WARN_PERCENT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			log.Printf("Warning: Invalid SESSION_CAP_WARN_PERCENT '%s', using default %d", v, session.DefaultCapWarnPercent)
+		} else {
+			sm.CapWarnPercent = n
+		}
+	}
+	if sm.MaxSessions == 0 {
+		return
+	}
+	if sessionStore == nil {
+		fmt.Printf("At most %d session(s) kept in memory; new sessions are refused beyond that (no session store to evict to).\n", sm.MaxSessions)
+	} else {
+		fmt.Printf("At most %d session(s) kept in memory; the least recently active are evicted to the session store.\n", sm.MaxSessions)
+	}
+}
+
+// archiveIdleSessions periodically moves sessions idle for archiveAfter to cold storage.
+func archiveIdleSessions(sm *session.InMemorySessionManager, archiveAfter time.Duration) {
+	ticker := time.NewTicker(min(archiveAfter/2, 10*time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		archived, err := sm.ArchiveIdle(archiveAfter)
+		if err != nil {
+			log.Printf("Warning: Session archival: %v", err)
+		}
+		if archived > 0 {
+			fmt.Printf("Archived %d idle session(s).\n", archived)
+		}
+	}
+}
+
+// restoreSessions loads every stored session into the in-memory manager. With archival
+// on (archiveAfter > 0), sessions already idle that long are registered as archived
+// instead, as are any beyond MAX_SESSIONS, and stay in the store until they're played
+// again.
+func restoreSessions(sm *session.InMemorySessionManager, archiveAfter time.Duration) {
+	ids, err := sessionStore.ListSessionIDs()
+	if err != nil {
+		log.Printf("Warning: Failed to list stored sessions: %v", err)
+		return
+	}
+	restored, archived, locked := 0, 0, 0
+	for _, id := range ids {
+		sess, loadErr := sessionStore.LoadSession(id)
+		if loadErr != nil {
+			log.Printf("Warning: Skipping stored session %s: %v", id, loadErr)
+			continue
+		}
+		idle := archiveAfter > 0 && sm.Clock.Now().Sub(sess.LastActive) > archiveAfter
+		full := sm.MaxSessions > 0 && sm.Usage().ActiveSessions >= sm.MaxSessions // Past MAX_SESSIONS, the rest wait in the store
+		idle = idle || full
+		add := sm.AddSession
+		if idle {
+			add = sm.AddArchivedSession
+		}
+		migrated, addErr := add(sess)
+		if addErr != nil {
+			log.Printf("Warning: Skipping stored session %s: %v", id, addErr)
+			continue
+		}
+		if migrated {
+			migrateStoredSession(id, sess)
+		}
+		restored++
+		if idle {
+			archived++
+		}
+		if sess.AccessTokenHash == "" {
+			locked++
+		}
+	}
+	fmt.Printf("Restored %d session(s) from store (%d archived).\n", restored, archived)
+	if locked > 0 {
+		log.Printf("Warning: %d restored session(s) were saved without an access token and can't be opened", locked)
+	}
+}
+
+// migrateStoredSession re-saves a session that was just given an opaque ID and removes
+// the save under its old ID, when the store can delete. The old ID is kept in the
+// session (LegacyID), so it still resolves after later restarts.
+func migrateStoredSession(oldID string, sess *session.GameSession) {
+	if err := sessionStore.SaveSession(sess); err != nil {
+		log.Printf("Warning: Failed to save migrated session %s (was %s): %v", sess.ID, oldID, err)
+		return
+	}
+	if deleter, ok := sessionStore.(interface{ DeleteSession(string) error }); ok {
+		if err := deleter.DeleteSession(oldID); err != nil {
+			log.Printf("Warning: Failed to remove old save of migrated session %s: %v", oldID, err)
+		}
+	}
+	fmt.Printf("Migrated session %s to ID %s.\n", oldID, sess.ID)
+}
+
+// createDefaultSession creates a default session if none exist (useful for development)
+func createDefaultSession() {
+	// Check if any sessions already exist
+	if len(sessionManager.GetAllSessionIDs()) > 0 {
+		fmt.Println("Default session creation skipped: Sessions already exist.")
+		return
+	}
+
+	// Define default character and starting location
+	player := character.NewCharacter(ids.New(), "Ash", "Wasteland-Born", "Courier")
+	player.Abilities = world.StartingAbilities(abilities, player.Class)
+	startLocationID := "oakhaven_gate" // Default start location ID from sample data
+
+	// Verify start location exists
+	if len(worldSystem.GetAllLocationIDs()) > 0 {
+		if _, err := worldSystem.GetLocation(startLocationID); err != nil {
+			fmt.Printf("Warning: Default start location '%s' not found. Using first available location.\n", startLocationID)
+			startLocationID = worldSystem.GetAllLocationIDs()[0] // Fallback to first loaded location
+		}
+	} else {
+		log.Println("Warning: Cannot create default session: No locations loaded.")
+		return // Cannot create session without locations
+	}
+
+	// Create the session
+	sess, err := sessionManager.CreateNewSession(player, startLocationID)
+	if err != nil {
+		// Log failure but don't necessarily stop the server
+		log.Printf("Warning: Failed to create default session: %v", err)
+		return
+	}
+	// Like any session, it only opens with its access token; print it for development use
+	accessToken := sess.IssueAccessToken()
+	if err := sessionManager.UpdateSession(sess); err != nil {
+		log.Printf("Warning: Failed to update default session: %v", err)
+	}
+	fmt.Printf("Default session created successfully (%s: %s).\n", sessionTokenHeader, accessToken)
+}
+
+// --- HTTP Handlers ---
+
+// handleAction processes player input via the NarrativeEngine.
+func handleAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get Session ID from query parameter
+	// (sessionTokenMiddleware has already required it and checked the access token)
+	sessionID := r.URL.Query().Get("sessionId")
+
+	// Decode request body
+	var requestBody struct {
+		Input string `json:"input"`
+	}
+	if !decodeJSONBody(w, r, &requestBody) {
+		return
+	}
+	requestBody.Input = narrative.SanitizeInput(requestBody.Input)
+	if requestBody.Input == "" {
+		http.Error(w, "Missing 'input' in request body", http.StatusBadRequest)
+		return
+	}
+	if !checkTextLength(w, "input", requestBody.Input, maxInputChars) {
+		return
+	}
+
+	// Process input using the engine
+	ctx := r.Context() // Use request context for potential cancellation
+	llmResponse, err := hostedWorldFrom(r).Engine.ProcessPlayerInput(ctx, sessionID, requestBody.Input)
+
+	// Handle errors from the engine
+	if err != nil {
+		log.Printf("ERROR [handleAction Session: %s]: %v\n", sessionID, err)
+		// Check if the error is due to client disconnecting
+		if errors.Is(err, context.Canceled) {
+			http.Error(w, "Request cancelled by client.", 499) // 499 Client Closed Request
+			return
+		}
+		if errors.Is(err, session.ErrSessionNotFound) {
+			http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, session.ErrSessionLimit) {
+			http.Error(w, "The server is at capacity; please try again later.", http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, session.ErrSessionCompleted) {
+			http.Error(w, "Session has reached an ending and accepts no further actions; see /state for the epilogue.", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, llm.ErrLLMTimeout) {
+			http.Error(w, "The narrator took too long to respond; please try again.", http.StatusGatewayTimeout)
+			return
+		}
+		if errors.Is(err, narrative.ErrInputRejected) {
+			http.Error(w, "Input rejected: speak and act as your character rather than instructing the narrator.", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, narrative.ErrInputModerated) {
+			http.Error(w, "Input rejected by content moderation.", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, narrative.ErrTurnFailed) {
+			http.Error(w, "The turn failed due to an internal error and was rolled back; please try again.", http.StatusInternalServerError)
+			return
+		}
+		// Return a generic server error to the client
+		http.Error(w, "Failed to process input due to an internal server error.", http.StatusInternalServerError)
+		return
+	}
+
+	// Send successful response: the versioned envelope, or the legacy flat LLMResponse if negotiated
+	var response interface{} = llmResponse
+	if !wantsLegacyTurnResponse(r) {
+		currentSession, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			log.Printf("ERROR [handleAction Session: %s]: Session disappeared after turn: %v\n", sessionID, err)
+			http.Error(w, "Failed to process input due to an internal server error.", http.StatusInternalServerError)
+			return
+		}
+		response = narrative.NewTurnEnvelope(currentSession, hostedWorldFrom(r).World, llmResponse)
+	}
+	offerAudience(sessionID, llmResponse.Suggestions) // Viewers vote on what the streamer's turn suggests
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		// Log error if encoding fails (response might be partially sent)
+		log.Printf("ERROR [handleAction Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// legacyTurnMediaType requests the pre-envelope flat LLMResponse shape from /action.
+const legacyTurnMediaType = "application/vnd.llmrpg.legacy+json"
+
+// wantsLegacyTurnResponse reports whether the client negotiated the legacy response shape,
+// via "Accept: application/vnd.llmrpg.legacy+json" or "?format=legacy".
+func wantsLegacyTurnResponse(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), legacyTurnMediaType) || r.URL.Query().Get("format") == "legacy"
+}
+
+// handleActionAsync queues player input for background processing and returns the turn ID.
+// Clients poll /turn?turnId=... for queue position and the final response.
+func handleActionAsync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		http.Error(w, "Missing 'sessionId' query parameter", http.StatusBadRequest)
+		return
+	}
+	if sess, err := sessionManager.GetSession(sessionID); err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	} else if sess.Completed() {
+		http.Error(w, "Session has reached an ending and accepts no further actions; see /state for the epilogue.", http.StatusConflict)
+		return
+	}
+
+	var requestBody struct {
+		Input string `json:"input"`
+	}
+	if !decodeJSONBody(w, r, &requestBody) {
+		return
+	}
+	requestBody.Input = narrative.SanitizeInput(requestBody.Input)
+	if requestBody.Input == "" {
+		http.Error(w, "Missing 'input' in request body", http.StatusBadRequest)
+		return
+	}
+	if !checkTextLength(w, "input", requestBody.Input, maxInputChars) {
+		return
+	}
+
+	turn, err := hostedWorldFrom(r).Turns.Submit(sessionID, requestBody.Input)
+	if err != nil {
+		if errors.Is(err, narrative.ErrTurnInProgress) {
+			http.Error(w, fmt.Sprintf("%v; poll /turn for its result.", err), http.StatusConflict)
+			return
+		}
+		log.Printf("ERROR [handleActionAsync Session: %s]: %v\n", sessionID, err)
+		http.Error(w, "Failed to queue the turn due to an internal server error.", http.StatusInternalServerError)
+		return
+	}
+
+	// 202 Accepted: the turn will complete in the background
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(turn); err != nil {
+		log.Printf("ERROR [handleActionAsync Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// handleGetTurn reports the status (queue position, result) of an async turn.
+func handleGetTurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	turnID := r.URL.Query().Get("turnId")
+	if turnID == "" {
+		http.Error(w, "Missing 'turnId' query parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Turn IDs are unique across worlds, so ask each world's tracker
+	var turn narrative.AsyncTurn
+	err := fmt.Errorf("turn not found: %s", turnID)
+	for _, hw := range hostedWorlds {
+		if turn, err = hw.Turns.Get(turnID); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(turn); err != nil {
+		log.Printf("ERROR [handleGetTurn Turn: %s]: Failed to encode response: %v\n", turnID, err)
+	}
+}
+
+// handleMemorySearch searches a session's long-term memory, e.g. "what did the smith tell me?".
+func handleMemorySearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing 'q' query parameter", http.StatusBadRequest)
+		return
+	}
+	limit := 10 // Default result count
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid 'limit' value: %s", v), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	currentSession, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	results, err := memorySearcher.Search(r.Context(), currentSession.Memory, query, limit)
+	if err != nil {
+		log.Printf("ERROR [handleMemorySearch Session: %s]: %v\n", sessionID, err)
+		http.Error(w, "Failed to search session memory due to an internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []memory.Result{} // Encode as [] rather than null
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessionId": sessionID,
+		"query":     query,
+		"results":   results,
+	}); err != nil {
+		log.Printf("ERROR [handleMemorySearch Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// handleGetStats returns a session's playthrough statistics.
+func handleGetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	currentSession, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessionId": sessionID,
+		"status":    currentSession.Status,
+		"stats":     currentSession.Stats,
+	}); err != nil {
+		log.Printf("ERROR [handleGetStats Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// handleGetHistory returns a session's structured turn history, oldest first.
+// An optional 'limit' query parameter returns only the most recent entries.
+func handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	currentSession, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	records := currentSession.Memory
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid 'limit' value: %s", v), http.StatusBadRequest)
+			return
+		}
+		if len(records) > n {
+			records = records[len(records)-n:]
+		}
+	}
+	if records == nil {
+		records = []history.TurnRecord{} // Encode as [] rather than null
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessionId": sessionID,
+		"turnCount": currentSession.TurnCount,
+		"records":   records,
+	}); err != nil {
+		log.Printf("ERROR [handleGetHistory Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// handleRewind is the entry point for rewinding a session to an earlier turn.
+// Ironman sessions are always refused; rewinding itself isn't available yet.
+func handleRewind(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	currentSession, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+	if err := currentSession.CheckRewindable(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	http.Error(w, "Rewind is not supported by this server yet.", http.StatusNotImplemented)
+}
+
+// handleChallengeResult reports the frontend's minigame result for the session's pending
+// challenge ({"success": true/false}; omit success to let the server roll it) and returns
+// the turn narrating the outcome, like /action.
+func handleChallengeResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if _, err := sessionManager.GetSession(sessionID); err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
 	}
-	if err := worldSystem.LoadWorldData(locPath, themePath); err != nil {
-		log.Fatalf("FATAL: Failed to load world data from '%s' and '%s': %v", locPath, themePath, err)
+	var requestBody struct {
+		Success *bool `json:"success"`
+	}
+	if !decodeJSONBody(w, r, &requestBody) {
+		return
 	}
-	fmt.Println("World system loaded.")
 
-	// Initialize Session Manager
-	sessionManager = session.NewInMemorySessionManager()
-	fmt.Println("Session manager initialized.")
+	llmResponse, err := hostedWorldFrom(r).Engine.ResolveChallenge(r.Context(), sessionID, requestBody.Success)
+	if err != nil {
+		log.Printf("ERROR [handleChallengeResult Session: %s]: %v\n", sessionID, err)
+		switch {
+		case errors.Is(err, narrative.ErrNoPendingChallenge):
+			http.Error(w, "No challenge is waiting for a minigame result.", http.StatusConflict)
+		case errors.Is(err, session.ErrSessionCompleted):
+			http.Error(w, "Session has reached an ending and accepts no further actions; see /state for the epilogue.", http.StatusConflict)
+		case errors.Is(err, narrative.ErrTurnFailed):
+			http.Error(w, "The turn failed due to an internal error and was rolled back; please try again.", http.StatusInternalServerError)
+		case errors.Is(err, llm.ErrLLMTimeout):
+			http.Error(w, "The narrator took too long to respond; please try again.", http.StatusGatewayTimeout)
+		case errors.Is(err, context.Canceled):
+			http.Error(w, "Request cancelled by client.", 499)
+		default:
+			http.Error(w, "Failed to resolve the challenge due to an internal server error.", http.StatusInternalServerError)
+		}
+		return
+	}
 
-	// Initialize LLM Adapter
-	modelName := os.Getenv("GEMINI_MODEL_NAME")
-	if modelName == "" {
-		modelName = "gemini-1.5-flash-latest" // Default model
+	var response interface{} = llmResponse
+	if !wantsLegacyTurnResponse(r) {
+		currentSession, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			http.Error(w, "Failed to resolve the challenge due to an internal server error.", http.StatusInternalServerError)
+			return
+		}
+		response = narrative.NewTurnEnvelope(currentSession, hostedWorldFrom(r).World, llmResponse)
 	}
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		// Decide if this is fatal or just a warning
-		log.Println("Warning: GEMINI_API_KEY environment variable not set (check .env or system env). LLM calls will fail.")
-		// log.Fatal("FATAL: GEMINI_API_KEY must be set")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("ERROR [handleChallengeResult Session: %s]: Failed to encode response: %v\n", sessionID, err)
 	}
-	llmAdapter = llm.NewGeminiAdapter(modelName) // Assumes NewGeminiAdapter doesn't immediately need the key
-	fmt.Printf("LLM adapter initialized (Model: %s).\n", modelName)
-
-	// Initialize Action Executor
-	// Inject dependencies needed by the executor (currently just WorldSystem)
-	actionExecutor = narrative.NewSimpleActionExecutor(worldSystem /*, inventorySystem, etc */)
-	fmt.Println("Action executor initialized.")
+}
 
-	// Initialize Narrative Engine
-	// Load system prompt from file or use default
-	defaultPromptPath := "data/prompts/system_prompt.txt" // Default system prompt path
-	systemPromptPath := os.Getenv("SYSTEM_PROMPT_PATH")
-	if systemPromptPath == "" {
-		systemPromptPath = defaultPromptPath
-		fmt.Printf("Using default prompt path: %s\n", defaultPromptPath)
+// handleConfirmActions applies ({"confirm": true}) or declines ({"confirm": false}) the
+// session's high-impact actions waiting for confirmation and returns the turn narrating
+// the outcome, like /action. GET lists them with a preview of what they would do.
+func handleConfirmActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	var systemPrompt string
-	promptBytes, err := os.ReadFile(systemPromptPath)
+	sessionID := r.PathValue("id")
+	currentSession, err := sessionManager.GetSession(sessionID)
 	if err != nil {
-		// Truly minimal fallback prompt as last resort
-		systemPrompt = `You are the narrator for a text adventure game. Describe the world vividly and respond to player actions.`
-		log.Printf("Warning: Failed to read system prompt from %s: %v. Using minimal fallback.", systemPromptPath, err)
-	} else {
-		systemPrompt = string(promptBytes)
-		fmt.Printf("Loaded system prompt from %s (%d bytes)\n", systemPromptPath, len(promptBytes))
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
 	}
-	narrativeEngine, err = narrative.NewNarrativeEngine(worldSystem, llmAdapter, actionExecutor, sessionManager, systemPrompt)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to create narrative engine: %v", err)
+	if r.Method == http.MethodGet {
+		if len(currentSession.PendingActions) == 0 {
+			http.Error(w, "No actions are waiting for confirmation.", http.StatusNotFound)
+			return
+		}
+		preview, err := hostedWorldFrom(r).Engine.DryRun(sessionID, nil)
+		if err != nil {
+			log.Printf("ERROR [handleConfirmActions Session: %s]: %v\n", sessionID, err)
+			http.Error(w, "Failed to preview the pending actions due to an internal server error.", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pending": currentSession.PendingActions,
+			"preview": preview, // Rolls are made for the preview only; confirming rolls again
+		})
+		return
+	}
+	var requestBody struct {
+		Confirm *bool `json:"confirm"`
+	}
+	if !decodeJSONBody(w, r, &requestBody) {
+		return
+	}
+	if requestBody.Confirm == nil {
+		http.Error(w, "Missing required field: confirm", http.StatusBadRequest)
+		return
 	}
-	fmt.Println("Narrative engine initialized.")
-
-	// Attempt to Create a Default Session (for testing/convenience)
-	createDefaultSession()
-
-	// --- HTTP Server Setup ---
-	// Register handlers and wrap them with CORS middleware
-	http.HandleFunc("/action", corsMiddleware(handleAction))
-	http.HandleFunc("/state", corsMiddleware(handleGetState))
-	http.HandleFunc("/create_session", corsMiddleware(handleCreateSession))
-	http.HandleFunc("/health", corsMiddleware(handleHealthCheck)) // Basic health check
 
-	// Determine port
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port
+	llmResponse, err := hostedWorldFrom(r).Engine.ResolvePendingActions(r.Context(), sessionID, *requestBody.Confirm)
+	if err != nil {
+		log.Printf("ERROR [handleConfirmActions Session: %s]: %v\n", sessionID, err)
+		switch {
+		case errors.Is(err, narrative.ErrNoPendingActions):
+			http.Error(w, "No actions are waiting for confirmation.", http.StatusConflict)
+		case errors.Is(err, session.ErrSessionCompleted):
+			http.Error(w, "Session has reached an ending and accepts no further actions; see /state for the epilogue.", http.StatusConflict)
+		case errors.Is(err, narrative.ErrTurnFailed):
+			http.Error(w, "The turn failed due to an internal error and was rolled back; please try again.", http.StatusInternalServerError)
+		case errors.Is(err, llm.ErrLLMTimeout):
+			http.Error(w, "The narrator took too long to respond; please try again.", http.StatusGatewayTimeout)
+		case errors.Is(err, context.Canceled):
+			http.Error(w, "Request cancelled by client.", 499)
+		default:
+			http.Error(w, "Failed to resolve the pending actions due to an internal server error.", http.StatusInternalServerError)
+		}
+		return
 	}
 
-	fmt.Printf("Starting llmrpg server on port %s with CORS enabled for origin: %s...\n", port, os.Getenv("ALLOWED_ORIGIN"))
-	// Start listening
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	var response interface{} = llmResponse
+	if !wantsLegacyTurnResponse(r) {
+		currentSession, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			http.Error(w, "Failed to resolve the pending actions due to an internal server error.", http.StatusInternalServerError)
+			return
+		}
+		response = narrative.NewTurnEnvelope(currentSession, hostedWorldFrom(r).World, llmResponse)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("ERROR [handleConfirmActions Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
 }
 
-// --- Helper Functions ---
-
-// createDefaultSession creates a default session if none exist (useful for development)
-func createDefaultSession() {
-	// Check if any sessions already exist
-	if len(sessionManager.GetAllSessionIDs()) > 0 {
-		fmt.Println("Default session creation skipped: Sessions already exist.")
+// handleReportTurn stores a player's report that a turn was inconsistent or bad
+// (POST {"turn": 0, "reason": "inconsistent", "comment": "...", "regenerate": false};
+// turn 0 = the latest). With regenerate the latest turn is rolled back and played
+// again, and the response includes the new turn.
+func handleReportTurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Define default character and starting location
-	player := character.NewCharacter("player_default", "Ash", "Wasteland-Born", "Courier")
-	startLocationID := "oakhaven_gate" // Default start location ID from sample data
+	sessionID := r.PathValue("id")
+	if _, err := sessionManager.GetSession(sessionID); err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+	var requestBody struct {
+		Turn       int    `json:"turn"`
+		Reason     string `json:"reason"`
+		Comment    string `json:"comment"`
+		Regenerate bool   `json:"regenerate"`
+	}
+	if !decodeJSONBody(w, r, &requestBody) {
+		return
+	}
 
-	// Verify start location exists
-	if len(worldSystem.GetAllLocationIDs()) > 0 {
-		if _, err := worldSystem.GetLocation(startLocationID); err != nil {
-			fmt.Printf("Warning: Default start location '%s' not found. Using first available location.\n", startLocationID)
-			startLocationID = worldSystem.GetAllLocationIDs()[0] // Fallback to first loaded location
+	report, llmResponse, err := hostedWorldFrom(r).Engine.ReportTurn(r.Context(), sessionID, narrative.ReportRequest{
+		Turn:       requestBody.Turn,
+		Reason:     session.ReportReason(requestBody.Reason),
+		Comment:    requestBody.Comment,
+		Regenerate: requestBody.Regenerate,
+	})
+	if err != nil && report == nil {
+		log.Printf("ERROR [handleReportTurn Session: %s]: %v\n", sessionID, err)
+		switch {
+		case errors.Is(err, narrative.ErrTurnNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, narrative.ErrCannotRegenerate):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, narrative.ErrInvalidReport):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to store the report due to an internal server error.", http.StatusInternalServerError)
 		}
-	} else {
-		log.Println("Warning: Cannot create default session: No locations loaded.")
-		return // Cannot create session without locations
+		return
 	}
 
-	// Create the session
-	_, err := sessionManager.CreateNewSession(player, startLocationID)
+	response := map[string]interface{}{"report": report}
 	if err != nil {
-		// Log failure but don't necessarily stop the server
-		log.Printf("Warning: Failed to create default session: %v", err)
-	} else {
-		fmt.Println("Default session created successfully.")
+		// The report is stored, but playing the turn again failed; the session is as it was before the turn
+		log.Printf("ERROR [handleReportTurn Session: %s]: Regeneration failed: %v\n", sessionID, err)
+		response["error"] = "The turn could not be regenerated; please send your input again."
+	} else if llmResponse != nil {
+		var turn interface{} = llmResponse
+		if !wantsLegacyTurnResponse(r) {
+			if currentSession, getErr := sessionManager.GetSession(sessionID); getErr == nil {
+				turn = narrative.NewTurnEnvelope(currentSession, hostedWorldFrom(r).World, llmResponse)
+			}
+		}
+		response["turn"] = turn
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("ERROR [handleReportTurn Session: %s]: Failed to encode response: %v\n", sessionID, err)
 	}
 }
 
-// --- HTTP Handlers ---
-
-// handleAction processes player input via the NarrativeEngine.
-func handleAction(w http.ResponseWriter, r *http.Request) {
+// handleRegenerateTurn rerolls the narration of the session's latest turn n, keeping the
+// actions it executed. With ?confirm=true the turn is rolled back and played again
+// instead, so its actions may change too.
+func handleRegenerateTurn(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Get Session ID from query parameter
-	sessionID := r.URL.Query().Get("sessionId")
-	if sessionID == "" {
-		// Fallback for testing/convenience: use the first available session ID
-		ids := sessionManager.GetAllSessionIDs()
-		if len(ids) > 0 {
-			sessionID = ids[0]
-			fmt.Println("Warning: No sessionId provided in /action request, using first available:", sessionID)
-		} else {
-			http.Error(w, "No active session found and no sessionId provided", http.StatusBadRequest)
-			return
-		}
-	}
-
-	// Decode request body
-	var requestBody struct {
-		Input string `json:"input"`
+	sessionID := r.PathValue("id")
+	if _, err := sessionManager.GetSession(sessionID); err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid turn number: %s", r.PathValue("n")), http.StatusBadRequest)
 		return
 	}
-	if requestBody.Input == "" {
-		http.Error(w, "Missing 'input' in request body", http.StatusBadRequest)
+	confirm := r.URL.Query().Get("confirm") == "true"
+
+	llmResponse, err := hostedWorldFrom(r).Engine.RegenerateTurn(r.Context(), sessionID, n, confirm)
+	if err != nil {
+		log.Printf("ERROR [handleRegenerateTurn Session: %s]: %v\n", sessionID, err)
+		switch {
+		case errors.Is(err, narrative.ErrTurnNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, narrative.ErrCannotRegenerate):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, narrative.ErrTurnFailed):
+			http.Error(w, "The turn failed due to an internal error and was rolled back; please send your input again.", http.StatusInternalServerError)
+		case errors.Is(err, llm.ErrLLMTimeout):
+			http.Error(w, "The narrator took too long to respond; please try again.", http.StatusGatewayTimeout)
+		case errors.Is(err, context.Canceled):
+			http.Error(w, "Request cancelled by client.", 499)
+		default:
+			http.Error(w, "Failed to regenerate the turn due to an internal server error.", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	// Process input using the engine
-	ctx := r.Context() // Use request context for potential cancellation
-	llmResponse, err := narrativeEngine.ProcessPlayerInput(ctx, sessionID, requestBody.Input)
+	var response interface{} = llmResponse
+	if !wantsLegacyTurnResponse(r) {
+		currentSession, err := sessionManager.GetSession(sessionID)
+		if err != nil {
+			http.Error(w, "Failed to regenerate the turn due to an internal server error.", http.StatusInternalServerError)
+			return
+		}
+		response = narrative.NewTurnEnvelope(currentSession, hostedWorldFrom(r).World, llmResponse)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("ERROR [handleRegenerateTurn Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
 
-	// Handle errors from the engine
-	if err != nil {
-		log.Printf("ERROR [handleAction Session: %s]: %v\n", sessionID, err)
-		// Check if the error is due to client disconnecting
-		if errors.Is(err, context.Canceled) {
-			http.Error(w, "Request cancelled by client.", 499) // 499 Client Closed Request
+// handlePerks shows the player's level progress and perk choices (GET), or applies a
+// perk choice for a pending level-up (POST {"perkId": "..."}) and shows the result.
+func handlePerks(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	var currentSession *session.GameSession
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		if currentSession, err = sessionManager.GetSession(sessionID); err != nil {
+			http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
 			return
 		}
-		// Return a generic server error to the client
-		http.Error(w, "Failed to process input due to an internal server error.", http.StatusInternalServerError)
+	case http.MethodPost:
+		if _, err := sessionManager.GetSession(sessionID); err != nil {
+			http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+			return
+		}
+		var requestBody struct {
+			PerkID string `json:"perkId"`
+		}
+		if !decodeJSONBody(w, r, &requestBody) {
+			return
+		}
+		if currentSession, err = hostedWorldFrom(r).Engine.ChoosePerk(sessionID, requestBody.PerkID); err != nil {
+			switch {
+			case errors.Is(err, narrative.ErrNoPendingPerk), errors.Is(err, session.ErrSessionCompleted):
+				http.Error(w, err.Error(), http.StatusConflict)
+			case errors.Is(err, narrative.ErrPerkUnavailable):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				log.Printf("ERROR [handlePerks Session: %s]: %v\n", sessionID, err)
+				http.Error(w, "Failed to choose the perk due to an internal server error.", http.StatusInternalServerError)
+			}
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Send successful response
+	player := currentSession.Player
+	choices := world.AvailablePerks(hostedWorldFrom(r).Perks, player.Level, player.Perks)
+	if choices == nil {
+		choices = []*world.Perk{}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(llmResponse); err != nil {
-		// Log error if encoding fails (response might be partially sent)
-		log.Printf("ERROR [handleAction Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessionId":    sessionID,
+		"level":        player.Level,
+		"xp":           player.XP,
+		"nextLevelXp":  narrative.XPForLevel(player.Level),
+		"pendingPerks": player.PendingPerks,
+		"perks":        player.Perks,
+		"bonuses":      player.Bonuses,
+		"choices":      choices, // Perks the player may pick once a level-up is pending
+	}); err != nil {
+		log.Printf("ERROR [handlePerks Session: %s]: Failed to encode response: %v\n", sessionID, err)
 	}
 }
 
@@ -268,18 +1995,8 @@ func handleGetState(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get Session ID from query parameter
+	// (sessionTokenMiddleware has already required it and checked the access token)
 	sessionID := r.URL.Query().Get("sessionId")
-	if sessionID == "" {
-		// Fallback for testing/convenience
-		ids := sessionManager.GetAllSessionIDs()
-		if len(ids) > 0 {
-			sessionID = ids[0]
-			fmt.Println("Warning: No sessionId provided in /state request, using first available:", sessionID)
-		} else {
-			http.Error(w, "No active session found", http.StatusNotFound)
-			return
-		}
-	}
 
 	// Get session data
 	currentSession, err := sessionManager.GetSession(sessionID)
@@ -290,25 +2007,138 @@ func handleGetState(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// --- Crucial Backend Change for Theme/Image Handling ---
-	// Fetch and attach the current location details to the session object before sending.
-	locationDetails, locErr := worldSystem.GetLocation(currentSession.CurrentLocationID)
-	if locErr != nil {
-		log.Printf("Warning [handleGetState Session: %s]: Could not fetch location details for %s: %v\n", sessionID, currentSession.CurrentLocationID, locErr)
-		currentSession.CurrentLocation = nil // Ensure it's explicitly null if fetch failed
-	} else {
-		currentSession.CurrentLocation = locationDetails // Attach the details
+	// Respond with the API form of the session, with the current location's details
+	state := sessionState(hostedWorldFrom(r), currentSession, "handleGetState")
+
+	// Optionally project to the requested sections (?include=character,location,...)
+	var response interface{} = state
+	if include := r.URL.Query().Get("include"); include != "" {
+		sections, err := api.ParseSections(include)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		projected, err := api.Project(state, sections)
+		if err != nil {
+			log.Printf("ERROR [handleGetState Session: %s]: %v\n", sessionID, err)
+			http.Error(w, "Failed to build state response.", http.StatusInternalServerError)
+			return
+		}
+		response = projected
 	}
-	// --- End Backend Change ---
 
 	// Send successful response
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(currentSession); err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("ERROR [handleGetState Session: %s]: Failed to encode state response: %v\n", sessionID, err)
 		// Don't write header again if encoding fails after starting response
 	}
 }
 
+// sessionState builds the API form of a session, attaching the details and theme of
+// its current location from hw's world. A location that can't be looked up is logged
+// (as handler) and sent as null.
+func sessionState(hw *hostedWorld, sess *session.GameSession, handler string) *api.SessionState {
+	loc, err := hw.World.GetLocation(sess.CurrentLocationID)
+	if err != nil {
+		log.Printf("Warning [%s Session: %s]: Could not fetch location details for %s: %v\n", handler, sess.ID, sess.CurrentLocationID, err)
+		loc = nil
+	}
+	return api.NewSessionState(sess, loc, themeFor(hw.World, loc))
+}
+
+// themeFor returns the theme of a location in ws, or nil if it has none (or it can't be found).
+func themeFor(ws world.WorldSystem, loc *world.LocationNode) *world.ThemeDefinition {
+	if loc == nil || loc.ThemeID == "" {
+		return nil
+	}
+	theme, err := ws.GetTheme(loc.ThemeID)
+	if err != nil {
+		return nil
+	}
+	return theme
+}
+
+// handleGetThemes lists every theme with its presentation metadata (?worldId= picks a hosted world).
+func handleGetThemes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+
+	ids := hw.World.GetAllThemeIDs()
+	sort.Strings(ids)
+	themes := make([]*world.ThemeDefinition, 0, len(ids))
+	for _, id := range ids {
+		if theme, err := hw.World.GetTheme(id); err == nil {
+			themes = append(themes, theme)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(themes); err != nil {
+		log.Printf("ERROR [handleGetThemes]: Failed to encode themes response: %v\n", err)
+	}
+}
+
+// loreSummary is a lore document as listed by /lore, without its text.
+type loreSummary struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// handleListLore lists the world's lore documents, optionally filtered by the
+// category and tag query parameters (?worldId= picks a hosted world). Fetch a
+// document's text from /lore/{id}.
+func handleListLore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+
+	docs := hw.Lore.Documents(r.URL.Query().Get("category"), r.URL.Query().Get("tag"))
+	summaries := make([]loreSummary, 0, len(docs))
+	for _, doc := range docs {
+		summaries = append(summaries, loreSummary{ID: doc.ID, Title: doc.Title, Category: doc.Category, Tags: doc.Tags})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summaries); err != nil {
+		log.Printf("ERROR [handleListLore]: Failed to encode lore response: %v\n", err)
+	}
+}
+
+// handleGetLore returns one lore document with its text.
+func handleGetLore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hw, ok := requestedWorld(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	doc, ok := hw.Lore.Document(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Lore document not found: %s", id), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		log.Printf("ERROR [handleGetLore]: Failed to encode lore response: %v\n", err)
+	}
+}
+
 // handleCreateSession creates a new game session.
 func handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -322,53 +2152,179 @@ func handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		ClassName       string `json:"className"`  // Optional
 		OriginName      string `json:"originName"` // Optional
 		StartLocationID string `json:"startLocationId"`
+		Ironman         bool   `json:"ironman"`        // Optional: permadeath mode, no rewind/fork/save slots
+		PlayerID        string `json:"playerId"`       // Optional: stable player identity, for listing their sessions later
+		ScenarioID      string `json:"scenarioId"`     // Optional: guided opening to play before free play
+		ContentRating   string `json:"contentRating"`  // Optional: E, T or M, up to the world's rating (default: the world's)
+		ConfirmActions  *bool  `json:"confirmActions"` // Optional: hold high-impact actions for confirmation (default: CONFIRM_ACTIONS)
+		Seed            uint64 `json:"seed,string"`    // Optional, DETERMINISTIC mode only: dice seed, as a decimal string, to reproduce another session's rolls (default: random)
+		WorldID         string `json:"worldId"`        // Optional: hosted world to play (default: the server's default world)
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	hw, ok := hostedWorldByID(req.WorldID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown world ID '%s'", req.WorldID), http.StatusBadRequest)
+		return
+	}
+	// A chosen seed predicts every roll of the session, so only reproduction runs may pick one
+	if req.Seed != 0 && !deterministic {
+		http.Error(w, "seed is only accepted when the server runs in DETERMINISTIC mode", http.StatusBadRequest)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	// Names appear in every prompt: sanitize and cap them like player input
+	req.PlayerName = narrative.SanitizeInput(req.PlayerName)
+	req.ClassName = narrative.SanitizeInput(req.ClassName)
+	req.OriginName = narrative.SanitizeInput(req.OriginName)
+	if !checkTextLength(w, "playerName", req.PlayerName, maxNameChars) ||
+		!checkTextLength(w, "className", req.ClassName, maxNameChars) ||
+		!checkTextLength(w, "originName", req.OriginName, maxNameChars) {
 		return
 	}
 
+	// A scenario may supply the start location
+	var scenario *world.Scenario
+	if req.ScenarioID != "" {
+		var ok bool
+		if scenario, ok = hw.Scenarios[req.ScenarioID]; !ok {
+			http.Error(w, fmt.Sprintf("Unknown scenario ID '%s'", req.ScenarioID), http.StatusBadRequest)
+			return
+		}
+		if req.StartLocationID == "" {
+			req.StartLocationID = scenario.StartLocationID
+		}
+	}
+	if req.StartLocationID == "" {
+		req.StartLocationID = hw.StartID // Archive worlds name their own start
+	}
+
 	// Validate required fields
 	if req.PlayerName == "" || req.StartLocationID == "" {
 		http.Error(w, "Missing required fields: playerName and startLocationId", http.StatusBadRequest)
 		return
 	}
 
+	if req.PlayerID != "" && !playerIDPattern.MatchString(req.PlayerID) {
+		http.Error(w, "Invalid playerId: use 1-128 letters, digits, '-', '_' or '.'", http.StatusBadRequest)
+		return
+	}
+
+	// Sessions may be played at a stricter rating than the world's, never a looser one
+	sessionRating := hw.Rating
+	if req.ContentRating != "" {
+		parsed, err := rating.Parse(req.ContentRating)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid contentRating: %v", err), http.StatusBadRequest)
+			return
+		}
+		if !hw.Rating.Allows(parsed) {
+			http.Error(w, fmt.Sprintf("contentRating '%s' exceeds this world's rating '%s'", parsed, hw.Rating), http.StatusBadRequest)
+			return
+		}
+		sessionRating = parsed
+	}
+
 	// Validate start location exists
-	if _, err := worldSystem.GetLocation(req.StartLocationID); err != nil {
+	if _, err := hw.World.GetLocation(req.StartLocationID); errors.Is(err, world.ErrLocationNotFound) {
 		http.Error(w, fmt.Sprintf("Invalid start location ID '%s': %v", req.StartLocationID, err), http.StatusBadRequest)
 		return
+	} else if err != nil {
+		log.Printf("ERROR [handleCreateSession]: Failed to look up start location: %v\n", err)
+		http.Error(w, "Failed to create session due to an internal error.", http.StatusInternalServerError)
+		return
 	}
 
 	// Create character and new session
-	// Generate a simple unique player ID
-	playerID := fmt.Sprintf("player_%s_%d", strings.ToLower(req.PlayerName), time.Now().UnixNano())
+	// The player ID is opaque; the name is kept on the character
+	playerID := ids.New()
 	player := character.NewCharacter(playerID, req.PlayerName, req.ClassName, req.OriginName)
+	player.Abilities = world.StartingAbilities(hw.Abilities, player.Class)
 
 	newSession, err := sessionManager.CreateNewSession(player, req.StartLocationID)
+	if errors.Is(err, session.ErrSessionLimit) {
+		log.Printf("Warning [handleCreateSession]: %v\n", err)
+		http.Error(w, "The server is at capacity; please try again later.", http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR [handleCreateSession]: Failed to create session: %v\n", err)
 		http.Error(w, "Failed to create session due to an internal error.", http.StatusInternalServerError)
 		return
 	}
+	newSession.WorldID = hw.ID
+	newSession.Ironman = req.Ironman
+	newSession.PlayerID = req.PlayerID
+	newSession.ScenarioID = req.ScenarioID
+	newSession.ContentRating = sessionRating
+	newSession.ConfirmActions = confirmActions
+	if req.ConfirmActions != nil {
+		newSession.ConfirmActions = *req.ConfirmActions
+	}
+	if req.Seed != 0 {
+		newSession.Seed = req.Seed
+	}
+	hw.Engine.AssignPromptVariant(newSession)
+	if analyticsRecorder != nil {
+		if err := analyticsRecorder.Record(newSession); err != nil {
+			log.Printf("Warning [handleCreateSession Session: %s]: %v\n", newSession.ID, err)
+		}
+	}
 
-	// Attach location details to the response for the new session
-	locationDetails, locErr := worldSystem.GetLocation(newSession.CurrentLocationID)
-	if locErr != nil {
-		log.Printf("Warning [handleCreateSession Session: %s]: Could not fetch location details for new session response: %v\n", newSession.ID, locErr)
-		newSession.CurrentLocation = nil
-	} else {
-		newSession.CurrentLocation = locationDetails
+	// The access token is only ever shown here; later requests must send it as X-Session-Token
+	accessToken := newSession.IssueAccessToken()
+	// Publish the setup above to the manager, which may snapshot the session for archiving
+	if err := sessionManager.UpdateSession(newSession); err != nil {
+		log.Printf("Warning [handleCreateSession Session: %s]: %v\n", newSession.ID, err)
 	}
 
-	// Send successful response (201 Created)
+	// Send successful response (201 Created), with the start location's details
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated) // Use 201 for resource creation
-	if err := json.NewEncoder(w).Encode(newSession); err != nil {
+	if err := json.NewEncoder(w).Encode(api.CreatedSession{
+		SessionState: *sessionState(hw, newSession, "handleCreateSession"),
+		AccessToken:  accessToken,
+	}); err != nil {
 		log.Printf("ERROR [handleCreateSession Session: %s]: Failed to encode new session response: %v\n", newSession.ID, err)
 	}
 }
 
+// playerIDPattern restricts player IDs to values safe in URLs (e.g. a client-generated UUID).
+var playerIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// handleListPlayerSessions lists a player's sessions so a returning client can resume one
+// without having stored its session ID.
+func handleListPlayerSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	playerID := r.PathValue("id")
+	if !playerIDPattern.MatchString(playerID) {
+		http.Error(w, fmt.Sprintf("Invalid player ID: %s", playerID), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"playerId": playerID,
+		"sessions": sessionManager.ListPlayerSessions(playerID),
+	}); err != nil {
+		log.Printf("ERROR [handleListPlayerSessions Player: %s]: Failed to encode response: %v\n", playerID, err)
+	}
+}
+
+// sessionUsage reports the session manager's memory use against its caps, or nil if
+// the manager doesn't track it.
+func sessionUsage() *session.Usage {
+	if tracked, ok := sessionManager.(interface{ Usage() session.Usage }); ok {
+		usage := tracked.Usage()
+		return &usage
+	}
+	return nil
+}
+
 // handleHealthCheck provides a simple endpoint to check server status.
 func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -378,7 +2334,12 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	// Simple JSON response is often preferred over plain text
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":        "ok",
+		"llmInFlight":   limitedAdapter.Running(),
+		"llmQueueDepth": limitedAdapter.QueueLength(),
+		"sessions":      sessionUsage(),
+	})
 }
 
 // --- Ensure necessary standard library imports ---
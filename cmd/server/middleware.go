@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Middleware Chain ---
+
+// middleware wraps a handler with cross-cutting behavior (CORS, auth, limits...).
+type middleware func(http.Handler) http.Handler
+
+// chain composes middleware so the first one listed runs first (outermost).
+func chain(mws ...middleware) middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
+
+// fromFunc adapts a HandlerFunc-style middleware (such as adminMiddleware) to the chain.
+func fromFunc(m func(http.HandlerFunc) http.HandlerFunc) middleware {
+	return func(h http.Handler) http.Handler {
+		return m(h.ServeHTTP)
+	}
+}
+
+// route is one registered endpoint and the middleware policy it is served with.
+type route struct {
+	pattern string // http.ServeMux pattern; handlers check the method themselves so CORS preflights reach them
+	handler http.HandlerFunc
+	policy  middleware
+}
+
+// --- API Versions ---
+
+// apiVersion is one major version of the HTTP API, served under its own path prefix.
+// Versions are served side by side, so a /v2 route can change a response shape while
+// /v1 clients keep the old one until they migrate.
+type apiVersion struct {
+	prefix     string // e.g. "/v1"
+	routes     []route
+	deprecated *deprecation // Set once the version is superseded; nil = current
+}
+
+// deprecation describes paths clients should move away from.
+type deprecation struct {
+	successor string    // Prefix of the version to move to, e.g. "/v2"
+	sunset    time.Time // When the paths stop being served; zero = not scheduled yet
+}
+
+// newRouter registers each version's routes under its prefix on a fresh ServeMux.
+// The routes of the version whose prefix is unversioned (the one that predates
+// versioning) are also served at their bare paths, deprecated in favour of that version.
+func newRouter(versions []apiVersion, unversioned string, sunset time.Time) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, v := range versions {
+		for _, rt := range v.routes {
+			handler := rt.policy(rt.handler)
+			if v.deprecated != nil {
+				handler = deprecationMiddleware(v.prefix, v.deprecated)(handler)
+			}
+			mux.Handle(v.prefix+rt.pattern, handler)
+			if v.prefix == unversioned {
+				mux.Handle(rt.pattern, deprecationMiddleware("", &deprecation{successor: unversioned, sunset: sunset})(rt.policy(rt.handler)))
+			}
+		}
+	}
+	return mux
+}
+
+// deprecationMiddleware marks responses from deprecated paths (those under prefix) with
+// a Deprecation header, a Link to the same path in the successor version and, once
+// scheduled, a Sunset date.
+func deprecationMiddleware(prefix string, dep *deprecation) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			successor := dep.successor + strings.TrimPrefix(r.URL.Path, prefix)
+			w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			if !dep.sunset.IsZero() {
+				w.Header().Set("Sunset", dep.sunset.UTC().Format(http.TimeFormat))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// loadUnversionedSunset reads API_UNVERSIONED_SUNSET, the date (2006-01-02) or time
+// (RFC 3339) after which the unversioned paths will no longer be served, announced in
+// their Sunset header. Unset, none is announced.
+func loadUnversionedSunset() time.Time {
+	v := os.Getenv("API_UNVERSIONED_SUNSET")
+	if v == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	log.Printf("Warning: Invalid API_UNVERSIONED_SUNSET '%s' (expected 2006-01-02 or RFC 3339), no sunset announced", v)
+	return time.Time{}
+}
+
+// --- CORS Middleware ---
+
+// corsConfig is the cross-origin policy shared by all routes.
+type corsConfig struct {
+	origins     []string // Allowed origins; "*" allows any
+	credentials bool     // Send Access-Control-Allow-Credentials (cookies, auth headers)
+}
+
+// loadCORSConfig reads ALLOWED_ORIGIN (a comma-separated list, e.g. staging and production
+// frontends; default http://localhost:3000) and CORS_ALLOW_CREDENTIALS.
+func loadCORSConfig() *corsConfig {
+	cfg := &corsConfig{
+		origins:     splitList(os.Getenv("ALLOWED_ORIGIN")),
+		credentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+	}
+	if len(cfg.origins) == 0 {
+		cfg.origins = []string{"http://localhost:3000"} // Default frontend dev server
+	}
+	if cfg.credentials && slices.Contains(cfg.origins, "*") {
+		log.Fatalf("FATAL: ALLOWED_ORIGIN '*' cannot be combined with CORS_ALLOW_CREDENTIALS=true; list the frontend origins instead")
+	}
+	return cfg
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request Origin, or "".
+func (c *corsConfig) allowedOrigin(origin string) string {
+	if slices.Contains(c.origins, "*") {
+		return "*"
+	}
+	if origin != "" && slices.Contains(c.origins, origin) {
+		return origin
+	}
+	return ""
+}
+
+// corsMiddleware adds CORS headers for requests from allowed origins and answers
+// preflight requests. methods are the methods the route accepts.
+func corsMiddleware(cfg *corsConfig, methods ...string) middleware {
+	allowMethods := strings.Join(append(slices.Clone(methods), http.MethodOptions), ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the Origin, so caches must not share it across origins
+			w.Header().Add("Vary", "Origin")
+
+			if allowed := cfg.allowedOrigin(r.Header.Get("Origin")); allowed != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowed)
+				if cfg.credentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+				// Set allowed headers that the frontend might send
+				w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID, X-Session-Token")
+				w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, Deprecation, Link, Sunset")
+			}
+
+			// Handle preflight OPTIONS requests
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusOK) // Respond OK to OPTIONS preflight
+				return                       // Don't call the next handler for OPTIONS
+			}
+
+			// Call the actual handler for other methods (GET, POST, etc.)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// --- Panic Recovery ---
+
+// recoverMiddleware turns a panic in any handler into a logged stack trace and a JSON
+// 500 response, instead of an aborted connection. If the handler had already started
+// writing its response, the connection is closed as net/http would have done.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p) // Deliberate abort; let net/http handle it silently
+			}
+			log.Printf("ERROR [recoverMiddleware %s %s Request: %s]: panic: %v\n%s", r.Method, r.URL.Path, requestIDFrom(r.Context()), p, debug.Stack())
+			if rw.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":     "Internal server error",
+				"status":    http.StatusInternalServerError,
+				"requestId": requestIDFrom(r.Context()),
+			})
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// statusWriter records the response status (0 until the response is started).
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. to flush).
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// --- Request IDs ---
+
+type requestIDKey struct{}
+
+// requestIDPattern accepts client-supplied request IDs that are safe to log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// requestIDMiddleware tags each request with an ID (the client's X-Request-ID if valid,
+// otherwise a random one), echoed in the response header and available to handlers
+// through requestIDFrom.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestIDFrom returns the request ID assigned by requestIDMiddleware, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// --- Session Access Tokens ---
+
+// sessionTokenHeader carries the secret issued by /create_session.
+const sessionTokenHeader = "X-Session-Token"
+
+// sessionTokenMiddleware restricts a session's routes to requests carrying its access
+// token, so knowing (or guessing) a session ID isn't enough to play or read it. The
+// session comes from the {id} path value or the sessionId query parameter. Unknown
+// sessions pass through so the handler reports them as usual; known ones have their
+// hosted world attached to the request (see hostedWorldFrom).
+func sessionTokenMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.PathValue("id")
+		if sessionID == "" {
+			sessionID = r.URL.Query().Get("sessionId")
+		}
+		if sessionID == "" {
+			http.Error(w, "Missing 'sessionId' query parameter", http.StatusBadRequest)
+			return
+		}
+		if sess, err := sessionManager.GetSession(sessionID); err == nil {
+			if !sess.CheckAccessToken(r.Header.Get(sessionTokenHeader)) {
+				http.Error(w, "Unauthorized: missing or wrong "+sessionTokenHeader, http.StatusUnauthorized)
+				return
+			}
+			hw, err := worldOf(sess)
+			if err != nil {
+				log.Printf("ERROR [sessionTokenMiddleware Session: %s]: %v\n", sessionID, err)
+				http.Error(w, "This session's world is not available on this server.", http.StatusServiceUnavailable)
+				return
+			}
+			r = withHostedWorld(r, hw)
+		}
+		next(w, r)
+	}
+}
+
+// --- Access Log ---
+
+// accessLogMiddleware logs one line per request: method, path, status, duration and request ID.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		log.Printf("ACCESS %s %s %d %s request=%s", r.Method, r.URL.Path, rw.status, time.Since(start).Round(time.Millisecond), requestIDFrom(r.Context()))
+	})
+}
+
+// --- Rate Limiting ---
+
+// rateLimiter is a per-client token bucket: each client IP may make burst requests at
+// once, refilled at perMinute requests per minute.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	burst     float64
+	clients   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	return &rateLimiter{
+		perMinute: float64(perMinute),
+		burst:     float64(max(burst, 1)),
+		clients:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes a token for client, or returns how long until one is available.
+func (rl *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Forget clients whose buckets have refilled, so the map doesn't grow without bound
+	if now.Sub(rl.lastSweep) > time.Minute {
+		for c, b := range rl.clients {
+			if b.tokens+now.Sub(b.last).Minutes()*rl.perMinute >= rl.burst {
+				delete(rl.clients, c)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	b, ok := rl.clients[client]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.clients[client] = b
+	}
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Minutes()*rl.perMinute)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rl.perMinute * float64(time.Minute))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// rateLimitMiddleware rejects clients over the limit with 429 and a Retry-After header.
+// A nil limiter disables limiting.
+func rateLimitMiddleware(rl *rateLimiter) middleware {
+	return func(next http.Handler) http.Handler {
+		if rl == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r) // Never limit CORS preflights
+				return
+			}
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+			if ok, wait := rl.allow(client, time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "Too many requests; slow down.", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newTurnRateLimiter builds the limiter for turn endpoints from RATE_LIMIT_PER_MINUTE
+// (0 or unset = no limit) and RATE_LIMIT_BURST (default 5).
+func newTurnRateLimiter() *rateLimiter {
+	perMinute, burst := 0, 5
+	if v := os.Getenv("RATE_LIMIT_PER_MINUTE"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil || n < 0 {
+			log.Printf("Warning: Invalid RATE_LIMIT_PER_MINUTE '%s', rate limiting disabled", v)
+		} else {
+			perMinute = n
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil || n < 1 {
+			log.Printf("Warning: Invalid RATE_LIMIT_BURST '%s', using default %d", v, burst)
+		} else {
+			burst = n
+		}
+	}
+	if perMinute == 0 {
+		return nil
+	}
+	return newRateLimiter(perMinute, burst)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"llmrpg/internal/events"
+	"llmrpg/internal/notify"
+)
+
+// --- Notifications ---
+// Players can be told about events while they're away, e.g. a slow async turn
+// finishing, by email (NOTIFY_SMTP_ADDR) and web push (VAPID_PRIVATE_KEY). Preferences
+// are per player (GameSession.PlayerID) and managed through any of their sessions:
+//
+//	GET|PUT /session/{id}/notifications  the player's preferences (owner)
+//	GET     /notifications/vapid-key     the key browsers subscribe to push with
+
+// eventBus carries every turn's events, and async turn completions, to subscribers.
+var eventBus = events.NewBus()
+
+// notifier sends notifications; nil when no channel is configured.
+var notifier *notify.Notifier
+
+// webPush is the web push adapter; nil when VAPID_PRIVATE_KEY is unset.
+var webPush *notify.WebPushAdapter
+
+// newNotifier configures the notification channels from the environment and subscribes
+// the notifier to the event bus. It returns nil if no channel is configured.
+func newNotifier() *notify.Notifier {
+	adapters := make(map[notify.Channel]notify.Adapter)
+	if addr := os.Getenv("NOTIFY_SMTP_ADDR"); addr != "" {
+		from := os.Getenv("NOTIFY_EMAIL_FROM")
+		if from == "" {
+			log.Fatal("FATAL: NOTIFY_SMTP_ADDR requires NOTIFY_EMAIL_FROM")
+		}
+		email := &notify.EmailAdapter{Addr: addr, From: from}
+		if user := os.Getenv("NOTIFY_SMTP_USERNAME"); user != "" {
+			host, _, _ := strings.Cut(addr, ":")
+			email.Auth = smtp.PlainAuth("", user, os.Getenv("NOTIFY_SMTP_PASSWORD"), host)
+		}
+		adapters[notify.ChannelEmail] = email
+		fmt.Printf("Notifications: email through %s.\n", addr)
+	}
+	if key := os.Getenv("VAPID_PRIVATE_KEY"); key != "" {
+		adapter, err := notify.NewWebPushAdapter(key, os.Getenv("VAPID_SUBJECT"), nil)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		webPush = adapter
+		adapters[notify.ChannelWebPush] = adapter
+		fmt.Println("Notifications: web push.")
+	}
+	if len(adapters) == 0 {
+		return nil
+	}
+
+	prefs, err := notify.NewPreferenceStore(os.Getenv("NOTIFY_PREFS_PATH"))
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	if os.Getenv("NOTIFY_PREFS_PATH") == "" {
+		log.Printf("Warning: NOTIFY_PREFS_PATH not set; notification preferences are lost on restart")
+	}
+	slowTurn := 30 * time.Second
+	if v := os.Getenv("NOTIFY_SLOW_TURN_SECONDS"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr != nil || n < 0 {
+			log.Printf("Warning: Invalid NOTIFY_SLOW_TURN_SECONDS '%s', using default %s", v, slowTurn)
+		} else {
+			slowTurn = time.Duration(n) * time.Second
+		}
+	}
+	n := &notify.Notifier{Adapters: adapters, Preferences: prefs, SlowTurn: slowTurn}
+	eventBus.Subscribe(n.Handle)
+	return n
+}
+
+// notificationRoutes returns the notification routes when a channel is configured.
+// control guards a player's preferences (their session's token); public guards the
+// VAPID key.
+func notificationRoutes(control, public middleware) []route {
+	if notifier == nil {
+		return nil
+	}
+	routes := []route{{"/session/{id}/notifications", handleNotificationPrefs, control}}
+	if webPush != nil {
+		routes = append(routes, route{"/notifications/vapid-key", handleVAPIDKey, public})
+	}
+	return routes
+}
+
+// handleNotificationPrefs reads (GET) or replaces (PUT) the preferences of the player
+// the session belongs to.
+func handleNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	sess, err := sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session not found: %s", sessionID), http.StatusNotFound)
+		return
+	}
+	if sess.PlayerID == "" {
+		http.Error(w, "Notifications are per player; create the session with a playerId to use them.", http.StatusConflict)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var prefs notify.Preferences
+		if !decodeJSONBody(w, r, &prefs) {
+			return
+		}
+		prefs.PlayerID = sess.PlayerID
+		for _, channel := range prefs.Channels {
+			if _, ok := notifier.Adapters[channel]; !ok {
+				http.Error(w, fmt.Sprintf("Channel '%s' is not available on this server", channel), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := notifier.Preferences.Put(prefs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefs, ok := notifier.Preferences.Get(sess.PlayerID)
+	if !ok {
+		prefs = notify.Preferences{PlayerID: sess.PlayerID, Channels: []notify.Channel{}, Kinds: notify.DefaultKinds}
+	}
+	available := make([]notify.Channel, 0, len(notifier.Adapters))
+	for channel := range notifier.Adapters {
+		available = append(available, channel)
+	}
+	slices.Sort(available)
+	kinds := make([]events.Type, 0, len(notify.Kinds))
+	for kind := range notify.Kinds {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"preferences":       prefs,
+		"availableChannels": available, // Channels this server can send on
+		"availableKinds":    kinds,     // Events players can be notified of
+	}); err != nil {
+		log.Printf("ERROR [handleNotificationPrefs Session: %s]: Failed to encode response: %v\n", sessionID, err)
+	}
+}
+
+// handleVAPIDKey returns the server's VAPID public key, for
+// pushManager.subscribe({applicationServerKey}).
+func handleVAPIDKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"publicKey": webPush.PublicKey()}); err != nil {
+		log.Printf("ERROR [handleVAPIDKey]: Failed to encode response: %v\n", err)
+	}
+}
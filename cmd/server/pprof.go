@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+)
+
+// --- Profiling ---
+
+// pprofHandler serves the net/http/pprof endpoints under /debug/pprof/.
+func pprofHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index) // Also serves named profiles: heap, goroutine, allocs, block, mutex...
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// pprofRoutes returns the profiling routes for the main server when PPROF=true: the
+// pprof endpoints at /admin/debug/pprof/, behind ADMIN_API_KEY like every admin route,
+// e.g. curl -H "Authorization: Bearer $ADMIN_API_KEY" .../admin/debug/pprof/heap > heap.out
+func pprofRoutes(policy middleware) []route {
+	if on, _ := strconv.ParseBool(os.Getenv("PPROF")); !on {
+		return nil
+	}
+	handler := http.StripPrefix("/admin", pprofHandler())
+	fmt.Println("Profiling enabled at /admin/debug/pprof/ (admin key required).")
+	return []route{{"/admin/debug/pprof/", handler.ServeHTTP, policy}}
+}
+
+// startPprofListener serves the pprof endpoints at /debug/pprof/ on PPROF_ADDR, a
+// separate listener without auth for tools like `go tool pprof` that can't send the
+// admin key. It should stay on a loopback or private address; anything else is warned
+// about, since profiles expose the process's internals.
+func startPprofListener() {
+	addr := os.Getenv("PPROF_ADDR")
+	if addr == "" {
+		return
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		log.Fatalf("FATAL: Invalid PPROF_ADDR '%s': %v", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !(ip.IsLoopback() || ip.IsPrivate())) {
+		log.Printf("Warning: PPROF_ADDR '%s' is not a loopback or private address; profiles are served there without auth", addr)
+	}
+	go func() {
+		log.Printf("Warning: Profiling listener stopped: %v", http.ListenAndServe(addr, pprofHandler()))
+	}()
+	fmt.Printf("Profiling listener on %s at /debug/pprof/.\n", addr)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// --- TLS ---
+
+// listenAndServe serves handler on addr over plain HTTP, or over HTTPS when configured:
+//   - TLS_CERT_FILE + TLS_KEY_FILE: a certificate you provide (reloaded only on restart).
+//   - TLS_AUTOCERT_DOMAINS (comma-separated): certificates obtained and renewed automatically
+//     from Let's Encrypt, cached in TLS_AUTOCERT_CACHE (default data/certs). The ACME HTTP-01
+//     challenge is answered on TLS_HTTP_PORT (default 80), which must be reachable from the
+//     internet as port 80; other plain-HTTP requests there are redirected to HTTPS.
+func listenAndServe(addr string, handler http.Handler) error {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	domains := splitList(os.Getenv("TLS_AUTOCERT_DOMAINS"))
+
+	switch {
+	case len(domains) > 0 && (certFile != "" || keyFile != ""):
+		return fmt.Errorf("set either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	case len(domains) > 0:
+		cacheDir := os.Getenv("TLS_AUTOCERT_CACHE")
+		if cacheDir == "" {
+			cacheDir = "data/certs" // Keep this across restarts to stay within Let's Encrypt rate limits
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      os.Getenv("TLS_AUTOCERT_EMAIL"),
+		}
+		httpPort := os.Getenv("TLS_HTTP_PORT")
+		if httpPort == "" {
+			httpPort = "80"
+		}
+		go func() {
+			// nil fallback: redirect everything but ACME challenges to HTTPS
+			if err := http.ListenAndServe(":"+httpPort, manager.HTTPHandler(nil)); err != nil {
+				log.Printf("ERROR: ACME challenge listener on :%s stopped: %v", httpPort, err)
+			}
+		}()
+		server := &http.Server{Addr: addr, Handler: handler, TLSConfig: manager.TLSConfig()}
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+		fmt.Printf("TLS: automatic certificates for %s (cache %s, ACME challenges on :%s)\n", strings.Join(domains, ", "), cacheDir, httpPort)
+		return server.ListenAndServeTLS("", "") // Certificates come from TLSConfig.GetCertificate
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		server := &http.Server{Addr: addr, Handler: handler, TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+		fmt.Printf("TLS: using certificate %s\n", certFile)
+		return server.ListenAndServeTLS(certFile, keyFile)
+	default:
+		return http.ListenAndServe(addr, handler)
+	}
+}
+
+// splitList parses a comma-separated environment value, dropping blanks.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"llmrpg/internal/llm"
+	"llmrpg/internal/lore"
+	"llmrpg/internal/narrative"
+	"llmrpg/internal/rating"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// --- Hosted Worlds ---
+// One process can host several games. The primary world is configured as before
+// (WORLD_ARCHIVE, data directories or the embedded world); WORLDS lists more
+// archives to host alongside it. Each world gets its own WorldSystem, executor and
+// engine, sharing the session manager and the rest of the configuration; the LLM
+// too, unless the world's manifest picks its own model ("llm").
+// Sessions are bound to a world when created (GameSession.WorldID).
+
+// archiveContent is everything a world archive defines.
+type archiveContent struct {
+	Manifest    world.Manifest
+	Prompt      string                    // System prompt bundled with the archive, if any
+	Variants    []narrative.PromptVariant // System prompt experiment, if the manifest runs one
+	Scenarios   map[string]*world.Scenario
+	Endings     map[string]*world.Ending
+	NPCs        map[string]*world.NPC
+	WorldEvents map[string]*world.WorldEvent
+	Items       map[string]*world.Item
+	Abilities   map[string]*world.Ability
+	Perks       map[string]*world.Perk
+	LootTables  map[string]*world.LootTable
+	Bestiary    map[string]*world.Creature
+	Survival    *world.SurvivalRules
+	LoreDocs    []lore.Document
+}
+
+// loadArchiveContent validates the archive at path and loads it into ws.
+func loadArchiveContent(path string, ws world.WorldSystem) (*archiveContent, error) {
+	archive, closeArchive, err := openWorldArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+	if _, err := archive.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &archiveContent{Manifest: archive.Manifest}
+	c.Prompt, _ = archive.SystemPrompt() // Validate already checked it's readable
+	for _, variant := range archive.Manifest.PromptVariants {
+		prompt, _ := archive.VariantPrompt(variant)
+		c.Variants = append(c.Variants, narrative.PromptVariant{ID: variant.ID, Prompt: prompt, Weight: variant.Weight})
+	}
+	fmt.Printf("Loading world '%s' (%s) from archive %s\n", archive.Manifest.Name, archive.Manifest.ID, path)
+	err = archive.LoadInto(ws)
+	if err == nil {
+		c.Scenarios, err = archive.Scenarios(ws)
+	}
+	if err == nil {
+		c.Endings, err = archive.Endings(ws)
+	}
+	if err == nil {
+		c.NPCs, err = archive.NPCs(ws)
+	}
+	if err == nil {
+		c.WorldEvents, err = archive.WorldEvents(ws)
+	}
+	if err == nil {
+		c.Items, err = archive.Items()
+	}
+	if err == nil {
+		c.Abilities, err = archive.Abilities()
+	}
+	if err == nil {
+		c.Perks, err = archive.Perks(c.Abilities)
+	}
+	if err == nil {
+		c.LootTables, err = archive.LootTables(c.Items)
+	}
+	if err == nil {
+		c.Bestiary, err = archive.Bestiary(c.Items, c.LootTables)
+	}
+	if err == nil {
+		c.Survival, err = archive.Survival(c.Items)
+	}
+	if err == nil {
+		c.LoreDocs, err = archiveLore(archive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load world archive: %w", err)
+	}
+	return c, nil
+}
+
+// hostedWorld is one game the server hosts: its content and the engine that plays it.
+type hostedWorld struct {
+	ID        string
+	Name      string
+	StartID   string // Default start location for new sessions
+	World     world.WorldSystem
+	Engine    *narrative.NarrativeEngine
+	Turns     *narrative.TurnTracker
+	Scenarios map[string]*world.Scenario
+	Abilities map[string]*world.Ability
+	Perks     map[string]*world.Perk
+	Lore      *lore.Library
+	Rating    rating.Rating
+}
+
+// hostedWorlds holds every world the server plays, by ID; defaultWorldID names the
+// primary one, used by sessions created without a worldId (and those saved before
+// sessions were bound to worlds).
+var hostedWorlds = map[string]*hostedWorld{}
+var defaultWorldID string
+
+// hostedWorldByID returns a hosted world; "" means the default world.
+func hostedWorldByID(id string) (*hostedWorld, bool) {
+	if id == "" {
+		id = defaultWorldID
+	}
+	hw, ok := hostedWorlds[id]
+	return hw, ok
+}
+
+// playsIn reports whether a session bound to worldID ("" for the default world) plays in hw.
+func playsIn(worldID string, hw *hostedWorld) bool {
+	if worldID == "" {
+		worldID = defaultWorldID
+	}
+	return worldID == hw.ID
+}
+
+// worldOf returns the world a session plays in.
+func worldOf(sess *session.GameSession) (*hostedWorld, error) {
+	hw, ok := hostedWorldByID(sess.WorldID)
+	if !ok {
+		return nil, fmt.Errorf("session %s belongs to world '%s', which this server doesn't host", sess.ID, sess.WorldID)
+	}
+	return hw, nil
+}
+
+// hostedWorldKey is the request context key for the world resolved by sessionTokenMiddleware.
+type hostedWorldKey struct{}
+
+// withHostedWorld records the world a request's session plays in.
+func withHostedWorld(r *http.Request, hw *hostedWorld) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), hostedWorldKey{}, hw))
+}
+
+// hostedWorldFrom returns the world of the request's session, or the default world
+// when the session is unknown (the handler then reports it missing as usual).
+func hostedWorldFrom(r *http.Request) *hostedWorld {
+	if hw, ok := r.Context().Value(hostedWorldKey{}).(*hostedWorld); ok {
+		return hw
+	}
+	hw, _ := hostedWorldByID("")
+	return hw
+}
+
+// requestedWorld resolves the ?worldId= of a request for world-wide data (themes,
+// lore, admin reports), writing a 404 if it isn't hosted.
+func requestedWorld(w http.ResponseWriter, r *http.Request) (*hostedWorld, bool) {
+	id := r.URL.Query().Get("worldId")
+	hw, ok := hostedWorldByID(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("World not found: %s", id), http.StatusNotFound)
+	}
+	return hw, ok
+}
+
+// worldAdapter returns the narrator for a world: the server's adapter, or one built
+// from the manifest's llm settings. Either way calls share the server's concurrency
+// limit, so a premium world can't starve a casual one of slots or vice versa.
+func worldAdapter(name string, settings *world.ModelSettings) llm.Adapter {
+	if settings == nil {
+		return llmAdapter
+	}
+	fmt.Printf("World '%s' picks its own narrator model:\n", name)
+	return limitedAdapter.Share(newNarratorAdapter(settings))
+}
+
+// hostWorld registers a world and gives it a turn tracker.
+func hostWorld(hw *hostedWorld) {
+	hw.Turns = narrative.NewTurnTracker(hw.Engine, 5*time.Minute)
+	hw.Turns.Events = hw.Engine.Events // Finished turns may notify players who stopped waiting
+	hostedWorlds[hw.ID] = hw
+}
+
+// hostArchiveWorlds loads the comma-separated archive paths in spec (WORLDS) and hosts
+// each under its manifest ID, configured like the primary world: its executor and
+// engine start as copies of base's, with the archive's content swapped in.
+func hostArchiveWorlds(spec string, baseExecutor *narrative.SimpleActionExecutor, base *narrative.NarrativeEngine) {
+	for _, path := range strings.Split(spec, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		ws := world.NewInMemoryWorldSystem()
+		c, err := loadArchiveContent(path, ws)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to host world %s: %v", path, err)
+		}
+		if _, exists := hostedWorlds[c.Manifest.ID]; exists {
+			log.Fatalf("FATAL: Failed to host world %s: world ID '%s' is already hosted", path, c.Manifest.ID)
+		}
+		worldRating := rating.Default
+		if c.Manifest.ContentRating != "" {
+			if worldRating, err = rating.Parse(c.Manifest.ContentRating); err != nil {
+				log.Fatalf("FATAL: Failed to host world %s: %v", path, err)
+			}
+		}
+		err = world.CheckLocationContainers(ws, c.Items)
+		if err == nil {
+			err = world.CheckLocationLoot(ws, c.LootTables)
+		}
+		if err == nil {
+			err = world.CheckLocationEncounters(ws, c.Bestiary)
+		}
+		if err != nil {
+			log.Fatalf("FATAL: Failed to host world %s: %v", path, err)
+		}
+
+		executor := *baseExecutor
+		executor.WorldSystem = ws
+		executor.Scenarios = c.Scenarios
+		executor.Items = c.Items
+		executor.Bestiary = c.Bestiary
+		executor.Abilities = c.Abilities
+		executor.Perks = c.Perks
+		executor.WorldEvents = c.WorldEvents
+		executor.LootTables = c.LootTables
+		executor.Survival = c.Survival
+
+		prompt := c.Prompt
+		if prompt == "" {
+			prompt = base.SystemPrompt
+		}
+		engine, err := narrative.NewNarrativeEngine(ws, worldAdapter(c.Manifest.Name, c.Manifest.LLM), &executor, base.SessionManager, prompt)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to host world %s: %v", path, err)
+		}
+		library := lore.NewLibrary(c.LoreDocs)
+		engine.ActionPolicy = base.ActionPolicy
+		engine.Lore = library
+		engine.LoreMentions = base.LoreMentions
+		engine.MemorySearcher = base.MemorySearcher
+		engine.MemoryRecall = base.MemoryRecall
+		engine.Autosaver = base.Autosaver
+		engine.Analytics = base.Analytics
+		engine.Events = base.Events
+		engine.Features = base.Features
+		engine.Guard = base.Guard
+		engine.Moderation = base.Moderation
+		engine.Triage = base.Triage
+		engine.Debug = base.Debug
+		engine.PromptVariants = c.Variants
+		engine.HistoryFormat = base.HistoryFormat
+		if c.Manifest.HistoryFormat != "" {
+			engine.HistoryFormat = llm.HistoryFormat(c.Manifest.HistoryFormat) // Validate checked it
+		}
+		engine.Scenarios = c.Scenarios
+		engine.Endings = c.Endings
+		engine.NPCs = c.NPCs
+		engine.WorldEvents = c.WorldEvents
+		engine.Items = c.Items
+		engine.Bestiary = c.Bestiary
+		engine.Abilities = c.Abilities
+		engine.Perks = c.Perks
+		engine.Survival = c.Survival
+		engine.ContentRating = worldRating
+
+		hostWorld(&hostedWorld{
+			ID:        c.Manifest.ID,
+			Name:      c.Manifest.Name,
+			StartID:   c.Manifest.StartLocationID,
+			World:     ws,
+			Engine:    engine,
+			Scenarios: c.Scenarios,
+			Abilities: c.Abilities,
+			Perks:     c.Perks,
+			Lore:      library,
+			Rating:    worldRating,
+		})
+		fmt.Printf("Hosting world '%s' (%s): %d location(s).\n", c.Manifest.Name, c.Manifest.ID, len(ws.GetAllLocationIDs()))
+		logPromptVariants(c.Manifest.Name, c.Variants)
+	}
+}
+
+// logPromptVariants reports a world's system prompt experiment, if it runs one.
+func logPromptVariants(name string, variants []narrative.PromptVariant) {
+	if len(variants) == 0 {
+		return
+	}
+	arms := make([]string, len(variants))
+	for i, variant := range variants {
+		arms[i] = fmt.Sprintf("%s (weight %d)", variant.ID, variant.Weight)
+	}
+	fmt.Printf("World '%s' runs a system prompt experiment: %s\n", name, strings.Join(arms, ", "))
+}
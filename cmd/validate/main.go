@@ -0,0 +1,186 @@
+// Command validate checks world content the way the server loads it, so authors can
+// catch mistakes before deploying. It takes a world archive (a .zip, or an unpacked
+// directory with world.json) or a pair of location and theme directories. -lint also
+// flags content that loads but reads or plays badly (see world.Lint), and -fix applies
+// the mechanical fixes to the content files in place:
+//
+//	go run ./cmd/validate -archive coast.zip -lint
+//	go run ./cmd/validate -locations data/locations -themes data/themes -start oakhaven_gate -fix
+//
+// It exits with status 1 if the content is invalid or lint issues remain.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"llmrpg/internal/world"
+)
+
+// report is the -json output.
+type report struct {
+	Valid  bool              `json:"valid"`
+	Errors []string          `json:"errors,omitempty"`
+	Issues []world.LintIssue `json:"issues,omitempty"`
+	Fixed  []string          `json:"fixed,omitempty"` // Locations -fix changed
+}
+
+func main() {
+	archivePath := flag.String("archive", "", "World archive to check: a .zip or an unpacked directory")
+	locationDir := flag.String("locations", "", "Location directory to check (with -themes)")
+	themeDir := flag.String("themes", "", "Theme directory to check (with -locations)")
+	startID := flag.String("start", "", "Start location for the reachability check (default: the archive's startLocationId)")
+	lint := flag.Bool("lint", false, "Also flag style issues")
+	fix := flag.Bool("fix", false, "Lint and apply the mechanical fixes to the content files (implies -lint)")
+	minWords := flag.Int("min-words", world.DefaultLintMinWords, "Shortest acceptable description, in words")
+	maxWords := flag.Int("max-words", world.DefaultLintMaxWords, "Longest acceptable description, in words")
+	disable := flag.String("disable", "", "Comma-separated lint rules to skip, e.g. one-way-exit")
+	jsonOutput := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	opts := world.LintOptions{StartID: *startID, MinWords: *minWords, MaxWords: *maxWords}
+	for _, name := range strings.Split(*disable, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		rule, err := world.ParseLintRule(name)
+		if err != nil {
+			log.Fatalf("FATAL: Invalid -disable: %v", err)
+		}
+		opts.Disable = append(opts.Disable, rule)
+	}
+
+	// Loading logs progress to stdout; keep it for the report
+	out := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	os.Stdout = devNull
+
+	var ws *world.InMemoryWorldSystem
+	var locFS, themeFS fs.FS
+	var store *world.DirContentStore // Where -fix writes; nil for a zip
+	switch {
+	case *archivePath != "" && (*locationDir != "" || *themeDir != ""):
+		log.Fatal("FATAL: Give either -archive or -locations and -themes, not both")
+	case *archivePath != "":
+		archive, err := openArchive(*archivePath)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		if opts.StartID == "" {
+			opts.StartID = archive.Manifest.StartLocationID
+		}
+		if ws, err = archive.Validate(); err != nil {
+			os.Exit(finish(out, *jsonOutput, report{Errors: []string{err.Error()}}))
+		}
+		if info, statErr := os.Stat(*archivePath); statErr == nil && info.IsDir() {
+			store = world.NewDirContentStore(filepath.Join(*archivePath, world.ArchiveLocationsDir), filepath.Join(*archivePath, world.ArchiveThemesDir))
+			locFS, _ = fs.Sub(archive.FS, world.ArchiveLocationsDir)
+			themeFS, _ = fs.Sub(archive.FS, world.ArchiveThemesDir)
+		}
+	case *locationDir != "" && *themeDir != "":
+		locFS, themeFS = os.DirFS(*locationDir), os.DirFS(*themeDir)
+		store = world.NewDirContentStore(*locationDir, *themeDir)
+		ws = world.NewInMemoryWorldSystem()
+		if err := ws.LoadWorldData(locFS, themeFS); err != nil {
+			os.Exit(finish(out, *jsonOutput, report{Errors: []string{err.Error()}}))
+		}
+		if opts.StartID != "" {
+			if _, err := ws.GetLocation(opts.StartID); err != nil {
+				os.Exit(finish(out, *jsonOutput, report{Errors: []string{fmt.Sprintf("-start: %v", err)}}))
+			}
+		}
+	default:
+		flag.Usage()
+		log.Fatal("FATAL: Give -archive, or -locations and -themes")
+	}
+
+	if *fix && store == nil {
+		log.Fatal("FATAL: -fix needs content directories; unpack the archive first")
+	}
+
+	result := report{Valid: true}
+	if !*lint && !*fix {
+		os.Exit(finish(out, *jsonOutput, result))
+	}
+	result.Issues = world.Lint(ws, opts)
+	if *fix {
+		// Reload with the store set, so fixes are written back to the files they came from
+		ws = world.NewInMemoryWorldSystem()
+		ws.SetContentStore(store)
+		if err := ws.LoadWorldData(locFS, themeFS); err != nil {
+			log.Fatalf("FATAL: Failed to reload content for fixing: %v", err)
+		}
+		changed, err := world.FixLint(ws, result.Issues)
+		for _, loc := range changed {
+			result.Fixed = append(result.Fixed, loc.ID)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Issues = world.Lint(ws, opts)
+	}
+	os.Exit(finish(out, *jsonOutput, result))
+}
+
+// openArchive opens a zip archive or an unpacked archive directory.
+func openArchive(path string) (*world.Archive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return world.NewArchive(os.DirFS(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return world.OpenArchive(f, info.Size())
+}
+
+// finish prints the report and returns the exit status: 1 for invalid content, errors
+// while fixing or remaining lint issues.
+func finish(out *os.File, asJSON bool, r report) int {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+	} else {
+		for _, e := range r.Errors {
+			fmt.Fprintf(out, "error: %s\n", e)
+		}
+		for _, issue := range r.Issues {
+			line := fmt.Sprintf("%s: %s: %s", issue.LocationID, issue.Rule, issue.Message)
+			if issue.Fix != "" {
+				line += fmt.Sprintf(" (fixable: %s)", issue.Fix)
+			}
+			fmt.Fprintln(out, line)
+		}
+		if len(r.Fixed) > 0 {
+			fmt.Fprintf(out, "fixed: %s\n", strings.Join(r.Fixed, ", "))
+		}
+		switch {
+		case !r.Valid:
+			fmt.Fprintln(out, "invalid")
+		case len(r.Issues) > 0:
+			fmt.Fprintf(out, "valid, %d lint issue(s)\n", len(r.Issues))
+		default:
+			fmt.Fprintln(out, "ok")
+		}
+	}
+	if !r.Valid || len(r.Errors) > 0 || len(r.Issues) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+// Package analytics records anonymized per-session aggregates so operators can see
+// how the game is played (sessions started, average length, popular locations)
+// without keeping player names, input or narration.
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"llmrpg/internal/session"
+)
+
+// SessionAggregate is the anonymized summary of one session.
+type SessionAggregate struct {
+	Key              string    `json:"key"`       // Salted hash of the session ID
+	StartedAt        time.Time `json:"startedAt"` // Truncated to the hour
+	Turns            int       `json:"turns"`
+	LocationsVisited []string  `json:"locationsVisited"`
+	ScenarioID       string    `json:"scenarioId,omitempty"`
+	PromptVariant    string    `json:"promptVariant,omitempty"` // System prompt variant the session was assigned
+	EndingID         string    `json:"endingId,omitempty"`
+	Completed        bool      `json:"completed"`
+	Ironman          bool      `json:"ironman"`
+	TotalTokens      int       `json:"totalTokens"`
+}
+
+// Recorder turns sessions into aggregates and writes them to a Store.
+type Recorder struct {
+	store Store
+	salt  string
+}
+
+// NewRecorder creates a recorder. The salt keeps keys from being linked back to
+// session IDs; keep it stable across restarts so a restored session updates its
+// existing aggregate rather than being counted again.
+func NewRecorder(store Store, salt string) *Recorder {
+	return &Recorder{store: store, salt: salt}
+}
+
+// Record stores (or replaces) the aggregate for sess.
+func (r *Recorder) Record(sess *session.GameSession) error {
+	sum := sha256.Sum256([]byte(r.salt + sess.ID))
+	agg := SessionAggregate{
+		Key:              hex.EncodeToString(sum[:16]),
+		StartedAt:        sess.CreatedAt.UTC().Truncate(time.Hour),
+		Turns:            sess.TurnCount,
+		LocationsVisited: slices.Clone(sess.Stats.LocationsVisited),
+		ScenarioID:       sess.ScenarioID,
+		PromptVariant:    sess.PromptVariant,
+		Completed:        sess.Completed(),
+		Ironman:          sess.Ironman,
+		TotalTokens:      sess.Stats.TotalTokens,
+	}
+	if sess.Ending != nil {
+		agg.EndingID = sess.Ending.ID
+	}
+	if err := r.store.Put(agg); err != nil {
+		return fmt.Errorf("failed to record analytics for session: %w", err)
+	}
+	return nil
+}
+
+// Totals reports the aggregates of every recorded session.
+func (r *Recorder) Totals(topLocations int) (*Totals, error) {
+	aggs, err := r.store.All()
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(aggs, topLocations), nil
+}
+
+// Totals summarizes every recorded session.
+type Totals struct {
+	SessionsStarted   int                       `json:"sessionsStarted"`
+	SessionsCompleted int                       `json:"sessionsCompleted"`
+	TotalTurns        int                       `json:"totalTurns"`
+	AverageTurns      float64                   `json:"averageTurns"`
+	TotalTokens       int                       `json:"totalTokens"`
+	PopularLocations  []LocationCount           `json:"popularLocations"` // By number of sessions that visited them
+	Endings           map[string]int            `json:"endings"`          // Sessions per ending reached
+	Scenarios         map[string]int            `json:"scenarios"`        // Sessions per scenario played
+	Variants          map[string]*VariantTotals `json:"variants"`         // Play per system prompt variant, for comparing prompt experiments
+}
+
+// VariantTotals summarizes the sessions assigned one system prompt variant.
+type VariantTotals struct {
+	Sessions     int     `json:"sessions"`
+	Completed    int     `json:"completed"`
+	TotalTurns   int     `json:"totalTurns"`
+	AverageTurns float64 `json:"averageTurns"`
+	TotalTokens  int     `json:"totalTokens"`
+}
+
+// LocationCount is the number of sessions that visited a location.
+type LocationCount struct {
+	LocationID string `json:"locationId"`
+	Sessions   int    `json:"sessions"`
+}
+
+// Summarize computes Totals, listing at most topLocations popular locations (0 = all).
+func Summarize(aggs []SessionAggregate, topLocations int) *Totals {
+	t := &Totals{PopularLocations: []LocationCount{}, Endings: map[string]int{}, Scenarios: map[string]int{}, Variants: map[string]*VariantTotals{}}
+	visits := make(map[string]int)
+	for _, agg := range aggs {
+		t.SessionsStarted++
+		t.TotalTurns += agg.Turns
+		t.TotalTokens += agg.TotalTokens
+		if agg.Completed {
+			t.SessionsCompleted++
+		}
+		if agg.EndingID != "" {
+			t.Endings[agg.EndingID]++
+		}
+		if agg.ScenarioID != "" {
+			t.Scenarios[agg.ScenarioID]++
+		}
+		if agg.PromptVariant != "" {
+			variant := t.Variants[agg.PromptVariant]
+			if variant == nil {
+				variant = &VariantTotals{}
+				t.Variants[agg.PromptVariant] = variant
+			}
+			variant.Sessions++
+			variant.TotalTurns += agg.Turns
+			variant.TotalTokens += agg.TotalTokens
+			if agg.Completed {
+				variant.Completed++
+			}
+		}
+		for _, id := range agg.LocationsVisited {
+			visits[id]++
+		}
+	}
+	if t.SessionsStarted > 0 {
+		t.AverageTurns = float64(t.TotalTurns) / float64(t.SessionsStarted)
+	}
+	for _, variant := range t.Variants {
+		variant.AverageTurns = float64(variant.TotalTurns) / float64(variant.Sessions)
+	}
+
+	for id, n := range visits {
+		t.PopularLocations = append(t.PopularLocations, LocationCount{LocationID: id, Sessions: n})
+	}
+	sort.Slice(t.PopularLocations, func(i, j int) bool {
+		a, b := t.PopularLocations[i], t.PopularLocations[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		return a.LocationID < b.LocationID
+	})
+	if topLocations > 0 && len(t.PopularLocations) > topLocations {
+		t.PopularLocations = t.PopularLocations[:topLocations]
+	}
+	return t
+}
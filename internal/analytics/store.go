@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists session aggregates, keyed by SessionAggregate.Key.
+type Store interface {
+	Put(agg SessionAggregate) error
+	All() ([]SessionAggregate, error)
+}
+
+// MemoryStore keeps aggregates in memory; they are lost on restart.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	aggs map[string]SessionAggregate
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{aggs: make(map[string]SessionAggregate)}
+}
+
+// Put stores or replaces an aggregate.
+func (m *MemoryStore) Put(agg SessionAggregate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aggs[agg.Key] = agg
+	return nil
+}
+
+// All returns every stored aggregate.
+func (m *MemoryStore) All() ([]SessionAggregate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	aggs := make([]SessionAggregate, 0, len(m.aggs))
+	for _, agg := range m.aggs {
+		aggs = append(aggs, agg)
+	}
+	return aggs, nil
+}
+
+// FileStore keeps one JSON file per session aggregate in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store, creating dir if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create analytics directory %s: %w", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes the aggregate atomically (temp file + rename).
+func (f *FileStore) Put(agg SessionAggregate) error {
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal aggregate %s: %w", agg.Key, err)
+	}
+	path := filepath.Join(f.dir, agg.Key+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write aggregate %s: %w", agg.Key, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to finalize aggregate %s: %w", agg.Key, err)
+	}
+	return nil
+}
+
+// All reads every aggregate in the directory.
+func (f *FileStore) All() ([]SessionAggregate, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analytics directory %s: %w", f.dir, err)
+	}
+	var aggs []SessionAggregate
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(f.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read aggregate %s: %w", e.Name(), err)
+		}
+		var agg SessionAggregate
+		if err := json.Unmarshal(data, &agg); err != nil {
+			return nil, fmt.Errorf("failed to parse aggregate %s: %w", e.Name(), err)
+		}
+		aggs = append(aggs, agg)
+	}
+	return aggs, nil
+}
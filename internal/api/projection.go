@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- State Projection ---
+// Clients can ask /state for only the sections they render. Sections map to one
+// or more top-level JSON fields of SessionState; "id" is always included.
+
+// StateSections maps each projectable section name to the SessionState JSON fields it covers.
+// New state fields should be added to a section here so clients can request them.
+var StateSections = map[string][]string{
+	"character":   {"character"},
+	"location":    {"currentLocationId", "currentLocation", "currentTheme", "stealth", "mount"},
+	"history":     {"recentActions", "turnCount"},
+	"turn":        {"turnCount", "lastTurnRolls", "lastTurnEvents", "lastTurnCombat", "lastTurnResults"},
+	"combat":      {"combat"},
+	"challenge":   {"pendingChallenge"},
+	"pending":     {"pendingActions"},
+	"survival":    {"resources"},
+	"stashes":     {"containers"},
+	"entities":    {"entities"},
+	"rumors":      {"rumors"},
+	"worldEvents": {"worldEvents", "worldEventsHour"},
+	"meta":        {"worldId", "createdAt", "lastActive", "ironman", "confirmActions", "lastAutosaveTurn", "playerId", "contentRating"},
+	"scenario":    {"scenarioId", "scenarioBeat"},
+	"progress":    {"flags", "karma", "completedQuests", "searchedLocations", "usedInteractions", "openedExits", "gameHours", "status", "ending"},
+	"stats":       {"stats"},
+}
+
+// ParseSections splits a comma-separated include list and checks every name is a known section.
+func ParseSections(include string) ([]string, error) {
+	var sections []string
+	for _, name := range strings.Split(include, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := StateSections[name]; !ok {
+			known := make([]string, 0, len(StateSections))
+			for k := range StateSections {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown state section '%s' (known: %s)", name, strings.Join(known, ", "))
+		}
+		sections = append(sections, name)
+	}
+	return sections, nil
+}
+
+// Project returns the state's JSON fields for the given sections, plus "id".
+func Project(state *SessionState, sections []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session %s: %w", state.ID, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", state.ID, err)
+	}
+
+	projected := map[string]json.RawMessage{"id": fields["id"]}
+	for _, section := range sections {
+		for _, field := range StateSections[section] {
+			if value, ok := fields[field]; ok {
+				projected[field] = value
+			}
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,192 @@
+// Package api declares the JSON shapes the HTTP API returns for sessions. They are
+// built from the internal types but declared separately, so renaming or adding a field
+// on GameSession (whose JSON form is also the save format) can't silently change what
+// clients receive: a response only changes when a type here does.
+//
+// The top-level session and the character are mapped field by field; smaller nested
+// values (history records, dice results, events...) are passed through in their
+// package's JSON form.
+package api
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"llmrpg/internal/character"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/rating"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// SessionState is a session as /state and /create_session return it, to its player.
+// Internals a player shouldn't see or could exploit stay out: the dice seed (which
+// predicts every roll), the prompt experiment variant, the pre-migration ID, turn
+// reports and long-term memory (which /session/{id}/history serves instead).
+type SessionState struct {
+	ID                string                    `json:"id"`
+	Character         *Character                `json:"character"`
+	WorldID           string                    `json:"worldId,omitempty"`
+	PlayerID          string                    `json:"playerId,omitempty"`
+	CurrentLocationID string                    `json:"currentLocationId"`
+	CurrentLocation   *world.LocationNode       `json:"currentLocation"`        // Details of CurrentLocationID; null if it couldn't be looked up
+	CurrentTheme      *world.ThemeDefinition    `json:"currentTheme,omitempty"` // Presentation metadata for CurrentLocation's theme
+	CreatedAt         time.Time                 `json:"createdAt"`
+	LastActive        time.Time                 `json:"lastActive"`
+	RecentActions     []history.TurnRecord      `json:"recentActions"`
+	TurnCount         int                       `json:"turnCount"`
+	LastTurnRolls     []dice.Result             `json:"lastTurnRolls,omitempty"`
+	LastTurnEvents    []events.Event            `json:"lastTurnEvents,omitempty"`
+	LastTurnCombat    []combat.LogEntry         `json:"lastTurnCombat,omitempty"`
+	LastTurnResults   []llm.ActionResult        `json:"lastTurnResults,omitempty"`
+	Combat            *combat.Encounter         `json:"combat,omitempty"`
+	Stealth           session.StealthState      `json:"stealth,omitempty"`
+	Mount             string                    `json:"mount,omitempty"`
+	PendingChallenge  *session.Challenge        `json:"pendingChallenge,omitempty"`
+	Ironman           bool                      `json:"ironman"`
+	ConfirmActions    bool                      `json:"confirmActions"`
+	PendingActions    []session.PendingAction   `json:"pendingActions,omitempty"`
+	LastAutosaveTurn  int                       `json:"lastAutosaveTurn"`
+	ScenarioID        string                    `json:"scenarioId,omitempty"`
+	ScenarioBeat      int                       `json:"scenarioBeat,omitempty"`
+	ContentRating     rating.Rating             `json:"contentRating,omitempty"`
+	Flags             map[string]bool           `json:"flags,omitempty"`
+	Entities          []session.Entity          `json:"entities,omitempty"`
+	Karma             int                       `json:"karma,omitempty"`
+	CompletedQuests   []string                  `json:"completedQuests,omitempty"`
+	SearchedLocations []string                  `json:"searchedLocations,omitempty"`
+	Containers        map[string]map[string]int `json:"containers,omitempty"`
+	UsedInteractions  []string                  `json:"usedInteractions,omitempty"`
+	OpenedExits       []string                  `json:"openedExits,omitempty"`
+	Rumors            []session.Rumor           `json:"rumors,omitempty"`
+	WorldEvents       []session.WorldEventRun   `json:"worldEvents,omitempty"`
+	WorldEventsHour   int                       `json:"worldEventsHour"`
+	GameHours         int                       `json:"gameHours"`
+	Resources         map[string]int            `json:"resources,omitempty"`
+	Status            session.Status            `json:"status,omitempty"`
+	Ending            *session.EndingRecord     `json:"ending,omitempty"`
+	Stats             session.Stats             `json:"stats"`
+}
+
+// Character is the player character as the API returns it.
+type Character struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Class        string             `json:"class,omitempty"`
+	Origin       string             `json:"origin,omitempty"`
+	Level        int                `json:"level"`
+	XP           int                `json:"xp"`
+	HP           int                `json:"hp"`
+	MaxHP        int                `json:"maxHp"`
+	MP           int                `json:"mp"`
+	MaxMP        int                `json:"maxMp"`
+	Inventory    map[string]int     `json:"inventory,omitempty"`
+	Effects      []character.Effect `json:"effects,omitempty"`
+	Wear         map[string]int     `json:"wear,omitempty"`
+	Abilities    []string           `json:"abilities,omitempty"`
+	Cooldowns    map[string]int     `json:"cooldowns,omitempty"`
+	Perks        []string           `json:"perks,omitempty"`
+	PendingPerks int                `json:"pendingPerks,omitempty"`
+	Bonuses      character.Bonuses  `json:"bonuses"`
+}
+
+// CreatedSession is the /create_session response: the new session's state plus the
+// access token, which is only ever returned here.
+type CreatedSession struct {
+	SessionState
+	AccessToken string `json:"accessToken"`
+}
+
+// NewSessionState maps a session to its API form. loc and theme are the details of
+// the session's current location, looked up by the caller (nil if unavailable). The
+// result shares no maps or slices with sess, so it can be encoded after the session
+// has moved on.
+func NewSessionState(sess *session.GameSession, loc *world.LocationNode, theme *world.ThemeDefinition) *SessionState {
+	return &SessionState{
+		ID:                sess.ID,
+		Character:         NewCharacter(sess.Player),
+		WorldID:           sess.WorldID,
+		PlayerID:          sess.PlayerID,
+		CurrentLocationID: sess.CurrentLocationID,
+		CurrentLocation:   loc,
+		CurrentTheme:      theme,
+		CreatedAt:         sess.CreatedAt,
+		LastActive:        sess.LastActive,
+		RecentActions:     slices.Clone(sess.RecentActions),
+		TurnCount:         sess.TurnCount,
+		LastTurnRolls:     slices.Clone(sess.LastTurnRolls),
+		LastTurnEvents:    slices.Clone(sess.LastTurnEvents),
+		LastTurnCombat:    slices.Clone(sess.LastTurnCombat),
+		LastTurnResults:   slices.Clone(sess.LastTurnResults),
+		Combat:            sess.Combat,
+		Stealth:           sess.Stealth,
+		Mount:             sess.Mount,
+		PendingChallenge:  sess.PendingChallenge,
+		Ironman:           sess.Ironman,
+		ConfirmActions:    sess.ConfirmActions,
+		PendingActions:    slices.Clone(sess.PendingActions),
+		LastAutosaveTurn:  sess.LastAutosaveTurn,
+		ScenarioID:        sess.ScenarioID,
+		ScenarioBeat:      sess.ScenarioBeat,
+		ContentRating:     sess.ContentRating,
+		Flags:             maps.Clone(sess.Flags),
+		Entities:          slices.Clone(sess.Entities),
+		Karma:             sess.Karma,
+		CompletedQuests:   slices.Clone(sess.CompletedQuests),
+		SearchedLocations: slices.Clone(sess.SearchedLocations),
+		Containers:        cloneContainers(sess.Containers),
+		UsedInteractions:  slices.Clone(sess.UsedInteractions),
+		OpenedExits:       slices.Clone(sess.OpenedExits),
+		Rumors:            slices.Clone(sess.Rumors),
+		WorldEvents:       slices.Clone(sess.WorldEvents),
+		WorldEventsHour:   sess.WorldEventsHour,
+		GameHours:         sess.GameHours,
+		Resources:         maps.Clone(sess.Resources),
+		Status:            sess.Status,
+		Ending:            sess.Ending,
+		Stats:             sess.Stats,
+	}
+}
+
+// NewCharacter maps a character to its API form (nil for nil).
+func NewCharacter(c *character.Character) *Character {
+	if c == nil {
+		return nil
+	}
+	return &Character{
+		ID:           c.ID,
+		Name:         c.Name,
+		Class:        c.Class,
+		Origin:       c.Origin,
+		Level:        c.Level,
+		XP:           c.XP,
+		HP:           c.HP,
+		MaxHP:        c.MaxHP,
+		MP:           c.MP,
+		MaxMP:        c.MaxMP,
+		Inventory:    maps.Clone(c.Inventory),
+		Effects:      slices.Clone(c.Effects),
+		Wear:         maps.Clone(c.Wear),
+		Abilities:    slices.Clone(c.Abilities),
+		Cooldowns:    maps.Clone(c.Cooldowns),
+		Perks:        slices.Clone(c.Perks),
+		PendingPerks: c.PendingPerks,
+		Bonuses:      c.Bonuses,
+	}
+}
+
+func cloneContainers(containers map[string]map[string]int) map[string]map[string]int {
+	if containers == nil {
+		return nil
+	}
+	clone := make(map[string]map[string]int, len(containers))
+	for key, contents := range containers {
+		clone[key] = maps.Clone(contents)
+	}
+	return clone
+}
@@ -0,0 +1,276 @@
+// Package audience lets a stream's viewers play a session: each round, chat votes
+// among the last turn's suggestions for a fixed window, and the winning suggestion
+// is submitted as the player's input. The next round opens with that turn's
+// suggestions.
+package audience
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEnded is returned by a SubmitFunc when the session can take no more input
+// (it reached an ending or was deleted); the mode stops instead of opening a round.
+var ErrEnded = errors.New("session accepts no further input")
+
+// Status describes what a Mode is doing.
+type Status string
+
+const (
+	StatusWaiting    Status = "waiting"    // No suggestions to vote on yet; the next turn's open a round
+	StatusVoting     Status = "voting"     // A round is open
+	StatusSubmitting Status = "submitting" // The winning option is being played
+	StatusStopped    Status = "stopped"
+)
+
+// SubmitFunc plays input as the session's next turn and returns the turn's suggestions.
+type SubmitFunc func(ctx context.Context, input string) ([]string, error)
+
+// Option is one choice in a round, numbered from 1 as viewers vote for it.
+type Option struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+	Votes  int    `json:"votes"`
+}
+
+// Result is how a round ended.
+type Result struct {
+	Round      int    `json:"round"`
+	Input      string `json:"input"`      // The winning option's text
+	Votes      int    `json:"votes"`      // Votes for the winner
+	TotalVotes int    `json:"totalVotes"` // Votes cast in the round
+	Error      string `json:"error,omitempty"`
+}
+
+// State is a snapshot of a Mode, as overlays show it.
+type State struct {
+	SessionID  string     `json:"sessionId"`
+	Channel    string     `json:"channel"`
+	Status     Status     `json:"status"`
+	Round      int        `json:"round"` // Numbered from 1; 0 before the first round opens
+	Options    []Option   `json:"options"`
+	TotalVotes int        `json:"totalVotes"`
+	ClosesAt   *time.Time `json:"closesAt,omitempty"` // Set while voting
+	Last       *Result    `json:"last,omitempty"`     // The previous round
+}
+
+// Mode runs voting rounds for one session. Create it with New, start it with Run,
+// and feed it chat messages with Vote.
+type Mode struct {
+	window time.Duration
+	submit SubmitFunc
+
+	mu      sync.Mutex
+	state   State
+	ballots map[string]int // Viewer -> option number, for the open round
+	wake    chan struct{}  // Signals Run that a round opened
+}
+
+// New creates a mode for a session whose rounds stay open for window.
+func New(sessionID, channel string, window time.Duration, submit SubmitFunc) *Mode {
+	return &Mode{
+		window: window,
+		submit: submit,
+		state: State{
+			SessionID: sessionID,
+			Channel:   channel,
+			Status:    StatusWaiting,
+			Options:   []Option{},
+		},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Offer opens a new round on suggestions, replacing any open round: call it when the
+// session takes a turn some other way, e.g. the streamer playing directly. It does
+// nothing while the mode is submitting (that turn's suggestions open the next round)
+// or after it stopped.
+func (m *Mode) Offer(suggestions []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.Status == StatusSubmitting || m.state.Status == StatusStopped {
+		return
+	}
+	m.open(suggestions)
+}
+
+// open starts a round on suggestions, or waits if there are none. Callers hold m.mu.
+func (m *Mode) open(suggestions []string) {
+	m.state.Options = make([]Option, 0, len(suggestions))
+	for i, text := range suggestions {
+		m.state.Options = append(m.state.Options, Option{Number: i + 1, Text: text})
+	}
+	m.state.TotalVotes = 0
+	m.ballots = make(map[string]int)
+	if len(suggestions) == 0 {
+		m.state.Status = StatusWaiting
+		m.state.ClosesAt = nil
+		return
+	}
+	m.state.Round++
+	m.state.Status = StatusVoting
+	closesAt := time.Now().Add(m.window)
+	m.state.ClosesAt = &closesAt
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Vote counts a chat message if it's a vote in the open round (see ParseVote). A
+// viewer has one vote per round; voting again moves it. Reports whether it counted.
+func (m *Mode) Vote(viewer, message string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.Status != StatusVoting {
+		return false
+	}
+	texts := make([]string, len(m.state.Options))
+	for i, option := range m.state.Options {
+		texts[i] = option.Text
+	}
+	number, ok := ParseVote(message, texts)
+	if !ok {
+		return false
+	}
+	if previous, voted := m.ballots[viewer]; voted {
+		m.state.Options[previous-1].Votes--
+	} else {
+		m.state.TotalVotes++
+	}
+	m.ballots[viewer] = number
+	m.state.Options[number-1].Votes++
+	return true
+}
+
+// State returns a snapshot of the mode.
+func (m *Mode) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.state
+	state.Options = append([]Option{}, m.state.Options...)
+	if m.state.Last != nil {
+		last := *m.state.Last
+		state.Last = &last
+	}
+	return state
+}
+
+// Run closes each round when its window ends and submits the winner, until ctx is
+// cancelled or a submission returns ErrEnded. A round nobody voted in stays open for
+// another window. It returns after marking the mode stopped.
+func (m *Mode) Run(ctx context.Context) {
+	defer func() {
+		m.mu.Lock()
+		m.state.Status = StatusStopped
+		m.state.ClosesAt = nil
+		m.mu.Unlock()
+	}()
+	for {
+		m.mu.Lock()
+		round, closesAt := m.state.Round, m.state.ClosesAt
+		voting := m.state.Status == StatusVoting
+		m.mu.Unlock()
+
+		var closed <-chan time.Time
+		var timer *time.Timer
+		if voting {
+			timer = time.NewTimer(time.Until(*closesAt))
+			closed = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-m.wake: // A new round replaced this one
+			stopTimer(timer)
+		case <-closed:
+			if !m.closeRound(ctx, round) {
+				return
+			}
+		}
+	}
+}
+
+// closeRound ends the round if it's still the open one, and plays its winner.
+// Reports false if the mode should stop.
+func (m *Mode) closeRound(ctx context.Context, round int) bool {
+	m.mu.Lock()
+	if m.state.Round != round || m.state.Status != StatusVoting {
+		m.mu.Unlock()
+		return true
+	}
+	if m.state.TotalVotes == 0 {
+		closesAt := time.Now().Add(m.window)
+		m.state.ClosesAt = &closesAt
+		m.mu.Unlock()
+		return true
+	}
+	winner := m.state.Options[0]
+	for _, option := range m.state.Options[1:] {
+		if option.Votes > winner.Votes { // Ties go to the lower number
+			winner = option
+		}
+	}
+	result := &Result{Round: round, Input: winner.Text, Votes: winner.Votes, TotalVotes: m.state.TotalVotes}
+	suggestions := make([]string, len(m.state.Options))
+	for i, option := range m.state.Options {
+		suggestions[i] = option.Text
+	}
+	m.state.Status = StatusSubmitting
+	m.state.ClosesAt = nil
+	m.mu.Unlock()
+
+	next, err := m.submit(ctx, winner.Text)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.Last = result
+	if err != nil {
+		result.Error = err.Error()
+		if errors.Is(err, ErrEnded) || ctx.Err() != nil {
+			return false
+		}
+		next = suggestions // The turn didn't happen; vote on the same options again
+	}
+	m.state.Status = StatusWaiting
+	m.open(next)
+	return true
+}
+
+// ParseVote reads a chat message as a vote among options, numbered from 1. It
+// accepts the number alone ("2"), with a prefix ("#2", "!vote 2"), or an option's
+// exact text, ignoring case.
+func ParseVote(message string, options []string) (int, bool) {
+	message = strings.TrimSpace(message)
+	if rest, ok := cutPrefixFold(message, "!vote"); ok {
+		message = strings.TrimSpace(rest)
+	}
+	message = strings.TrimPrefix(message, "#")
+	if n, err := strconv.Atoi(message); err == nil {
+		return n, n >= 1 && n <= len(options)
+	}
+	for i, option := range options {
+		if strings.EqualFold(message, strings.TrimSpace(option)) {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
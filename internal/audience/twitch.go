@@ -0,0 +1,132 @@
+package audience
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTwitchAddr is Twitch chat's IRC endpoint (TLS).
+const DefaultTwitchAddr = "irc.chat.twitch.tv:6697"
+
+// channelPattern matches Twitch login names, which are also channel names.
+var channelPattern = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+// NormalizeChannel lower-cases a channel name and drops a leading '#', or returns an
+// error if it isn't a valid Twitch login.
+func NormalizeChannel(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "#"))
+	if !channelPattern.MatchString(channel) {
+		return "", fmt.Errorf("invalid Twitch channel '%s'", channel)
+	}
+	return channel, nil
+}
+
+// TwitchChat reads a channel's chat over Twitch's IRC interface. Reading needs no
+// account: without a Token it logs in anonymously.
+type TwitchChat struct {
+	Addr  string // Defaults to DefaultTwitchAddr
+	Nick  string // Login of the account Token belongs to
+	Token string // OAuth token ("oauth:..." or bare); empty = anonymous read-only login
+}
+
+// Follow reads channel's chat until ctx is cancelled, reconnecting with backoff when
+// the connection drops, and calls handle with each message's sender and text.
+func (tc *TwitchChat) Follow(ctx context.Context, channel string, handle func(viewer, text string)) {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := tc.read(ctx, channel, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second // It was up for a while; this is a fresh failure
+		}
+		log.Printf("Warning: Twitch chat for #%s disconnected (%v); reconnecting in %s", channel, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 2*time.Minute)
+	}
+}
+
+// read holds one connection to channel's chat until it fails or ctx is cancelled.
+func (tc *TwitchChat) read(ctx context.Context, channel string, handle func(viewer, text string)) error {
+	addr := tc.Addr
+	if addr == "" {
+		addr = DefaultTwitchAddr
+	}
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 10 * time.Second}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	nick, pass := tc.Nick, tc.Token
+	if pass == "" {
+		nick = fmt.Sprintf("justinfan%d", 10000+time.Now().UnixNano()%90000) // Twitch's anonymous login
+	} else if !strings.HasPrefix(pass, "oauth:") {
+		pass = "oauth:" + pass
+	}
+	if pass != "" {
+		fmt.Fprintf(conn, "PASS %s\r\n", pass)
+	}
+	fmt.Fprintf(conn, "NICK %s\r\nJOIN #%s\r\n", strings.ToLower(nick), channel)
+
+	// Twitch pings every ~5 minutes; a longer silence means the link is dead
+	conn.SetReadDeadline(time.Now().Add(10 * time.Minute))
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Minute))
+		prefix, command, params, trailing := parseIRCLine(scanner.Text())
+		switch command {
+		case "PING":
+			fmt.Fprintf(conn, "PONG :%s\r\n", trailing)
+		case "RECONNECT":
+			return fmt.Errorf("server asked to reconnect")
+		case "NOTICE":
+			if strings.Contains(trailing, "failed") { // e.g. "Login authentication failed"
+				return fmt.Errorf("%s", trailing)
+			}
+		case "PRIVMSG":
+			if len(params) > 0 && params[0] == "#"+channel {
+				viewer, _, _ := strings.Cut(prefix, "!")
+				handle(viewer, trailing)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("connection closed")
+}
+
+// parseIRCLine splits an IRC message like ":nick!user@host PRIVMSG #channel :text"
+// into its prefix (without ':'), command, middle parameters and trailing parameter.
+// Message tags ("@key=value ...") are skipped.
+func parseIRCLine(line string) (prefix, command string, params []string, trailing string) {
+	if strings.HasPrefix(line, "@") {
+		_, line, _ = strings.Cut(line, " ")
+	}
+	if strings.HasPrefix(line, ":") {
+		prefix, line, _ = strings.Cut(line[1:], " ")
+	}
+	line, trailing, _ = strings.Cut(line, " :")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return prefix, "", nil, trailing
+	}
+	return prefix, fields[0], fields[1:], trailing
+}
@@ -1,13 +1,31 @@
 package character
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Character holds player-specific data based on the technical design
-// We are omitting Inventory and Equipment for the initial MVP focus.
+// We are omitting Equipment for the initial MVP focus.
 type Character struct {
-	ID     string `json:"id"`               // Unique identifier for the character/player
-	Name   string `json:"name"`             // Character's name
-	Class  string `json:"class,omitempty"`  // e.g., "Psychic", "Courier"
-	Origin string `json:"origin,omitempty"` // e.g., "Wasteland-Born"
-	Level  int    `json:"level"`            // Starts at 1, progression mechanism TBD
+	ID           string         `json:"id"`                     // Unique identifier for the character/player
+	Name         string         `json:"name"`                   // Character's name
+	Class        string         `json:"class,omitempty"`        // e.g., "Psychic", "Courier"
+	Origin       string         `json:"origin,omitempty"`       // e.g., "Wasteland-Born"
+	Level        int            `json:"level"`                  // Starts at 1; experience raises it (see XP)
+	XP           int            `json:"xp"`                     // Total experience earned
+	HP           int            `json:"hp"`                     // Current hit points; 0 means defeated
+	MaxHP        int            `json:"maxHp"`                  // Hit points when fully healed
+	MP           int            `json:"mp"`                     // Current mana, spent on abilities
+	MaxMP        int            `json:"maxMp"`                  // Mana when fully rested
+	Inventory    map[string]int `json:"inventory,omitempty"`    // Item ID -> count carried
+	Effects      []Effect       `json:"effects,omitempty"`      // Active status effects, in the order applied
+	Wear         map[string]int `json:"wear,omitempty"`         // Item ID -> durability lost by the one in use (see world.Item.Durability)
+	Abilities    []string       `json:"abilities,omitempty"`    // Known ability IDs (see world.Ability), in the order learned
+	Cooldowns    map[string]int `json:"cooldowns,omitempty"`    // Ability ID -> first turn it can be used again
+	Perks        []string       `json:"perks,omitempty"`        // Chosen perk IDs (see world.Perk), in the order picked
+	PendingPerks int            `json:"pendingPerks,omitempty"` // Level-ups still waiting for a perk choice
+	Bonuses      Bonuses        `json:"bonuses"`                // Permanent modifiers from perks
 	// Flags map[string]bool `json:"flags,omitempty"` // Optional narrative tags - Consider managing in Session state instead?
 	// Appearance string `json:"appearance,omitempty"` // Optional description for prompts
 }
@@ -21,8 +39,161 @@ func NewCharacter(id, name, class, origin string) *Character {
 		Class:  class,
 		Origin: origin,
 		Level:  1, // Characters typically start at level 1
+		HP:     DefaultMaxHP,
+		MaxHP:  DefaultMaxHP,
+		MP:     DefaultMaxMP,
+		MaxMP:  DefaultMaxMP,
+	}
+}
+
+// DefaultMaxHP is a new character's maximum hit points.
+const DefaultMaxHP = 20
+
+// DefaultMaxMP is a new character's maximum mana.
+const DefaultMaxMP = 10
+
+// EnsureVitals gives characters from before hit points (or mana) existed a full default pool.
+func (c *Character) EnsureVitals() {
+	if c.MaxHP <= 0 {
+		c.MaxHP, c.HP = DefaultMaxHP, DefaultMaxHP
+	}
+	if c.MaxMP <= 0 {
+		c.MaxMP, c.MP = DefaultMaxMP, DefaultMaxMP
+	}
+}
+
+// Heal restores up to amount hit points (capped at MaxHP) and returns how many were restored.
+func (c *Character) Heal(amount int) int {
+	c.EnsureVitals()
+	restored := min(amount, c.MaxHP-c.HP)
+	if restored < 0 {
+		restored = 0
+	}
+	c.HP += restored
+	return restored
+}
+
+// TakeDamage removes up to amount hit points (never below 0) and returns how many were lost.
+func (c *Character) TakeDamage(amount int) int {
+	c.EnsureVitals()
+	lost := min(amount, c.HP)
+	c.HP -= lost
+	return lost
+}
+
+// ItemCount returns how many of itemID the character carries.
+func (c *Character) ItemCount(itemID string) int {
+	return c.Inventory[itemID]
+}
+
+// AddItem adds count of itemID to the inventory.
+func (c *Character) AddItem(itemID string, count int) {
+	if c.Inventory == nil {
+		c.Inventory = make(map[string]int)
+	}
+	c.Inventory[itemID] += count
+}
+
+// ErrInsufficientItems is returned when an action needs more of an item than is available.
+var ErrInsufficientItems = errors.New("not enough items")
+
+// RemoveItem removes count of itemID, failing if the character carries fewer.
+func (c *Character) RemoveItem(itemID string, count int) error {
+	if have := c.Inventory[itemID]; have < count {
+		return fmt.Errorf("%w: character has %d of item '%s', cannot remove %d", ErrInsufficientItems, have, itemID, count)
+	}
+	c.Inventory[itemID] -= count
+	if c.Inventory[itemID] == 0 {
+		delete(c.Inventory, itemID)
+		delete(c.Wear, itemID)
+	}
+	return nil
+}
+
+// Bonuses are permanent modifiers to the character's rolls and defenses.
+type Bonuses struct {
+	Attack     int `json:"attack,omitempty"`     // Added to attack rolls
+	Armor      int `json:"armor,omitempty"`      // Added to armor class
+	Checks     int `json:"checks,omitempty"`     // Added to skill checks, challenges and stealth rolls
+	Initiative int `json:"initiative,omitempty"` // Added to initiative rolls
+}
+
+// Effect is a status effect on the character. The effects package gives some IDs
+// mechanical consequences; others only inform the narration.
+type Effect struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Remaining   int    `json:"remaining,omitempty"` // Turns left; 0 = lasts until removed
+}
+
+// HasEffect reports whether the character is under effectID.
+func (c *Character) HasEffect(effectID string) bool {
+	for _, e := range c.Effects {
+		if e.ID == effectID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddEffect applies an effect, replacing (refreshing) any existing one with the same ID.
+func (c *Character) AddEffect(effect Effect) {
+	for i, e := range c.Effects {
+		if e.ID == effect.ID {
+			c.Effects[i] = effect
+			return
+		}
+	}
+	c.Effects = append(c.Effects, effect)
+}
+
+// RemoveEffect removes effectID and reports whether the character had it.
+func (c *Character) RemoveEffect(effectID string) bool {
+	for i, e := range c.Effects {
+		if e.ID == effectID {
+			c.Effects = append(c.Effects[:i], c.Effects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// AddWear adds amount of wear to the itemID in use and returns its total wear.
+func (c *Character) AddWear(itemID string, amount int) int {
+	if c.Wear == nil {
+		c.Wear = make(map[string]int)
+	}
+	c.Wear[itemID] += amount
+	return c.Wear[itemID]
+}
+
+// KnowsAbility reports whether the character knows abilityID.
+func (c *Character) KnowsAbility(abilityID string) bool {
+	for _, id := range c.Abilities {
+		if id == abilityID {
+			return true
+		}
+	}
+	return false
+}
+
+// CooldownLeft returns how many turns, from turn, until abilityID can be used again
+// (0 = usable now).
+func (c *Character) CooldownLeft(abilityID string, turn int) int {
+	return max(c.Cooldowns[abilityID]-turn, 0)
+}
+
+// StartCooldown makes abilityID unusable for the turns turns after turn.
+func (c *Character) StartCooldown(abilityID string, turn, turns int) {
+	if turns <= 0 {
+		delete(c.Cooldowns, abilityID)
+		return
+	}
+	if c.Cooldowns == nil {
+		c.Cooldowns = make(map[string]int)
 	}
+	c.Cooldowns[abilityID] = turn + turns + 1
 }
 
 // Add methods here later if needed, e.g., LevelUp(), AddFlag(), etc.
-// For now, it's just a data container.
\ No newline at end of file
+// For now, it's just a data container.
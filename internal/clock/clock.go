@@ -0,0 +1,58 @@
+// Package clock provides the engine's notion of "now", which deterministic mode
+// replaces with a stepped fake so timestamps (and IDs derived from them) repeat
+// exactly from run to run.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real wall clock.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Default is the clock the engine reads through Now. Replace it (with Set) before
+// serving requests, not while they're running.
+var Default = System
+
+// Set replaces Default.
+func Set(c Clock) {
+	Default = c
+}
+
+// Now returns Default's current time.
+func Now() time.Time {
+	return Default.Now()
+}
+
+// Stepped is a fake clock that starts at a fixed time and moves forward by a fixed
+// step every time it's read, so successive readings stay ordered and distinct.
+// Safe for concurrent use.
+type Stepped struct {
+	mu   sync.Mutex
+	next time.Time
+	step time.Duration
+}
+
+// NewStepped creates a stepped clock whose first reading is start.
+func NewStepped(start time.Time, step time.Duration) *Stepped {
+	return &Stepped{next: start, step: step}
+}
+
+// Now returns the current reading and advances the clock by one step.
+func (s *Stepped) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.next
+	s.next = s.next.Add(s.step)
+	return now
+}
@@ -0,0 +1,567 @@
+// Package combat resolves fights with deterministic server-side rules. The LLM decides
+// what the player does ("attack", "defend", "flee", "useItem") and narrates it; whether
+// blows land and how hard they hit comes from dice rolled here, and every step is
+// written to a combat log returned with the turn.
+//
+// Combatants act in initiative order, rolled when the encounter starts. A round runs
+// the enemies ahead of the player, the player's move, then the rest; each enemy picks
+// its move by the behavior its bestiary entry defines.
+package combat
+
+import (
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/effects"
+	"llmrpg/internal/world"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Combatant is an enemy in an encounter. The player fights with their character's
+// hit points and the player stats below.
+type Combatant struct {
+	ID          string                  `json:"id"`
+	CreatureID  string                  `json:"creatureId,omitempty"` // Bestiary entry the enemy was built from
+	Name        string                  `json:"name"`
+	HP          int                     `json:"hp"`
+	MaxHP       int                     `json:"maxHp"`
+	ArmorClass  int                     `json:"armorClass"`  // Attack total needed to hit
+	AttackBonus int                     `json:"attackBonus"` // Added to the d20 attack roll
+	Damage      string                  `json:"damage"`      // Dice notation, e.g. "1d6+1"
+	Abilities   []world.CreatureAbility `json:"abilities,omitempty"`
+	Initiative  int                     `json:"initiative,omitempty"` // Added to the d20 initiative roll
+	Behavior    string                  `json:"behavior,omitempty"`   // How it picks its moves (world.Behavior*; empty = aggressive)
+	Guarding    bool                    `json:"guarding,omitempty"`   // Guarding until its next turn (+DefendBonus armor class)
+	Fled        bool                    `json:"fled,omitempty"`       // Ran from the fight
+}
+
+// FromCreature builds an enemy from a bestiary entry; NewEncounter fills in defaults.
+func FromCreature(c *world.Creature) Combatant {
+	return Combatant{
+		ID:          c.ID,
+		CreatureID:  c.ID,
+		Name:        c.Name,
+		HP:          c.HP,
+		ArmorClass:  c.ArmorClass,
+		AttackBonus: c.AttackBonus,
+		Damage:      c.Damage,
+		Abilities:   c.Abilities,
+		Initiative:  c.Initiative,
+		Behavior:    c.Behavior,
+	}
+}
+
+// Alive reports whether the combatant can still fight: it has HP left and hasn't fled.
+func (c *Combatant) Alive() bool {
+	return c.HP > 0 && !c.Fled
+}
+
+// CurrentArmorClass is the combatant's armor class, raised while it is guarding.
+func (c *Combatant) CurrentArmorClass() int {
+	if c.Guarding {
+		return c.ArmorClass + DefendBonus
+	}
+	return c.ArmorClass
+}
+
+// Outcome is how an encounter ended.
+type Outcome string
+
+const (
+	OutcomeVictory Outcome = "victory" // Every enemy was defeated or fled
+	OutcomeFled    Outcome = "fled"    // The player escaped
+	OutcomeDefeat  Outcome = "defeat"  // The player was reduced to 0 HP
+)
+
+// Encounter is a fight in progress.
+type Encounter struct {
+	Enemies   []*Combatant     `json:"enemies"`
+	Order     []InitiativeRoll `json:"order,omitempty"`     // Turn order, highest initiative first (see RollInitiative)
+	Round     int              `json:"round"`               // Rounds resolved so far
+	Defending bool             `json:"defending,omitempty"` // The player is defending until their next turn
+	Outcome   Outcome          `json:"outcome,omitempty"`   // Set once the encounter is over
+}
+
+// InitiativeRoll is one combatant's place in the turn order.
+type InitiativeRoll struct {
+	ID    string `json:"id"` // PlayerActor or an enemy ID
+	Name  string `json:"name"`
+	Total int    `json:"total"`
+}
+
+// Over reports whether the encounter has ended.
+func (e *Encounter) Over() bool {
+	return e.Outcome != ""
+}
+
+// Living returns the enemies still standing.
+func (e *Encounter) Living() []*Combatant {
+	var living []*Combatant
+	for _, enemy := range e.Enemies {
+		if enemy.Alive() {
+			living = append(living, enemy)
+		}
+	}
+	return living
+}
+
+// Target finds a living enemy by ID; an empty ID picks the first one standing.
+func (e *Encounter) Target(id string) (*Combatant, error) {
+	for _, enemy := range e.Enemies {
+		if (id == "" || enemy.ID == id) && enemy.Alive() {
+			return enemy, nil
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("no enemies left standing")
+	}
+	return nil, fmt.Errorf("no living enemy with ID '%s' (enemies: %s)", id, strings.Join(e.enemyIDs(), ", "))
+}
+
+// turnOrder splits the living enemies into those acting before the player in a round
+// and those acting after. Without an initiative order the player goes first.
+func (e *Encounter) turnOrder() (before, after []*Combatant) {
+	if len(e.Order) == 0 {
+		return nil, e.Living()
+	}
+	byID := make(map[string]*Combatant, len(e.Enemies))
+	for _, enemy := range e.Enemies {
+		byID[enemy.ID] = enemy
+	}
+	playerDone := false
+	for _, slot := range e.Order {
+		if slot.ID == PlayerActor {
+			playerDone = true
+			continue
+		}
+		if enemy, ok := byID[slot.ID]; ok && enemy.Alive() {
+			if playerDone {
+				after = append(after, enemy)
+			} else {
+				before = append(before, enemy)
+			}
+		}
+	}
+	return before, after
+}
+
+func (e *Encounter) enemyIDs() []string {
+	ids := make([]string, 0, len(e.Enemies))
+	for _, enemy := range e.Enemies {
+		ids = append(ids, enemy.ID)
+	}
+	return ids
+}
+
+// LogEntry is one resolved step of combat.
+type LogEntry struct {
+	Round      int          `json:"round"`
+	Actor      string       `json:"actor"` // "player" or an enemy ID
+	Action     string       `json:"action"`
+	Ability    string       `json:"ability,omitempty"`    // Creature ability used instead of a basic attack
+	Target     string       `json:"target,omitempty"`     // "player" or an enemy ID
+	Roll       *dice.Result `json:"roll,omitempty"`       // Attack or flee check
+	DamageRoll *dice.Result `json:"damageRoll,omitempty"` // Damage or healing dice
+	Damage     int          `json:"damage,omitempty"`
+	Healing    int          `json:"healing,omitempty"`
+	Defeated   bool         `json:"defeated,omitempty"` // The target dropped to 0 HP
+	Summary    string       `json:"summary"`            // Human-readable line, also recorded in session history
+}
+
+// PlayerActor identifies the player in log entries.
+const PlayerActor = "player"
+
+// Player combat stats, until characters have attributes. Gear adjusts damage and
+// armor class (see Rules).
+const (
+	PlayerArmorClass  = 12
+	PlayerDamage      = "1d6+1"
+	SneakAttackDamage = "3d6+1" // Damage of a sneak attack (see SneakAttack)
+	DefendBonus       = 4       // Added to armor class while defending (player) or guarding (enemy)
+	FleeDC            = 10
+	FleeDCPerEnemy    = 2 // Added to FleeDC for each living enemy after the first
+)
+
+// PlayerAttackBonus is the player's attack roll bonus at their level, including
+// modifiers from perks and status effects (see effects.RollBonus).
+func PlayerAttackBonus(player *character.Character) int {
+	return 2 + player.Level/2 + player.Bonuses.Attack + effects.RollBonus(player)
+}
+
+// PlayerInitiative is the player's initiative bonus at their level, including
+// modifiers from perks and status effects.
+func PlayerInitiative(player *character.Character) int {
+	return player.Level/2 + player.Bonuses.Initiative + effects.RollBonus(player)
+}
+
+// Enemy stat defaults, and limits for LLM-supplied enemies.
+const (
+	defaultEnemyHP          = 8
+	defaultEnemyArmorClass  = 11
+	defaultEnemyAttackBonus = 2
+	defaultEnemyDamage      = "1d6"
+	maxEnemyHP              = 200
+	maxEnemies              = 6
+)
+
+var nonIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewEncounter starts a fight against enemies, filling in default stats, clamping
+// out-of-range ones and giving every enemy a unique ID.
+func NewEncounter(enemies []Combatant) (*Encounter, error) {
+	if len(enemies) == 0 {
+		return nil, fmt.Errorf("an encounter needs at least one enemy")
+	}
+	if len(enemies) > maxEnemies {
+		return nil, fmt.Errorf("an encounter may have at most %d enemies, got %d", maxEnemies, len(enemies))
+	}
+
+	enc := &Encounter{}
+	seen := make(map[string]int)
+	for _, e := range enemies {
+		enemy := e
+		if strings.TrimSpace(enemy.Name) == "" {
+			return nil, fmt.Errorf("every enemy needs a name")
+		}
+		if enemy.Damage == "" {
+			enemy.Damage = defaultEnemyDamage
+		}
+		if _, err := dice.Parse(enemy.Damage); err != nil {
+			return nil, fmt.Errorf("enemy '%s' damage: %w", enemy.Name, err)
+		}
+		if enemy.MaxHP <= 0 {
+			enemy.MaxHP = enemy.HP
+		}
+		if enemy.MaxHP <= 0 {
+			enemy.MaxHP = defaultEnemyHP
+		}
+		enemy.MaxHP = min(enemy.MaxHP, maxEnemyHP)
+		enemy.HP = enemy.MaxHP
+		if enemy.ArmorClass <= 0 {
+			enemy.ArmorClass = defaultEnemyArmorClass
+		}
+		enemy.ArmorClass = min(enemy.ArmorClass, 25)
+		if enemy.AttackBonus == 0 {
+			enemy.AttackBonus = defaultEnemyAttackBonus
+		}
+		enemy.AttackBonus = max(min(enemy.AttackBonus, 15), -5)
+		enemy.Initiative = max(min(enemy.Initiative, 10), -5)
+
+		base := enemy.ID
+		if base == "" {
+			base = strings.Trim(nonIDChars.ReplaceAllString(strings.ToLower(enemy.Name), "_"), "_")
+		}
+		seen[base]++
+		enemy.ID = fmt.Sprintf("%s_%d", base, seen[base])
+		enc.Enemies = append(enc.Enemies, &enemy)
+	}
+	return enc, nil
+}
+
+// Rules resolves combat actions with its dice roller, for a player fighting with the
+// given gear.
+type Rules struct {
+	Roller *dice.Roller
+	Weapon string // Damage notation of the player's weapon ("" = PlayerDamage, unarmed)
+	Armor  int    // Armor class bonus from the player's armor
+}
+
+func (r *Rules) playerDamage() string {
+	if r.Weapon == "" {
+		return PlayerDamage
+	}
+	return r.Weapon
+}
+
+func (r *Rules) playerArmorClass(player *character.Character) int {
+	return PlayerArmorClass + r.Armor + player.Bonuses.Armor
+}
+
+// RollInitiative rolls d20 + initiative for the player and every enemy and sets the
+// encounter's turn order, highest first; the player wins ties. It returns a log entry
+// announcing the order.
+func (r *Rules) RollInitiative(enc *Encounter, player *character.Character) LogEntry {
+	roll := func(id, name string, bonus int) InitiativeRoll {
+		result := r.Roller.Roll(dice.Expression{Count: 1, Sides: 20, Modifier: bonus}, dice.Normal)
+		return InitiativeRoll{ID: id, Name: name, Total: result.Total}
+	}
+	order := []InitiativeRoll{roll(PlayerActor, player.Name, PlayerInitiative(player))}
+	for _, enemy := range enc.Enemies {
+		order = append(order, roll(enemy.ID, enemy.Name, enemy.Initiative))
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Total > order[j].Total })
+	enc.Order = order
+
+	parts := make([]string, 0, len(order))
+	for _, slot := range order {
+		parts = append(parts, fmt.Sprintf("%s %d", slot.Name, slot.Total))
+	}
+	return LogEntry{Actor: PlayerActor, Action: "initiative", Summary: fmt.Sprintf("Initiative: %s.", strings.Join(parts, ", "))}
+}
+
+// Attack has the player attack an enemy (targetID, or the first one standing), then
+// the enemies take their turn.
+func (r *Rules) Attack(enc *Encounter, player *character.Character, targetID string) ([]LogEntry, error) {
+	return r.playerAttack(enc, player, targetID, "", dice.Normal, r.playerDamage(), false)
+}
+
+// SneakAttack has a hidden player strike an enemy unawares: the attack roll has
+// advantage and deals SneakAttackDamage, and the surprised enemies ahead of the player
+// in the turn order lose their turn. Then the rest take theirs. Whether the player is
+// hidden is up to the caller.
+func (r *Rules) SneakAttack(enc *Encounter, player *character.Character, targetID string) ([]LogEntry, error) {
+	return r.playerAttack(enc, player, targetID, "Sneak Attack", dice.Advantage, SneakAttackDamage, true)
+}
+
+func (r *Rules) playerAttack(enc *Encounter, player *character.Character, targetID, move string, mode dice.Mode, damage string, surprise bool) ([]LogEntry, error) {
+	if _, err := enc.Target(targetID); err != nil {
+		return nil, err
+	}
+	var round int
+	var entries []LogEntry
+	if surprise {
+		enc.Round++
+		round = enc.Round
+	} else if round, entries = r.beginRound(enc, player); enc.Over() {
+		return entries, nil
+	}
+	target, err := enc.Target(targetID)
+	if err != nil {
+		target, _ = enc.Target("") // The target fled before the player's turn
+	}
+	entry := r.attack(round, PlayerActor, player.Name, move, PlayerAttackBonus(player), mode, damage, target.ID, target.Name, target.CurrentArmorClass())
+	if entry.Damage > 0 {
+		target.HP = max(target.HP-entry.Damage, 0)
+		entry.Defeated = !target.Alive()
+		if entry.Defeated {
+			entry.Summary += fmt.Sprintf(" %s is defeated.", target.Name)
+		}
+	}
+	return r.endRound(enc, player, append(entries, entry)), nil
+}
+
+// Defend has the player guard for the round (+DefendBonus armor class), then the
+// enemies take their turn.
+func (r *Rules) Defend(enc *Encounter, player *character.Character) []LogEntry {
+	round, entries := r.beginRound(enc, player)
+	if enc.Over() {
+		return entries
+	}
+	enc.Defending = true
+	entry := LogEntry{Round: round, Actor: PlayerActor, Action: "defend",
+		Summary: fmt.Sprintf("%s takes a defensive stance (armor class %d until their next turn).", player.Name, r.playerArmorClass(player)+DefendBonus)}
+	return r.endRound(enc, player, append(entries, entry))
+}
+
+// Flee has the player try to escape: a d20 check against FleeDC, harder with more
+// enemies standing. On failure the enemies take their turn.
+func (r *Rules) Flee(enc *Encounter, player *character.Character) []LogEntry {
+	round, entries := r.beginRound(enc, player)
+	if enc.Over() {
+		return entries
+	}
+	dc := FleeDC + FleeDCPerEnemy*max(len(enc.Living())-1, 0)
+	expr := dice.Expression{Count: 1, Sides: 20, Modifier: PlayerAttackBonus(player)}
+	roll := r.Roller.Check(expr, dice.Normal, dc, "Flee")
+	entry := LogEntry{Round: round, Actor: PlayerActor, Action: "flee", Roll: &roll}
+	if *roll.Success {
+		enc.Outcome = OutcomeFled
+		entry.Summary = fmt.Sprintf("%s flees (rolled %d vs DC %d) and escapes.", player.Name, roll.Total, dc)
+		return append(entries, entry)
+	}
+	entry.Summary = fmt.Sprintf("%s tries to flee (rolled %d vs DC %d) but can't break away.", player.Name, roll.Total, dc)
+	return r.endRound(enc, player, append(entries, entry))
+}
+
+// UseItem has the player use a consumable: healing items restore the player's HP,
+// damaging items hit an enemy (targetID, or the first one standing) without an attack
+// roll. The caller removes the item from the inventory. Then the enemies take their turn.
+func (r *Rules) UseItem(enc *Encounter, player *character.Character, item *world.Item, targetID string) ([]LogEntry, error) {
+	if item.Damage != "" {
+		if _, err := enc.Target(targetID); err != nil {
+			return nil, err
+		}
+	}
+	round, entries := r.beginRound(enc, player)
+	if enc.Over() {
+		return entries, nil
+	}
+	var target *Combatant
+	if item.Damage != "" {
+		var err error
+		if target, err = enc.Target(targetID); err != nil {
+			target, _ = enc.Target("") // The target fled before the player's turn
+		}
+	}
+	entry := r.ApplyItem(player, item, target)
+	entry.Round = round
+	return r.endRound(enc, player, append(entries, entry)), nil
+}
+
+// ApplyItem resolves an item's effects on the player (healing) and target (damage;
+// may be nil for items that don't deal damage). Outside combat there is no round.
+func (r *Rules) ApplyItem(player *character.Character, item *world.Item, target *Combatant) LogEntry {
+	entry := LogEntry{Actor: PlayerActor, Action: "useItem"}
+	var parts []string
+	if item.Heal != "" {
+		expr, _ := dice.Parse(item.Heal) // Validated when items are loaded
+		roll := r.Roller.Roll(expr, dice.Normal)
+		roll.Label = item.Name
+		entry.DamageRoll = &roll
+		entry.Healing = player.Heal(roll.Total)
+		entry.Target = PlayerActor
+		parts = append(parts, fmt.Sprintf("%s uses %s and recovers %d HP (%d/%d).", player.Name, item.Name, entry.Healing, player.HP, player.MaxHP))
+	}
+	if item.Damage != "" && target != nil {
+		expr, _ := dice.Parse(item.Damage)
+		roll := r.Roller.Roll(expr, dice.Normal)
+		roll.Label = item.Name
+		entry.DamageRoll = &roll
+		entry.Target = target.ID
+		entry.Damage = min(max(roll.Total, 0), target.HP)
+		target.HP -= entry.Damage
+		entry.Defeated = !target.Alive()
+		summary := fmt.Sprintf("%s uses %s on %s for %d damage.", player.Name, item.Name, target.Name, entry.Damage)
+		if entry.Defeated {
+			summary += fmt.Sprintf(" %s is defeated.", target.Name)
+		}
+		parts = append(parts, summary)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%s uses %s.", player.Name, item.Name))
+	}
+	entry.Summary = strings.Join(parts, " ")
+	return entry
+}
+
+// beginRound starts the next round and lets the enemies ahead of the player in the
+// turn order act. The player's defensive stance lasts until their turn, so it ends
+// here. Callers check enc.Over() before resolving the player's move.
+func (r *Rules) beginRound(enc *Encounter, player *character.Character) (int, []LogEntry) {
+	enc.Round++
+	before, _ := enc.turnOrder()
+	entries := r.enemyTurns(enc, player, before, nil)
+	enc.Defending = false
+	return enc.Round, entries
+}
+
+// endRound lets the enemies after the player in the turn order act, then settles the outcome.
+func (r *Rules) endRound(enc *Encounter, player *character.Character, entries []LogEntry) []LogEntry {
+	if !enc.Over() {
+		_, after := enc.turnOrder()
+		entries = r.enemyTurns(enc, player, after, entries)
+	}
+	if !enc.Over() && len(enc.Living()) == 0 {
+		enc.Outcome = OutcomeVictory
+	}
+	return entries
+}
+
+// enemyTurns resolves each enemy's turn in order, stopping if the fight ends.
+func (r *Rules) enemyTurns(enc *Encounter, player *character.Character, enemies []*Combatant, entries []LogEntry) []LogEntry {
+	for _, enemy := range enemies {
+		if !enemy.Alive() {
+			continue
+		}
+		entries = append(entries, r.enemyTurn(enc, player, enemy))
+		if player.HP == 0 {
+			enc.Outcome = OutcomeDefeat
+			return entries
+		}
+		if len(enc.Living()) == 0 {
+			enc.Outcome = OutcomeVictory
+			return entries
+		}
+	}
+	return entries
+}
+
+// enemyTurn resolves one enemy's move as its behavior dictates: cowardly enemies flee
+// once down to a third of their HP, defensive ones below half HP guard half the time,
+// and otherwise they attack the player (see chooseMove).
+func (r *Rules) enemyTurn(enc *Encounter, player *character.Character, enemy *Combatant) LogEntry {
+	enemy.Guarding = false
+	switch enemy.Behavior {
+	case world.BehaviorCowardly:
+		if enemy.HP*3 <= enemy.MaxHP {
+			enemy.Fled = true
+			return LogEntry{Round: enc.Round, Actor: enemy.ID, Action: "flee", Summary: fmt.Sprintf("%s breaks and flees the fight.", enemy.Name)}
+		}
+	case world.BehaviorDefensive:
+		if enemy.HP*2 <= enemy.MaxHP && r.Roller.Roll(dice.Expression{Count: 1, Sides: 100}, dice.Normal).Total <= 50 {
+			enemy.Guarding = true
+			return LogEntry{Round: enc.Round, Actor: enemy.ID, Action: "defend",
+				Summary: fmt.Sprintf("%s guards itself (armor class %d until its next turn).", enemy.Name, enemy.CurrentArmorClass())}
+		}
+	}
+
+	playerAC := r.playerArmorClass(player)
+	if enc.Defending {
+		playerAC += DefendBonus
+	}
+	move, bonus, damage := r.chooseMove(enemy)
+	entry := r.attack(enc.Round, enemy.ID, enemy.Name, move, bonus, dice.Normal, damage, PlayerActor, player.Name, playerAC)
+	if entry.Damage > 0 {
+		player.TakeDamage(entry.Damage)
+		if player.HP == 0 {
+			entry.Defeated = true
+			entry.Summary += fmt.Sprintf(" %s falls.", player.Name)
+		}
+	}
+	return entry
+}
+
+// chooseMove picks an enemy's attack for the round: each ability in order gets a d100
+// roll against its chance, falling back to the basic attack. It returns the ability
+// name ("" for the basic attack), attack bonus and damage notation.
+func (r *Rules) chooseMove(enemy *Combatant) (string, int, string) {
+	for _, ability := range enemy.Abilities {
+		chance := ability.Chance
+		if chance == 0 {
+			chance = world.DefaultAbilityChance
+		}
+		if r.Roller.Roll(dice.Expression{Count: 1, Sides: 100}, dice.Normal).Total <= chance {
+			return ability.Name, enemy.AttackBonus + ability.AttackBonus, ability.Damage
+		}
+	}
+	return "", enemy.AttackBonus, enemy.Damage
+}
+
+// attack rolls a d20 attack (in mode) against armorClass and, on a hit, the damage. A
+// natural 20 always hits and doubles the damage dice; a natural 1 always misses. move
+// names the ability used, if any. The caller applies the damage.
+func (r *Rules) attack(round int, actorID, actorName, move string, bonus int, mode dice.Mode, damage string, targetID, targetName string, armorClass int) LogEntry {
+	expr := dice.Expression{Count: 1, Sides: 20, Modifier: bonus}
+	roll := r.Roller.Check(expr, mode, armorClass, fmt.Sprintf("%s attacks %s", actorName, targetName))
+	natural := roll.Dice[0]
+	critical := natural == 20
+	hit := critical || (natural != 1 && *roll.Success)
+	roll.Success = &hit
+
+	entry := LogEntry{Round: round, Actor: actorID, Action: "attack", Ability: move, Target: targetID, Roll: &roll}
+	if move != "" {
+		roll.Label = fmt.Sprintf("%s uses %s on %s", actorName, move, targetName)
+		actorName = fmt.Sprintf("%s (%s)", actorName, move)
+	}
+	if !hit {
+		entry.Summary = fmt.Sprintf("%s attacks %s (rolled %d vs AC %d) and misses.", actorName, targetName, roll.Total, armorClass)
+		return entry
+	}
+
+	damageExpr, _ := dice.Parse(damage) // Validated when the encounter or stats were set up
+	if critical {
+		damageExpr.Count *= 2
+	}
+	damageRoll := r.Roller.Roll(damageExpr, dice.Normal)
+	damageRoll.Label = "Damage"
+	entry.DamageRoll = &damageRoll
+	entry.Damage = max(damageRoll.Total, 1)
+	verb := "hits"
+	if critical {
+		verb = "critically hits"
+	}
+	entry.Summary = fmt.Sprintf("%s %s %s (rolled %d vs AC %d) for %d damage.", actorName, verb, targetName, roll.Total, armorClass, entry.Damage)
+	return entry
+}
@@ -0,0 +1,154 @@
+package dice
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mode selects normal, advantage (roll twice, keep higher) or disadvantage (keep lower).
+type Mode string
+
+const (
+	Normal       Mode = "normal"
+	Advantage    Mode = "advantage"
+	Disadvantage Mode = "disadvantage"
+)
+
+// Expression is a parsed dice notation such as "2d6+3".
+type Expression struct {
+	Count    int `json:"count"`
+	Sides    int `json:"sides"`
+	Modifier int `json:"modifier"`
+}
+
+// Limits keep LLM-supplied notation from requesting absurd rolls.
+const (
+	maxDice  = 100
+	maxSides = 1000
+)
+
+var notationPattern = regexp.MustCompile(`^(\d*)d(\d+)\s*(?:([+-])\s*(\d+))?$`)
+
+// Parse reads XdY+Z notation. X defaults to 1 ("d20"), and the modifier may be negative.
+func Parse(notation string) (Expression, error) {
+	m := notationPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(notation)))
+	if m == nil {
+		return Expression{}, fmt.Errorf("invalid dice notation '%s' (expected XdY+Z)", notation)
+	}
+
+	expr := Expression{Count: 1}
+	if m[1] != "" {
+		expr.Count, _ = strconv.Atoi(m[1])
+	}
+	expr.Sides, _ = strconv.Atoi(m[2])
+	if m[4] != "" {
+		expr.Modifier, _ = strconv.Atoi(m[4])
+		if m[3] == "-" {
+			expr.Modifier = -expr.Modifier
+		}
+	}
+
+	if expr.Count < 1 || expr.Count > maxDice {
+		return Expression{}, fmt.Errorf("dice count in '%s' must be between 1 and %d", notation, maxDice)
+	}
+	if expr.Sides < 2 || expr.Sides > maxSides {
+		return Expression{}, fmt.Errorf("dice sides in '%s' must be between 2 and %d", notation, maxSides)
+	}
+	return expr, nil
+}
+
+// String formats the expression back into notation.
+func (e Expression) String() string {
+	switch {
+	case e.Modifier > 0:
+		return fmt.Sprintf("%dd%d+%d", e.Count, e.Sides, e.Modifier)
+	case e.Modifier < 0:
+		return fmt.Sprintf("%dd%d%d", e.Count, e.Sides, e.Modifier)
+	default:
+		return fmt.Sprintf("%dd%d", e.Count, e.Sides)
+	}
+}
+
+// Result is a complete roll breakdown, suitable for frontends to animate.
+type Result struct {
+	Notation  string `json:"notation"`
+	Mode      Mode   `json:"mode"`
+	Label     string `json:"label,omitempty"`     // What the roll was for, e.g. "Climb the wall"
+	Dice      []int  `json:"dice"`                // Individual dice that were kept
+	Discarded []int  `json:"discarded,omitempty"` // The other set under advantage/disadvantage
+	Modifier  int    `json:"modifier"`
+	Total     int    `json:"total"`
+	Target    int    `json:"target,omitempty"`  // Difficulty class for checks
+	Success   *bool  `json:"success,omitempty"` // Set for checks only
+}
+
+// Roller rolls dice from its own random source. Safe for concurrent use.
+type Roller struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRoller creates a roller from the given source; nil uses a randomly seeded source.
+func NewRoller(src rand.Source) *Roller {
+	if src == nil {
+		src = rand.NewPCG(rand.Uint64(), rand.Uint64())
+	}
+	return &Roller{rng: rand.New(src)}
+}
+
+// Roll rolls the expression in the given mode.
+func (r *Roller) Roll(expr Expression, mode Mode) Result {
+	if mode == "" {
+		mode = Normal
+	}
+	first := r.rollSet(expr)
+	result := Result{Notation: expr.String(), Mode: mode, Dice: first, Modifier: expr.Modifier}
+
+	if mode == Advantage || mode == Disadvantage {
+		second := r.rollSet(expr)
+		keepSecond := sum(second) > sum(first)
+		if mode == Disadvantage {
+			keepSecond = sum(second) < sum(first)
+		}
+		if keepSecond {
+			result.Dice, result.Discarded = second, first
+		} else {
+			result.Discarded = second
+		}
+	}
+
+	result.Total = sum(result.Dice) + expr.Modifier
+	return result
+}
+
+// Check rolls against a difficulty class; the check succeeds if Total >= dc.
+func (r *Roller) Check(expr Expression, mode Mode, dc int, label string) Result {
+	result := r.Roll(expr, mode)
+	success := result.Total >= dc
+	result.Target = dc
+	result.Success = &success
+	result.Label = label
+	return result
+}
+
+func (r *Roller) rollSet(expr Expression) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rolls := make([]int, expr.Count)
+	for i := range rolls {
+		rolls[i] = r.rng.IntN(expr.Sides) + 1
+	}
+	return rolls
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,28 @@
+package dice
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// Benchmarks of the dice work a turn's checks do: parsing the model's notation and
+// rolling it.
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("2d6+3"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCheck(b *testing.B) {
+	roller := NewRoller(rand.NewPCG(1, 2))
+	expr := Expression{Count: 1, Sides: 20, Modifier: 2}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roller.Check(expr, Advantage, 12, "bench")
+	}
+}
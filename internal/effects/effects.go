@@ -0,0 +1,106 @@
+// Package effects gives status effects their mechanical consequences: damage over
+// time, travel restrictions and roll modifiers. Effects not listed in Rules only
+// inform the narration.
+package effects
+
+import (
+	"fmt"
+	"llmrpg/internal/character"
+	"slices"
+)
+
+// Effect IDs with built-in rules.
+const (
+	Poisoned  = "poisoned"
+	Exhausted = "exhausted"
+	Blessed   = "blessed"
+)
+
+// Rule is what an effect does while active.
+type Rule struct {
+	Description   string // Default description when the narrator gives none
+	DamagePerTurn int    // Hit points lost at the start of each turn
+	BlocksTravel  bool   // The player can't move to another location
+	RollBonus     int    // Added to the player's attack rolls and checks
+	ClearedByRest bool   // A full, uninterrupted rest removes the effect
+}
+
+// Rules maps effect IDs to their mechanics.
+var Rules = map[string]Rule{
+	Poisoned:  {Description: "Poison burns in the veins, costing 2 HP each turn.", DamagePerTurn: 2},
+	Exhausted: {Description: "Too exhausted to travel until rested.", BlocksTravel: true, ClearedByRest: true},
+	Blessed:   {Description: "Divine favour grants +2 to attack rolls and checks.", RollBonus: 2},
+}
+
+// RollBonus is the total roll modifier from the character's active effects.
+func RollBonus(c *character.Character) int {
+	bonus := 0
+	for _, e := range c.Effects {
+		bonus += Rules[e.ID].RollBonus
+	}
+	return bonus
+}
+
+// TravelBlocker returns the ID of an active effect that prevents travel, or "".
+func TravelBlocker(c *character.Character) string {
+	for _, e := range c.Effects {
+		if Rules[e.ID].BlocksTravel {
+			return e.ID
+		}
+	}
+	return ""
+}
+
+// ClearRested removes effects a full rest cures and returns their IDs.
+func ClearRested(c *character.Character) []string {
+	var cleared []string
+	for _, e := range slices.Clone(c.Effects) {
+		if Rules[e.ID].ClearedByRest && c.RemoveEffect(e.ID) {
+			cleared = append(cleared, e.ID)
+		}
+	}
+	return cleared
+}
+
+// Resolution is what one effect did during Resolve.
+type Resolution struct {
+	EffectID string
+	Damage   int  // Hit points lost this turn
+	Expired  bool // The effect ran out and was removed
+	Summary  string
+}
+
+// Resolve runs the start-of-turn step for the character's effects: damage over time
+// is applied, then timed effects count down and expire.
+func Resolve(c *character.Character) []Resolution {
+	var results []Resolution
+	kept := c.Effects[:0]
+	for _, e := range c.Effects {
+		res := Resolution{EffectID: e.ID}
+		if dmg := Rules[e.ID].DamagePerTurn; dmg > 0 {
+			res.Damage = c.TakeDamage(dmg)
+			res.Summary = fmt.Sprintf("%s loses %d HP to being %s (HP %d/%d).", c.Name, res.Damage, e.ID, c.HP, c.MaxHP)
+		}
+		if e.Remaining > 0 {
+			e.Remaining--
+			res.Expired = e.Remaining == 0
+		}
+		if res.Expired {
+			res.Summary = joinSummary(res.Summary, fmt.Sprintf("%s is no longer %s.", c.Name, e.ID))
+		} else {
+			kept = append(kept, e)
+		}
+		if res.Summary != "" {
+			results = append(results, res)
+		}
+	}
+	c.Effects = kept
+	return results
+}
+
+func joinSummary(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
@@ -0,0 +1,58 @@
+package events
+
+import "sync"
+
+// TurnReady is published on the Bus when a background (async) turn finishes; it is not
+// a turn event. Data: turnId, status ("completed" or "failed"), seconds (time from
+// submission), error (when failed).
+const TurnReady Type = "turnReady"
+
+// NewTurnReady describes an async turn finishing with status, seconds after it was
+// submitted; errMsg is set when it failed.
+func NewTurnReady(turn int, turnID, status string, seconds float64, errMsg string) Event {
+	data := map[string]interface{}{"turnId": turnID, "status": status, "seconds": seconds}
+	if errMsg != "" {
+		data["error"] = errMsg
+	}
+	return Event{Type: TurnReady, Turn: turn, Data: data}
+}
+
+// Published is an event on the Bus, with the session and player it concerns.
+type Published struct {
+	SessionID string
+	PlayerID  string // Empty for sessions created without a player ID
+	Event     Event
+}
+
+// Bus fans events out to in-process subscribers: the engine publishes every turn's
+// events, the async turn tracker publishes TurnReady. A nil *Bus drops everything.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Published)
+}
+
+// NewBus creates a bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn to receive every event published from now on. fn runs on the
+// publisher's goroutine, so it must return quickly (hand slow work to a goroutine).
+func (b *Bus) Subscribe(fn func(Published)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish delivers p to every subscriber.
+func (b *Bus) Publish(p Published) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(p)
+	}
+}
@@ -0,0 +1,219 @@
+// Package events defines typed client events describing state changes in a turn,
+// so frontends can animate what happened without diffing the whole session state.
+package events
+
+// Type identifies what kind of state change an Event describes.
+type Type string
+
+const (
+	LocationChanged   Type = "locationChanged"   // Data: fromId, toId, toName
+	ItemGained        Type = "itemGained"        // Data: itemId, count
+	ItemLost          Type = "itemLost"          // Data: itemId, count
+	ItemWorn          Type = "itemWorn"          // Data: itemId, durability, maxDurability (0 durability = broke)
+	AbilityUsed       Type = "abilityUsed"       // Data: abilityId, mp, hp (costs paid), cooldown (turns)
+	AbilityLearned    Type = "abilityLearned"    // Data: abilityId
+	XPGained          Type = "xpGained"          // Data: amount, total, reason
+	LevelUp           Type = "levelUp"           // Data: level, perkChoices (perk IDs to pick from; empty = none left)
+	PerkChosen        Type = "perkChosen"        // Data: perkId
+	KarmaChanged      Type = "karmaChanged"      // Data: amount, total, reason
+	QuestUpdated      Type = "questUpdated"      // Data: questId, status, description
+	EffectApplied     Type = "effectApplied"     // Data: effectId, duration, description
+	EffectTicked      Type = "effectTicked"      // Data: effectId, damage
+	EffectExpired     Type = "effectExpired"     // Data: effectId
+	SessionEnded      Type = "sessionEnded"      // Data: endingId, name
+	NPCMet            Type = "npcMet"            // Data: npcId, name
+	CombatStarted     Type = "combatStarted"     // Data: enemies (names)
+	CombatEnded       Type = "combatEnded"       // Data: outcome ("victory", "fled" or "defeat")
+	LootDropped       Type = "lootDropped"       // Data: source, sourceId, items ([{itemId, count}])
+	Rested            Type = "rested"            // Data: hours, healed, interrupted
+	StealthChanged    Type = "stealthChanged"    // Data: from, to ("" = not sneaking, "hidden", "suspected", "detected")
+	MountChanged      Type = "mountChanged"      // Data: from, to (item IDs; "" = on foot)
+	ChallengeStarted  Type = "challengeStarted"  // Data: kind, label, dc (the frontend may now play the minigame)
+	ChallengeResolved Type = "challengeResolved" // Data: kind, label, dc, success, source ("roll" or "minigame")
+	Interacted        Type = "interacted"        // Data: locationId, interactionId, name, event (the author's event name; may be empty), openedExits
+	WorldEventStarted Type = "worldEventStarted" // Data: eventId, name, locations (empty = everywhere)
+	WorldEventEnded   Type = "worldEventEnded"   // Data: eventId, name
+)
+
+// Event is a single client-facing state change. The keys in Data depend on Type.
+type Event struct {
+	Type Type                   `json:"type"`
+	Turn int                    `json:"turn"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// NewLocationChanged describes the player moving between locations.
+func NewLocationChanged(turn int, fromID, toID, toName string) Event {
+	return Event{Type: LocationChanged, Turn: turn, Data: map[string]interface{}{
+		"fromId": fromID,
+		"toId":   toID,
+		"toName": toName,
+	}}
+}
+
+// NewItemGained describes items added to the player's inventory.
+func NewItemGained(turn int, itemID string, count int) Event {
+	return Event{Type: ItemGained, Turn: turn, Data: map[string]interface{}{"itemId": itemID, "count": count}}
+}
+
+// NewItemLost describes items removed from the player's inventory.
+func NewItemLost(turn int, itemID string, count int) Event {
+	return Event{Type: ItemLost, Turn: turn, Data: map[string]interface{}{"itemId": itemID, "count": count}}
+}
+
+// NewItemWorn describes an item's durability changing through wear or repair. An item
+// whose durability reaches 0 breaks (and is reported lost).
+func NewItemWorn(turn int, itemID string, durability, maxDurability int) Event {
+	return Event{Type: ItemWorn, Turn: turn, Data: map[string]interface{}{"itemId": itemID, "durability": durability, "maxDurability": maxDurability}}
+}
+
+// NewAbilityUsed describes the player using an ability, with the costs paid and its cooldown.
+func NewAbilityUsed(turn int, abilityID string, mp, hp, cooldown int) Event {
+	return Event{Type: AbilityUsed, Turn: turn, Data: map[string]interface{}{"abilityId": abilityID, "mp": mp, "hp": hp, "cooldown": cooldown}}
+}
+
+// NewAbilityLearned describes the player learning an ability.
+func NewAbilityLearned(turn int, abilityID string) Event {
+	return Event{Type: AbilityLearned, Turn: turn, Data: map[string]interface{}{"abilityId": abilityID}}
+}
+
+// NewXPGained describes the player earning experience.
+func NewXPGained(turn, amount, total int, reason string) Event {
+	return Event{Type: XPGained, Turn: turn, Data: map[string]interface{}{"amount": amount, "total": total, "reason": reason}}
+}
+
+// NewLevelUp describes the player reaching a new level, with the perks they may choose
+// from (see /session/{id}/perks).
+func NewLevelUp(turn, level int, perkChoices []string) Event {
+	if perkChoices == nil {
+		perkChoices = []string{}
+	}
+	return Event{Type: LevelUp, Turn: turn, Data: map[string]interface{}{"level": level, "perkChoices": perkChoices}}
+}
+
+// NewPerkChosen describes the player picking a perk for a level-up.
+func NewPerkChosen(turn int, perkID string) Event {
+	return Event{Type: PerkChosen, Turn: turn, Data: map[string]interface{}{"perkId": perkID}}
+}
+
+// NewKarmaChanged describes the player's karma shifting by amount to total.
+func NewKarmaChanged(turn, amount, total int, reason string) Event {
+	return Event{Type: KarmaChanged, Turn: turn, Data: map[string]interface{}{"amount": amount, "total": total, "reason": reason}}
+}
+
+// NewQuestUpdated describes a quest starting, advancing or finishing.
+func NewQuestUpdated(turn int, questID, status, description string) Event {
+	return Event{Type: QuestUpdated, Turn: turn, Data: map[string]interface{}{
+		"questId":     questID,
+		"status":      status,
+		"description": description,
+	}}
+}
+
+// NewEffectApplied describes a status effect applied to the player (duration in turns, 0 = permanent).
+func NewEffectApplied(turn int, effectID string, duration int, description string) Event {
+	return Event{Type: EffectApplied, Turn: turn, Data: map[string]interface{}{
+		"effectId":    effectID,
+		"duration":    duration,
+		"description": description,
+	}}
+}
+
+// NewEffectTicked describes a status effect hurting the player at the start of a turn.
+func NewEffectTicked(turn int, effectID string, damage int) Event {
+	return Event{Type: EffectTicked, Turn: turn, Data: map[string]interface{}{"effectId": effectID, "damage": damage}}
+}
+
+// NewEffectExpired describes a timed status effect running out.
+func NewEffectExpired(turn int, effectID string) Event {
+	return Event{Type: EffectExpired, Turn: turn, Data: map[string]interface{}{"effectId": effectID}}
+}
+
+// NewNPCMet describes the player meeting an NPC.
+func NewNPCMet(turn int, npcID, name string) Event {
+	return Event{Type: NPCMet, Turn: turn, Data: map[string]interface{}{"npcId": npcID, "name": name}}
+}
+
+// NewCombatStarted describes a fight breaking out against the named enemies.
+func NewCombatStarted(turn int, enemies []string) Event {
+	return Event{Type: CombatStarted, Turn: turn, Data: map[string]interface{}{"enemies": enemies}}
+}
+
+// NewCombatEnded describes a fight ending with outcome.
+func NewCombatEnded(turn int, outcome string) Event {
+	return Event{Type: CombatEnded, Turn: turn, Data: map[string]interface{}{"outcome": outcome}}
+}
+
+// NewLootDropped describes loot found on a defeated creature (source "creature") or by
+// searching a location (source "location"). items holds {itemId, count} objects; each
+// stack is also reported as an itemGained event.
+func NewLootDropped(turn int, source, sourceID string, items []map[string]interface{}) Event {
+	return Event{Type: LootDropped, Turn: turn, Data: map[string]interface{}{
+		"source":   source,
+		"sourceId": sourceID,
+		"items":    items,
+	}}
+}
+
+// NewRested describes the player resting for hours (in-story), healing healed HP.
+// interrupted is set when a random encounter cut the rest short.
+func NewRested(turn, hours, healed int, interrupted bool) Event {
+	return Event{Type: Rested, Turn: turn, Data: map[string]interface{}{
+		"hours":       hours,
+		"healed":      healed,
+		"interrupted": interrupted,
+	}}
+}
+
+// NewStealthChanged describes the player's stealth state changing.
+func NewStealthChanged(turn int, from, to string) Event {
+	return Event{Type: StealthChanged, Turn: turn, Data: map[string]interface{}{"from": from, "to": to}}
+}
+
+// NewMountChanged describes the player boarding or leaving a mount or vehicle.
+func NewMountChanged(turn int, from, to string) Event {
+	return Event{Type: MountChanged, Turn: turn, Data: map[string]interface{}{"from": from, "to": to}}
+}
+
+// NewChallengeStarted describes a challenge waiting for the frontend's minigame result.
+func NewChallengeStarted(turn int, kind, label string, dc int) Event {
+	return Event{Type: ChallengeStarted, Turn: turn, Data: map[string]interface{}{"kind": kind, "label": label, "dc": dc}}
+}
+
+// NewChallengeResolved describes a challenge's outcome; source says whether the server
+// rolled it or the frontend reported a minigame result.
+func NewChallengeResolved(turn int, kind, label string, dc int, success bool, source string) Event {
+	return Event{Type: ChallengeResolved, Turn: turn, Data: map[string]interface{}{
+		"kind":    kind,
+		"label":   label,
+		"dc":      dc,
+		"success": success,
+		"source":  source,
+	}}
+}
+
+// NewInteracted describes the player doing one of a location's interactions.
+func NewInteracted(turn int, locationID, interactionID, name, event string, openedExits []string) Event {
+	return Event{Type: Interacted, Turn: turn, Data: map[string]interface{}{
+		"locationId":    locationID,
+		"interactionId": interactionID,
+		"name":          name,
+		"event":         event,
+		"openedExits":   openedExits,
+	}}
+}
+
+// NewWorldEventStarted describes a world event beginning.
+func NewWorldEventStarted(turn int, eventID, name string, locations []string) Event {
+	return Event{Type: WorldEventStarted, Turn: turn, Data: map[string]interface{}{"eventId": eventID, "name": name, "locations": locations}}
+}
+
+// NewWorldEventEnded describes a world event coming to an end.
+func NewWorldEventEnded(turn int, eventID, name string) Event {
+	return Event{Type: WorldEventEnded, Turn: turn, Data: map[string]interface{}{"eventId": eventID, "name": name}}
+}
+
+// NewSessionEnded describes the story reaching one of the world's endings.
+func NewSessionEnded(turn int, endingID, name string) Event {
+	return Event{Type: SessionEnded, Turn: turn, Data: map[string]interface{}{"endingId": endingID, "name": name}}
+}
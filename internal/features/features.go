@@ -0,0 +1,114 @@
+// Package features gates experimental engine behaviors behind server-level flags,
+// so a deployment can turn them on or off through configuration alone.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Flag names an engine behavior that can be switched on or off.
+type Flag string
+
+const (
+	LoreContext      Flag = "lore_context"      // Include tagged and retrieved (RAG) lore in each prompt
+	MemoryRecall     Flag = "memory_recall"     // Recall relevant past events into each prompt
+	Renarration      Flag = "renarration"       // Ask the LLM to re-narrate turns whose actions were rejected
+	RoundNarration   Flag = "round_narration"   // Narrate each resolved combat round in one follow-up LLM call
+	FailureNarration Flag = "failure_narration" // Rewrite narration in-fiction when some of its actions failed
+)
+
+// Definition describes a known flag.
+type Definition struct {
+	Name        Flag   `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Definitions lists every known flag. Add new experimental behaviors here.
+var Definitions = []Definition{
+	{Name: LoreContext, Description: "Include lore documents whose tags the scene mentions, and retrieve relevant lore (RAG) when a lore index is configured, into each prompt", Default: true},
+	{Name: MemoryRecall, Description: "Recall relevant past events from session memory into each prompt", Default: true},
+	{Name: Renarration, Description: "Re-narrate a turn once when its actions are not allowed at the location or by the scenario", Default: true},
+	{Name: RoundNarration, Description: "After a combat round resolves, narrate all of its steps (in initiative order) in one follow-up LLM call", Default: true},
+	{Name: FailureNarration, Description: "When actions fail validation, rewrite the turn's narration in one follow-up LLM call so the failure happens in the story, instead of prefixing a system error", Default: true},
+}
+
+// Flags is the resolved flag configuration. It is read-only once parsed; a nil
+// *Flags reports every flag at its default.
+type Flags struct {
+	overrides map[Flag]bool
+}
+
+// Status is a flag's resolved state, as reported by /admin/flags.
+type Status struct {
+	Definition
+	Enabled    bool `json:"enabled"`
+	Overridden bool `json:"overridden"` // Set by configuration rather than the default
+}
+
+// Parse reads a comma-separated list of name=bool overrides (e.g.
+// "lore_context=false,renarration=true"). Unknown names and invalid values are
+// reported in the error; the valid overrides are still returned.
+func Parse(spec string) (*Flags, error) {
+	flags := &Flags{overrides: make(map[Flag]bool)}
+	var problems []error
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			problems = append(problems, fmt.Errorf("flag '%s' has no value (expected name=true or name=false)", entry))
+			continue
+		}
+		flag := Flag(strings.TrimSpace(name))
+		if _, known := lookup(flag); !known {
+			problems = append(problems, fmt.Errorf("unknown flag '%s'", flag))
+			continue
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid value '%s' for flag '%s'", value, flag))
+			continue
+		}
+		flags.overrides[flag] = enabled
+	}
+	return flags, errors.Join(problems...)
+}
+
+// Enabled reports whether flag is on.
+func (f *Flags) Enabled(flag Flag) bool {
+	if f != nil {
+		if enabled, ok := f.overrides[flag]; ok {
+			return enabled
+		}
+	}
+	def, _ := lookup(flag)
+	return def.Default
+}
+
+// List returns the state of every known flag.
+func (f *Flags) List() []Status {
+	statuses := make([]Status, 0, len(Definitions))
+	for _, def := range Definitions {
+		status := Status{Definition: def, Enabled: f.Enabled(def.Name)}
+		if f != nil {
+			_, status.Overridden = f.overrides[def.Name]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func lookup(flag Flag) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Name == flag {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
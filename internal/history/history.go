@@ -0,0 +1,73 @@
+package history
+
+import (
+	"fmt"
+	"time"
+)
+
+// Actor identifies who produced a history entry.
+type Actor string
+
+const (
+	ActorPlayer   Actor = "player"
+	ActorNarrator Actor = "narrator"
+	ActorSystem   Actor = "system"
+)
+
+// RecordType classifies what happened in a history entry.
+type RecordType string
+
+const (
+	TypeInput     RecordType = "input"     // Player input for a turn
+	TypeNarration RecordType = "narration" // Narrative text returned by the LLM
+	TypeAction    RecordType = "action"    // A system action executed by the engine
+)
+
+// TurnRecord is one structured entry in a session's history. The same type backs
+// the prompt's recent-events context, long-term memory and the history API.
+type TurnRecord struct {
+	Turn      int        `json:"turn"`
+	Actor     Actor      `json:"actor"`
+	Type      RecordType `json:"type"`
+	Summary   string     `json:"summary"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// String renders the record for prompts, e.g. "Player: I open the door".
+func (r TurnRecord) String() string {
+	switch r.Actor {
+	case ActorPlayer:
+		return fmt.Sprintf("Player: %s", r.Summary)
+	case ActorNarrator:
+		return fmt.Sprintf("Narrator: %s", r.Summary)
+	default:
+		return fmt.Sprintf("System executed: %s", r.Summary)
+	}
+}
+
+// Policy controls how much history a session keeps and shows the LLM.
+type Policy struct {
+	RecentWindow     int  // Entries kept in the recent-events window sent with every prompt
+	MaxRetained      int  // Entries kept in long-term memory before the oldest are dropped
+	IncludeNarration bool // Whether narration entries appear in the recent window (they're long)
+}
+
+// DefaultPolicy mirrors the original behaviour: the last 5 non-narration events.
+func DefaultPolicy() Policy {
+	return Policy{
+		RecentWindow: 5,
+		MaxRetained:  1000,
+	}
+}
+
+// Normalized fills zero values with defaults.
+func (p Policy) Normalized() Policy {
+	defaults := DefaultPolicy()
+	if p.RecentWindow <= 0 {
+		p.RecentWindow = defaults.RecentWindow
+	}
+	if p.MaxRetained <= 0 {
+		p.MaxRetained = defaults.MaxRetained
+	}
+	return p
+}
@@ -0,0 +1,71 @@
+// Package ids generates the unique part of session, player and turn IDs: random
+// UUIDs normally, a counter in deterministic mode.
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+)
+
+// Generator hands out unique IDs.
+type Generator interface {
+	NewID() string
+}
+
+// UUID generates random (version 4) UUIDs.
+var UUID Generator = uuidGenerator{}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])         // crypto/rand.Read never returns an error on supported platforms
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return format(b)
+}
+
+// Default is the generator New draws from and the one components take when none is
+// injected. Replace it (with Set) before serving requests, not while they're running.
+var Default = UUID
+
+// Set replaces Default.
+func Set(g Generator) {
+	Default = g
+}
+
+// New returns an ID from Default.
+func New() string {
+	return Default.NewID()
+}
+
+// Sequence generates UUID-shaped IDs from a counter (…-000000000001, …-000000000002, …)
+// so runs that create things in the same order get the same IDs. Safe for concurrent use.
+type Sequence struct {
+	mu sync.Mutex
+	n  uint64
+}
+
+// NewSequence creates a sequence whose first ID ends in 1.
+func NewSequence() *Sequence {
+	return &Sequence{}
+}
+
+// NewID returns the next ID in the sequence.
+func (s *Sequence) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n++
+	var b [16]byte
+	for i := 15; i >= 8; i-- {
+		b[i] = byte(s.n >> (8 * (15 - i)))
+	}
+	b[6] = 0x40
+	b[8] |= 0x80
+	return format(b)
+}
+
+func format(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
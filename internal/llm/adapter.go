@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/rating"
 	"net/http"
 	"os"
-	"strings"
 	"time" // Added for http client timeout
 	// We don't strictly need world/character imports here,
 	// as PromptData uses simplified structures.
@@ -24,18 +28,51 @@ type LLMAction struct {
 
 // LLMResponse is the structure returned by our adapter to the narrative engine.
 type LLMResponse struct {
-	Narrative   string      `json:"narrative"`
-	Suggestions []string    `json:"suggestions,omitempty"`
-	Actions     []LLMAction `json:"actions,omitempty"`
+	Narrative    string            `json:"narrative"`
+	Suggestions  []string          `json:"suggestions,omitempty"`
+	Actions      []LLMAction       `json:"actions,omitempty"`
+	Rolls        []dice.Result     `json:"rolls,omitempty"`     // Filled by the engine from executed checks, never by the LLM
+	Events       []events.Event    `json:"events,omitempty"`    // Filled by the engine from executed actions, never by the LLM
+	CombatLog    []combat.LogEntry `json:"combatLog,omitempty"` // Combat steps resolved this turn, filled by the engine
+	Warnings     []string          `json:"warnings,omitempty"`  // Non-fatal problems during the turn (e.g. rejected actions), filled by the engine
+	Results      []ActionResult    `json:"results,omitempty"`   // What each executed or held action did, filled by the engine
+	Pending      []LLMAction       `json:"pending,omitempty"`   // Actions held for the player's confirmation (see /session/{id}/actions/confirm), filled by the engine
+	Triage       string            `json:"triage,omitempty"`    // Input kind, when triage answered the turn without the narrator; filled by the engine
+	Usage        *TokenUsage       `json:"-"`                   // Tokens consumed by the call, when the provider reports them
+	Model        string            `json:"-"`                   // Model that answered, as the provider reports it
+	FinishReason string            `json:"-"`                   // Why generation stopped (e.g. STOP, MAX_TOKENS), as the provider reports it
+	Debug        *TurnDebug        `json:"-"`                   // The turn's model calls, filled by the engine in debug mode
+}
+
+// TokenUsage is the token count a provider reported for one call.
+type TokenUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+	CachedTokens     int `json:"cachedTokens,omitempty"` // Prompt tokens served from the provider's prompt cache
 }
 
 // --- Prompt Data Structures ---
 // (Simplified context structs remain the same)
 type PlayerContextData struct {
-	Name   string `json:"name"`
-	Class  string `json:"class,omitempty"`
-	Origin string `json:"origin,omitempty"`
-	Level  int    `json:"level"`
+	Name        string   `json:"name"`
+	Class       string   `json:"class,omitempty"`
+	Origin      string   `json:"origin,omitempty"`
+	Level       int      `json:"level"`
+	HP          int      `json:"hp"`
+	MaxHP       int      `json:"maxHp"`
+	Inventory   []string `json:"inventory,omitempty"` // "Name (item_id) xN" entries
+	Effects     []string `json:"effects,omitempty"`   // "effect_id (N turns left): description" entries
+	Survival    []string `json:"survival,omitempty"`  // "Name level/max" for each survival resource
+	Stealth     string   `json:"stealth,omitempty"`   // Stealth state with what it allows; empty when not sneaking
+	Mount       string   `json:"mount,omitempty"`     // Mount or vehicle being ridden; empty on foot
+	MP          int      `json:"mp,omitempty"`        // Mana, shown with the abilities
+	MaxMP       int      `json:"maxMp,omitempty"`
+	Abilities   []string `json:"abilities,omitempty"` // "Name (ability_id) [cost, readiness]: description" for each known ability
+	XP          int      `json:"xp,omitempty"`
+	NextLevelXP int      `json:"nextLevelXp,omitempty"` // Total XP needed for the next level
+	Perks       []string `json:"perks,omitempty"`       // "Name: description" for each chosen perk
+	Karma       string   `json:"karma,omitempty"`       // Karma with its standing, e.g. "35 (respected)"
 }
 
 type LocationContextData struct {
@@ -44,11 +81,29 @@ type LocationContextData struct {
 	AdjacentLocationIDs   []string `json:"adjacentLocationIds"`
 	AdjacentLocationNames []string `json:"adjacentLocationNames"`
 	CurrentThemeID        string   `json:"currentThemeId,omitempty"`
+	AllowedActions        []string `json:"allowedActions,omitempty"` // Action types the engine will accept here
+	Containers            []string `json:"containers,omitempty"`     // Containers here with what they hold
+	Interactions          []string `json:"interactions,omitempty"`   // Things the player can do here (see world.Interaction)
+	People                []string `json:"people,omitempty"`         // NPCs here at this hour, with what they're doing
+	Away                  []string `json:"away,omitempty"`           // NPCs who are usually here but not at this hour
+	Events                []string `json:"events,omitempty"`         // World events under way here
 }
 
 type SessionContextData struct {
-	TimeElapsed   string   `json:"timeElapsed,omitempty"`
-	RecentActions []string `json:"recentActions,omitempty"`
+	TimeElapsed   string               `json:"timeElapsed,omitempty"`
+	GameTime      string               `json:"gameTime,omitempty"` // In-story clock, e.g. "Day 1, 08:00"
+	RecentActions []history.TurnRecord `json:"recentActions,omitempty"`
+	// Older events recalled from long-term memory as relevant to the current input
+	RelevantMemories []history.TurnRecord `json:"relevantMemories,omitempty"`
+	Entities         []string             `json:"entities,omitempty"` // "Name (kind): established details" for names invented earlier in the session
+	Rumors           []string             `json:"rumors,omitempty"`   // Word of the player's deeds that has reached this location, newest first
+}
+
+// CombatContextData describes a fight in progress.
+type CombatContextData struct {
+	Round     int      `json:"round"`
+	Enemies   []string `json:"enemies"`             // "Name (enemy_id, HP x/y)" for each enemy still standing, with its description
+	TurnOrder []string `json:"turnOrder,omitempty"` // Names in initiative order, the player included
 }
 
 type PromptData struct {
@@ -56,6 +111,13 @@ type PromptData struct {
 	LocationContext LocationContextData `json:"locationContext"`
 	SessionContext  SessionContextData  `json:"sessionContext,omitempty"`
 	PlayerInput     string              `json:"playerInput"`
+	Examples        []FewShotExample    `json:"examples,omitempty"`      // Few-shot exchanges prepended to the prompt
+	SystemNotes     []string            `json:"systemNotes,omitempty"`   // Engine feedback for this turn (e.g. rejected actions)
+	LoreContext     []string            `json:"loreContext,omitempty"`   // Lore snippets the scene mentions or retrieved as relevant to the input
+	ContentRating   rating.Rating       `json:"contentRating,omitempty"` // Session's content rating; empty = rating.Default
+	Combat          *CombatContextData  `json:"combat,omitempty"`        // Fight in progress, if any
+	Bestiary        []string            `json:"bestiary,omitempty"`      // "creature_id (Name)" for creatures startCombat may use
+	HistoryFormat   HistoryFormat       `json:"historyFormat,omitempty"` // How SessionContext.RecentActions are laid out (empty = HistoryInline)
 }
 
 // --- LLM Adapter Interface ---
@@ -71,16 +133,42 @@ type GeminiAdapter struct {
 	modelName   string
 	httpClient  *http.Client
 	apiEndpoint string
+	tokenSource TokenSource // When set (Vertex AI), use bearer auth instead of GEMINI_API_KEY
+	schema      *JSONSchema // Optional response schema, already in Gemini format
+	params      GenerationParams
+	flatPrompt  bool                // Send one user message instead of a conversation (see SetChatMessages)
+	cache       *geminiContextCache // Context caches for the prompt prefix; nil = caching off (see SetPromptCaching)
+}
+
+// SetChatMessages chooses between sending a conversation (system instruction, example
+// and history turns, then the context) and a single flattened user message, for
+// models without system instruction support.
+func (g *GeminiAdapter) SetChatMessages(enabled bool) {
+	g.flatPrompt = !enabled
+}
+
+// SetResponseSchema makes the adapter send a response schema with each request.
+func (g *GeminiAdapter) SetResponseSchema(schema *JSONSchema) {
+	g.schema = toGeminiSchema(schema)
+}
+
+// SetGenerationParams sets the sampling parameters sent with each request.
+func (g *GeminiAdapter) SetGenerationParams(params GenerationParams) {
+	g.params = params
 }
 
 // NewGeminiAdapter creates a new Gemini adapter instance using HTTP.
-func NewGeminiAdapter(modelName string) *GeminiAdapter {
+// Pass a shared client from NewHTTPClient to reuse pooled connections; nil uses a private default client.
+func NewGeminiAdapter(modelName string, httpClient *http.Client) *GeminiAdapter {
 	if modelName == "" {
 		modelName = "gemini-1.5-flash-latest" // Default model supporting JSON mode
 	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 90 * time.Second} // Increased timeout slightly
+	}
 	return &GeminiAdapter{
 		modelName:   modelName,
-		httpClient:  &http.Client{Timeout: 90 * time.Second}, // Increased timeout slightly
+		httpClient:  httpClient,
 		apiEndpoint: "https://generativelanguage.googleapis.com/v1beta/models",
 	}
 }
@@ -101,6 +189,24 @@ type geminiSafetySetting struct {
 	Threshold string `json:"threshold"`
 }
 
+// geminiHarmCategories are the categories the content rating applies to.
+var geminiHarmCategories = []string{
+	"HARM_CATEGORY_HARASSMENT",
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+}
+
+// geminiSafetySettings maps a content rating onto Gemini's safety thresholds.
+func geminiSafetySettings(r rating.Rating) []geminiSafetySetting {
+	threshold := r.Profile().SafetyThreshold
+	settings := make([]geminiSafetySetting, 0, len(geminiHarmCategories))
+	for _, category := range geminiHarmCategories {
+		settings = append(settings, geminiSafetySetting{Category: category, Threshold: threshold})
+	}
+	return settings
+}
+
 type geminiGenerationConfig struct {
 	Temperature     *float32 `json:"temperature,omitempty"`
 	TopP            *float32 `json:"topP,omitempty"`
@@ -109,15 +215,17 @@ type geminiGenerationConfig struct {
 	StopSequences   []string `json:"stopSequences,omitempty"`
 	// *** Add responseMimeType for JSON Mode ***
 	ResponseMimeType string `json:"responseMimeType,omitempty"`
-	// Optional: Define responseSchema for stricter control later
-	// ResponseSchema *geminiResponseSchema `json:"responseSchema,omitempty"`
+	// Constrains output to a schema derived from LLMResponse (see schema.go)
+	ResponseSchema *JSONSchema `json:"responseSchema,omitempty"`
 }
 
 // geminiRequest is the structure sent to the Gemini API generateContent endpoint
 type geminiRequest struct {
-	Contents         []geminiContent         `json:"contents"`
-	SafetySettings   []geminiSafetySetting   `json:"safetySettings,omitempty"`
-	GenerationConfig *geminiGenerationConfig `json:"generationConfig,omitempty"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	CachedContent     string                  `json:"cachedContent,omitempty"` // Context cache holding the system instruction and leading contents
+	Contents          []geminiContent         `json:"contents"`
+	SafetySettings    []geminiSafetySetting   `json:"safetySettings,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
 }
 
 // --- Gemini API Response Structures ---
@@ -145,12 +253,14 @@ type geminiResponse struct {
 	Candidates     []geminiCandidate     `json:"candidates"`
 	PromptFeedback *geminiPromptFeedback `json:"promptFeedback,omitempty"`
 	UsageMetadata  *geminiUsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string                `json:"modelVersion,omitempty"`
 }
 
 type geminiUsageMetadata struct {
-	PromptTokenCount     int `json:"promptTokenCount"`
-	CandidatesTokenCount int `json:"candidatesTokenCount"`
-	TotalTokenCount      int `json:"totalTokenCount"`
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
 }
 
 // --- Expected JSON structure within the LLM's text response ---
@@ -163,63 +273,96 @@ type expectedLLMJsonOutput struct {
 	// Add any other fields the LLM might generate
 }
 
+// PreviewPrompt returns the messages GenerateResponse would send, with Gemini's role
+// names. Consecutive messages from the same role are merged, since Gemini expects
+// user and model turns to alternate.
+func (g *GeminiAdapter) PreviewPrompt(systemPrompt string, promptData PromptData) []PromptMessage {
+	if g.flatPrompt {
+		return []PromptMessage{{Role: "user", Content: buildPrompt(systemPrompt, promptData)}}
+	}
+	var messages []PromptMessage
+	for _, m := range buildMessages(systemPrompt, promptData) {
+		if m.Role == "assistant" {
+			m.Role = "model"
+		}
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == m.Role {
+			messages[last].Content += "\n\n" + m.Content
+			messages[last].Cached = messages[last].Cached && m.Cached
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// geminiContents splits messages into Gemini's system instruction and contents.
+func geminiContents(messages []PromptMessage) (*geminiContent, []geminiContent) {
+	var systemInstruction *geminiContent
+	var contents []geminiContent
+	for _, m := range messages {
+		content := geminiContent{Role: m.Role, Parts: []geminiPart{{Text: m.Content}}}
+		if m.Role == "system" {
+			content.Role = "" // The system instruction has no role
+			systemInstruction = &content
+			continue
+		}
+		contents = append(contents, content)
+	}
+	return systemInstruction, contents
+}
+
 // GenerateResponse makes a call to the Gemini API using standard HTTP, requesting JSON output.
 func (g *GeminiAdapter) GenerateResponse(ctx context.Context, systemPrompt string, promptData PromptData) (*LLMResponse, error) {
 	fmt.Println("--- GeminiAdapter: GenerateResponse Called (HTTP JSON Mode) ---")
 
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+	// Resolve credentials: bearer token (Vertex AI) or API key query param
+	var apiKey, bearerToken string
+	if g.tokenSource != nil {
+		token, err := g.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain access token: %w", err)
+		}
+		bearerToken = token
+	} else {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+		}
 	}
 
 	// --- Construct Prompt ---
-	// Combine system prompt and dynamic context + user input.
-	// When using JSON mode, clearly instruct the LLM to populate specific fields
-	// in the JSON output (narrative, suggestions, actions).
-	var fullPromptBuilder strings.Builder
-	if systemPrompt != "" {
-		fullPromptBuilder.WriteString(systemPrompt)
-		// Add specific instructions for JSON mode:
-		fullPromptBuilder.WriteString("\n\nRespond ONLY with a valid JSON object containing 'narrative' (string), 'suggestions' (array of strings, optional), and 'actions' (array of action objects, optional) fields.")
-		fullPromptBuilder.WriteString(" The 'narrative' should describe the current scene and outcome. Only include 'actions' if the player's input implies a specific game action like moving location.")
-		fullPromptBuilder.WriteString("\n\n---\n\n") // Separator
-	}
-	// Add context (as before)
-	fullPromptBuilder.WriteString(fmt.Sprintf("Current Location: %s (%s)\n", promptData.LocationContext.CurrentLocationName, promptData.LocationContext.CurrentLocationDesc))
-	if len(promptData.LocationContext.AdjacentLocationNames) > 0 {
-		fullPromptBuilder.WriteString(fmt.Sprintf("Nearby: %s\n", strings.Join(promptData.LocationContext.AdjacentLocationNames, ", ")))
-	}
-	if len(promptData.SessionContext.RecentActions) > 0 {
-		fullPromptBuilder.WriteString(fmt.Sprintf("Recent Events: %s\n", strings.Join(promptData.SessionContext.RecentActions, "; ")))
-	}
-	fullPromptBuilder.WriteString(fmt.Sprintf("\nPlayer (%s - %s): %s", promptData.PlayerContext.Name, promptData.PlayerContext.Class, promptData.PlayerInput))
+	messages := g.PreviewPrompt(systemPrompt, promptData)
 
 	// --- Log the final prompt ---
-	finalPrompt := fullPromptBuilder.String()
-	fmt.Printf("--- Final Prompt Sent to Gemini ---\n%s\n---------------------------------\n", finalPrompt)
+	fmt.Println("--- Final Prompt Sent to Gemini ---")
+	for _, m := range messages {
+		fmt.Printf("[%s]\n%s\n", m.Role, m.Content)
+	}
+	fmt.Println("---------------------------------")
 
 	// --- Construct Request Body ---
+	systemInstruction, contents := geminiContents(messages)
 	apiRequest := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Role: "user",
-				Parts: []geminiPart{
-					{Text: finalPrompt}, // Use the logged prompt string
-				},
-			},
-		},
+		SystemInstruction: systemInstruction,
+		Contents:          contents,
 		// *** Configure JSON Mode ***
 		GenerationConfig: &geminiGenerationConfig{
 			ResponseMimeType: "application/json",
-			// Optional: Add other generation parameters
-			// Temperature: float32Ptr(0.8),
-			// MaxOutputTokens: intPtr(2048),
+			ResponseSchema:   g.schema,
+			Temperature:      toFloat32(g.params.Temperature),
+			TopP:             toFloat32(g.params.TopP),
+			MaxOutputTokens:  g.params.MaxTokens,
 		},
-		// Optional: Add Safety Settings if needed
-		// SafetySettings: []geminiSafetySetting{
-		//     {Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_MEDIUM_AND_ABOVE"},
-		//     // ... other categories
-		// },
+		// Safety filters follow the session's content rating
+		SafetySettings: geminiSafetySettings(promptData.ContentRating),
+	}
+	// The cached prefix is named rather than sent; it must include the system instruction
+	if prefix := cachedPrefix(messages); g.cache != nil && prefix > 0 && messages[0].Role == "system" {
+		if name := g.cachedContent(ctx, messages[:prefix], apiKey, bearerToken); name != "" {
+			apiRequest.CachedContent = name
+			apiRequest.SystemInstruction = nil
+			apiRequest.Contents = contents[prefix-1:]
+		}
 	}
 
 	// --- Marshal Request Body ---
@@ -230,18 +373,24 @@ func (g *GeminiAdapter) GenerateResponse(ctx context.Context, systemPrompt strin
 	// fmt.Printf("Request Body JSON:\n%s\n", string(reqBodyBytes)) // Debug logging
 
 	// --- Prepare HTTP Request ---
-	url := fmt.Sprintf("%s/%s:generateContent?key=%s", g.apiEndpoint, g.modelName, apiKey)
+	url := fmt.Sprintf("%s/%s:generateContent", g.apiEndpoint, g.modelName)
+	if apiKey != "" {
+		url += "?key=" + apiKey
+	}
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBodyBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if bearerToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
 
 	// --- Execute HTTP Request ---
 	fmt.Printf("Sending request to Gemini API (JSON Mode): %s...\n", url)
 	httpResp, err := g.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+		return nil, requestError(err)
 	}
 	defer httpResp.Body.Close()
 
@@ -292,24 +441,24 @@ func (g *GeminiAdapter) GenerateResponse(ctx context.Context, systemPrompt strin
 	llmOutputJsonString := apiResponse.Candidates[0].Content.Parts[0].Text
 	// fmt.Printf("LLM Output JSON String:\n%s\n", llmOutputJsonString) // Debug logging
 
-	// Unmarshal the JSON string generated by the LLM into our expected structure
-	var parsedOutput expectedLLMJsonOutput
-	if err := json.Unmarshal([]byte(llmOutputJsonString), &parsedOutput); err != nil {
-		// Fallback: Return the raw string as narrative if parsing fails? Or return error?
-		// Let's return an error for now, as structured output was expected.
-		return nil, fmt.Errorf("failed to parse LLM's JSON output: %w. Raw output: %s", err, llmOutputJsonString)
+	llmResponse, err := parseLLMOutput(llmOutputJsonString)
+	if err != nil {
+		return nil, err
 	}
-
-	// --- Map Parsed Output to internal LLMResponse ---
-	llmResponse := &LLMResponse{
-		Narrative:   parsedOutput.Narrative,   // Use the parsed narrative
-		Suggestions: parsedOutput.Suggestions, // Use the parsed suggestions
-		Actions:     parsedOutput.Actions,     // Use the parsed actions
+	llmResponse.Model, llmResponse.FinishReason = g.modelName, apiResponse.Candidates[0].FinishReason
+	if apiResponse.ModelVersion != "" {
+		llmResponse.Model = apiResponse.ModelVersion
 	}
 
 	// Log token usage if available
 	if apiResponse.UsageMetadata != nil { /* ... (logging as before) ... */
-		fmt.Printf("Gemini API Token Usage: Prompt=%d, Candidates=%d, Total=%d\n", apiResponse.UsageMetadata.PromptTokenCount, apiResponse.UsageMetadata.CandidatesTokenCount, apiResponse.UsageMetadata.TotalTokenCount)
+		fmt.Printf("Gemini API Token Usage: Prompt=%d (cached %d), Candidates=%d, Total=%d\n", apiResponse.UsageMetadata.PromptTokenCount, apiResponse.UsageMetadata.CachedContentTokenCount, apiResponse.UsageMetadata.CandidatesTokenCount, apiResponse.UsageMetadata.TotalTokenCount)
+		llmResponse.Usage = &TokenUsage{
+			PromptTokens:     apiResponse.UsageMetadata.PromptTokenCount,
+			CompletionTokens: apiResponse.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      apiResponse.UsageMetadata.TotalTokenCount,
+			CachedTokens:     apiResponse.UsageMetadata.CachedContentTokenCount,
+		}
 	}
 
 	fmt.Println("--- GeminiAdapter: Successfully Received and Parsed JSON Response ---")
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Prompt Caching ---
+// Every turn opens with the same long prefix: the system prompt and few-shot examples
+// (the messages marked Cached). Providers can keep that prefix on their side and bill
+// it at a fraction of the price. OpenAI caches prefixes on its own; Anthropic models
+// (through OpenRouter) need the prefix marked with a cache breakpoint; Gemini needs a
+// context cache created up front and named in each request. Caching is opt-in, since
+// Gemini bills cache storage by the hour.
+
+// CacheConfigurable is implemented by adapters that can have the provider cache the
+// stable prompt prefix. ttl is how long the provider keeps it, where it lets the
+// caller choose; zero disables caching.
+type CacheConfigurable interface {
+	SetPromptCaching(ttl time.Duration)
+}
+
+// cachedPrefix returns how many leading messages are marked Cached.
+func cachedPrefix(messages []PromptMessage) int {
+	for i, m := range messages {
+		if !m.Cached {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+// geminiContextCache tracks the context caches created for one adapter, by prefix.
+type geminiContextCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]geminiCacheEntry
+}
+
+// geminiCacheEntry is a created cache, or a failed attempt (no name) not to retry
+// until it expires: prefixes below the model's minimum size can't be cached at all.
+type geminiCacheEntry struct {
+	name    string
+	expires time.Time
+}
+
+// geminiCacheRenewMargin is how long before a cache expires a new one is created, so
+// a request never names a cache that lapses in flight.
+const geminiCacheRenewMargin = time.Minute
+
+// SetPromptCaching has the adapter create a Gemini context cache for each distinct
+// prompt prefix and name it in requests, instead of sending the prefix every turn.
+func (g *GeminiAdapter) SetPromptCaching(ttl time.Duration) {
+	if ttl <= 0 {
+		g.cache = nil
+		return
+	}
+	g.cache = &geminiContextCache{ttl: ttl, entries: make(map[string]geminiCacheEntry)}
+}
+
+// cachedContent returns the name of the context cache holding prefix, creating it if
+// needed, or "" if it can't be cached. Failures are logged, and the request goes out
+// uncached.
+func (g *GeminiAdapter) cachedContent(ctx context.Context, prefix []PromptMessage, apiKey, bearerToken string) string {
+	keyData, _ := json.Marshal(prefix)
+	sum := sha256.Sum256(append([]byte(g.modelName+"\n"), keyData...))
+	key := hex.EncodeToString(sum[:])
+
+	c := g.cache
+	c.mu.Lock()
+	defer c.mu.Unlock() // Held while creating, so concurrent turns don't create duplicates
+	if entry, ok := c.entries[key]; ok && time.Now().Add(geminiCacheRenewMargin).Before(entry.expires) {
+		return entry.name
+	}
+	for k, entry := range c.entries {
+		if time.Now().After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	name, err := g.createContextCache(ctx, prefix, apiKey, bearerToken)
+	if err != nil {
+		fmt.Printf("Warning: Gemini context cache not created, sending the prompt uncached: %v\n", err)
+	} else {
+		fmt.Printf("Created Gemini context cache %s for %d prompt message(s).\n", name, len(prefix))
+	}
+	c.entries[key] = geminiCacheEntry{name: name, expires: time.Now().Add(c.ttl)}
+	return name
+}
+
+// geminiCacheRequest creates a context cache.
+type geminiCacheRequest struct {
+	Model             string          `json:"model"`
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents,omitempty"`
+	TTL               string          `json:"ttl"`
+}
+
+// createContextCache stores prefix in a new context cache and returns its name.
+func (g *GeminiAdapter) createContextCache(ctx context.Context, prefix []PromptMessage, apiKey, bearerToken string) (string, error) {
+	url, model := g.cacheTarget()
+	systemInstruction, contents := geminiContents(prefix)
+	body, err := json.Marshal(geminiCacheRequest{
+		Model:             model,
+		SystemInstruction: systemInstruction,
+		Contents:          contents,
+		TTL:               fmt.Sprintf("%ds", int(g.cache.ttl.Seconds())),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cache request: %w", err)
+	}
+	if apiKey != "" {
+		url += "?key=" + apiKey
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if bearerToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+	httpResp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("cache request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cache response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cache request failed: status %s, body: %s", httpResp.Status, string(respBody))
+	}
+	var created struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(respBody, &created); err != nil || created.Name == "" {
+		return "", fmt.Errorf("cache response has no name: %s", string(respBody))
+	}
+	return created.Name, nil
+}
+
+// cacheTarget returns the cachedContents endpoint and the model's resource name.
+// Vertex AI keeps caches per location, the public API per project.
+func (g *GeminiAdapter) cacheTarget() (url, model string) {
+	if location, _, found := strings.Cut(g.apiEndpoint, "/publishers/"); found {
+		_, resource, _ := strings.Cut(g.apiEndpoint, "/v1/")
+		return location + "/cachedContents", resource + "/" + g.modelName
+	}
+	return strings.TrimSuffix(g.apiEndpoint, "/models") + "/cachedContents", "models/" + g.modelName
+}
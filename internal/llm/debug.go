@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// --- Call Log ---
+// In debug mode the engine records every model call a turn makes, so frontend
+// developers can watch prompt growth and latency from the responses alone.
+
+// CallRecord describes one model call.
+type CallRecord struct {
+	Purpose          string `json:"purpose"` // What the call was for: turn, triage, renarrate, epilogue, ...
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"promptTokens"`
+	CachedTokens     int    `json:"cachedTokens,omitempty"` // Prompt tokens served from the provider's prompt cache
+	CompletionTokens int    `json:"completionTokens"`
+	TotalTokens      int    `json:"totalTokens"`
+	LatencyMs        int64  `json:"latencyMs"` // Including time queued for an LLM slot
+	FinishReason     string `json:"finishReason,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// TurnDebug is the debug report for one turn: its calls and their totals.
+type TurnDebug struct {
+	Calls            []CallRecord `json:"calls"`
+	PromptTokens     int          `json:"promptTokens"`
+	CompletionTokens int          `json:"completionTokens"`
+	TotalTokens      int          `json:"totalTokens"`
+	LatencyMs        int64        `json:"latencyMs"` // The whole turn, model calls included
+}
+
+// CallLog collects the calls made under one context. It is safe for concurrent use.
+type CallLog struct {
+	mu    sync.Mutex
+	calls []CallRecord
+}
+
+type callLogKey struct{}
+
+// WithCallLog attaches a new CallLog to the context.
+func WithCallLog(ctx context.Context) (context.Context, *CallLog) {
+	log := &CallLog{}
+	return context.WithValue(ctx, callLogKey{}, log), log
+}
+
+// CallLogFrom returns the context's CallLog, or nil if calls aren't being logged.
+func CallLogFrom(ctx context.Context) *CallLog {
+	log, _ := ctx.Value(callLogKey{}).(*CallLog)
+	return log
+}
+
+// Record adds a call that took latency and returned response or err. A nil log ignores it.
+func (l *CallLog) Record(purpose string, latency time.Duration, response *LLMResponse, err error) {
+	if l == nil {
+		return
+	}
+	record := CallRecord{Purpose: purpose, LatencyMs: latency.Milliseconds()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if response != nil {
+		record.Model, record.FinishReason = response.Model, response.FinishReason
+		if response.Usage != nil {
+			record.PromptTokens = response.Usage.PromptTokens
+			record.CachedTokens = response.Usage.CachedTokens
+			record.CompletionTokens = response.Usage.CompletionTokens
+			record.TotalTokens = response.Usage.TotalTokens
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, record)
+}
+
+// Report summarizes the logged calls for a turn that took elapsed.
+func (l *CallLog) Report(elapsed time.Duration) *TurnDebug {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	report := &TurnDebug{Calls: append([]CallRecord{}, l.calls...), LatencyMs: elapsed.Milliseconds()}
+	for _, call := range l.calls {
+		report.PromptTokens += call.PromptTokens
+		report.CompletionTokens += call.CompletionTokens
+		report.TotalTokens += call.TotalTokens
+	}
+	return report
+}
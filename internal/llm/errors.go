@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrLLMTimeout is returned when the provider doesn't answer in time: the request's
+// deadline or the HTTP client's timeout passed.
+var ErrLLMTimeout = errors.New("LLM request timed out")
+
+// requestError wraps an error from sending a provider request, marking timeouts
+// with ErrLLMTimeout.
+func requestError(err error) error {
+	if timedOut(err) {
+		return fmt.Errorf("%w: %w", ErrLLMTimeout, err)
+	}
+	return fmt.Errorf("failed to execute HTTP request: %w", err)
+}
+
+// timedOut reports whether err is a deadline or network timeout.
+func timedOut(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
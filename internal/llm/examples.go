@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// --- Few-Shot Examples ---
+
+// FewShotExample is one ideal exchange (player input → JSON response) shown to the
+// model before the real turn. Smaller models format actions far more reliably with these.
+type FewShotExample struct {
+	Context     string      `json:"context,omitempty"` // Optional scene summary, e.g. "Current Location: oakhaven_square"
+	PlayerInput string      `json:"playerInput"`
+	Response    LLMResponse `json:"response"`
+}
+
+// LoadFewShotExamples reads every *.json file in fsys as a FewShotExample.
+// Files are loaded in name order so prompts are stable between restarts.
+func LoadFewShotExamples(fsys fs.FS) ([]FewShotExample, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking examples directory: %w", err)
+	}
+	sort.Strings(paths)
+
+	examples := make([]FewShotExample, 0, len(paths))
+	for _, path := range paths {
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read example file %s: %w", path, err)
+		}
+		var example FewShotExample
+		if err := json.Unmarshal(content, &example); err != nil {
+			return nil, fmt.Errorf("failed to parse example JSON %s: %w", path, err)
+		}
+		if example.PlayerInput == "" || example.Response.Narrative == "" {
+			return nil, fmt.Errorf("example %s must have 'playerInput' and 'response.narrative'", path)
+		}
+		examples = append(examples, example)
+	}
+	return examples, nil
+}
+
+// exampleUserText renders the user side of an example the same way real turns are rendered.
+func exampleUserText(example FewShotExample) string {
+	if example.Context != "" {
+		return example.Context + "\n\nPlayer:\n" + quotePlayerInput(example.PlayerInput)
+	}
+	return "Player:\n" + quotePlayerInput(example.PlayerInput)
+}
+
+// exampleResponseText renders the ideal JSON reply for an example.
+func exampleResponseText(example FewShotExample) string {
+	out, err := json.Marshal(example.Response)
+	if err != nil {
+		// LLMResponse only holds JSON-safe types, so this shouldn't happen.
+		return example.Response.Narrative
+	}
+	return string(out)
+}
+
+// buildExamplesBlock renders examples as a single text block, for single-message providers.
+func buildExamplesBlock(examples []FewShotExample) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Example exchanges (follow this response format exactly):\n")
+	for i, example := range examples {
+		b.WriteString(fmt.Sprintf("\nExample %d:\n%s\nResponse: %s\n", i+1, exampleUserText(example), exampleResponseText(example)))
+	}
+	return b.String()
+}
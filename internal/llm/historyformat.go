@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"llmrpg/internal/history"
+)
+
+// --- History Formatting ---
+// How the recent-events window is laid out in the prompt. Models differ in which
+// layout they follow best, so each world can pick one.
+
+// HistoryFormat names a layout for PromptData's recent events.
+type HistoryFormat string
+
+const (
+	HistoryInline  HistoryFormat = "inline"  // One "Recent Events" line, entries separated by semicolons (the default)
+	HistoryBullets HistoryFormat = "bullets" // One bullet per entry, with its turn number
+	HistoryChat    HistoryFormat = "chat"    // Past turns as a player/narrator exchange; chat providers send them as prior messages
+	HistoryDigest  HistoryFormat = "digest"  // One shortened line per turn, for small context windows
+)
+
+// ParseHistoryFormat parses a HistoryFormat name; "" is HistoryInline.
+func ParseHistoryFormat(s string) (HistoryFormat, error) {
+	switch format := HistoryFormat(s); format {
+	case "":
+		return HistoryInline, nil
+	case HistoryInline, HistoryBullets, HistoryChat, HistoryDigest:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown history format '%s' (expected inline, bullets, chat or digest)", s)
+	}
+}
+
+// digestSummaryLimit is how many characters of each entry a digest keeps.
+const digestSummaryLimit = 60
+
+// formatHistory renders records as the prompt's recent-events section, ending in a
+// newline ("" when there are none).
+func formatHistory(format HistoryFormat, records []history.TurnRecord) string {
+	if len(records) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	switch format {
+	case HistoryBullets:
+		b.WriteString("Recent Events:\n")
+		for _, r := range records {
+			b.WriteString(fmt.Sprintf("- (turn %d) %s\n", r.Turn, escapePlayerText(r.String())))
+		}
+	case HistoryChat:
+		b.WriteString("Recent Turns:\n")
+		for i, turn := range historyTurns(records) {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			for _, r := range turn {
+				b.WriteString(escapePlayerText(r.String()) + "\n")
+			}
+		}
+	case HistoryDigest:
+		b.WriteString("Story So Far (digest):\n")
+		for _, turn := range historyTurns(records) {
+			parts := make([]string, len(turn))
+			for i, r := range turn {
+				parts[i] = escapePlayerText(shorten(r.Summary, digestSummaryLimit))
+			}
+			b.WriteString(fmt.Sprintf("- T%d: %s\n", turn[0].Turn, strings.Join(parts, " → ")))
+		}
+	default:
+		recent := make([]string, 0, len(records))
+		for _, r := range records {
+			recent = append(recent, escapePlayerText(r.String())) // Past inputs are player text too
+		}
+		b.WriteString(fmt.Sprintf("Recent Events: %s\n", strings.Join(recent, "; ")))
+	}
+	return b.String()
+}
+
+// historyMessages renders records as prior chat turns: each turn's player input as a
+// user message and what followed it as an assistant message.
+func historyMessages(records []history.TurnRecord) []PromptMessage {
+	var messages []PromptMessage
+	for _, turn := range historyTurns(records) {
+		var said, happened []string
+		for _, r := range turn {
+			if r.Actor == history.ActorPlayer {
+				said = append(said, r.Summary)
+			} else {
+				happened = append(happened, escapePlayerText(r.String()))
+			}
+		}
+		if len(said) > 0 {
+			messages = append(messages, PromptMessage{Role: "user", Content: quotePlayerInput(strings.Join(said, "\n"))})
+		}
+		if len(happened) > 0 {
+			messages = append(messages, PromptMessage{Role: "assistant", Content: strings.Join(happened, "\n")})
+		}
+	}
+	return messages
+}
+
+// historyTurns splits records into runs of the same turn, in order.
+func historyTurns(records []history.TurnRecord) [][]history.TurnRecord {
+	var turns [][]history.TurnRecord
+	for i, r := range records {
+		if i == 0 || r.Turn != records[i-1].Turn {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], r)
+	}
+	return turns
+}
+
+// shorten cuts text to at most limit runes, marking the cut with an ellipsis.
+func shorten(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"llmrpg/internal/tracing"
+)
+
+// --- Shared HTTP Client ---
+
+// HTTPClientConfig controls the transport shared by all provider adapters.
+type HTTPClientConfig struct {
+	Timeout             time.Duration // Overall per-request timeout
+	MaxIdleConns        int           // Idle connections kept across all hosts
+	MaxIdleConnsPerHost int           // Idle connections kept per provider host
+	MaxConnsPerHost     int           // Hard cap on connections per host (0 = unlimited)
+	IdleConnTimeout     time.Duration // How long idle keep-alive connections live
+	ProxyURL            string        // Optional explicit proxy; empty falls back to HTTP(S)_PROXY env vars
+	CACertPath          string        // Optional PEM bundle appended to the system roots
+}
+
+// DefaultHTTPClientConfig returns settings tuned for a handful of long-lived provider hosts.
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:             90 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// NewHTTPClient builds an *http.Client from the config. Build it once and share it
+// between adapters so they reuse the same connection pool.
+func NewHTTPClient(cfg HTTPClientConfig) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %w", cfg.ProxyURL, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CACertPath != "" {
+		pemBytes, err := os.ReadFile(cfg.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", cfg.CACertPath, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no valid certificates found in CA bundle %s", cfg.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: tracing.Transport(transport), // Client spans for provider calls when tracing is enabled
+	}, nil
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// --- Concurrency Limiting ---
+
+// QueueObserver is called whenever a queued call's position changes.
+// Position 0 means the call has acquired a slot and is now running.
+type QueueObserver func(position int)
+
+type queueObserverKey struct{}
+
+// WithQueueObserver attaches a QueueObserver to the context so callers
+// (e.g. the async turn API) can report queue positions to players.
+func WithQueueObserver(ctx context.Context, observer QueueObserver) context.Context {
+	return context.WithValue(ctx, queueObserverKey{}, observer)
+}
+
+func queueObserverFromContext(ctx context.Context) QueueObserver {
+	observer, _ := ctx.Value(queueObserverKey{}).(QueueObserver)
+	return observer
+}
+
+// queueTicket represents one caller waiting for a slot.
+type queueTicket struct {
+	ready    chan struct{}
+	observer QueueObserver
+}
+
+// LimitedAdapter wraps another Adapter and caps the number of simultaneous calls.
+// Waiting callers are served in FIFO order so queue positions are meaningful.
+type LimitedAdapter struct {
+	inner         Adapter
+	maxConcurrent int
+
+	mu      sync.Mutex
+	running int
+	queue   []*queueTicket
+}
+
+// NewLimitedAdapter creates a LimitedAdapter allowing at most maxConcurrent in-flight calls.
+// A maxConcurrent of zero or less disables limiting (the inner adapter is still wrapped).
+func NewLimitedAdapter(inner Adapter, maxConcurrent int) *LimitedAdapter {
+	return &LimitedAdapter{
+		inner:         inner,
+		maxConcurrent: maxConcurrent,
+	}
+}
+
+// GenerateResponse waits for a free slot, then delegates to the wrapped adapter.
+func (l *LimitedAdapter) GenerateResponse(ctx context.Context, systemPrompt string, promptData PromptData) (*LLMResponse, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return l.inner.GenerateResponse(ctx, systemPrompt, promptData)
+}
+
+// Share wraps another adapter so its calls draw on this limiter's slots and queue.
+// Worlds narrated by different models still compete fairly for the same capacity.
+func (l *LimitedAdapter) Share(inner Adapter) Adapter {
+	return &sharedLimit{limiter: l, inner: inner}
+}
+
+// sharedLimit is an adapter that borrows slots from another LimitedAdapter.
+type sharedLimit struct {
+	limiter *LimitedAdapter
+	inner   Adapter
+}
+
+func (s *sharedLimit) GenerateResponse(ctx context.Context, systemPrompt string, promptData PromptData) (*LLMResponse, error) {
+	if err := s.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.limiter.release()
+	return s.inner.GenerateResponse(ctx, systemPrompt, promptData)
+}
+
+func (s *sharedLimit) PreviewPrompt(systemPrompt string, promptData PromptData) []PromptMessage {
+	return PreviewPrompt(s.inner, systemPrompt, promptData)
+}
+
+// PreviewPrompt renders the wrapped adapter's prompt (see PromptPreviewer).
+func (l *LimitedAdapter) PreviewPrompt(systemPrompt string, promptData PromptData) []PromptMessage {
+	return PreviewPrompt(l.inner, systemPrompt, promptData)
+}
+
+// QueueLength returns the number of calls currently waiting for a slot.
+func (l *LimitedAdapter) QueueLength() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// Running returns the number of calls currently in flight.
+func (l *LimitedAdapter) Running() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running
+}
+
+func (l *LimitedAdapter) acquire(ctx context.Context) error {
+	observer := queueObserverFromContext(ctx)
+
+	l.mu.Lock()
+	if l.maxConcurrent <= 0 || (l.running < l.maxConcurrent && len(l.queue) == 0) {
+		l.running++
+		l.mu.Unlock()
+		if observer != nil {
+			observer(0)
+		}
+		return nil
+	}
+	ticket := &queueTicket{ready: make(chan struct{}), observer: observer}
+	l.queue = append(l.queue, ticket)
+	position := len(l.queue)
+	l.mu.Unlock()
+
+	if observer != nil {
+		observer(position)
+	}
+
+	select {
+	case <-ticket.ready:
+		// release() already counted us as running
+		if observer != nil {
+			observer(0)
+		}
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-ticket.ready:
+			// Slot was handed to us just as we gave up; pass it on.
+			l.running--
+			l.promoteLocked()
+		default:
+			l.removeLocked(ticket)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w while waiting for LLM slot: %w", ErrLLMTimeout, ctx.Err())
+		}
+		return ctx.Err() // Cancelled by the caller, not a timeout
+	}
+}
+
+func (l *LimitedAdapter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	l.promoteLocked()
+}
+
+// promoteLocked hands free slots to queued callers. Caller must hold l.mu.
+func (l *LimitedAdapter) promoteLocked() {
+	promoted := false
+	for len(l.queue) > 0 && (l.maxConcurrent <= 0 || l.running < l.maxConcurrent) {
+		next := l.queue[0]
+		l.queue = l.queue[1:]
+		l.running++
+		close(next.ready)
+		promoted = true
+	}
+	if promoted {
+		l.notifyPositionsLocked()
+	}
+}
+
+// removeLocked drops a ticket from the queue (e.g. on cancellation). Caller must hold l.mu.
+func (l *LimitedAdapter) removeLocked(ticket *queueTicket) {
+	for i, t := range l.queue {
+		if t == ticket {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			l.notifyPositionsLocked()
+			return
+		}
+	}
+}
+
+// notifyPositionsLocked tells every waiting caller its (1-based) queue position.
+func (l *LimitedAdapter) notifyPositionsLocked() {
+	for i, t := range l.queue {
+		if t.observer != nil {
+			t.observer(i + 1)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package llm
+
+import "slices"
+
+// --- Chat Messages ---
+// Providers with multi-turn APIs get the prompt as a conversation rather than one
+// flattened message: instructions in the system role, few-shot examples (and, in the
+// chat history format, earlier turns) as user/assistant exchanges, then the turn's
+// context. The system message and examples open every request unchanged, so they're
+// marked Cached: providers that cache prompt prefixes can reuse them from turn to turn.
+
+// ChatConfigurable is implemented by adapters that can send the prompt either as a
+// conversation or as a single flattened user message.
+type ChatConfigurable interface {
+	SetChatMessages(enabled bool)
+}
+
+// buildMessages builds the conversation sent for promptData.
+func buildMessages(systemPrompt string, promptData PromptData) []PromptMessage {
+	var messages []PromptMessage
+	if instructions := buildSystemInstructions(systemPrompt); instructions != "" {
+		messages = append(messages, PromptMessage{Role: "system", Content: instructions, Cached: true})
+	}
+	// Few-shot examples become prior user/assistant turns.
+	for _, example := range promptData.Examples {
+		messages = append(messages,
+			PromptMessage{Role: "user", Content: exampleUserText(example), Cached: true},
+			PromptMessage{Role: "assistant", Content: exampleResponseText(example), Cached: true},
+		)
+	}
+	// So do recent turns in the chat history format, except the latest: that's the
+	// turn being played, and stays with its context.
+	if promptData.HistoryFormat == HistoryChat {
+		if turns := historyTurns(promptData.SessionContext.RecentActions); len(turns) > 1 {
+			messages = append(messages, historyMessages(slices.Concat(turns[:len(turns)-1]...))...)
+			promptData.SessionContext.RecentActions = turns[len(turns)-1]
+		}
+	}
+	return append(messages, PromptMessage{Role: "user", Content: buildContextPrompt(promptData)})
+}
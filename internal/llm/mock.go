@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// --- Mock Adapter ---
+// Answers without calling a model, so end-to-end tests (and deterministic mode) get
+// the same responses for the same inputs every run.
+
+// MockAdapter implements the Adapter interface from a script of canned responses.
+// Responses are handed out in order; once the script runs out (or when there is
+// none) it echoes the player's input back as plain narrative with no actions.
+type MockAdapter struct {
+	mu     sync.Mutex
+	script []LLMResponse
+	next   int
+}
+
+// NewMockAdapter creates a mock adapter that replays script in order.
+func NewMockAdapter(script []LLMResponse) *MockAdapter {
+	return &MockAdapter{script: script}
+}
+
+// LoadMockScript reads a JSON array of responses for NewMockAdapter.
+func LoadMockScript(path string) ([]LLMResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock script %s: %w", path, err)
+	}
+	var script []LLMResponse
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse mock script %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// GenerateResponse returns the next scripted response, or an echo once the script is spent.
+func (m *MockAdapter) GenerateResponse(ctx context.Context, systemPrompt string, promptData PromptData) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.next < len(m.script) {
+		resp := m.script[m.next]
+		m.next++
+		// Copy the slices so the engine's appends can't reach back into the script.
+		resp.Suggestions = append([]string(nil), resp.Suggestions...)
+		resp.Actions = append([]LLMAction(nil), resp.Actions...)
+		resp.Model, resp.FinishReason = "mock", "stop"
+		return &resp, nil
+	}
+	return &LLMResponse{
+		Narrative:    fmt.Sprintf("At %s, you %s.", promptData.LocationContext.CurrentLocationName, promptData.PlayerInput),
+		Suggestions:  []string{"Look around"},
+		Model:        "mock",
+		FinishReason: "stop",
+	}, nil
+}
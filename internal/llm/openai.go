@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// --- OpenAI-Compatible Adapter ---
+// Speaks the chat-completions wire format, so one adapter covers OpenAI,
+// OpenRouter, Together, vLLM, Ollama and any other compatible endpoint.
+
+// OpenAICompatibleAdapter implements the Adapter interface for chat-completions APIs.
+type OpenAICompatibleAdapter struct {
+	baseURL         string // e.g. "https://openrouter.ai/api/v1"
+	modelName       string
+	apiKey          string // Optional; local servers (vLLM, Ollama) often need none
+	httpClient      *http.Client
+	schema          *JSONSchema // Optional; sent via json_schema response format
+	params          GenerationParams
+	cacheBreakpoint bool // Mark the end of the cached prompt prefix (see SetPromptCaching)
+}
+
+// SetPromptCaching marks the end of the stable prompt prefix with a cache_control
+// breakpoint, which Anthropic models (e.g. through OpenRouter) need to cache it. OpenAI
+// caches prefixes without one, and may reject the field. ttl isn't sent: a breakpoint
+// keeps the provider's default lifetime.
+func (o *OpenAICompatibleAdapter) SetPromptCaching(ttl time.Duration) {
+	o.cacheBreakpoint = ttl > 0
+}
+
+// SetResponseSchema switches the adapter from plain JSON mode to json_schema mode.
+func (o *OpenAICompatibleAdapter) SetResponseSchema(schema *JSONSchema) {
+	o.schema = schema
+}
+
+// SetGenerationParams sets the sampling parameters sent with each request.
+func (o *OpenAICompatibleAdapter) SetGenerationParams(params GenerationParams) {
+	o.params = params
+}
+
+// NewOpenAICompatibleAdapter creates an adapter for the given base URL and model.
+// A nil httpClient uses a private default client.
+func NewOpenAICompatibleAdapter(baseURL, modelName, apiKey string, httpClient *http.Client) (*OpenAICompatibleAdapter, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai-compatible adapter requires a base URL")
+	}
+	if modelName == "" {
+		return nil, fmt.Errorf("openai-compatible adapter requires a model name")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 90 * time.Second}
+	}
+	return &OpenAICompatibleAdapter{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		modelName:  modelName,
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}, nil
+}
+
+// --- Internal Structs for Chat Completions Request/Response ---
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIRequestMessage is a message as sent. Content is a string, or text parts when
+// the message carries a cache breakpoint.
+type openAIRequestMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *openAICacheControl `json:"cache_control,omitempty"`
+}
+
+type openAICacheControl struct {
+	Type string `json:"type"`
+}
+
+type openAIJSONSchema struct {
+	Name   string      `json:"name"`
+	Schema *JSONSchema `json:"schema"`
+	Strict bool        `json:"strict"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []openAIRequestMessage `json:"messages"`
+	ResponseFormat *openAIResponseFormat  `json:"response_format,omitempty"`
+	Temperature    *float64               `json:"temperature,omitempty"`
+	TopP           *float64               `json:"top_p,omitempty"`
+	MaxTokens      *int                   `json:"max_tokens,omitempty"`
+}
+
+type openAIChoice struct {
+	Index        int           `json:"index"`
+	Message      openAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+type openAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string         `json:"model,omitempty"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage,omitempty"`
+}
+
+// chatMessages builds the conversation sent for promptData. Chat APIs have a native
+// system role, so instructions and context are sent separately.
+func (o *OpenAICompatibleAdapter) chatMessages(systemPrompt string, promptData PromptData) []openAIRequestMessage {
+	prompt := buildMessages(systemPrompt, promptData)
+	breakpoint := -1
+	if o.cacheBreakpoint {
+		breakpoint = cachedPrefix(prompt) - 1
+	}
+	messages := make([]openAIRequestMessage, len(prompt))
+	for i, m := range prompt {
+		messages[i] = openAIRequestMessage{Role: m.Role, Content: m.Content}
+		if i == breakpoint {
+			messages[i].Content = []openAIContentPart{{Type: "text", Text: m.Content, CacheControl: &openAICacheControl{Type: "ephemeral"}}}
+		}
+	}
+	return messages
+}
+
+// PreviewPrompt returns the messages GenerateResponse would send.
+func (o *OpenAICompatibleAdapter) PreviewPrompt(systemPrompt string, promptData PromptData) []PromptMessage {
+	return buildMessages(systemPrompt, promptData)
+}
+
+// GenerateResponse calls the chat-completions endpoint, requesting JSON output.
+func (o *OpenAICompatibleAdapter) GenerateResponse(ctx context.Context, systemPrompt string, promptData PromptData) (*LLMResponse, error) {
+	fmt.Println("--- OpenAICompatibleAdapter: GenerateResponse Called ---")
+
+	messages := o.chatMessages(systemPrompt, promptData)
+
+	responseFormat := &openAIResponseFormat{Type: "json_object"}
+	if o.schema != nil {
+		// Non-strict: strict mode forbids optional fields, and suggestions/actions are optional.
+		responseFormat = &openAIResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &openAIJSONSchema{Name: "narrative_response", Schema: o.schema},
+		}
+	}
+
+	apiRequest := openAIRequest{
+		Model:          o.modelName,
+		Messages:       messages,
+		ResponseFormat: responseFormat,
+		Temperature:    o.params.Temperature,
+		TopP:           o.params.TopP,
+		MaxTokens:      o.params.MaxTokens,
+	}
+
+	reqBodyBytes, err := json.Marshal(apiRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	// --- Prepare HTTP Request ---
+	url := o.baseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	// --- Execute HTTP Request ---
+	fmt.Printf("Sending request to OpenAI-compatible API: %s (model: %s)...\n", url, o.modelName)
+	httpResp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, requestError(err)
+	}
+	defer httpResp.Body.Close()
+
+	respBodyBytes, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// --- Handle Non-200 Status Codes ---
+	if httpResp.StatusCode != http.StatusOK {
+		var apiError struct {
+			Error struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBodyBytes, &apiError) == nil && apiError.Error.Message != "" {
+			return nil, fmt.Errorf("openai-compatible API request failed: status %d, type %s, message: %s", httpResp.StatusCode, apiError.Error.Type, apiError.Error.Message)
+		}
+		return nil, fmt.Errorf("openai-compatible API request failed: status %s, body: %s", httpResp.Status, string(respBodyBytes))
+	}
+
+	// --- Unmarshal and Extract ---
+	var apiResponse openAIResponse
+	if err := json.Unmarshal(respBodyBytes, &apiResponse); err != nil {
+		fmt.Printf("Raw Response Body on Unmarshal Error:\n%s\n", string(respBodyBytes))
+		return nil, fmt.Errorf("failed to unmarshal chat completions response: %w", err)
+	}
+	if len(apiResponse.Choices) == 0 || apiResponse.Choices[0].Message.Content == "" {
+		if len(apiResponse.Choices) > 0 && apiResponse.Choices[0].FinishReason == "content_filter" {
+			return nil, fmt.Errorf("content generation stopped by provider content filter")
+		}
+		return nil, fmt.Errorf("chat completions response missing expected content")
+	}
+
+	llmResponse, err := parseLLMOutput(apiResponse.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	llmResponse.Model, llmResponse.FinishReason = o.modelName, apiResponse.Choices[0].FinishReason
+	if apiResponse.Model != "" {
+		llmResponse.Model = apiResponse.Model
+	}
+
+	if apiResponse.Usage != nil {
+		llmResponse.Usage = &TokenUsage{
+			PromptTokens:     apiResponse.Usage.PromptTokens,
+			CompletionTokens: apiResponse.Usage.CompletionTokens,
+			TotalTokens:      apiResponse.Usage.TotalTokens,
+		}
+		if details := apiResponse.Usage.PromptTokensDetails; details != nil {
+			llmResponse.Usage.CachedTokens = details.CachedTokens
+		}
+		fmt.Printf("OpenAI-compatible API Token Usage: Prompt=%d (cached %d), Completion=%d, Total=%d\n", apiResponse.Usage.PromptTokens, llmResponse.Usage.CachedTokens, apiResponse.Usage.CompletionTokens, apiResponse.Usage.TotalTokens)
+	}
+
+	fmt.Println("--- OpenAICompatibleAdapter: Successfully Received and Parsed JSON Response ---")
+	return llmResponse, nil
+}
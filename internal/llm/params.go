@@ -0,0 +1,22 @@
+package llm
+
+// GenerationParams tune how a model samples its response. Nil fields leave the
+// provider's defaults.
+type GenerationParams struct {
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   *int
+}
+
+// ParamsConfigurable is implemented by adapters that can send generation parameters.
+type ParamsConfigurable interface {
+	SetGenerationParams(params GenerationParams)
+}
+
+func toFloat32(f *float64) *float32 {
+	if f == nil {
+		return nil
+	}
+	v := float32(*f)
+	return &v
+}
@@ -0,0 +1,25 @@
+package llm
+
+// --- Prompt Preview ---
+
+// PromptMessage is one message of a rendered prompt.
+type PromptMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Cached  bool   `json:"cached,omitempty"` // Part of the stable prefix providers may cache (see CacheConfigurable)
+}
+
+// PromptPreviewer is implemented by adapters that can render the prompt they would send.
+type PromptPreviewer interface {
+	PreviewPrompt(systemPrompt string, promptData PromptData) []PromptMessage
+}
+
+// PreviewPrompt renders the prompt adapter would send for promptData, without sending
+// it. Adapters that don't implement PromptPreviewer get the single flattened user
+// message.
+func PreviewPrompt(adapter Adapter, systemPrompt string, promptData PromptData) []PromptMessage {
+	if previewer, ok := adapter.(PromptPreviewer); ok {
+		return previewer.PreviewPrompt(systemPrompt, promptData)
+	}
+	return []PromptMessage{{Role: "user", Content: buildPrompt(systemPrompt, promptData)}}
+}
@@ -0,0 +1,16 @@
+package llm
+
+import (
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+)
+
+// ActionResult is what executing one LLMAction did, filled by the engine.
+type ActionResult struct {
+	Type    string         `json:"type"`
+	Success bool           `json:"success"`
+	Pending bool           `json:"pending,omitempty"` // Held for the player's confirmation rather than executed
+	Message string         `json:"message,omitempty"` // Why the action failed or was held
+	Changes []events.Event `json:"changes,omitempty"` // State changes the action made
+	Rolls   []dice.Result  `json:"rolls,omitempty"`   // Dice the action rolled
+}
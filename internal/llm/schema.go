@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// --- Response Schema Generation ---
+// Providers can constrain output to a JSON schema. We derive the schema from the
+// Go response types so the two can't drift apart.
+
+// JSONSchema is the subset of JSON Schema understood by both Gemini and OpenAI.
+type JSONSchema struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+}
+
+// SchemaConfigurable is implemented by adapters that can send a response schema to the provider.
+type SchemaConfigurable interface {
+	SetResponseSchema(schema *JSONSchema)
+}
+
+// SchemaFromType builds a schema from a Go type using its json tags.
+// Fields tagged omitempty are optional; everything else is required.
+// Free-form values (interface{}, map[string]interface{}) become untyped objects.
+func SchemaFromType(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: SchemaFromType(t.Elem())}
+	case reflect.Map, reflect.Interface:
+		return &JSONSchema{Type: "object"}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema.Properties[name] = SchemaFromType(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return schema
+	default:
+		return &JSONSchema{Type: "string"}
+	}
+}
+
+// ResponseSchema returns the schema for what the model writes (narrative, suggestions
+// and actions, as parsed into expectedLLMJsonOutput; LLMResponse's other fields are
+// filled by the engine), narrowing each action's "type" to actionTypes and its "data"
+// to actionData (when provided).
+func ResponseSchema(actionTypes []string, actionData *JSONSchema) *JSONSchema {
+	schema := SchemaFromType(reflect.TypeOf(expectedLLMJsonOutput{}))
+	schema.Properties["narrative"].Description = "Descriptive text that paints the scene and responds to the player's action"
+	schema.Properties["suggestions"].Description = "3-5 contextual actions the player might take next"
+
+	action := schema.Properties["actions"].Items
+	if len(actionTypes) > 0 {
+		action.Properties["type"].Enum = actionTypes
+	}
+	if actionData != nil {
+		action.Properties["data"] = actionData
+	}
+	return schema
+}
+
+// toGeminiSchema converts to Gemini's OpenAPI-style schema, which uses upper-case
+// type names and rejects objects without properties.
+func toGeminiSchema(schema *JSONSchema) *JSONSchema {
+	if schema == nil {
+		return nil
+	}
+	converted := &JSONSchema{
+		Type:        strings.ToUpper(schema.Type),
+		Description: schema.Description,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Items:       toGeminiSchema(schema.Items),
+	}
+	if converted.Type == "OBJECT" && len(schema.Properties) == 0 {
+		// Gemini can't express a free-form object; fall back to a JSON-encoded string.
+		converted.Type = "STRING"
+		return converted
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*JSONSchema, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			converted.Properties[name] = toGeminiSchema(prop)
+		}
+	}
+	return converted
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Vertex AI Authentication ---
+
+// TokenSource supplies OAuth2 bearer tokens for providers that don't use API keys.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// serviceAccountKey is the subset of a Google service-account JSON key we need.
+type serviceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// ServiceAccountTokenSource exchanges a signed JWT for an access token (the
+// OAuth2 JWT-bearer flow) and caches it until shortly before expiry.
+type ServiceAccountTokenSource struct {
+	clientEmail string
+	tokenURI    string
+	privateKey  *rsa.PrivateKey
+	httpClient  *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewServiceAccountTokenSource loads a service-account key file (as pointed to by
+// GOOGLE_APPLICATION_CREDENTIALS). A nil httpClient uses http.DefaultClient.
+func NewServiceAccountTokenSource(credentialsPath string, httpClient *http.Client) (*ServiceAccountTokenSource, error) {
+	content, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account credentials %s: %w", credentialsPath, err)
+	}
+
+	var key serviceAccountKey
+	if err := json.Unmarshal(content, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse service account credentials %s: %w", credentialsPath, err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("credentials %s are of type '%s', expected 'service_account'", credentialsPath, key.Type)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account private key is not valid PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account private key is not an RSA key")
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &ServiceAccountTokenSource{
+		clientEmail: key.ClientEmail,
+		tokenURI:    key.TokenURI,
+		privateKey:  rsaKey,
+		httpClient:  httpClient,
+	}, nil
+}
+
+// Token returns a cached access token, refreshing it when it is about to expire.
+func (ts *ServiceAccountTokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Until(ts.expires) > time.Minute {
+		return ts.token, nil
+	}
+
+	assertion, err := ts.signedAssertion()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token exchange failed: status %s, body: %s", resp.Status, string(body))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token response missing access_token")
+	}
+
+	ts.token = tokenResp.AccessToken
+	ts.expires = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return ts.token, nil
+}
+
+// signedAssertion builds an RS256-signed JWT asserting the service account identity.
+func (ts *ServiceAccountTokenSource) signedAssertion() (string, error) {
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]interface{}{
+		"iss":   ts.clientEmail,
+		"scope": cloudPlatformScope,
+		"aud":   ts.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(headerJSON) + "." + enc.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, ts.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
+
+// --- Vertex AI Gemini Adapter ---
+
+// NewVertexGeminiAdapter creates a Gemini adapter that calls the Vertex AI endpoint
+// for the given project/region, authenticating with bearer tokens instead of an API key.
+func NewVertexGeminiAdapter(modelName, projectID, region string, tokens TokenSource, httpClient *http.Client) (*GeminiAdapter, error) {
+	if projectID == "" || region == "" {
+		return nil, fmt.Errorf("vertex AI requires both a project ID and a region")
+	}
+	if tokens == nil {
+		return nil, fmt.Errorf("vertex AI requires a token source")
+	}
+	adapter := NewGeminiAdapter(modelName, httpClient)
+	adapter.apiEndpoint = fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models", region, projectID, region)
+	adapter.tokenSource = tokens
+	return adapter, nil
+}
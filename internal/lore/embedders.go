@@ -0,0 +1,170 @@
+package lore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// --- Hashing Embedder ---
+
+// HashingEmbedder is a local, dependency-free embedder using the hashing trick
+// over lower-cased word tokens. Good enough for keyword-style lore lookup and
+// useful offline or in development; use GeminiEmbedder for semantic matching.
+type HashingEmbedder struct {
+	Dimensions int
+}
+
+// NewHashingEmbedder creates a hashing embedder with the given vector size.
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	if dimensions <= 0 {
+		dimensions = 512
+	}
+	return &HashingEmbedder{Dimensions: dimensions}
+}
+
+// Embed hashes each token into a bucket and counts occurrences.
+func (h *HashingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, h.Dimensions)
+		for _, token := range Tokenize(text) {
+			hasher := fnv.New32a()
+			hasher.Write([]byte(token))
+			vec[hasher.Sum32()%uint32(h.Dimensions)]++
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+// Tokenize lower-cases text and splits it into words, dropping very short ones.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len(f) > 2 {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// --- Gemini Embedder ---
+
+// GeminiEmbedder calls the Gemini batchEmbedContents API.
+type GeminiEmbedder struct {
+	modelName   string
+	httpClient  *http.Client
+	apiEndpoint string
+}
+
+// NewGeminiEmbedder creates an embedder for the given model (default "text-embedding-004").
+// A nil httpClient uses http.DefaultClient.
+func NewGeminiEmbedder(modelName string, httpClient *http.Client) *GeminiEmbedder {
+	if modelName == "" {
+		modelName = "text-embedding-004"
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GeminiEmbedder{
+		modelName:   modelName,
+		httpClient:  httpClient,
+		apiEndpoint: "https://generativelanguage.googleapis.com/v1beta/models",
+	}
+}
+
+type geminiEmbedPart struct {
+	Text string `json:"text"`
+}
+
+type geminiEmbedRequest struct {
+	Model   string `json:"model"`
+	Content struct {
+		Parts []geminiEmbedPart `json:"parts"`
+	} `json:"content"`
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// geminiMaxBatch is the API's limit on requests per batchEmbedContents call.
+const geminiMaxBatch = 100
+
+// Embed sends texts in batches of up to geminiMaxBatch.
+func (g *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += geminiMaxBatch {
+		end := start + geminiMaxBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := g.embedBatch(ctx, apiKey, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (g *GeminiEmbedder) embedBatch(ctx context.Context, apiKey string, texts []string) ([][]float32, error) {
+
+	requests := make([]geminiEmbedRequest, len(texts))
+	for i, text := range texts {
+		requests[i].Model = "models/" + g.modelName
+		requests[i].Content.Parts = []geminiEmbedPart{{Text: text}}
+	}
+	body, err := json.Marshal(map[string]interface{}{"requests": requests})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embed request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s:batchEmbedContents?key=%s", g.apiEndpoint, g.modelName, apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embed request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute embed request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embed response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gemini embed request failed: status %s, body: %s", resp.Status, string(respBody))
+	}
+
+	var parsed geminiBatchEmbedResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse embed response: %w", err)
+	}
+	vectors := make([][]float32, len(parsed.Embeddings))
+	for i, e := range parsed.Embeddings {
+		vectors[i] = e.Values
+	}
+	return vectors, nil
+}
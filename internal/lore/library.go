@@ -0,0 +1,112 @@
+package lore
+
+import (
+	"slices"
+	"strings"
+)
+
+// Library is a world's lore documents, browsable by category and tag. Unlike the
+// Retriever it needs no embedder: documents are selected for a scene by the tags
+// it mentions.
+type Library struct {
+	docs []Document // Sorted by ID
+}
+
+// NewLibrary creates a library of docs, which should be sorted by ID (as
+// LoadDocuments returns them).
+func NewLibrary(docs []Document) *Library {
+	return &Library{docs: docs}
+}
+
+// Len returns the number of documents in the library.
+func (l *Library) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.docs)
+}
+
+// Documents returns the documents in category carrying tag; empty filters match everything.
+func (l *Library) Documents(category, tag string) []Document {
+	if l == nil {
+		return nil
+	}
+	category, tag = strings.ToLower(category), strings.ToLower(tag)
+	var docs []Document
+	for _, doc := range l.docs {
+		if (category == "" || doc.Category == category) && (tag == "" || slices.Contains(doc.Tags, tag)) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// Document returns the document with the given ID.
+func (l *Library) Document(id string) (Document, bool) {
+	if l == nil {
+		return Document{}, false
+	}
+	i, found := slices.BinarySearchFunc(l.docs, id, func(doc Document, id string) int {
+		return strings.Compare(doc.ID, id)
+	})
+	if !found {
+		return Document{}, false
+	}
+	return l.docs[i], true
+}
+
+// Mentioned returns up to limit documents with a tag that input or scene mentions as a
+// whole word or phrase (case-insensitive), or that appears in sceneTags (e.g. the
+// current location's tags). Documents the player's input mentions come first, then
+// those matching more tags.
+func (l *Library) Mentioned(input, scene string, sceneTags []string, limit int) []Document {
+	if l == nil || limit <= 0 {
+		return nil
+	}
+	inputText, sceneText := wordText(input), wordText(scene)
+	type match struct {
+		doc   Document
+		score int
+	}
+	var matches []match
+	for _, doc := range l.docs {
+		score := 0
+		for _, tag := range doc.Tags {
+			phrase := wordText(tag)
+			if phrase == wordText("") {
+				phrase = "" // A tag of only short words can't be mentioned, only listed in sceneTags
+			}
+			switch {
+			case phrase != "" && strings.Contains(inputText, phrase):
+				score += 2 // What the player is doing outweighs the backdrop
+			case (phrase != "" && strings.Contains(sceneText, phrase)) || slices.Contains(sceneTags, tag):
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, match{doc, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+	docs := make([]Document, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		docs = append(docs, m.doc)
+	}
+	return docs
+}
+
+// wordText normalizes text to its tokens, space-separated and space-padded, so that
+// phrases can be matched as whole words with strings.Contains.
+func wordText(text string) string {
+	return " " + strings.Join(Tokenize(text), " ") + " "
+}
+
+// Excerpt returns the start of the document's text, at most one retrieval chunk long
+// and on a single line, for inclusion in prompts.
+func (d Document) Excerpt() string {
+	chunks := chunkText(d.Text, maxChunkChars)
+	if len(chunks) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(chunks[0], "\n\n", " ")
+}
@@ -0,0 +1,229 @@
+package lore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"llmrpg/internal/world"
+)
+
+// Document is a piece of world knowledge that can be retrieved into prompts.
+type Document struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Text     string   `json:"text"`
+	Source   string   `json:"source"`             // e.g. "lore", "location"
+	Category string   `json:"category,omitempty"` // e.g. "faction", "history", "place"
+	Tags     []string `json:"tags,omitempty"`     // Lower-case keywords; a scene mentioning one pulls the document into the prompt
+}
+
+// Chunk is an embedded slice of a Document.
+type Chunk struct {
+	DocumentID string    `json:"documentId"`
+	Title      string    `json:"title"`
+	Text       string    `json:"text"`
+	Vector     []float32 `json:"-"`
+}
+
+// ScoredChunk is a search result with its similarity to the query.
+type ScoredChunk struct {
+	Chunk
+	Score float32 `json:"score"`
+}
+
+// Embedder turns text into vectors. Implementations: GeminiEmbedder, HashingEmbedder.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// maxChunkChars bounds chunk size so retrieved context stays small.
+const maxChunkChars = 800
+
+// Retriever indexes documents and returns the chunks most relevant to a query.
+type Retriever struct {
+	embedder Embedder
+	store    VectorStore
+	mu       sync.Mutex // Serializes indexing
+}
+
+// NewRetriever creates a retriever backed by the given embedder and store.
+func NewRetriever(embedder Embedder, store VectorStore) *Retriever {
+	return &Retriever{embedder: embedder, store: store}
+}
+
+// IndexDocuments chunks, embeds and stores the given documents.
+func (r *Retriever) IndexDocuments(ctx context.Context, docs []Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var chunks []Chunk
+	for _, doc := range docs {
+		for _, text := range chunkText(doc.Text, maxChunkChars) {
+			chunks = append(chunks, Chunk{DocumentID: doc.ID, Title: doc.Title, Text: text})
+		}
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		// Include the title so short chunks still carry their subject.
+		texts[i] = c.Title + "\n" + c.Text
+	}
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed %d lore chunk(s): %w", len(chunks), err)
+	}
+	if len(vectors) != len(chunks) {
+		return fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	for i := range chunks {
+		chunks[i].Vector = vectors[i]
+	}
+	r.store.Add(chunks)
+	return nil
+}
+
+// Retrieve returns the topK chunks most similar to query.
+func (r *Retriever) Retrieve(ctx context.Context, query string, topK int) ([]ScoredChunk, error) {
+	if query == "" || topK <= 0 {
+		return nil, nil
+	}
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed lore query: %w", err)
+	}
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	return r.store.Search(vectors[0], topK), nil
+}
+
+// frontMatter is the optional YAML header of a lore file, between '---' lines:
+//
+//	---
+//	category: faction
+//	tags: [town guard, oakhaven]
+//	---
+//	# The Oakhaven Watch
+type frontMatter struct {
+	Category string   `yaml:"category"`
+	Tags     []string `yaml:"tags"`
+}
+
+// splitFrontMatter separates a lore file's front matter (if any) from its text.
+func splitFrontMatter(text string) (frontMatter, string, error) {
+	var fm frontMatter
+	if !strings.HasPrefix(text, "---\n") {
+		return fm, text, nil
+	}
+	header, body, ok := strings.Cut(text[len("---\n"):], "\n---")
+	if !ok {
+		return fm, text, fmt.Errorf("front matter is missing its closing '---'")
+	}
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(header)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fm); err != nil {
+		return fm, text, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, strings.TrimSpace(body), nil
+}
+
+// LoadDocuments reads every .md/.txt file in fsys as a lore Document.
+// An optional front matter header sets the category and tags (see frontMatter);
+// the first line after it (minus any leading '#') is used as the title.
+func LoadDocuments(fsys fs.FS) ([]Document, error) {
+	var docs []Document
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if d.IsDir() || (ext != ".md" && ext != ".txt") {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read lore file %s: %w", path, err)
+		}
+		fm, text, err := splitFrontMatter(strings.TrimSpace(string(content)))
+		if err != nil {
+			return fmt.Errorf("lore file %s: %w", path, err)
+		}
+		title, body, _ := strings.Cut(text, "\n")
+		tags := make([]string, 0, len(fm.Tags))
+		for _, tag := range fm.Tags {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		docs = append(docs, Document{
+			ID:       strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())),
+			Title:    strings.TrimSpace(strings.TrimLeft(title, "#")),
+			Text:     strings.TrimSpace(body),
+			Source:   "lore",
+			Category: strings.ToLower(fm.Category),
+			Tags:     tags,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking lore directory: %w", err)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, nil
+}
+
+// DocumentsFromWorld turns location descriptions into lore Documents.
+func DocumentsFromWorld(ws world.WorldSystem) []Document {
+	ids := ws.GetAllLocationIDs()
+	sort.Strings(ids)
+	docs := make([]Document, 0, len(ids))
+	for _, id := range ids {
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			continue
+		}
+		docs = append(docs, Document{
+			ID:     "location:" + loc.ID,
+			Title:  loc.Name,
+			Text:   loc.Description,
+			Source: "location",
+		})
+	}
+	return docs
+}
+
+// chunkText splits text on paragraph boundaries into chunks of at most maxChars
+// (a single oversized paragraph becomes its own chunk).
+func chunkText(text string, maxChars int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if current.Len() > 0 && current.Len()+len(para)+2 > maxChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
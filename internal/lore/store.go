@@ -0,0 +1,73 @@
+package lore
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// VectorStore holds embedded chunks and finds the nearest ones to a query vector.
+type VectorStore interface {
+	Add(chunks []Chunk)
+	Search(query []float32, topK int) []ScoredChunk
+	Len() int
+}
+
+// InMemoryVectorStore is a brute-force cosine-similarity store.
+// World lore is small (hundreds of chunks), so a linear scan is plenty.
+type InMemoryVectorStore struct {
+	chunks []Chunk
+	mu     sync.RWMutex
+}
+
+// NewInMemoryVectorStore creates an empty store.
+func NewInMemoryVectorStore() *InMemoryVectorStore {
+	return &InMemoryVectorStore{}
+}
+
+// Add appends chunks to the store.
+func (s *InMemoryVectorStore) Add(chunks []Chunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = append(s.chunks, chunks...)
+}
+
+// Len returns the number of stored chunks.
+func (s *InMemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.chunks)
+}
+
+// Search returns up to topK chunks ordered by descending cosine similarity.
+func (s *InMemoryVectorStore) Search(query []float32, topK int) []ScoredChunk {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scored := make([]ScoredChunk, 0, len(s.chunks))
+	for _, c := range s.chunks {
+		scored = append(scored, ScoredChunk{Chunk: c, Score: CosineSimilarity(query, c.Vector)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > topK {
+		scored = scored[:topK]
+	}
+	return scored
+}
+
+// CosineSimilarity returns the cosine of the angle between two vectors (0 if mismatched or zero).
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"llmrpg/internal/history"
+	"llmrpg/internal/lore"
+)
+
+// Result is a memory entry matched by a search, with its relevance score.
+type Result struct {
+	history.TurnRecord
+	Score float32 `json:"score"`
+}
+
+// Searcher finds past session events relevant to a query. With an embedder it
+// ranks by embedding similarity; without one it falls back to keyword overlap.
+type Searcher struct {
+	embedder lore.Embedder // Optional
+
+	mu    sync.Mutex
+	cache map[string][]float32 // Entry text -> vector, so each event is embedded once
+}
+
+// NewSearcher creates a searcher. Pass a nil embedder for keyword-only search.
+func NewSearcher(embedder lore.Embedder) *Searcher {
+	return &Searcher{
+		embedder: embedder,
+		cache:    make(map[string][]float32),
+	}
+}
+
+// Search returns up to limit entries ranked by relevance to query.
+// Entries with no relevance at all are omitted.
+func (s *Searcher) Search(ctx context.Context, entries []history.TurnRecord, query string, limit int) ([]Result, error) {
+	if strings.TrimSpace(query) == "" || len(entries) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	var results []Result
+	if s.embedder != nil {
+		scored, err := s.embeddingScores(ctx, entries, query)
+		if err != nil {
+			return nil, err
+		}
+		results = scored
+	} else {
+		results = keywordScores(entries, query)
+	}
+
+	// Most relevant first; ties go to the more recent event.
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Turn > results[j].Turn
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// keywordScores scores entries by the fraction of query terms they contain.
+func keywordScores(entries []history.TurnRecord, query string) []Result {
+	queryTerms := lore.Tokenize(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, entry := range entries {
+		entryTerms := make(map[string]bool)
+		for _, t := range lore.Tokenize(entry.Summary) {
+			entryTerms[t] = true
+		}
+		matched := 0
+		for _, t := range queryTerms {
+			if entryTerms[t] {
+				matched++
+			}
+		}
+		if matched > 0 {
+			results = append(results, Result{TurnRecord: entry, Score: float32(matched) / float32(len(queryTerms))})
+		}
+	}
+	return results
+}
+
+// embeddingScores scores entries by cosine similarity to the query embedding.
+func (s *Searcher) embeddingScores(ctx context.Context, entries []history.TurnRecord, query string) ([]Result, error) {
+	// Embed any entries we haven't seen before, plus the query, in one call.
+	s.mu.Lock()
+	var missing []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if _, ok := s.cache[entry.Summary]; !ok && !seen[entry.Summary] {
+			missing = append(missing, entry.Summary)
+			seen[entry.Summary] = true
+		}
+	}
+	s.mu.Unlock()
+
+	vectors, err := s.embedder.Embed(ctx, append(missing, query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed memory search: %w", err)
+	}
+	if len(vectors) != len(missing)+1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(missing)+1)
+	}
+	queryVec := vectors[len(missing)]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, text := range missing {
+		s.cache[text] = vectors[i]
+	}
+
+	var results []Result
+	for _, entry := range entries {
+		if score := lore.CosineSimilarity(queryVec, s.cache[entry.Summary]); score > 0 {
+			results = append(results, Result{TurnRecord: entry, Score: score})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Stage is where in the turn a text was moderated.
+type Stage string
+
+const (
+	StageInput  Stage = "input"  // Player input
+	StageOutput Stage = "output" // Generated narrative
+)
+
+// AuditEntry records one moderation check. The text itself is not stored, only its length.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	SessionID  string    `json:"sessionId"`
+	Turn       int       `json:"turn"`
+	Stage      Stage     `json:"stage"`
+	Rating     string    `json:"rating,omitempty"`  // Session content rating the verdict was judged at
+	Variant    string    `json:"variant,omitempty"` // Session's system prompt variant, if it's in a prompt experiment
+	TextLength int       `json:"textLength"`
+	Verdict    Verdict   `json:"verdict"`
+	Action     Action    `json:"action,omitempty"` // Action taken; empty when not flagged
+	Error      string    `json:"error,omitempty"`  // Moderator failure (the text was let through)
+}
+
+// AuditLog records moderation checks.
+type AuditLog interface {
+	Record(entry AuditEntry)
+}
+
+// LogAuditLog writes entries as AUDIT lines to the standard logger.
+type LogAuditLog struct{}
+
+func (LogAuditLog) Record(entry AuditEntry) {
+	data, _ := json.Marshal(entry)
+	log.Printf("AUDIT moderation %s", data)
+}
+
+// FileAuditLog appends entries as JSON lines to a file.
+type FileAuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditLog opens (or creates) path for appending.
+func NewFileAuditLog(path string) (*FileAuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &FileAuditLog{file: f}, nil
+}
+
+func (a *FileAuditLog) Record(entry AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Warning: Failed to encode audit entry: %v", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		log.Printf("Warning: Failed to write audit entry: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package moderation checks player input and generated narrative against a content
+// policy, using a provider moderation API or a keyword list, and records every verdict
+// in an audit log.
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Verdict is the result of moderating one text.
+type Verdict struct {
+	Flagged    bool               `json:"flagged"`
+	Categories []string           `json:"categories,omitempty"` // Policy categories that matched, e.g. "violence"
+	Score      float64            `json:"score,omitempty"`      // Highest category score, when the provider reports one (0-1)
+	Scores     map[string]float64 `json:"scores,omitempty"`     // Per-category scores, when the provider reports them
+	Provider   string             `json:"provider"`
+}
+
+// AtThreshold re-evaluates the verdict against a score threshold instead of the
+// provider's own cut-off, so stricter content ratings flag more. Verdicts without
+// per-category scores (e.g. keyword matches) are returned unchanged.
+func (v Verdict) AtThreshold(threshold float64) Verdict {
+	if len(v.Scores) == 0 {
+		return v
+	}
+	v.Categories = nil
+	for category, score := range v.Scores {
+		if score >= threshold {
+			v.Categories = append(v.Categories, category)
+		}
+	}
+	sort.Strings(v.Categories)
+	v.Flagged = len(v.Categories) > 0
+	return v
+}
+
+// Moderator checks a text against a content policy.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Verdict, error)
+}
+
+// Action is what the engine does with flagged text.
+type Action string
+
+const (
+	ActionBlock  Action = "block"  // Input: reject the turn. Output: replace the narration with a neutral one
+	ActionSoften Action = "soften" // Input: ask the narrator to tone it down. Output: regenerate once, toned down
+	ActionLog    Action = "log"    // Only record the verdict
+)
+
+// ParseAction parses an Action name.
+func ParseAction(s string) (Action, error) {
+	switch action := Action(s); action {
+	case ActionBlock, ActionSoften, ActionLog:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown moderation action '%s' (expected block, soften or log)", s)
+	}
+}
+
+// --- Keyword Moderator ---
+
+// KeywordModerator flags texts containing any listed term (whole words, case-insensitive).
+type KeywordModerator struct {
+	categories map[string]*regexp.Regexp
+}
+
+// LoadKeywords reads a keyword list: one term per line, optionally prefixed with a
+// category ("violence: behead"). Terms without a category are filed under "blocklist".
+// Blank lines and lines starting with # are ignored.
+func LoadKeywords(r io.Reader) (*KeywordModerator, error) {
+	terms := make(map[string][]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		category, term := "blocklist", line
+		if c, t, ok := strings.Cut(line, ":"); ok {
+			category, term = strings.TrimSpace(c), strings.TrimSpace(t)
+		}
+		if term != "" {
+			terms[category] = append(terms[category], regexp.QuoteMeta(strings.ToLower(term)))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keyword list: %w", err)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("keyword list is empty")
+	}
+
+	m := &KeywordModerator{categories: make(map[string]*regexp.Regexp)}
+	for category, list := range terms {
+		pattern, err := regexp.Compile(`\b(` + strings.Join(list, "|") + `)\b`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keywords in category '%s': %w", category, err)
+		}
+		m.categories[category] = pattern
+	}
+	return m, nil
+}
+
+func (m *KeywordModerator) Moderate(ctx context.Context, text string) (Verdict, error) {
+	verdict := Verdict{Provider: "keywords"}
+	lower := strings.ToLower(text)
+	for category, pattern := range m.categories {
+		if pattern.MatchString(lower) {
+			verdict.Categories = append(verdict.Categories, category)
+		}
+	}
+	sort.Strings(verdict.Categories)
+	verdict.Flagged = len(verdict.Categories) > 0
+	if verdict.Flagged {
+		verdict.Score = 1
+	}
+	return verdict, nil
+}
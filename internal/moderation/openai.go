@@ -0,0 +1,88 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OpenAIModerator uses an OpenAI-compatible /moderations endpoint.
+type OpenAIModerator struct {
+	baseURL    string
+	model      string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAIModerator creates a moderator for baseURL (e.g. "https://api.openai.com/v1").
+// An empty model uses "omni-moderation-latest".
+func NewOpenAIModerator(baseURL, model, apiKey string, httpClient *http.Client) *OpenAIModerator {
+	if model == "" {
+		model = "omni-moderation-latest"
+	}
+	return &OpenAIModerator{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged        bool               `json:"flagged"`
+		Categories     map[string]bool    `json:"categories"`
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
+
+func (m *OpenAIModerator) Moderate(ctx context.Context, text string) (Verdict, error) {
+	body, err := json.Marshal(map[string]string{"model": m.model, "input": text})
+	if err != nil {
+		return Verdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Verdict{}, fmt.Errorf("moderation API returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var parsed openAIModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Verdict{}, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if len(parsed.Results) == 0 {
+		return Verdict{}, fmt.Errorf("moderation response has no results")
+	}
+
+	result := parsed.Results[0]
+	verdict := Verdict{Flagged: result.Flagged, Provider: "openai"}
+	for category, hit := range result.Categories {
+		if hit {
+			verdict.Categories = append(verdict.Categories, category)
+		}
+	}
+	sort.Strings(verdict.Categories)
+	verdict.Scores = result.CategoryScores
+	for _, score := range result.CategoryScores {
+		verdict.Score = max(verdict.Score, score)
+	}
+	return verdict, nil
+}
@@ -0,0 +1,158 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/effects"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"strings"
+)
+
+// lookupAbility resolves the action's 'abilityId' against the world's abilities.
+func (e *SimpleActionExecutor) lookupAbility(action llm.LLMAction) (*world.Ability, error) {
+	abilityID, ok := action.Data["abilityId"].(string)
+	if !ok || abilityID == "" {
+		return nil, errors.New("action data field 'abilityId' must be a non-empty string")
+	}
+	ability, ok := e.Abilities[abilityID]
+	if !ok {
+		return nil, fmt.Errorf("ability '%s' is not defined in this world", abilityID)
+	}
+	return ability, nil
+}
+
+// handleUseAbility processes the 'useAbility' action: the player uses a known
+// 'abilityId' that is off cooldown and affordable, paying its cost. In combat it takes
+// the player's turn and damaging abilities hit 'targetId'; outside combat only
+// abilities that heal or apply an effect can be used.
+func (e *SimpleActionExecutor) handleUseAbility(action llm.LLMAction, currentSession *session.GameSession) error {
+	ability, err := e.lookupAbility(action)
+	if err != nil {
+		return err
+	}
+	player := currentSession.Player
+	player.EnsureVitals()
+	if !player.KnowsAbility(ability.ID) {
+		return fmt.Errorf("the player doesn't know ability '%s'", ability.ID)
+	}
+	if left := player.CooldownLeft(ability.ID, currentSession.TurnCount); left > 0 {
+		return fmt.Errorf("ability '%s' is on cooldown for %d more turn(s)", ability.ID, left)
+	}
+	if player.MP < ability.Cost.MP {
+		return fmt.Errorf("ability '%s' costs %d MP; the player has %d", ability.ID, ability.Cost.MP, player.MP)
+	}
+	if ability.Cost.HP > 0 && player.HP <= ability.Cost.HP {
+		return fmt.Errorf("ability '%s' costs %d HP; the player has %d", ability.ID, ability.Cost.HP, player.HP)
+	}
+	if currentSession.Combat == nil && ability.Heal == "" && ability.Effect == nil {
+		return fmt.Errorf("ability '%s' can only be used in combat", ability.ID)
+	}
+
+	// The cost is paid up front, so the enemies' turn can't leave it unpaid. Abilities
+	// then resolve like consumables: heal the player, damage a target.
+	player.MP -= ability.Cost.MP
+	player.HP -= ability.Cost.HP
+	power := &world.Item{ID: ability.ID, Name: ability.Name, Heal: ability.Heal, Damage: ability.Damage}
+	var entries []combat.LogEntry
+	if currentSession.Combat != nil {
+		targetID, _ := action.Data["targetId"].(string)
+		if entries, err = e.rules(currentSession).UseItem(currentSession.Combat, player, power, targetID); err != nil {
+			player.MP += ability.Cost.MP
+			player.HP += ability.Cost.HP
+			return err
+		}
+	} else if ability.Heal != "" {
+		entries = []combat.LogEntry{e.rules(currentSession).ApplyItem(player, power, nil)}
+	}
+	for i := range entries {
+		if entries[i].Actor == combat.PlayerActor && entries[i].Action == "useItem" {
+			entries[i].Action = "useAbility"
+		}
+	}
+
+	player.StartCooldown(ability.ID, currentSession.TurnCount, ability.Cooldown)
+	summary := fmt.Sprintf("%s uses %s", player.Name, ability.Name)
+	if cost := abilityCost(ability); cost != "" {
+		summary += fmt.Sprintf(" (%s; MP left %d/%d)", cost, player.MP, player.MaxMP)
+	}
+	fmt.Printf("Executor: %s\n", summary)
+	currentSession.Record(history.ActorSystem, history.TypeAction, summary+".")
+	currentSession.Emit(events.NewAbilityUsed(currentSession.TurnCount, ability.ID, ability.Cost.MP, ability.Cost.HP, ability.Cooldown))
+	if ability.Effect != nil && player.HP > 0 {
+		description := effects.Rules[ability.Effect.ID].Description
+		player.AddEffect(character.Effect{ID: ability.Effect.ID, Description: description, Remaining: ability.Effect.Duration})
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s is now %s.", player.Name, ability.Effect.ID))
+		currentSession.Emit(events.NewEffectApplied(currentSession.TurnCount, ability.Effect.ID, ability.Effect.Duration, description))
+	}
+	e.logCombat(currentSession, entries)
+	return nil
+}
+
+// handleLearnAbility processes the 'learnAbility' action: the player learns 'abilityId'
+// (from a trainer, a tome, ...).
+func (e *SimpleActionExecutor) handleLearnAbility(action llm.LLMAction, currentSession *session.GameSession) error {
+	ability, err := e.lookupAbility(action)
+	if err != nil {
+		return err
+	}
+	player := currentSession.Player
+	if player.KnowsAbility(ability.ID) {
+		return fmt.Errorf("the player already knows ability '%s'", ability.ID)
+	}
+	player.Abilities = append(player.Abilities, ability.ID)
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s learns %s.", player.Name, ability.Name))
+	currentSession.Emit(events.NewAbilityLearned(currentSession.TurnCount, ability.ID))
+	return nil
+}
+
+// abilityCost describes what using ability takes, e.g. "4 MP" ("" if free).
+func abilityCost(ability *world.Ability) string {
+	var parts []string
+	if ability.Cost.MP > 0 {
+		parts = append(parts, fmt.Sprintf("%d MP", ability.Cost.MP))
+	}
+	if ability.Cost.HP > 0 {
+		parts = append(parts, fmt.Sprintf("%d HP", ability.Cost.HP))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// abilitiesContext lists the player's known abilities for the prompt with their cost
+// and whether they can be used this turn.
+func abilitiesContext(sess *session.GameSession, abilities map[string]*world.Ability) []string {
+	player := sess.Player
+	lines := make([]string, 0, len(player.Abilities))
+	for _, id := range player.Abilities {
+		ability, ok := abilities[id]
+		if !ok {
+			continue
+		}
+		var notes []string
+		if cost := abilityCost(ability); cost != "" {
+			notes = append(notes, cost)
+		}
+		if ability.Damage != "" {
+			notes = append(notes, "combat only")
+		}
+		switch left := player.CooldownLeft(id, sess.TurnCount); {
+		case left > 0:
+			notes = append(notes, fmt.Sprintf("on cooldown for %d turn(s)", left))
+		case player.MP < ability.Cost.MP || (ability.Cost.HP > 0 && player.HP <= ability.Cost.HP):
+			notes = append(notes, "can't afford")
+		default:
+			notes = append(notes, "ready")
+		}
+		line := fmt.Sprintf("%s (%s) [%s]", ability.Name, id, strings.Join(notes, ", "))
+		if ability.Description != "" {
+			line += ": " + ability.Description
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,277 @@
+package narrative
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"llmrpg/internal/llm"
+	"llmrpg/internal/tracing"
+	"llmrpg/internal/world"
+)
+
+// --- World Authoring ---
+// Authors can ask the model to draft locations from a short brief ("a smugglers' cove
+// below the lighthouse"). Drafts are candidates for review and are never written to the
+// world: the author keeps, edits or discards each one and saves the keepers through the
+// world editor.
+
+// MaxLocationDrafts is the most candidates one request may ask for.
+const MaxLocationDrafts = 5
+
+// Drafting errors. Check with errors.Is.
+var (
+	ErrInvalidDraftRequest = errors.New("invalid draft request")                        // The brief, count or near locations are wrong
+	ErrNoDrafts            = errors.New("the model returned no usable location drafts") // The reply held no usable location
+)
+
+// LocationDrafter drafts world locations with the model.
+type LocationDrafter struct {
+	Adapter llm.Adapter // Replies in the narrator's response format, like triage
+}
+
+// DraftRequest is an author's brief.
+type DraftRequest struct {
+	Brief string   `json:"brief"`           // What the author wants, in a sentence or two
+	Count int      `json:"count,omitempty"` // Candidates wanted (0 = 3, at most MaxLocationDrafts)
+	Near  []string `json:"near,omitempty"`  // Existing locations the drafts should connect to
+}
+
+// LocationDraft is one candidate location.
+type LocationDraft struct {
+	Location world.LocationNode `json:"location"`
+	Warnings []string           `json:"warnings,omitempty"` // What the author should check before saving it
+}
+
+// authoringPrompt instructs the drafting model. Like triagePrompt it uses the narrator's
+// response format, so the same adapters and response schema serve both.
+const authoringPrompt = `You help the author of a text adventure world write new locations. The author's brief is the player input; the world's existing locations are listed as lore, and the system notes say how many drafts to write and where they should connect.
+
+Reply with JSON: put the drafts in "narrative" as a JSON array (and nothing else), leave "suggestions" empty and return no actions. Each draft is an object with:
+- "id": a short snake_case ID that no existing location uses
+- "name": the location's name as players see it
+- "description": two to four sentences in second person, present tense, describing what a player arriving there sees, hears and smells; no events, no dialogue
+- "tags": a few lower-case tags in the style of the existing ones (e.g. "outdoor", "town", "dark")
+- "adjacentIds": IDs of existing locations, or of your other drafts, a player could walk to from here
+
+Keep the world's tone and naming. Make the drafts distinct options for the same brief, not parts of one place, unless the brief asks for several connected places.`
+
+// authoringWorldListing is how many existing locations the prompt lists, so a large
+// world doesn't crowd out the brief.
+const authoringWorldListing = 200
+
+// DraftLocations asks the model for candidate locations matching req in ws. Drafts get
+// IDs free in ws, and exits only to locations in ws or to other drafts; what was changed
+// or is missing is noted in each draft's Warnings.
+func (d *LocationDrafter) DraftLocations(ctx context.Context, ws world.WorldSystem, req DraftRequest) ([]LocationDraft, error) {
+	ctx, span := tracing.Start(ctx, "llm.authoring", tracing.KindInternal)
+	defer span.End()
+
+	req.Brief = strings.TrimSpace(req.Brief)
+	if req.Brief == "" {
+		return nil, fmt.Errorf("%w: brief is required", ErrInvalidDraftRequest)
+	}
+	if req.Count == 0 {
+		req.Count = 3
+	}
+	if req.Count < 1 || req.Count > MaxLocationDrafts {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidDraftRequest, MaxLocationDrafts)
+	}
+	for _, id := range req.Near {
+		if _, err := ws.GetLocation(id); err != nil {
+			return nil, fmt.Errorf("%w: unknown location '%s' in near", ErrInvalidDraftRequest, id)
+		}
+	}
+
+	promptData := llm.PromptData{
+		PlayerInput: req.Brief,
+		LoreContext: worldListing(ws, req.Near),
+		SystemNotes: []string{fmt.Sprintf("Write %d draft(s).", req.Count)},
+	}
+	if len(req.Near) > 0 {
+		promptData.SystemNotes = append(promptData.SystemNotes,
+			fmt.Sprintf("Each draft should connect to at least one of: %s.", strings.Join(req.Near, ", ")))
+	}
+	response, err := d.Adapter.GenerateResponse(ctx, authoringPrompt, promptData)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	drafts := parseLocationDrafts(response.Narrative)
+	if len(drafts) == 0 {
+		span.RecordError(ErrNoDrafts)
+		return nil, ErrNoDrafts
+	}
+	if len(drafts) > req.Count {
+		drafts = drafts[:req.Count]
+	}
+	reconcileDrafts(ws, drafts, req.Near)
+	span.SetAttr("authoring.drafts", len(drafts))
+	return drafts, nil
+}
+
+// worldListing describes ws's locations for the prompt, the ones in near first and
+// with their full descriptions.
+func worldListing(ws world.WorldSystem, near []string) []string {
+	ids := ws.GetAllLocationIDs()
+	slices.Sort(ids)
+	ids = slices.DeleteFunc(ids, func(id string) bool { return slices.Contains(near, id) })
+	ids = append(slices.Clone(near), ids...)
+
+	var lines []string
+	for _, id := range ids {
+		if len(lines) == authoringWorldListing {
+			break
+		}
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			continue
+		}
+		description := loc.Description
+		if !slices.Contains(near, id) {
+			description = firstSentence(description)
+		}
+		line := fmt.Sprintf("%s (%s)", loc.ID, loc.Name)
+		if len(loc.Tags) > 0 {
+			line += fmt.Sprintf(" [%s]", strings.Join(loc.Tags, ", "))
+		}
+		if len(loc.AdjacentIDs) > 0 {
+			line += fmt.Sprintf(" exits: %s", strings.Join(loc.AdjacentIDs, ", "))
+		}
+		lines = append(lines, line+": "+description)
+	}
+	return lines
+}
+
+// firstSentence shortens a description to its first sentence.
+func firstSentence(s string) string {
+	if i := strings.IndexAny(s, ".!?"); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// parseLocationDrafts reads the JSON array from the model's narrative, tolerating a
+// code fence or prose around it. Drafts without a name or description are dropped.
+func parseLocationDrafts(narrative string) []LocationDraft {
+	start, end := strings.Index(narrative, "["), strings.LastIndex(narrative, "]")
+	if start < 0 || end < start {
+		return nil
+	}
+	var raw []struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+		AdjacentIDs []string `json:"adjacentIds"`
+	}
+	if err := json.Unmarshal([]byte(narrative[start:end+1]), &raw); err != nil {
+		return nil
+	}
+	var drafts []LocationDraft
+	for _, r := range raw {
+		name, description := strings.TrimSpace(r.Name), strings.TrimSpace(r.Description)
+		if name == "" || description == "" {
+			continue
+		}
+		var tags []string
+		for _, tag := range r.Tags {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		drafts = append(drafts, LocationDraft{Location: world.LocationNode{
+			ID:          strings.TrimSpace(r.ID),
+			Name:        name,
+			Description: description,
+			Tags:        tags,
+			AdjacentIDs: r.AdjacentIDs,
+		}})
+	}
+	return drafts
+}
+
+// reconcileDrafts makes drafts fit ws: IDs become valid and unique, and exits that lead
+// nowhere are dropped. Each change, and a draft left unconnected to near or to anything,
+// is noted in the draft's Warnings.
+func reconcileDrafts(ws world.WorldSystem, drafts []LocationDraft, near []string) {
+	taken := make(map[string]bool)
+	for _, id := range ws.GetAllLocationIDs() {
+		taken[id] = true
+	}
+	renamed := make(map[string]string) // The model's ID -> the draft's final ID
+	for i := range drafts {
+		draft := &drafts[i]
+		proposed := draft.Location.ID
+		id := draftID(proposed)
+		if id == "" {
+			id = draftID(draft.Location.Name)
+		}
+		if id == "" {
+			id = "new_location"
+		}
+		base := id
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%s_%d", base, n)
+		}
+		if proposed != "" && id != proposed {
+			draft.Warnings = append(draft.Warnings, fmt.Sprintf("ID '%s' was taken or invalid; renamed to '%s'", proposed, id))
+		}
+		taken[id] = true
+		if proposed != "" {
+			renamed[proposed] = id
+		}
+		draft.Location.ID = id
+	}
+
+	for i := range drafts {
+		draft := &drafts[i]
+		var exits []string
+		for _, exit := range draft.Location.AdjacentIDs {
+			// An existing location wins over a draft the model gave the same ID
+			if _, err := ws.GetLocation(exit); err != nil {
+				id, ok := renamed[exit]
+				if !ok || id == draft.Location.ID {
+					draft.Warnings = append(draft.Warnings, fmt.Sprintf("dropped exit to unknown location '%s'", exit))
+					continue
+				}
+				exit = id // Another draft
+			}
+			if !slices.Contains(exits, exit) {
+				exits = append(exits, exit)
+			}
+		}
+		draft.Location.AdjacentIDs = exits
+		switch {
+		case len(exits) == 0:
+			draft.Warnings = append(draft.Warnings, "has no exits; players couldn't reach it until you add some")
+		case len(near) > 0 && !slices.ContainsFunc(exits, func(id string) bool { return slices.Contains(near, id) }):
+			draft.Warnings = append(draft.Warnings, fmt.Sprintf("doesn't connect to any of %s", strings.Join(near, ", ")))
+		}
+	}
+}
+
+// draftID turns s into a snake_case content ID ("Smugglers' Cove" -> "smugglers_cove").
+func draftID(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+		case r == '\'':
+			// Dropped, so "smugglers'" stays one word
+		default:
+			underscore = true
+		}
+	}
+	return b.String()
+}
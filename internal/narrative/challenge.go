@@ -0,0 +1,222 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"sort"
+	"strings"
+)
+
+// Where a challenge's outcome came from.
+const (
+	ChallengeRolled   = "roll"     // The server rolled it
+	ChallengeMinigame = "minigame" // The frontend reported a minigame result
+)
+
+// ChallengeResolver rolls a challenge on the server. bonus is the player's roll bonus
+// (e.g. from being blessed); it returns whether the player succeeded and the rolls made.
+type ChallengeResolver interface {
+	Roll(roller *dice.Roller, label string, dc, bonus int) (bool, []dice.Result)
+}
+
+// CheckResolver resolves a challenge as a single check: Notation (default 1d20) against the DC.
+type CheckResolver struct {
+	Notation string
+}
+
+func (r CheckResolver) Roll(roller *dice.Roller, label string, dc, bonus int) (bool, []dice.Result) {
+	expr := parseOr(r.Notation, "1d20")
+	expr.Modifier += bonus
+	result := roller.Check(expr, dice.Normal, dc, label)
+	return *result.Success, []dice.Result{result}
+}
+
+// ContestResolver resolves a challenge as an opposed roll, e.g. a persuasion contest. The
+// DC is the opponent's passive score: they roll 1d20 + (DC-10), and the player wins by
+// beating that total with Notation (default 1d20). Ties go to the opponent.
+type ContestResolver struct {
+	Notation string
+}
+
+func (r ContestResolver) Roll(roller *dice.Roller, label string, dc, bonus int) (bool, []dice.Result) {
+	expr := parseOr(r.Notation, "1d20")
+	expr.Modifier += bonus
+	opponent := roller.Roll(dice.Expression{Count: 1, Sides: 20, Modifier: dc - 10}, dice.Normal)
+	opponent.Label = label + " (opponent)"
+	player := roller.Check(expr, dice.Normal, opponent.Total+1, label)
+	return *player.Success, []dice.Result{opponent, player}
+}
+
+// parseOr parses notation, falling back to fallback if it is empty or invalid.
+func parseOr(notation, fallback string) dice.Expression {
+	if expr, err := dice.Parse(notation); err == nil {
+		return expr
+	}
+	expr, _ := dice.Parse(fallback)
+	return expr
+}
+
+// ChallengeKind is a registered kind of challenge the 'challenge' action can start.
+type ChallengeKind struct {
+	Label    string            // Shown in history and to frontends, e.g. "Lockpicking"
+	DC       int               // Default difficulty when the action gives no 'dc'
+	Minigame bool              // Frontends resolve it with a minigame (see NarrativeEngine.ResolveChallenge)
+	Resolver ChallengeResolver // Rolls it when the server resolves it
+}
+
+// DefaultChallenges returns the built-in challenge kinds, all rolled by the server.
+func DefaultChallenges() map[string]*ChallengeKind {
+	return map[string]*ChallengeKind{
+		"lockpicking": {Label: "Lockpicking", DC: 13, Resolver: CheckResolver{}},
+		"hacking":     {Label: "Hacking", DC: 14, Resolver: CheckResolver{}},
+		"persuasion":  {Label: "Persuasion contest", DC: 12, Resolver: ContestResolver{}},
+	}
+}
+
+// ParseMinigames marks the challenge kinds in a comma-separated list as resolved by
+// frontend minigames. Every kind must be registered in challenges.
+func ParseMinigames(spec string, challenges map[string]*ChallengeKind) error {
+	for _, kind := range strings.Split(spec, ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		c, ok := challenges[kind]
+		if !ok {
+			return fmt.Errorf("unknown challenge kind '%s' (known: %s)", kind, strings.Join(challengeKinds(challenges), ", "))
+		}
+		c.Minigame = true
+	}
+	return nil
+}
+
+func challengeKinds(challenges map[string]*ChallengeKind) []string {
+	kinds := make([]string, 0, len(challenges))
+	for kind := range challenges {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (e *SimpleActionExecutor) challenges() map[string]*ChallengeKind {
+	if e.Challenges == nil {
+		return DefaultChallenges()
+	}
+	return e.Challenges
+}
+
+// ChallengeExecutor is implemented by executors that run challenges, like
+// SimpleActionExecutor; the engine uses it to settle pending minigame challenges.
+type ChallengeExecutor interface {
+	ResolveChallenge(currentSession *session.GameSession, reported *bool) (string, error)
+}
+
+// ErrNoPendingChallenge is returned when a minigame result is reported for a session
+// that has no challenge waiting for one.
+var ErrNoPendingChallenge = errors.New("no challenge is waiting for a minigame result")
+
+// handleChallenge processes the 'challenge' action: starts a challenge of the registered
+// 'kind' against 'dc' (default the kind's). Minigame kinds are left pending for the
+// frontend (see NarrativeEngine.ResolveChallenge); others are rolled right away, and the
+// engine resumes the narration with the outcome.
+func (e *SimpleActionExecutor) handleChallenge(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't attempt a challenge during combat")
+	}
+	if currentSession.PendingChallenge != nil {
+		return fmt.Errorf("a %s challenge is already waiting for its result", currentSession.PendingChallenge.Label)
+	}
+	kindID, ok := action.Data["kind"].(string)
+	if !ok || kindID == "" {
+		return errors.New("action data field 'kind' must be a non-empty string")
+	}
+	kind, ok := e.challenges()[kindID]
+	if !ok {
+		return fmt.Errorf("unknown challenge kind '%s' (known: %s)", kindID, strings.Join(challengeKinds(e.challenges()), ", "))
+	}
+	dc := kind.DC
+	if v, ok := action.Data["dc"]; ok {
+		n, ok := v.(float64) // JSON numbers decode as float64
+		if !ok {
+			return errors.New("action data field 'dc' must be a number")
+		}
+		dc = int(n)
+	}
+
+	challenge := &session.Challenge{Kind: kindID, Label: kind.Label, DC: dc, Turn: currentSession.TurnCount}
+	if kind.Minigame {
+		currentSession.PendingChallenge = challenge
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s attempts a %s challenge (DC %d).", currentSession.Player.Name, kind.Label, dc))
+		currentSession.Emit(events.NewChallengeStarted(currentSession.TurnCount, kindID, kind.Label, dc))
+		return nil
+	}
+	e.rollChallenge(currentSession, challenge, kind)
+	return nil
+}
+
+// ResolveChallenge settles the session's pending challenge: with the frontend's minigame
+// result if reported is non-nil, otherwise by rolling it. It returns a note telling the
+// narrator the outcome.
+func (e *SimpleActionExecutor) ResolveChallenge(currentSession *session.GameSession, reported *bool) (string, error) {
+	challenge := currentSession.PendingChallenge
+	if challenge == nil {
+		return "", ErrNoPendingChallenge
+	}
+	currentSession.PendingChallenge = nil
+	kind, ok := e.challenges()[challenge.Kind]
+	if !ok {
+		return "", fmt.Errorf("pending challenge has unknown kind '%s'", challenge.Kind)
+	}
+	if reported == nil {
+		success := e.rollChallenge(currentSession, challenge, kind)
+		return fmt.Sprintf("The player's %s challenge (DC %d) was rolled instead of played: %s. Narrate that outcome.", challenge.Label, challenge.DC, outcomeWord(success)), nil
+	}
+	recordChallenge(currentSession, challenge, *reported, ChallengeMinigame, "")
+	return fmt.Sprintf("The player played the %s challenge (DC %d) as a minigame: %s. Narrate the outcome of the attempt.", challenge.Label, challenge.DC, outcomeWord(*reported)), nil
+}
+
+// rollChallenge resolves challenge with kind's resolver and records the outcome.
+func (e *SimpleActionExecutor) rollChallenge(sess *session.GameSession, challenge *session.Challenge, kind *ChallengeKind) bool {
+	success, rolls := kind.Resolver.Roll(e.roller(sess), challenge.Label, challenge.DC, checkBonus(sess.Player))
+	sess.LastTurnRolls = append(sess.LastTurnRolls, rolls...)
+	detail := ""
+	if len(rolls) > 0 {
+		last := rolls[len(rolls)-1]
+		detail = fmt.Sprintf(" (rolled %s = %d)", last.Notation, last.Total)
+	}
+	recordChallenge(sess, challenge, success, ChallengeRolled, detail)
+	return success
+}
+
+func recordChallenge(sess *session.GameSession, challenge *session.Challenge, success bool, source, detail string) {
+	summary := fmt.Sprintf("%s challenge vs DC %d: %s%s", challenge.Label, challenge.DC, outcomeWord(success), detail)
+	fmt.Printf("Executor: %s\n", summary)
+	sess.Record(history.ActorSystem, history.TypeAction, summary)
+	sess.Emit(events.NewChallengeResolved(sess.TurnCount, challenge.Kind, challenge.Label, challenge.DC, success, source))
+}
+
+func outcomeWord(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
+
+// challengeNotes describes the challenges the server rolled among evts, for resuming
+// the narration of the turn that started them.
+func challengeNotes(evts []events.Event) []string {
+	var notes []string
+	for _, ev := range evts {
+		if ev.Type != events.ChallengeResolved || ev.Data["source"] != ChallengeRolled {
+			continue
+		}
+		success, _ := ev.Data["success"].(bool)
+		notes = append(notes, fmt.Sprintf("The %s challenge (DC %v) the player attempted was a %s.", ev.Data["label"], ev.Data["dc"], outcomeWord(success)))
+	}
+	return notes
+}
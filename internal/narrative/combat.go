@@ -0,0 +1,295 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"sort"
+	"strings"
+)
+
+// errNotInCombat is returned for combat actions outside a fight.
+var errNotInCombat = errors.New("the player is not in combat (use startCombat first)")
+
+// rules returns the combat rules for sess's player, fighting with their loadout.
+func (e *SimpleActionExecutor) rules(sess *session.GameSession) *combat.Rules {
+	rules := &combat.Rules{Roller: e.roller(sess)}
+	weapon, armor := e.loadout(sess)
+	if weapon != nil {
+		rules.Weapon = weapon.Weapon
+	}
+	if armor != nil {
+		rules.Armor = armor.Armor
+	}
+	return rules
+}
+
+// handleStartCombat processes the 'startCombat' action: 'enemies' lists the opponents.
+// In worlds with a bestiary each entry names a 'creatureId' (and optional 'count') and
+// stats come from the bestiary; otherwise entries give a 'name' and optional 'hp',
+// 'armorClass', 'attackBonus' and 'damage'.
+func (e *SimpleActionExecutor) handleStartCombat(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player is already in combat")
+	}
+	raw, ok := action.Data["enemies"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return errors.New("action data field 'enemies' must be a non-empty array")
+	}
+	var enemies []combat.Combatant
+	for i, v := range raw {
+		data, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("action data field 'enemies[%d]' must be an object", i)
+		}
+		if len(e.Bestiary) > 0 {
+			creatureEnemies, err := e.creatureEnemies(i, data)
+			if err != nil {
+				return err
+			}
+			enemies = append(enemies, creatureEnemies...)
+			continue
+		}
+		enemy := combat.Combatant{}
+		enemy.Name, _ = data["name"].(string)
+		enemy.Damage, _ = data["damage"].(string)
+		for field, dst := range map[string]*int{"hp": &enemy.HP, "armorClass": &enemy.ArmorClass, "attackBonus": &enemy.AttackBonus} {
+			if n, ok := data[field].(float64); ok { // JSON numbers decode as float64
+				*dst = int(n)
+			}
+		}
+		enemies = append(enemies, enemy)
+	}
+	return e.beginCombat(currentSession, enemies)
+}
+
+// beginCombat starts an encounter against enemies and rolls initiative.
+func (e *SimpleActionExecutor) beginCombat(sess *session.GameSession, enemies []combat.Combatant) error {
+	enc, err := combat.NewEncounter(enemies)
+	if err != nil {
+		return err
+	}
+	sess.Player.EnsureVitals()
+	sess.Combat = enc
+	names := make([]string, 0, len(enc.Enemies))
+	for _, enemy := range enc.Enemies {
+		names = append(names, enemy.Name)
+	}
+	fmt.Printf("Executor: Combat started against %v\n", names)
+	sess.Emit(events.NewCombatStarted(sess.TurnCount, names))
+
+	// Not logged via logCombat: rolling initiative doesn't give a hidden player away
+	initiative := e.rules(sess).RollInitiative(enc, sess.Player)
+	fmt.Printf("Executor: Combat: %s\n", initiative.Summary)
+	sess.LastTurnCombat = append(sess.LastTurnCombat, initiative)
+	sess.Record(history.ActorSystem, history.TypeAction, initiative.Summary)
+	return nil
+}
+
+// creatureEnemies builds 'count' (default 1) enemies from the bestiary entry named by
+// 'creatureId' in enemies[i]. Any stats the LLM supplied are ignored.
+func (e *SimpleActionExecutor) creatureEnemies(i int, data map[string]interface{}) ([]combat.Combatant, error) {
+	creatureID, _ := data["creatureId"].(string)
+	if creatureID == "" {
+		return nil, fmt.Errorf("action data field 'enemies[%d].creatureId' is required in this world (creatures: %s)", i, strings.Join(sortedKeys(e.Bestiary), ", "))
+	}
+	creature, ok := e.Bestiary[creatureID]
+	if !ok {
+		return nil, fmt.Errorf("creature '%s' is not in this world's bestiary (creatures: %s)", creatureID, strings.Join(sortedKeys(e.Bestiary), ", "))
+	}
+	count := 1
+	if n, ok := data["count"].(float64); ok {
+		count = int(n)
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("action data field 'enemies[%d].count' must be a positive integer", i)
+	}
+	enemies := make([]combat.Combatant, 0, count)
+	for range min(count, maxEnemiesPerEntry) {
+		enemies = append(enemies, combat.FromCreature(creature))
+	}
+	return enemies, nil
+}
+
+// maxEnemiesPerEntry caps 'count' before NewEncounter checks the encounter size.
+const maxEnemiesPerEntry = 100
+
+// handleAttack processes the 'attack' action against 'targetId' (default: the first enemy standing).
+func (e *SimpleActionExecutor) handleAttack(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat == nil {
+		return errNotInCombat
+	}
+	targetID, _ := action.Data["targetId"].(string)
+	entries, err := e.rules(currentSession).Attack(currentSession.Combat, currentSession.Player, targetID)
+	if err != nil {
+		return err
+	}
+	e.logCombat(currentSession, entries)
+	return nil
+}
+
+// handleDefend processes the 'defend' action.
+func (e *SimpleActionExecutor) handleDefend(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat == nil {
+		return errNotInCombat
+	}
+	e.logCombat(currentSession, e.rules(currentSession).Defend(currentSession.Combat, currentSession.Player))
+	return nil
+}
+
+// handleFlee processes the 'flee' action.
+func (e *SimpleActionExecutor) handleFlee(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat == nil {
+		return errNotInCombat
+	}
+	e.logCombat(currentSession, e.rules(currentSession).Flee(currentSession.Combat, currentSession.Player))
+	return nil
+}
+
+// handleUseItem processes the 'useItem' action: consumes one 'itemId' from the inventory
+// and applies its effects. In combat it takes the player's turn and damaging items hit
+// 'targetId'; outside combat only healing items and survival supplies can be used.
+func (e *SimpleActionExecutor) handleUseItem(action llm.LLMAction, currentSession *session.GameSession) error {
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	combatUse := item.Heal != "" || item.Damage != ""
+	if !combatUse && (e.Survival == nil || len(item.Restores) == 0) {
+		return fmt.Errorf("item '%s' has no use effect", item.ID)
+	}
+	player := currentSession.Player
+	if player.ItemCount(item.ID) < 1 {
+		return fmt.Errorf("%w: the player doesn't carry item '%s'", character.ErrInsufficientItems, item.ID)
+	}
+
+	var entries []combat.LogEntry
+	if currentSession.Combat != nil {
+		if !combatUse {
+			return fmt.Errorf("item '%s' can't be used in combat", item.ID)
+		}
+		targetID, _ := action.Data["targetId"].(string)
+		if entries, err = e.rules(currentSession).UseItem(currentSession.Combat, player, item, targetID); err != nil {
+			return err
+		}
+	} else if item.Heal != "" {
+		entries = []combat.LogEntry{e.rules(currentSession).ApplyItem(player, item, nil)}
+	} else if len(item.Restores) == 0 || e.Survival == nil {
+		return fmt.Errorf("item '%s' can only be used in combat", item.ID)
+	}
+
+	if err := player.RemoveItem(item.ID, 1); err != nil {
+		return err
+	}
+	currentSession.Emit(events.NewItemLost(currentSession.TurnCount, item.ID, 1))
+	if summary := restoreResources(currentSession, e.Survival, item); summary != "" {
+		fmt.Printf("Executor: %s\n", summary)
+		currentSession.Record(history.ActorSystem, history.TypeAction, summary)
+	}
+	e.logCombat(currentSession, entries)
+	return nil
+}
+
+// logCombat records resolved combat steps for the turn response and session history,
+// and settles the encounter once it is over: a victory drops the defeated creatures' loot
+// and awards their maximum HP as experience.
+func (e *SimpleActionExecutor) logCombat(sess *session.GameSession, entries []combat.LogEntry) {
+	if sess.Combat != nil && sess.Stealth != "" {
+		setStealth(sess, session.StealthDetected) // Nobody stays hidden once blows are traded
+	}
+	for _, entry := range entries {
+		fmt.Printf("Executor: Combat: %s\n", entry.Summary)
+		sess.LastTurnCombat = append(sess.LastTurnCombat, entry)
+		sess.Record(history.ActorSystem, history.TypeAction, entry.Summary)
+	}
+	e.wearGear(sess, entries)
+	enc := sess.Combat
+	if enc == nil || !enc.Over() {
+		return
+	}
+	if enc.Outcome == combat.OutcomeDefeat {
+		sess.SetFlag(session.FlagDead, true)
+	}
+	sess.Combat = nil
+	sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Combat ended: %s", enc.Outcome))
+	sess.Emit(events.NewCombatEnded(sess.TurnCount, string(enc.Outcome)))
+	if enc.Outcome == combat.OutcomeVictory {
+		var foes []string
+		for _, enemy := range enc.Enemies {
+			foes = append(foes, enemy.Name)
+		}
+		sess.AddRumor(fmt.Sprintf("%s fought off %s", sess.Player.Name, strings.Join(foes, ", ")), sess.CurrentLocationID)
+		xp := 0
+		for _, enemy := range enc.Enemies {
+			if enemy.HP > 0 {
+				continue // Enemies that fled keep their loot and give no experience
+			}
+			xp += enemy.MaxHP
+			if creature, ok := e.Bestiary[enemy.CreatureID]; ok && creature.Loot != nil {
+				e.rollLoot(sess, "creature", enemy.ID, enemy.Name, creature.Loot)
+			}
+		}
+		e.gainXP(sess, xp, "victory")
+	}
+}
+
+// roundNotes asks the narrator to narrate the combat rounds resolved in entries (if any)
+// as one exchange, in turn order.
+func roundNotes(entries []combat.LogEntry) []string {
+	var steps []string
+	for _, entry := range entries {
+		if entry.Round > 0 {
+			steps = append(steps, entry.Summary)
+		}
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("The combat round resolved, in turn order: %s Narrate these steps as one continuous exchange; hits, misses and damage are already decided.", strings.Join(steps, " "))}
+}
+
+// combatContext summarizes the fight in progress for the prompt, with bestiary
+// descriptions so the narrator describes creatures as authored.
+func combatContext(sess *session.GameSession, bestiary map[string]*world.Creature) *llm.CombatContextData {
+	if sess.Combat == nil {
+		return nil
+	}
+	ctx := &llm.CombatContextData{Round: sess.Combat.Round}
+	for _, slot := range sess.Combat.Order {
+		if _, err := sess.Combat.Target(slot.ID); slot.ID == combat.PlayerActor || err == nil { // Skip the fallen and fled
+			ctx.TurnOrder = append(ctx.TurnOrder, slot.Name)
+		}
+	}
+	for _, enemy := range sess.Combat.Living() {
+		line := fmt.Sprintf("%s (%s, HP %d/%d)", enemy.Name, enemy.ID, enemy.HP, enemy.MaxHP)
+		if creature, ok := bestiary[enemy.CreatureID]; ok && creature.Description != "" {
+			line += ": " + creature.Description
+		}
+		ctx.Enemies = append(ctx.Enemies, line)
+	}
+	return ctx
+}
+
+// bestiaryContext lists the creatures encounters may use, for the prompt.
+func bestiaryContext(bestiary map[string]*world.Creature) []string {
+	lines := make([]string, 0, len(bestiary))
+	for _, id := range sortedKeys(bestiary) {
+		lines = append(lines, fmt.Sprintf("%s (%s)", id, bestiary[id].Name))
+	}
+	return lines
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
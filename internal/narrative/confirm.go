@@ -0,0 +1,176 @@
+package narrative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
+	"strings"
+)
+
+// ErrNoPendingActions is returned when confirming actions for a session that has none waiting.
+var ErrNoPendingActions = errors.New("no actions are waiting for confirmation")
+
+// confirmationNote tells the narrator of a session that confirms high-impact actions
+// how to narrate them.
+const confirmationNote = "The player confirms high-impact actions before they happen: starting a fight, a sneak attack, picking a pocket and discarding a quest item. When you use one of these actions, narrate up to the moment the character commits to it, not its outcome."
+
+// highImpact reports why action needs the player's confirmation, or "" if it doesn't.
+func (ne *NarrativeEngine) highImpact(action llm.LLMAction) string {
+	switch ActionType(action.Type) {
+	case StartCombat:
+		var names []string
+		enemies, _ := action.Data["enemies"].([]interface{})
+		for _, raw := range enemies {
+			enemy, _ := raw.(map[string]interface{})
+			creatureID, _ := enemy["creatureId"].(string)
+			if name, _ := enemy["name"].(string); name != "" {
+				names = append(names, name)
+			} else if creature, ok := ne.Bestiary[creatureID]; ok {
+				names = append(names, creature.Name)
+			}
+		}
+		if len(names) == 0 {
+			return "starts a fight"
+		}
+		return "starts a fight with " + strings.Join(names, ", ")
+	case SneakAttack:
+		return "attacks from hiding"
+	case Pickpocket:
+		itemID, _ := action.Data["itemId"].(string)
+		return "tries to steal " + ne.itemLabel(itemID)
+	case RemoveItem:
+		itemID, _ := action.Data["itemId"].(string)
+		if ne.questItem(itemID) {
+			return fmt.Sprintf("discards %s, a quest item", ne.itemLabel(itemID))
+		}
+	}
+	return ""
+}
+
+// questItem reports whether itemID is marked as a quest item, or is one a scenario beat asks for.
+func (ne *NarrativeEngine) questItem(itemID string) bool {
+	if item, ok := ne.Items[itemID]; ok && item.Quest {
+		return true
+	}
+	for _, scenario := range ne.Scenarios {
+		for _, beat := range scenario.Beats {
+			if beat.Complete.ObtainItem == itemID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// itemLabel returns the item's display name, or its ID if the world doesn't define it.
+func (ne *NarrativeEngine) itemLabel(itemID string) string {
+	if item, ok := ne.Items[itemID]; ok {
+		return item.Name
+	}
+	return itemID
+}
+
+// holdActions returns the actions to execute now. In a session that confirms
+// high-impact actions, the first high-impact action and every action after it (which
+// may depend on it) are held in sess.PendingActions instead.
+func (ne *NarrativeEngine) holdActions(sess *session.GameSession, actions []llm.LLMAction) []llm.LLMAction {
+	if !sess.ConfirmActions {
+		return actions
+	}
+	for i, action := range actions {
+		reason := ne.highImpact(action)
+		if reason == "" {
+			continue
+		}
+		for j, held := range actions[i:] {
+			if j > 0 {
+				reason = "" // Follows from the high-impact action
+			}
+			sess.PendingActions = append(sess.PendingActions, session.PendingAction{Type: held.Type, Data: held.Data, Reason: reason, Turn: sess.TurnCount})
+		}
+		fmt.Printf("NarrativeEngine: Holding %d action(s) for confirmation in session %s\n", len(actions)-i, sess.ID)
+		return actions[:i]
+	}
+	return actions
+}
+
+// pendingResult is the result reported for an action held for confirmation.
+func pendingResult(action session.PendingAction) llm.ActionResult {
+	message := "waiting for the player's confirmation"
+	if action.Reason != "" {
+		message += ": " + action.Reason
+	}
+	return llm.ActionResult{Type: action.Type, Pending: true, Message: message}
+}
+
+// pendingSummary lists the reasons of the session's pending actions, e.g. "starts a fight with Bandit".
+func pendingSummary(pending []session.PendingAction) string {
+	var reasons []string
+	for _, action := range pending {
+		if action.Reason != "" {
+			reasons = append(reasons, action.Reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// settlePendingActions resolves the session's pending actions at the start of a turn:
+// with confirmed they are executed, otherwise dropped. It returns the note telling the
+// narrator what happened, and the errors of executed actions.
+func (ne *NarrativeEngine) settlePendingActions(ctx context.Context, sess *session.GameSession, confirmed bool) ([]string, []error) {
+	pending := sess.PendingActions
+	sess.PendingActions = nil
+	summary := pendingSummary(pending)
+	if !confirmed {
+		return []string{fmt.Sprintf("The player decided against what your previous response set up (%s). None of it happened; narrate accordingly.", summary)}, nil
+	}
+
+	executionErrors := ne.applyActions(ctx, pendingLLMActions(pending), sess)
+	note := fmt.Sprintf("The player confirmed what your previous response set up (%s), and it has now been carried out. Narrate the outcome.", summary)
+	if len(executionErrors) > 0 {
+		note = fmt.Sprintf("The player confirmed what your previous response set up (%s), but it could not be carried out. Narrate why it falls through.", summary)
+	}
+	notes := []string{note}
+	notes = append(notes, challengeNotes(sess.LastTurnEvents)...)
+	notes = append(notes, roundNotes(sess.LastTurnCombat)...)
+	return notes, executionErrors
+}
+
+// ResolvePendingActions applies (confirm) or drops the session's pending high-impact
+// actions and runs a turn narrating the outcome.
+func (ne *NarrativeEngine) ResolvePendingActions(ctx context.Context, sessionID string, confirm bool) (*llm.LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "narrative.confirm", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("session.id", sessionID)
+
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	if currentSession.Completed() {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, session.ErrSessionCompleted)
+	}
+	if len(currentSession.PendingActions) == 0 {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, ErrNoPendingActions)
+	}
+
+	input := fmt.Sprintf("[Confirmed: %s]", pendingSummary(currentSession.PendingActions))
+	if !confirm {
+		input = fmt.Sprintf("[Declined: %s]", pendingSummary(currentSession.PendingActions))
+	}
+	response, err := ne.runTurn(ctx, currentSession, input, nil, nil, confirm)
+	span.RecordError(err)
+	return response, err
+}
+
+// pendingLLMActions converts pending actions back to the LLM's action form.
+func pendingLLMActions(pending []session.PendingAction) []llm.LLMAction {
+	var actions []llm.LLMAction
+	for _, action := range pending {
+		actions = append(actions, llm.LLMAction{Type: action.Type, Data: action.Data})
+	}
+	return actions
+}
@@ -0,0 +1,148 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// containerContents returns what c at locationID holds in this session: the session's
+// copy once the player has changed it, otherwise the authored contents. Don't modify it;
+// use stash for that.
+func containerContents(sess *session.GameSession, locationID string, c *world.Container) map[string]int {
+	if contents, ok := sess.Containers[session.ContainerKey(locationID, c.ID)]; ok {
+		return contents
+	}
+	return c.Items
+}
+
+// stash returns the session's own, modifiable copy of c's contents at locationID.
+func stash(sess *session.GameSession, locationID string, c *world.Container) map[string]int {
+	key := session.ContainerKey(locationID, c.ID)
+	if contents, ok := sess.Containers[key]; ok {
+		return contents
+	}
+	if sess.Containers == nil {
+		sess.Containers = make(map[string]map[string]int)
+	}
+	contents := maps.Clone(c.Items)
+	if contents == nil {
+		contents = make(map[string]int)
+	}
+	sess.Containers[key] = contents
+	return contents
+}
+
+// lookupContainer resolves the action's 'containerId' against the current location.
+func (e *SimpleActionExecutor) lookupContainer(action llm.LLMAction, currentSession *session.GameSession) (*world.Container, error) {
+	containerID, ok := action.Data["containerId"].(string)
+	if !ok || containerID == "" {
+		return nil, errors.New("action data field 'containerId' must be a non-empty string")
+	}
+	loc, err := e.WorldSystem.GetLocation(currentSession.CurrentLocationID)
+	if err != nil {
+		return nil, err
+	}
+	c := loc.Container(containerID)
+	if c == nil {
+		return nil, fmt.Errorf("there is no container '%s' at '%s'", containerID, loc.ID)
+	}
+	return c, nil
+}
+
+// handleTakeFromContainer processes the 'takeFromContainer' action: moves 'count'
+// (default 1) of 'itemId' from the current location's 'containerId' to the inventory.
+func (e *SimpleActionExecutor) handleTakeFromContainer(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't loot a container during combat")
+	}
+	c, err := e.lookupContainer(action, currentSession)
+	if err != nil {
+		return err
+	}
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	count, err := itemCount(action)
+	if err != nil {
+		return err
+	}
+	locationID := currentSession.CurrentLocationID
+	if have := containerContents(currentSession, locationID, c)[item.ID]; have < count {
+		return fmt.Errorf("%w: container '%s' holds %d x '%s', not %d", character.ErrInsufficientItems, c.ID, have, item.ID, count)
+	}
+	contents := stash(currentSession, locationID, c)
+	if contents[item.ID] -= count; contents[item.ID] == 0 {
+		delete(contents, item.ID)
+	}
+	currentSession.Player.AddItem(item.ID, count)
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s takes %d x %s from %s.", currentSession.Player.Name, count, item.Name, c.Name))
+	currentSession.Emit(events.NewItemGained(currentSession.TurnCount, item.ID, count))
+	return nil
+}
+
+// handlePutInContainer processes the 'putInContainer' action: moves 'count' (default 1)
+// of 'itemId' from the inventory into the current location's 'containerId', where it
+// stays for the rest of the playthrough.
+func (e *SimpleActionExecutor) handlePutInContainer(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't stash items during combat")
+	}
+	c, err := e.lookupContainer(action, currentSession)
+	if err != nil {
+		return err
+	}
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	count, err := itemCount(action)
+	if err != nil {
+		return err
+	}
+	if err := currentSession.Player.RemoveItem(item.ID, count); err != nil {
+		return err
+	}
+	stash(currentSession, currentSession.CurrentLocationID, c)[item.ID] += count
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s puts %d x %s in %s.", currentSession.Player.Name, count, item.Name, c.Name))
+	currentSession.Emit(events.NewItemLost(currentSession.TurnCount, item.ID, count))
+	dismountIfGone(currentSession, item.ID)
+	return nil
+}
+
+// containersContext lists loc's containers and what they hold for the prompt.
+func containersContext(sess *session.GameSession, loc *world.LocationNode, items map[string]*world.Item) []string {
+	lines := make([]string, 0, len(loc.Containers))
+	for i := range loc.Containers {
+		c := &loc.Containers[i]
+		contents := containerContents(sess, loc.ID, c)
+		ids := make([]string, 0, len(contents))
+		for id := range contents {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		held := make([]string, 0, len(ids))
+		for _, id := range ids {
+			name := id
+			if item, ok := items[id]; ok {
+				name = item.Name
+			}
+			held = append(held, fmt.Sprintf("%d x %s (%s)", contents[id], name, id))
+		}
+		summary := "empty"
+		if len(held) > 0 {
+			summary = strings.Join(held, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s): %s", c.ID, c.Name, summary))
+	}
+	return lines
+}
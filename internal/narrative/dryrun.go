@@ -0,0 +1,73 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+)
+
+// DryRunResult is what executing a set of actions would do to a session. Rolls are
+// made on the spot, so a real run may come out differently.
+type DryRunResult struct {
+	Actions   []llm.ActionResult    `json:"actions"` // Whether each action would apply, and what it would change
+	Rolls     []dice.Result         `json:"rolls"`
+	Events    []events.Event        `json:"events"`
+	CombatLog []combat.LogEntry     `json:"combatLog"`
+	Changes   []session.FieldChange `json:"changes"` // Session fields the actions would change, before and after
+}
+
+// DryRun executes actions in order against a copy of sess and reports each action's
+// validation result and the resulting state changes. sess itself is left untouched.
+func DryRun(executor ActionExecutor, sess *session.GameSession, actions []llm.LLMAction) (*DryRunResult, error) {
+	trial, err := sess.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy session '%s' for a dry run: %w", sess.ID, err)
+	}
+	trial.LastTurnRolls, trial.LastTurnEvents, trial.LastTurnCombat, trial.LastTurnResults = nil, nil, nil, nil
+	before, err := trial.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy session '%s' for a dry run: %w", sess.ID, err)
+	}
+
+	result := &DryRunResult{Actions: make([]llm.ActionResult, 0, len(actions))}
+	for _, action := range actions {
+		eventsBefore, rollsBefore := len(trial.LastTurnEvents), len(trial.LastTurnRolls)
+		// One at a time, so each error is attributed to its action
+		var err error
+		if errs := executor.ExecuteActions([]llm.LLMAction{action}, trial); len(errs) > 0 {
+			err = errs[0]
+		}
+		result.Actions = append(result.Actions, actionResult(action, err, trial.LastTurnEvents[eventsBefore:], trial.LastTurnRolls[rollsBefore:]))
+	}
+	result.Rolls = nonNil(trial.LastTurnRolls)
+	result.Events = nonNil(trial.LastTurnEvents)
+	result.CombatLog = nonNil(trial.LastTurnCombat)
+
+	// The turn's own records aren't part of the state change
+	trial.LastTurnRolls, trial.LastTurnEvents, trial.LastTurnCombat, trial.LastTurnResults = nil, nil, nil, nil
+	if result.Changes, err = session.Diff(before, trial); err != nil {
+		return nil, err
+	}
+	result.Changes = nonNil(result.Changes)
+	return result, nil
+}
+
+// DryRun reports what actions would do to the session without applying them. With no
+// actions, the session's pending high-impact actions are tried (ErrNoPendingActions if
+// there are none).
+func (ne *NarrativeEngine) DryRun(sessionID string, actions []llm.LLMAction) (*DryRunResult, error) {
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	if len(actions) == 0 {
+		if len(currentSession.PendingActions) == 0 {
+			return nil, fmt.Errorf("session '%s': %w", sessionID, ErrNoPendingActions)
+		}
+		actions = pendingLLMActions(currentSession.PendingActions)
+	}
+	return DryRun(ne.ActionExecutor, currentSession, actions)
+}
@@ -0,0 +1,175 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"sort"
+	"strings"
+)
+
+// loadout picks the gear the player fights with: the carried weapon with the best
+// average damage and the carried armor with the best bonus (nil = none). Ties go to
+// the lower item ID.
+func (e *SimpleActionExecutor) loadout(sess *session.GameSession) (weapon, armor *world.Item) {
+	ids := make([]string, 0, len(sess.Player.Inventory))
+	for id := range sess.Player.Inventory {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	best := 0.0
+	for _, id := range ids {
+		item, ok := e.Items[id]
+		if !ok {
+			continue
+		}
+		if item.Weapon != "" {
+			if avg := averageRoll(item.Weapon); weapon == nil || avg > best {
+				weapon, best = item, avg
+			}
+		}
+		if item.Armor > 0 && (armor == nil || item.Armor > armor.Armor) {
+			armor = item
+		}
+	}
+	return weapon, armor
+}
+
+func averageRoll(notation string) float64 {
+	expr, err := dice.Parse(notation)
+	if err != nil {
+		return 0
+	}
+	return float64(expr.Count)*float64(expr.Sides+1)/2 + float64(expr.Modifier)
+}
+
+// wearGear wears the player's gear for the combat steps in entries: their weapon by 1
+// per attack they made, their armor by 1 per hit they took.
+func (e *SimpleActionExecutor) wearGear(sess *session.GameSession, entries []combat.LogEntry) {
+	weapon, armor := e.loadout(sess)
+	attacks, hits, round := 0, 0, 0
+	for _, entry := range entries {
+		if entry.Round == 0 {
+			continue
+		}
+		round = entry.Round
+		if entry.Action != "attack" {
+			continue
+		}
+		if entry.Actor == combat.PlayerActor {
+			attacks++
+		} else if entry.Target == combat.PlayerActor && entry.Damage > 0 {
+			hits++
+		}
+	}
+	if weapon != nil && attacks > 0 {
+		e.wearItem(sess, weapon, attacks, round)
+	}
+	if armor != nil && hits > 0 {
+		e.wearItem(sess, armor, hits, round)
+	}
+}
+
+// wearItem adds amount of wear to item. When its durability runs out it breaks: one is
+// removed from the inventory (the next of a stack starts unworn) and the break is added
+// to the turn's combat log for round, so the narration covers it.
+func (e *SimpleActionExecutor) wearItem(sess *session.GameSession, item *world.Item, amount, round int) {
+	if item.Durability == 0 {
+		return
+	}
+	wear := sess.Player.AddWear(item.ID, amount)
+	if wear < item.Durability {
+		sess.Emit(events.NewItemWorn(sess.TurnCount, item.ID, item.Durability-wear, item.Durability))
+		return
+	}
+	delete(sess.Player.Wear, item.ID)
+	if err := sess.Player.RemoveItem(item.ID, 1); err != nil {
+		return
+	}
+	summary := fmt.Sprintf("%s's %s breaks.", sess.Player.Name, item.Name)
+	fmt.Printf("Executor: %s\n", summary)
+	sess.LastTurnCombat = append(sess.LastTurnCombat, combat.LogEntry{Round: round, Actor: combat.PlayerActor, Action: "break", Summary: summary})
+	sess.Record(history.ActorSystem, history.TypeAction, summary)
+	sess.Emit(events.NewItemWorn(sess.TurnCount, item.ID, 0, item.Durability))
+	sess.Emit(events.NewItemLost(sess.TurnCount, item.ID, 1))
+}
+
+// handleRepairItem processes the 'repairItem' action: restores the full durability of
+// the player's worn 'itemId', consuming the materials its repair needs.
+func (e *SimpleActionExecutor) handleRepairItem(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't repair items during combat")
+	}
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	player := currentSession.Player
+	if player.ItemCount(item.ID) < 1 {
+		return fmt.Errorf("%w: the player doesn't carry item '%s'", character.ErrInsufficientItems, item.ID)
+	}
+	if item.Durability == 0 || len(item.Repair) == 0 {
+		return fmt.Errorf("item '%s' can't be repaired", item.ID)
+	}
+	if player.Wear[item.ID] == 0 {
+		return fmt.Errorf("item '%s' isn't worn", item.ID)
+	}
+	materials := sortedKeys(item.Repair)
+	for _, id := range materials {
+		if player.ItemCount(id) < item.Repair[id] {
+			return fmt.Errorf("%w: repairing '%s' needs %s", character.ErrInsufficientItems, item.ID, repairCost(item, e.Items))
+		}
+	}
+	for _, id := range materials {
+		if err := player.RemoveItem(id, item.Repair[id]); err != nil {
+			return err
+		}
+		currentSession.Emit(events.NewItemLost(currentSession.TurnCount, id, item.Repair[id]))
+	}
+	delete(player.Wear, item.ID)
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s repairs %s using %s.", player.Name, item.Name, repairCost(item, e.Items)))
+	currentSession.Emit(events.NewItemWorn(currentSession.TurnCount, item.ID, item.Durability, item.Durability))
+	return nil
+}
+
+// repairCost describes the materials repairing item consumes, e.g. "1 x Whetstone".
+func repairCost(item *world.Item, items map[string]*world.Item) string {
+	parts := make([]string, 0, len(item.Repair))
+	for _, id := range sortedKeys(item.Repair) {
+		name := id
+		if material, ok := items[id]; ok {
+			name = material.Name
+		}
+		parts = append(parts, fmt.Sprintf("%d x %s", item.Repair[id], name))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// gearContext describes a carried item's combat use and condition for the inventory
+// summary, e.g. " [weapon 1d8+1, durability 7/10, repair: 1 x Whetstone]" ("" for other items).
+func gearContext(sess *session.GameSession, item *world.Item, items map[string]*world.Item) string {
+	var parts []string
+	if item.Weapon != "" {
+		parts = append(parts, "weapon "+item.Weapon)
+	}
+	if item.Armor > 0 {
+		parts = append(parts, fmt.Sprintf("armor +%d", item.Armor))
+	}
+	if item.Durability > 0 {
+		parts = append(parts, fmt.Sprintf("durability %d/%d", item.Durability-sess.Player.Wear[item.ID], item.Durability))
+		if len(item.Repair) > 0 {
+			parts = append(parts, "repair: "+repairCost(item, items))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
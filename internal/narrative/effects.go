@@ -0,0 +1,111 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/effects"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+)
+
+// EffectRestrictionError is returned when the LLM moves the player while a status
+// effect prevents travel. Like ScenarioRestrictionError, the engine reacts by asking
+// the LLM to re-narrate the turn.
+type EffectRestrictionError struct {
+	EffectID string
+	TargetID string
+}
+
+func (e *EffectRestrictionError) Error() string {
+	return fmt.Sprintf("the player can't travel to '%s' while %s", e.TargetID, e.EffectID)
+}
+
+// handleApplyEffect processes the 'applyEffect' action: puts 'effectId' on the player for
+// 'duration' turns (0 or omitted = until removed). Effects listed in effects.Rules have
+// mechanical consequences; 'description' defaults to the rule's.
+func (e *SimpleActionExecutor) handleApplyEffect(action llm.LLMAction, currentSession *session.GameSession) error {
+	effectID, ok := action.Data["effectId"].(string)
+	if !ok || effectID == "" {
+		return errors.New("action data field 'effectId' must be a non-empty string")
+	}
+	duration := 0
+	if v, ok := action.Data["duration"]; ok {
+		n, ok := v.(float64) // JSON numbers decode as float64
+		if !ok || n < 0 || n != float64(int(n)) {
+			return errors.New("action data field 'duration' must be a non-negative integer")
+		}
+		duration = int(n)
+	}
+	description, _ := action.Data["description"].(string)
+	if description == "" {
+		description = effects.Rules[effectID].Description
+	}
+
+	currentSession.Player.AddEffect(character.Effect{ID: effectID, Description: description, Remaining: duration})
+	summary := fmt.Sprintf("%s is now %s", currentSession.Player.Name, effectID)
+	if duration > 0 {
+		summary += fmt.Sprintf(" for %d turn(s)", duration)
+	}
+	fmt.Printf("Executor: %s\n", summary)
+	currentSession.Record(history.ActorSystem, history.TypeAction, summary+".")
+	currentSession.Emit(events.NewEffectApplied(currentSession.TurnCount, effectID, duration, description))
+	return nil
+}
+
+// resolveEffects is the start-of-turn step for the player's status effects (see
+// effects.Resolve). It returns notes for the narrator, e.g. when poison proves fatal.
+func resolveEffects(sess *session.GameSession) []string {
+	player := sess.Player
+	if len(player.Effects) == 0 {
+		return nil
+	}
+	for _, res := range effects.Resolve(player) {
+		sess.Record(history.ActorSystem, history.TypeAction, res.Summary)
+		if res.Damage > 0 {
+			sess.Emit(events.NewEffectTicked(sess.TurnCount, res.EffectID, res.Damage))
+		}
+		if res.Expired {
+			sess.Emit(events.NewEffectExpired(sess.TurnCount, res.EffectID))
+		}
+		if note := succumb(sess, "being "+res.EffectID); note != "" {
+			return []string{note}
+		}
+	}
+	return nil
+}
+
+// succumb marks the player dead if a start-of-turn hazard (cause, e.g. "being poisoned")
+// took their last hit point, ending any fight as a defeat. It returns a note for the
+// narrator, or "" if the player is still alive (or was already dead).
+func succumb(sess *session.GameSession, cause string) string {
+	if sess.Player.HP > 0 || sess.Flags[session.FlagDead] {
+		return ""
+	}
+	sess.SetFlag(session.FlagDead, true)
+	if sess.Combat != nil {
+		sess.Combat = nil
+		sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Combat ended: %s", combat.OutcomeDefeat))
+		sess.Emit(events.NewCombatEnded(sess.TurnCount, string(combat.OutcomeDefeat)))
+	}
+	return fmt.Sprintf("The player character has succumbed to %s (0 HP). Narrate their death; do not let the story continue as if they survived.", cause)
+}
+
+// effectsContext lists the player's active effects for the prompt.
+func effectsContext(player *character.Character) []string {
+	lines := make([]string, 0, len(player.Effects))
+	for _, e := range player.Effects {
+		line := e.ID
+		if e.Remaining > 0 {
+			line += fmt.Sprintf(" (%d turn(s) left)", e.Remaining)
+		}
+		if e.Description != "" {
+			line += ": " + e.Description
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package narrative
+
+import (
+	"context"
+	"fmt"
+	"llmrpg/internal/clock"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// endingState extracts what ending conditions are checked against.
+func endingState(sess *session.GameSession) world.EndingState {
+	return world.EndingState{
+		LocationID:      sess.CurrentLocationID,
+		CompletedQuests: sess.CompletedQuests,
+		Flags:           sess.Flags,
+		Dead:            sess.Flags[session.FlagDead],
+		Karma:           sess.Karma,
+	}
+}
+
+// endSession generates the epilogue for ending, marks the session completed and emits
+// a sessionEnded event. The session is completed even if the LLM call fails; a short
+// fallback epilogue is used instead.
+func (ne *NarrativeEngine) endSession(ctx context.Context, sess *session.GameSession, ending *world.Ending) {
+	fmt.Printf("NarrativeEngine: Session %s reached ending '%s', generating epilogue...\n", sess.ID, ending.ID)
+	epilogue, err := ne.generateEpilogue(ctx, sess, ending)
+	if err != nil {
+		fmt.Printf("Warning: Epilogue generation failed for session '%s': %v\n", sess.ID, err)
+		epilogue = fmt.Sprintf("%s. Here the story of %s comes to an end.", ending.Name, sess.Player.Name)
+	}
+
+	sess.Status = session.StatusCompleted
+	sess.Ending = &session.EndingRecord{
+		ID:       ending.ID,
+		Name:     ending.Name,
+		Epilogue: epilogue,
+		Turn:     sess.TurnCount,
+		EndedAt:  clock.Now(),
+		Recap:    sess.Stats.Snapshot(),
+	}
+	sess.Record(history.ActorNarrator, history.TypeNarration, epilogue)
+	sess.Emit(events.NewSessionEnded(sess.TurnCount, ending.ID, ending.Name))
+}
+
+// generateEpilogue asks the LLM for a closing narrative summarizing the whole playthrough.
+func (ne *NarrativeEngine) generateEpilogue(ctx context.Context, sess *session.GameSession, ending *world.Ending) (string, error) {
+	promptData, err := ne.buildPromptContext(sess)
+	if err != nil {
+		return "", err
+	}
+	promptData.SessionContext.RecentActions = sess.Memory // The whole (retained) playthrough, not just the recent window
+	promptData.PlayerInput = "(The story has ended.)"
+	promptData.SystemNotes = append(promptData.SystemNotes,
+		fmt.Sprintf("The story has reached its ending: '%s'. Write a closing epilogue of 2-4 paragraphs that summarizes the playthrough from the recent actions and gives it a fitting conclusion. Return no actions and no suggestions.", ending.Name))
+	if ending.Epilogue != "" {
+		promptData.SystemNotes = append(promptData.SystemNotes, ending.Epilogue)
+	}
+
+	response, err := ne.generate(ctx, sess, "epilogue", *promptData)
+	if err != nil {
+		return "", err
+	}
+	sess.Stats.AddUsage(response.Usage)
+	response = ne.moderateResponse(ctx, sess, promptData, response)
+	if response.Narrative == moderatedNarrative {
+		return "", fmt.Errorf("epilogue withheld by content moderation") // endSession falls back to a short epilogue
+	}
+	if response.Narrative == "" {
+		return "", fmt.Errorf("LLM returned an empty epilogue")
+	}
+	return response.Narrative, nil
+}
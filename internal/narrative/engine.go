@@ -2,10 +2,23 @@ package narrative
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"llmrpg/internal/llm"     // Adapter interface and data structures
-	"llmrpg/internal/session" // Session manager and data structure
-	"llmrpg/internal/world"   // World system interface
+	"llmrpg/internal/analytics" // Anonymized gameplay aggregates
+	"llmrpg/internal/clock"     // Engine time source (fake in deterministic mode)
+	"llmrpg/internal/events"    // Turn event bus
+	"llmrpg/internal/features"  // Experimental behavior flags
+	"llmrpg/internal/history"   // Structured turn records
+	"llmrpg/internal/llm"       // Adapter interface and data structures
+	"llmrpg/internal/lore"      // Lore retrieval (RAG)
+	"llmrpg/internal/memory"    // Long-term session memory search
+	"llmrpg/internal/rating"    // Content ratings (E/T/M)
+	"llmrpg/internal/session"   // Session manager and data structure
+	"llmrpg/internal/tracing"   // Turn processing spans
+	"llmrpg/internal/world"     // World system interface
+	"runtime/debug"
+	"strings"
+	"sync"
 
 	// "llmrpg/character" // Character struct (used via session)
 	"time"
@@ -16,8 +29,44 @@ type NarrativeEngine struct {
 	WorldSystem    world.WorldSystem
 	LLMAdapter     llm.Adapter
 	ActionExecutor ActionExecutor
-	SessionManager session.Manager // Added dependency to fetch/update sessions
-	SystemPrompt   string          // Store the base system prompt
+	SessionManager session.Manager              // Added dependency to fetch/update sessions
+	SystemPrompt   string                       // Store the base system prompt
+	Examples       []llm.FewShotExample         // Optional few-shot exchanges for the current world
+	ActionPolicy   *ActionPolicy                // Which action types are legal where (nil = all known types)
+	LoreRetriever  *lore.Retriever              // Optional; injects relevant lore into each prompt
+	LoreTopK       int                          // Number of lore chunks to retrieve per turn
+	Lore           *lore.Library                // Optional; lore documents whose tags the scene mentions are included in each prompt
+	LoreMentions   int                          // Most tagged lore documents to include per turn (0 = DefaultLoreMentions)
+	MemorySearcher *memory.Searcher             // Optional; recalls relevant past events into each prompt
+	MemoryRecall   int                          // Number of past events to recall per turn
+	Autosaver      *session.Autosaver           // Optional; persists sessions per the autosave policy
+	Scenarios      map[string]*world.Scenario   // Guided openings sessions can play through, by ID
+	Endings        map[string]*world.Ending     // Ways the story can end; checked after every turn
+	NPCs           map[string]*world.NPC        // Named characters; their schedules decide who is where in prompts
+	WorldEvents    map[string]*world.WorldEvent // Scheduled and random world events, run against each session's clock
+	Items          map[string]*world.Item       // Items the world defines, for inventory names in prompts
+	Bestiary       map[string]*world.Creature   // Creatures encounters may use, listed in prompts
+	Abilities      map[string]*world.Ability    // Abilities the world defines, for the player's ability list in prompts
+	Perks          map[string]*world.Perk       // Perk catalog, for level-up choices and the player's perks in prompts
+	Survival       *world.SurvivalRules         // Optional survival ruleset, resolved at the start of each turn
+	Analytics      *analytics.Recorder          // Optional; records anonymized per-session aggregates after every turn
+	Events         *events.Bus                  // Optional; every turn's events are published here (e.g. for notifications)
+	Features       *features.Flags              // Experimental behavior flags (nil = defaults)
+	Guard          *InjectionGuard              // Optional; scores player input for prompt injection attempts
+	Moderation     *ModerationPolicy            // Optional; moderates player input and generated narrative
+	Triage         *TriagePolicy                // Optional; answers trivial inputs without the narrator
+	Debug          bool                         // Report each turn's model calls (tokens, latency, model, finish reason) in its response
+	PromptVariants []PromptVariant              // Optional; system prompt experiments, one assigned to each new session (see AssignPromptVariant)
+	HistoryFormat  llm.HistoryFormat            // How recent events are laid out in prompts (empty = llm.HistoryInline)
+	ContentRating  rating.Rating                // World's content rating, for sessions that didn't choose one (empty = rating.Default)
+
+	retainedMu sync.Mutex
+	retained   map[string]*retainedTurn // Starting state of each session's latest turn, so a reported turn can be regenerated
+}
+
+// contentRating returns the rating sess is played at: its own, else the world's.
+func (ne *NarrativeEngine) contentRating(sess *session.GameSession) rating.Rating {
+	return sess.ContentRating.Or(ne.ContentRating.Or(rating.Default))
 }
 
 // NewNarrativeEngine creates a new engine instance with its dependencies.
@@ -44,47 +93,413 @@ func NewNarrativeEngine(ws world.WorldSystem, adapter llm.Adapter, executor Acti
 // ProcessPlayerInput takes player input for a given session and processes one turn.
 // It returns the LLM's response (narrative, suggestions, potentially raw actions)
 // after attempting to execute any valid actions returned by the LLM.
-func (ne *NarrativeEngine) ProcessPlayerInput(ctx context.Context, sessionID string, playerInput string) (*llm.LLMResponse, error) {
+//
+// A panic during the turn is recovered: the session is rolled back to its state
+// before the turn and ErrTurnFailed is returned.
+func (ne *NarrativeEngine) ProcessPlayerInput(ctx context.Context, sessionID string, playerInput string) (response *llm.LLMResponse, err error) {
+	ctx, span := tracing.Start(ctx, "narrative.turn", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("session.id", sessionID)
+	playerInput = SanitizeInput(playerInput)
+
 	// 1. Get current game session
 	currentSession, err := ne.SessionManager.GetSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
 	}
-	// Log player input to session history
-	currentSession.AddRecentAction(fmt.Sprintf("Player: %s", playerInput))
+	if currentSession.Completed() {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, session.ErrSessionCompleted)
+	}
+
+	// Screen the input before the turn touches the session, so a blocked input changes nothing
+	var inputNotes []string
+	if assessment, risky := ne.Guard.check(playerInput); risky {
+		inputNotes = append(inputNotes, injectionNote(assessment))
+		fmt.Printf("NarrativeEngine: Input for session %s flagged as possible prompt injection (score %.2f: %s)\n", sessionID, assessment.Score, strings.Join(assessment.Signals, ", "))
+		span.SetAttr("guard.score", assessment.Score)
+		if ne.Guard.Mode == GuardBlock {
+			return nil, fmt.Errorf("session '%s': %w", sessionID, ErrInputRejected)
+		}
+	}
+	moderationNotes, err := ne.moderateInput(ctx, currentSession, playerInput)
+	if err != nil {
+		return nil, err
+	}
+	inputNotes = append(inputNotes, moderationNotes...)
+
+	response, err = ne.runTurn(ctx, currentSession, playerInput, inputNotes, nil, false)
+	span.RecordError(err)
+	return response, err
+}
+
+// ResolveChallenge reports the frontend's minigame result for the session's pending
+// challenge (success nil = let the server roll it) and runs a turn narrating the outcome.
+func (ne *NarrativeEngine) ResolveChallenge(ctx context.Context, sessionID string, success *bool) (*llm.LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "narrative.challenge", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("session.id", sessionID)
+
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	if currentSession.Completed() {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, session.ErrSessionCompleted)
+	}
+	challenge := currentSession.PendingChallenge
+	if challenge == nil {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, ErrNoPendingChallenge)
+	}
+
+	response, err := ne.runTurn(ctx, currentSession, fmt.Sprintf("[%s attempt]", challenge.Label), nil, success, false)
+	span.RecordError(err)
+	return response, err
+}
+
+// ChoosePerk applies the player's perk choice for a pending level-up outside of a turn
+// and saves the session.
+func (ne *NarrativeEngine) ChoosePerk(sessionID, perkID string) (*session.GameSession, error) {
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	if currentSession.Completed() {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, session.ErrSessionCompleted)
+	}
+	if err := ChoosePerk(currentSession, ne.Perks, perkID); err != nil {
+		return nil, fmt.Errorf("session '%s': %w", sessionID, err)
+	}
+	if err := ne.SessionManager.UpdateSession(currentSession); err != nil {
+		return nil, fmt.Errorf("failed to save session '%s': %w", sessionID, err)
+	}
+	return currentSession, nil
+}
+
+// turnLocker is implemented by session managers that need turns excluded while they
+// work on a session, like InMemorySessionManager when archiving.
+type turnLocker interface {
+	TurnLock(sessionID string) *sync.Mutex
+}
+
+// lockTurn holds the session's turn lock, if the session manager has one, until the
+// returned function is called.
+func (ne *NarrativeEngine) lockTurn(sessionID string) (unlock func()) {
+	tl, ok := ne.SessionManager.(turnLocker)
+	if !ok {
+		return func() {}
+	}
+	lock := tl.TurnLock(sessionID)
+	lock.Lock()
+	return lock.Unlock
+}
+
+// runTurn runs processTurn for currentSession under its turn lock, recovering a panic by
+// rolling the session back to its state before the turn and returning ErrTurnFailed.
+// confirmed applies the session's pending high-impact actions; otherwise the turn drops
+// them. In debug mode the response carries a report of the turn's model calls.
+func (ne *NarrativeEngine) runTurn(ctx context.Context, currentSession *session.GameSession, playerInput string, inputNotes []string, minigame *bool, confirmed bool) (*llm.LLMResponse, error) {
+	defer ne.lockTurn(currentSession.ID)()
+	return ne.runTurnLocked(ctx, currentSession, playerInput, inputNotes, minigame, confirmed)
+}
+
+// runTurnLocked is runTurn for a caller already holding the session's turn lock.
+func (ne *NarrativeEngine) runTurnLocked(ctx context.Context, currentSession *session.GameSession, playerInput string, inputNotes []string, minigame *bool, confirmed bool) (response *llm.LLMResponse, err error) {
+	sessionID := currentSession.ID
+	checkpoint, err := currentSession.Checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Printf("ERROR: Panic during turn for session '%s': %v\n%s", sessionID, p, debug.Stack())
+			if restoreErr := currentSession.Restore(checkpoint); restoreErr != nil {
+				fmt.Printf("ERROR: Failed to roll back session '%s' after panic: %v\n", sessionID, restoreErr)
+			}
+			response, err = nil, fmt.Errorf("session '%s': %w", sessionID, ErrTurnFailed)
+		}
+	}()
+
+	var calls *llm.CallLog
+	started := clock.Now()
+	if ne.Debug {
+		ctx, calls = llm.WithCallLog(ctx)
+	}
+
+	retained := &retainedTurn{checkpoint: checkpoint, input: playerInput, inputNotes: inputNotes, minigame: minigame, confirmed: confirmed}
+	response, err = ne.processTurn(ctx, currentSession, playerInput, inputNotes, minigame, retained)
+	if err == nil {
+		ne.retainTurn(currentSession, retained)
+		if calls != nil {
+			response.Debug = calls.Report(clock.Now().Sub(started))
+		}
+	}
+	return response, err
+}
+
+// ErrTurnFailed is returned when a turn aborted on an internal error (a recovered
+// panic). The session is left as it was before the turn, so the player can retry.
+var ErrTurnFailed = errors.New("turn failed due to an internal error")
+
+// processTurn runs one turn for currentSession; see ProcessPlayerInput. inputNotes are
+// the guard's and moderation's notes about the input, for the narrator. A pending
+// challenge is settled first, with the minigame result if one was reported, and so are
+// pending high-impact actions, as retained.confirmed says. The prompt the turn is
+// narrated from is kept in retained, for rerolling the narration.
+func (ne *NarrativeEngine) processTurn(ctx context.Context, currentSession *session.GameSession, playerInput string, inputNotes []string, minigame *bool, retained *retainedTurn) (*llm.LLMResponse, error) {
+	sessionID := currentSession.ID
+	startLocationID := currentSession.CurrentLocationID
+	effectNotes, pendingErrors := ne.startTurn(ctx, currentSession, playerInput, minigame, retained.confirmed)
+
+	tracing.FromContext(ctx).SetAttr("session.turn", currentSession.TurnCount)
 
 	// 2. Build prompt context from session and world state
+	promptData, err := ne.turnPrompt(ctx, currentSession, playerInput, inputNotes, effectNotes)
+	if err != nil {
+		return nil, err
+	}
+	retained.prompt = *promptData
+
+	// 3. Answer a trivial input mechanically if triage allows, else narrate the turn:
+	// call the LLM and execute the actions it returns
+	var finalResponse *llm.LLMResponse
+	if ne.triageable(currentSession, len(inputNotes)+len(effectNotes)) {
+		finalResponse = ne.triageTurn(ctx, currentSession, promptData)
+	}
+	if finalResponse == nil {
+		if finalResponse, err = ne.narrateTurn(ctx, currentSession, playerInput, promptData); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, execErr := range pendingErrors {
+		finalResponse.Warnings = append(finalResponse.Warnings, execErr.Error())
+	}
+	finalResponse.Pending = pendingLLMActions(currentSession.PendingActions)
+
+	// Complete scenario beats the turn satisfied (emits questUpdated events)
+	advanceScenario(ne.Scenarios, currentSession)
+
+	// Keep the narration in long-term memory so players (and the engine) can recall it later,
+	// and remember the names it introduced
+	currentSession.Record(history.ActorNarrator, history.TypeNarration, finalResponse.Narrative)
+	ne.recordNamedEntities(currentSession, finalResponse.Narrative)
+
+	// If the turn reached an ending, close the story with an epilogue
+	if ending := world.FirstMetEnding(ne.Endings, endingState(currentSession)); ending != nil {
+		ne.endSession(ctx, currentSession, ending)
+		finalResponse.Suggestions = nil // No further actions are accepted
+	}
+
+	// Surface this turn's dice rolls and state-change events so frontends can animate them
+	finalResponse.Rolls = currentSession.LastTurnRolls
+	finalResponse.Events = currentSession.LastTurnEvents
+	finalResponse.CombatLog = currentSession.LastTurnCombat
+	finalResponse.Results = currentSession.LastTurnResults
+
+	// 4. Update session (e.g., LastActive time - already done by GetSession, but explicit save might go here later)
+	err = ne.SessionManager.UpdateSession(currentSession)
+	if err != nil {
+		// Log this error, but probably don't fail the whole turn?
+		fmt.Printf("Warning: Failed to update session '%s' after turn: %v\n", sessionID, err)
+	}
+
+	// Autosave if the policy calls for it this turn
+	if ne.Autosaver != nil {
+		var events []session.AutosaveEvent
+		if currentSession.CurrentLocationID != startLocationID {
+			events = append(events, session.EventLocationChange)
+		}
+		if currentSession.Completed() {
+			events = append(events, session.EventSessionEnd)
+		}
+		if len(currentSession.LastTurnCombat) > 0 && currentSession.Combat == nil {
+			events = append(events, session.EventCombatEnd)
+		}
+		if _, saveErr := ne.Autosaver.AfterTurn(currentSession, events); saveErr != nil {
+			fmt.Printf("Warning: Autosave failed for session '%s': %v\n", sessionID, saveErr)
+		}
+	}
+
+	if ne.Analytics != nil {
+		if recordErr := ne.Analytics.Record(currentSession); recordErr != nil {
+			fmt.Printf("Warning: Analytics failed for session '%s': %v\n", sessionID, recordErr)
+		}
+	}
+	for _, event := range currentSession.LastTurnEvents {
+		ne.Events.Publish(events.Published{SessionID: sessionID, PlayerID: currentSession.PlayerID, Event: event})
+	}
+
+	// 5. Return the final response (potentially modified narrative)
+	return finalResponse, nil
+}
+
+// startTurn opens a turn for currentSession: it advances the turn counter, resolves
+// survival, timed effects and world events, settles a pending challenge and pending
+// high-impact actions, and records the input. It returns notes on what happened, for
+// the narrator, and the errors from pending actions.
+func (ne *NarrativeEngine) startTurn(ctx context.Context, currentSession *session.GameSession, playerInput string, minigame *bool, confirmed bool) (effectNotes []string, pendingErrors []error) {
+	// Log player input to session history
+	currentSession.TurnCount++
+	currentSession.Stats.TurnsTaken++
+	currentSession.LastTurnRolls = nil   // Rolls are per turn
+	currentSession.LastTurnEvents = nil  // So are client events
+	currentSession.LastTurnCombat = nil  // And combat steps
+	currentSession.LastTurnResults = nil // And action results
+	startLocationID := currentSession.CurrentLocationID
+	startLocation, _ := ne.WorldSystem.GetLocation(startLocationID)
+	effectNotes = resolveSurvival(currentSession, ne.Survival, startLocation) // Supplies run down
+	effectNotes = append(effectNotes, resolveEffects(currentSession)...)      // Poison ticks, timed effects expire
+	effectNotes = append(effectNotes, ne.runWorldEvents(currentSession)...)   // Caravans arrive, festivals end
+	if currentSession.PendingChallenge != nil {
+		effectNotes = append(effectNotes, ne.settleChallenge(currentSession, minigame)...)
+	}
+	if len(currentSession.PendingActions) > 0 {
+		var pendingNotes []string
+		pendingNotes, pendingErrors = ne.settlePendingActions(ctx, currentSession, confirmed)
+		effectNotes = append(effectNotes, pendingNotes...)
+	}
+	currentSession.Record(history.ActorPlayer, history.TypeInput, playerInput)
+	return effectNotes, pendingErrors
+}
+
+// turnPrompt assembles the narrator's context for playerInput from the session and
+// world state: the notes on the input and on what the turn's start resolved, the
+// active scenario beat, recalled memories, established names and lore.
+func (ne *NarrativeEngine) turnPrompt(ctx context.Context, currentSession *session.GameSession, playerInput string, inputNotes, effectNotes []string) (*llm.PromptData, error) {
+	sessionID := currentSession.ID
+	promptCtx, promptSpan := tracing.Start(ctx, "narrative.build_prompt", tracing.KindInternal)
 	promptData, err := ne.buildPromptContext(currentSession)
 	if err != nil {
+		promptSpan.RecordError(err)
+		promptSpan.End()
 		return nil, fmt.Errorf("failed to build prompt context for session '%s': %w", sessionID, err)
 	}
 	promptData.PlayerInput = playerInput // Add the current input
 
-	// 3. Call LLM Adapter
+	promptData.SystemNotes = append(promptData.SystemNotes, inputNotes...)
+	promptData.SystemNotes = append(promptData.SystemNotes, effectNotes...)
+	if currentSession.ConfirmActions {
+		promptData.SystemNotes = append(promptData.SystemNotes, confirmationNote)
+	}
+
+	// Guided openings: tell the narrator what the active beat requires
+	if scenario, beat := activeBeat(ne.Scenarios, currentSession); beat != nil {
+		promptData.SystemNotes = append(promptData.SystemNotes, scenarioNotes(scenario, beat)...)
+	}
+
+	// Recall older events relevant to this input (the current turn is already in RecentActions)
+	if ne.MemorySearcher != nil && ne.MemoryRecall > 0 && ne.Features.Enabled(features.MemoryRecall) {
+		past := currentSession.Memory
+		for len(past) > 0 && past[len(past)-1].Turn == currentSession.TurnCount {
+			past = past[:len(past)-1]
+		}
+		recalled, memErr := ne.MemorySearcher.Search(promptCtx, past, playerInput, ne.MemoryRecall)
+		if memErr != nil {
+			fmt.Printf("Warning: Memory recall failed for session '%s': %v\n", sessionID, memErr)
+		}
+		for _, r := range recalled {
+			promptData.SessionContext.RelevantMemories = append(promptData.SessionContext.RelevantMemories, r.TurnRecord)
+		}
+	}
+
+	// Names invented in earlier turns, so the narrator keeps them consistent
+	promptData.SessionContext.Entities = entitiesContext(currentSession, playerInput)
+
+	// Include lore the scene mentions by tag, then retrieve lore relevant to what the player just did
+	if ne.Features.Enabled(features.LoreContext) {
+		included := ne.mentionedLore(currentSession, playerInput, promptData)
+		if ne.LoreRetriever != nil {
+			chunks, loreErr := ne.LoreRetriever.Retrieve(promptCtx, playerInput, ne.LoreTopK)
+			if loreErr != nil {
+				// Lore is a nice-to-have; narrate without it rather than failing the turn.
+				fmt.Printf("Warning: Lore retrieval failed for session '%s': %v\n", sessionID, loreErr)
+			}
+			for _, chunk := range chunks {
+				if !included[chunk.DocumentID] {
+					promptData.LoreContext = append(promptData.LoreContext, fmt.Sprintf("%s: %s", chunk.Title, chunk.Text))
+				}
+			}
+		}
+	}
+	promptSpan.End()
+	return promptData, nil
+}
+
+// narrateTurn asks the narrator for the turn's response to playerInput and executes
+// the actions it returns, re-narrating or continuing the narration as their outcomes
+// require. Execution problems are reported in the response's warnings.
+func (ne *NarrativeEngine) narrateTurn(ctx context.Context, currentSession *session.GameSession, playerInput string, promptData *llm.PromptData) (*llm.LLMResponse, error) {
+	sessionID := currentSession.ID
+	// Call LLM Adapter
 	fmt.Printf("NarrativeEngine: Calling LLM adapter for session %s...\n", sessionID)
-	llmResponse, err := ne.LLMAdapter.GenerateResponse(ctx, ne.SystemPrompt, *promptData)
+	llmResponse, err := ne.generate(ctx, currentSession, "turn", *promptData)
 	if err != nil {
 		// LLM call itself failed (network, API error, etc.)
 		// TODO: Consider fallback logic? Generate a default "confused" response?
 		return nil, fmt.Errorf("LLM adapter failed for session '%s': %w", sessionID, err)
 	}
-	// Log LLM narrative to session history? Be mindful of length.
-	// currentSession.AddRecentAction(fmt.Sprintf("Narrator: %s", llmResponse.Narrative))
+	currentSession.Stats.AddUsage(llmResponse.Usage)
+	llmResponse = ne.moderateResponse(ctx, currentSession, promptData, llmResponse)
+	// LLM narrative is recorded after actions run (see processTurn); the policy decides
+	// whether it appears in the recent window, since narration is long.
 
-	// 4. Execute Actions returned by LLM
+	// Execute Actions returned by LLM
 	finalResponse := llmResponse // Start with the direct LLM response
 	if len(llmResponse.Actions) > 0 {
 		fmt.Printf("NarrativeEngine: Executing %d action(s) for session %s...\n", len(llmResponse.Actions), sessionID)
-		executionErrors := ne.ActionExecutor.ExecuteActions(llmResponse.Actions, currentSession)
+		eventsBefore, combatBefore := len(currentSession.LastTurnEvents), len(currentSession.LastTurnCombat)
+		// A re-narrated response replaces this one, so keep the state to undo its actions with
+		var beforeActions *session.Checkpoint
+		if ne.Features.Enabled(features.Renarration) {
+			if beforeActions, err = currentSession.Checkpoint(); err != nil {
+				fmt.Printf("Warning: Re-narration unavailable for session '%s' this turn: %v\n", sessionID, err)
+			}
+		}
+		executionErrors := ne.executeActions(ctx, llmResponse.Actions, currentSession)
+
+		// Out-of-scope actions: ask the LLM once to re-narrate the turn without them.
+		if rejected := rejectionNotes(executionErrors); len(rejected) > 0 && beforeActions != nil {
+			fmt.Printf("NarrativeEngine: %d action(s) not allowed for session %s, requesting re-narration...\n", len(rejected), sessionID)
+			renarrated, renarrateErrors, rErr := ne.renarrate(ctx, currentSession, promptData, rejected, beforeActions)
+			if rErr != nil {
+				fmt.Printf("Warning: Re-narration failed for session '%s': %v\n", sessionID, rErr)
+			} else {
+				finalResponse = renarrated
+				executionErrors = renarrateErrors
+			}
+		}
+
+		// Challenges the server rolled and combat rounds: the narration stopped at the attempt, so continue it with the outcome
+		notes := challengeNotes(currentSession.LastTurnEvents[eventsBefore:])
+		if ne.Features.Enabled(features.RoundNarration) {
+			notes = append(notes, roundNotes(currentSession.LastTurnCombat[combatBefore:])...)
+		}
+		if len(notes) > 0 {
+			resumeErrors, rErr := ne.resumeNarration(ctx, currentSession, playerInput, finalResponse, notes)
+			if rErr != nil {
+				fmt.Printf("Warning: Resuming narration failed for session '%s': %v\n", sessionID, rErr)
+			}
+			executionErrors = append(executionErrors, resumeErrors...)
+		}
 
 		if len(executionErrors) > 0 {
-			// How to handle action execution errors?
-			// - Log them (already done by executor)
-			// - Modify the narrative to inform the player?
-			// - Return the errors alongside the response?
-			// For now, let's prepend an error message to the narrative.
-			errorNarrative := fmt.Sprintf("[System Error processing actions: %d error(s) occurred. The story continues...]\n\n", len(executionErrors))
-			finalResponse.Narrative = errorNarrative + finalResponse.Narrative
+			// Rewrite the narration so the failures happen in the story; if that's off or
+			// fails, prepend an error message to the narrative.
+			rewritten := false
+			if ne.Features.Enabled(features.FailureNarration) {
+				if rErr := ne.narrateFailures(ctx, currentSession, playerInput, finalResponse, executionErrors); rErr != nil {
+					fmt.Printf("Warning: Rewriting narration for failed actions failed for session '%s': %v\n", sessionID, rErr)
+				} else {
+					rewritten = true
+				}
+			}
+			if !rewritten {
+				errorNarrative := fmt.Sprintf("[System Error processing actions: %d error(s) occurred. The story continues...]\n\n", len(executionErrors))
+				finalResponse.Narrative = errorNarrative + finalResponse.Narrative
+			}
+			for _, execErr := range executionErrors {
+				finalResponse.Warnings = append(finalResponse.Warnings, execErr.Error())
+			}
 
 			// Optionally, clear the actions from the response if they failed significantly?
 			// Or maybe filter out only the failed actions? For simplicity, keep original actions for now.
@@ -94,16 +509,120 @@ func (ne *NarrativeEngine) ProcessPlayerInput(ctx context.Context, sessionID str
 			fmt.Printf("NarrativeEngine: All %d action(s) executed successfully for session %s.\n", len(llmResponse.Actions), sessionID)
 		}
 	}
+	return finalResponse, nil
+}
 
-	// 5. Update session (e.g., LastActive time - already done by GetSession, but explicit save might go here later)
-	err = ne.SessionManager.UpdateSession(currentSession)
+// rejectionNotes explains each out-of-scope action (not allowed here, or blocked by the
+// active scenario beat, a status effect, the stealth state or a route's mount) in a list of execution errors, for re-narration.
+func rejectionNotes(executionErrors []error) []string {
+	var notes []string
+	for _, err := range executionErrors {
+		var notAllowed *ActionNotAllowedError
+		var restricted *ScenarioRestrictionError
+		var effectBlocked *EffectRestrictionError
+		var unstealthy *StealthRequiredError
+		var routeBlocked *RouteBlockedError
+		switch {
+		case errors.As(err, &notAllowed):
+			notes = append(notes, fmt.Sprintf("Your previous response used the action '%s', which is not allowed here. Re-narrate the outcome without it, using only the allowed actions.", notAllowed.ActionType))
+		case errors.As(err, &restricted):
+			notes = append(notes, fmt.Sprintf("Your previous response moved the player to '%s', but the current objective keeps them within: %s. Re-narrate the outcome without that move.", restricted.TargetID, strings.Join(restricted.Allowed, ", ")))
+		case errors.As(err, &unstealthy):
+			notes = append(notes, fmt.Sprintf("Your previous response used the action '%s', but the player is %s, not hidden. Re-narrate the outcome without it.", unstealthy.ActionType, stealthLabel(unstealthy.State)))
+		case errors.As(err, &routeBlocked):
+			notes = append(notes, fmt.Sprintf("Your previous response moved the player to '%s', but that route requires a %s and they aren't riding one. Re-narrate the outcome without that move.", routeBlocked.TargetID, routeBlocked.Requires))
+		case errors.As(err, &effectBlocked):
+			notes = append(notes, fmt.Sprintf("Your previous response moved the player to '%s', but they are %s and can't travel. Re-narrate the outcome without that move.", effectBlocked.TargetID, effectBlocked.EffectID))
+		}
+	}
+	return notes
+}
+
+// renarrate re-prompts the LLM after some of its actions were rejected as out of scope,
+// rolls the session back to beforeActions (undoing the first response's allowed actions)
+// and executes the actions from the new response. If the LLM call fails, the session is
+// left as the first response made it.
+func (ne *NarrativeEngine) renarrate(ctx context.Context, currentSession *session.GameSession, promptData *llm.PromptData, rejectionNotes []string, beforeActions *session.Checkpoint) (*llm.LLMResponse, []error, error) {
+	retryPrompt := *promptData
+	retryPrompt.SystemNotes = append([]string{}, promptData.SystemNotes...)
+	retryPrompt.SystemNotes = append(retryPrompt.SystemNotes, rejectionNotes...)
+
+	response, err := ne.generate(ctx, currentSession, "renarrate", retryPrompt)
 	if err != nil {
-		// Log this error, but probably don't fail the whole turn?
-		fmt.Printf("Warning: Failed to update session '%s' after turn: %v\n", sessionID, err)
+		return nil, nil, err
+	}
+	if err := currentSession.Restore(beforeActions); err != nil {
+		return nil, nil, err
 	}
+	currentSession.Stats.AddUsage(response.Usage)
+	response = ne.moderateResponse(ctx, currentSession, &retryPrompt, response)
+	var executionErrors []error
+	if len(response.Actions) > 0 {
+		executionErrors = ne.executeActions(ctx, response.Actions, currentSession)
+	}
+	return response, executionErrors, nil
+}
 
-	// 6. Return the final response (potentially modified narrative)
-	return finalResponse, nil
+// settleChallenge resolves the session's pending challenge at the start of a turn, with
+// the frontend's minigame result or, if there is none, by rolling it. It returns the
+// note telling the narrator the outcome.
+func (ne *NarrativeEngine) settleChallenge(sess *session.GameSession, minigame *bool) []string {
+	executor, ok := ne.ActionExecutor.(ChallengeExecutor)
+	if !ok {
+		sess.PendingChallenge = nil // Nothing can resolve it
+		return nil
+	}
+	note, err := executor.ResolveChallenge(sess, minigame)
+	if err != nil {
+		fmt.Printf("Warning: Failed to resolve pending challenge for session '%s': %v\n", sess.ID, err)
+		return nil
+	}
+	return []string{note}
+}
+
+// resumeNarration continues response, whose narration stopped at challenges the server
+// rolled or a combat round: the LLM is told the outcomes (notes), and its continuation is
+// appended. It returns errors from the continuation's actions.
+func (ne *NarrativeEngine) resumeNarration(ctx context.Context, currentSession *session.GameSession, playerInput string, response *llm.LLMResponse, notes []string) ([]error, error) {
+	promptData, err := ne.buildPromptContext(currentSession) // State changed since the turn's prompt
+	if err != nil {
+		return nil, err
+	}
+	promptData.PlayerInput = playerInput
+	promptData.SystemNotes = append(promptData.SystemNotes, notes...)
+	promptData.SystemNotes = append(promptData.SystemNotes, fmt.Sprintf("Your narration so far this turn was: %q. Continue it from the outcome above without repeating it.", response.Narrative))
+
+	continuation, err := ne.generate(ctx, currentSession, "resume", *promptData)
+	if err != nil {
+		return nil, err
+	}
+	currentSession.Stats.AddUsage(continuation.Usage)
+	continuation = ne.moderateResponse(ctx, currentSession, promptData, continuation)
+	response.Narrative += "\n\n" + continuation.Narrative
+	response.Suggestions = continuation.Suggestions
+	var actions []llm.LLMAction
+	for _, action := range continuation.Actions {
+		if !resolvedMove(ActionType(action.Type), currentSession) { // The attempt or round was already resolved; don't roll it again
+			actions = append(actions, action)
+		}
+	}
+	response.Actions = append(response.Actions, actions...)
+	if len(actions) == 0 {
+		return nil, nil
+	}
+	return ne.executeActions(ctx, actions, currentSession), nil
+}
+
+// resolvedMove reports whether a continuation's action would repeat a move the turn
+// already resolved: a challenge, an ability, or (while the fight goes on) another combat round.
+func resolvedMove(actionType ActionType, sess *session.GameSession) bool {
+	switch actionType {
+	case Challenge, Attack, Defend, Flee, SneakAttack, UseAbility:
+		return true
+	case UseItem:
+		return sess.Combat != nil
+	}
+	return false
 }
 
 // buildPromptContext gathers data from the session and world to create the LLM prompt data.
@@ -115,7 +634,20 @@ func (ne *NarrativeEngine) buildPromptContext(currentSession *session.GameSessio
 		Class:  currentSession.Player.Class,
 		Origin: currentSession.Player.Origin,
 		Level:  currentSession.Player.Level,
-		// Add inventory later
+		HP:     currentSession.Player.HP,
+		MaxHP:  currentSession.Player.MaxHP,
+	}
+	playerCtx.Inventory = inventoryContext(currentSession, ne.Items)
+	playerCtx.Effects = effectsContext(currentSession.Player)
+	playerCtx.Survival = survivalContext(currentSession, ne.Survival)
+	playerCtx.Stealth = stealthContext(currentSession.Stealth)
+	playerCtx.Mount = mountContext(currentSession, ne.Items)
+	playerCtx.XP, playerCtx.NextLevelXP = currentSession.Player.XP, XPForLevel(currentSession.Player.Level)
+	playerCtx.Perks = perksContext(currentSession.Player, ne.Perks)
+	playerCtx.Karma = karmaContext(currentSession)
+	if len(currentSession.Player.Abilities) > 0 {
+		playerCtx.MP, playerCtx.MaxMP = currentSession.Player.MP, currentSession.Player.MaxMP
+		playerCtx.Abilities = abilitiesContext(currentSession, ne.Abilities)
 	}
 
 	// Location Context
@@ -131,6 +663,7 @@ func (ne *NarrativeEngine) buildPromptContext(currentSession *session.GameSessio
 		fmt.Printf("Warning: Failed to get adjacent locations for '%s': %v\n", currentSession.CurrentLocationID, err)
 		adjacentLocNodes = []*world.LocationNode{} // Send empty slice
 	}
+	adjacentLocNodes = append(adjacentLocNodes, ne.openedExits(currentSession, currentSession.CurrentLocationID)...)
 
 	adjLocIDs := make([]string, 0, len(adjacentLocNodes))
 	adjLocNames := make([]string, 0, len(adjacentLocNodes))
@@ -139,7 +672,11 @@ func (ne *NarrativeEngine) buildPromptContext(currentSession *session.GameSessio
 			adjLocIDs = append(adjLocIDs, node.ID)
 			// Important change here: Use ID for name to ensure consistency
 			// Format: "location_id (Human Readable Name)"
-			adjLocNames = append(adjLocNames, fmt.Sprintf("%s (%s)", node.ID, node.Name))
+			name := fmt.Sprintf("%s (%s%s)", node.ID, node.Name, routeContext(currentLoc.Route(node.ID)))
+			if ev := exitClosedBy(ne.WorldEvents, currentSession, currentLoc.ID, node.ID); ev != nil {
+				name += fmt.Sprintf(" [closed: %s]", ev.Name)
+			}
+			adjLocNames = append(adjLocNames, name)
 		}
 	}
 
@@ -149,18 +686,32 @@ func (ne *NarrativeEngine) buildPromptContext(currentSession *session.GameSessio
 		AdjacentLocationIDs:   adjLocIDs,
 		AdjacentLocationNames: adjLocNames,
 		CurrentThemeID:        currentLoc.ThemeID,
+		Containers:            containersContext(currentSession, currentLoc, ne.Items),
+		Interactions:          interactionsContext(currentSession, currentLoc),
+	}
+	locCtx.People, locCtx.Away = npcsContext(ne.NPCs, currentLoc.ID, currentSession.HourOfDay())
+	locCtx.Events = worldEventsContext(ne.WorldEvents, currentSession, currentLoc.ID)
+	for _, a := range ne.ActionPolicy.Allowed(currentLoc) {
+		locCtx.AllowedActions = append(locCtx.AllowedActions, string(a))
 	}
 
 	// Session Context
 	sessionCtx := llm.SessionContextData{
-		TimeElapsed:   time.Since(currentSession.CreatedAt).Round(time.Second).String(),
+		TimeElapsed:   clock.Now().Sub(currentSession.CreatedAt).Round(time.Second).String(),
+		GameTime:      currentSession.Clock(),
 		RecentActions: currentSession.RecentActions, // Get limited history
+		Rumors:        rumorsContext(ne.WorldSystem, currentSession),
 	}
 
 	promptData := &llm.PromptData{
 		PlayerContext:   playerCtx,
 		LocationContext: locCtx,
 		SessionContext:  sessionCtx,
+		Examples:        ne.Examples,
+		ContentRating:   ne.contentRating(currentSession),
+		Combat:          combatContext(currentSession, ne.Bestiary),
+		Bestiary:        bestiaryContext(ne.Bestiary),
+		HistoryFormat:   ne.HistoryFormat,
 		// PlayerInput is added by the caller (ProcessPlayerInput)
 	}
 
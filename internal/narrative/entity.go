@@ -0,0 +1,217 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EntityKinds are the kinds 'registerEntity' accepts.
+var EntityKinds = []string{"npc", "place", "shop", "item", "faction", "creature", "other"}
+
+// Entity registry tuning.
+const (
+	maxEntityName        = 80  // Longest entity name, in characters
+	maxEntityDescription = 300 // Longest declared description, in characters
+	maxExcerpt           = 160 // Longest narration excerpt kept for an extracted name
+	maxExtractedPerTurn  = 5   // Most names extracted from one narration
+	maxPromptEntities    = 20  // Most entities listed in a prompt
+)
+
+// handleRegisterEntity processes the 'registerEntity' action: records 'name' with its
+// 'kind' and established 'description' so later turns keep it consistent. Registering
+// an NPC for the first time counts as meeting them.
+func (e *SimpleActionExecutor) handleRegisterEntity(action llm.LLMAction, currentSession *session.GameSession) error {
+	name, _ := action.Data["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxEntityName {
+		return fmt.Errorf("action data field 'name' must be a non-empty string of at most %d characters", maxEntityName)
+	}
+	kind, _ := action.Data["kind"].(string)
+	if !slices.Contains(EntityKinds, kind) {
+		return fmt.Errorf("action data field 'kind' must be one of: %s", strings.Join(EntityKinds, ", "))
+	}
+	description, _ := action.Data["description"].(string)
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxEntityDescription {
+		return fmt.Errorf("action data field 'description' must be at most %d characters", maxEntityDescription)
+	}
+
+	isNew := currentSession.RegisterEntity(session.Entity{
+		Name:        name,
+		Kind:        kind,
+		Description: description,
+		LocationID:  currentSession.CurrentLocationID,
+		Turn:        currentSession.TurnCount,
+	})
+	fmt.Printf("Executor: Registered %s '%s'\n", kind, name)
+	if isNew {
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Introduced %s (%s).", name, kind))
+	}
+	if kind == "npc" && !slices.Contains(currentSession.Stats.NPCsMet, entityID(name)) {
+		currentSession.Emit(events.NewNPCMet(currentSession.TurnCount, entityID(name), name))
+	}
+	return nil
+}
+
+// entityID derives a stable ID from an entity name, e.g. "old_marta" for "Old Marta".
+func entityID(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "_")
+}
+
+// nameRun matches runs of capitalized words, allowing lower-case "of", "the" and "and"
+// between them ("Order of the Ash").
+var nameRun = regexp.MustCompile(`\p{Lu}[\p{L}\p{N}'’-]*(?:\s+(?:(?:of|the|and)\s+)*\p{Lu}[\p{L}\p{N}'’-]*)*`)
+
+// bracketed matches engine notes such as "[System Error ...]" that aren't narration.
+var bracketed = regexp.MustCompile(`\[[^\]]*\]`)
+
+// notNames are capitalized words that commonly appear mid-sentence without being names.
+var notNames = map[string]bool{"i": true, "i'm": true, "i'll": true, "i've": true, "i'd": true, "ok": true}
+
+// extractNames finds proper names in narration: capitalized words that don't start a
+// sentence or a line of dialogue. Names for which known reports true (world content,
+// the player) are skipped. It returns each name with the sentence it appeared in.
+func extractNames(narration string, known func(name string) bool) [][2]string {
+	narration = bracketed.ReplaceAllString(narration, "")
+	var found [][2]string
+	seen := make(map[string]bool)
+	for _, loc := range nameRun.FindAllStringIndex(narration, -1) {
+		name := narration[loc[0]:loc[1]]
+		if startsSentence(narration[:loc[0]]) {
+			// The first word is capitalized anyway; what follows may still be a name
+			_, rest, ok := strings.Cut(name, " ")
+			if !ok {
+				continue
+			}
+			name = strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(rest, "of "), "the "), " ")
+			if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+				continue
+			}
+		}
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "'s"), "’s")
+		key := strings.ToLower(name)
+		if seen[key] || notNames[key] || known(name) {
+			continue
+		}
+		seen[key] = true
+		found = append(found, [2]string{name, sentenceAround(narration, loc[0], loc[1])})
+	}
+	return found
+}
+
+// startsSentence reports whether a word following before begins a sentence or a line
+// of dialogue.
+func startsSentence(before string) bool {
+	trimmed := strings.TrimRightFunc(before, func(r rune) bool {
+		return unicode.IsSpace(r) && r != '\n'
+	})
+	if trimmed == "" {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(trimmed)
+	return strings.ContainsRune(".!?…:\n\"“'‘(—*_", last)
+}
+
+// sentenceAround returns the sentence containing text[start:end], shortened to maxExcerpt.
+func sentenceAround(text string, start, end int) string {
+	from := strings.LastIndexAny(text[:start], ".!?\n") + 1
+	to := len(text)
+	if i := strings.IndexAny(text[end:], ".!?\n"); i >= 0 {
+		to = end + i + 1
+	}
+	sentence := strings.TrimSpace(text[from:to])
+	if runes := []rune(sentence); len(runes) > maxExcerpt {
+		sentence = string(runes[:maxExcerpt-1]) + "…"
+	}
+	return sentence
+}
+
+// recordNamedEntities registers names the narration introduced that aren't part of the
+// world's content, so later prompts keep them consistent.
+func (ne *NarrativeEngine) recordNamedEntities(sess *session.GameSession, narration string) {
+	known := ne.knownNames(sess)
+	names := extractNames(narration, func(name string) bool {
+		phrase := " " + strings.ToLower(name) + " "
+		return slices.ContainsFunc(known, func(k string) bool { return strings.Contains(k, phrase) })
+	})
+	for _, found := range names[:min(len(names), maxExtractedPerTurn)] {
+		sess.RegisterEntity(session.Entity{Name: found[0], Description: found[1], LocationID: sess.CurrentLocationID, Turn: sess.TurnCount})
+	}
+}
+
+// knownNames lists the names the world already defines (locations, containers, items,
+// creatures, abilities, perks, lore) and the player's, lower-cased and space-padded for
+// whole-word matching.
+func (ne *NarrativeEngine) knownNames(sess *session.GameSession) []string {
+	names := []string{sess.Player.Name}
+	for _, id := range ne.WorldSystem.GetAllLocationIDs() {
+		if loc, err := ne.WorldSystem.GetLocation(id); err == nil {
+			names = append(names, loc.Name)
+			for _, c := range loc.Containers {
+				names = append(names, c.Name)
+			}
+		}
+	}
+	for _, item := range ne.Items {
+		names = append(names, item.Name)
+	}
+	for _, creature := range ne.Bestiary {
+		names = append(names, creature.Name)
+	}
+	for _, ability := range ne.Abilities {
+		names = append(names, ability.Name)
+	}
+	for _, perk := range ne.Perks {
+		names = append(names, perk.Name)
+	}
+	for _, doc := range ne.Lore.Documents("", "") {
+		names = append(names, doc.Title)
+	}
+	for i, name := range names {
+		names[i] = " " + strings.ToLower(name) + " "
+	}
+	return names
+}
+
+// entitiesContext lists registered entities for the prompt, at most maxPromptEntities:
+// those the player's input names and those introduced at the current location first,
+// then the most recently registered.
+func entitiesContext(sess *session.GameSession, playerInput string) []string {
+	input := strings.ToLower(playerInput)
+	ranked := make([]session.Entity, len(sess.Entities))
+	copy(ranked, sess.Entities)
+	slices.Reverse(ranked) // Most recent first
+	rank := func(e session.Entity) int {
+		switch {
+		case strings.Contains(input, strings.ToLower(e.Name)):
+			return 0
+		case e.LocationID == sess.CurrentLocationID:
+			return 1
+		default:
+			return 2
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b session.Entity) int { return rank(a) - rank(b) })
+	lines := make([]string, 0, min(len(ranked), maxPromptEntities))
+	for _, e := range ranked[:min(len(ranked), maxPromptEntities)] {
+		line := e.Name
+		if e.Kind != "" {
+			line += " (" + e.Kind + ")"
+		}
+		if e.Description != "" {
+			line += ": " + e.Description
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
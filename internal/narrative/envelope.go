@@ -0,0 +1,94 @@
+package narrative
+
+import (
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// TurnAPIVersion is the version of the TurnEnvelope shape. Fields may be added
+// within a version; removing or changing fields requires a new version.
+const TurnAPIVersion = "v1"
+
+// TurnEnvelope is the versioned response for a processed turn.
+type TurnEnvelope struct {
+	APIVersion  string                  `json:"apiVersion"`
+	SessionID   string                  `json:"sessionId"`
+	TurnNumber  int                     `json:"turnNumber"`
+	Narrative   string                  `json:"narrative"`
+	Suggestions []string                `json:"suggestions"`
+	Actions     []llm.LLMAction         `json:"actions"`
+	Rolls       []dice.Result           `json:"rolls"`
+	Events      []events.Event          `json:"events"`
+	CombatLog   []combat.LogEntry       `json:"combatLog"`
+	Results     []llm.ActionResult      `json:"results"` // What each action the engine ran or held did, in order
+	State       TurnStateSummary        `json:"state"`
+	Warnings    []string                `json:"warnings"`
+	Pending     []session.PendingAction `json:"pending"`          // High-impact actions waiting for the player's confirmation
+	Ending      *session.EndingRecord   `json:"ending,omitempty"` // Set on the turn that reached an ending, with its epilogue
+	Triage      string                  `json:"triage,omitempty"` // Input kind, when triage answered the turn without the narrator
+	Debug       *llm.TurnDebug          `json:"debug,omitempty"`  // The turn's model calls, when the server runs in debug mode
+}
+
+// TurnStateSummary is the small slice of session state most clients need after a turn.
+// The full state remains available from /state.
+type TurnStateSummary struct {
+	LocationID   string `json:"locationId"`
+	LocationName string `json:"locationName,omitempty"`
+	ThemeID      string `json:"themeId,omitempty"`
+	Ironman      bool   `json:"ironman"`
+	Status       string `json:"status"` // "active", or "completed" once an ending is reached
+	HP           int    `json:"hp"`
+	MaxHP        int    `json:"maxHp"`
+	InCombat     bool   `json:"inCombat"`
+}
+
+// NewTurnEnvelope wraps a turn's response with the session's post-turn state.
+// Slices are never nil so clients can rely on arrays being present.
+func NewTurnEnvelope(sess *session.GameSession, ws world.WorldSystem, resp *llm.LLMResponse) *TurnEnvelope {
+	env := &TurnEnvelope{
+		APIVersion:  TurnAPIVersion,
+		SessionID:   sess.ID,
+		TurnNumber:  sess.TurnCount,
+		Narrative:   resp.Narrative,
+		Suggestions: nonNil(resp.Suggestions),
+		Actions:     nonNil(resp.Actions),
+		Rolls:       nonNil(resp.Rolls),
+		Events:      nonNil(resp.Events),
+		CombatLog:   nonNil(resp.CombatLog),
+		Results:     nonNil(resp.Results),
+		Warnings:    nonNil(resp.Warnings),
+		Pending:     nonNil(sess.PendingActions),
+		Triage:      resp.Triage,
+		Debug:       resp.Debug,
+		State: TurnStateSummary{
+			LocationID: sess.CurrentLocationID,
+			Ironman:    sess.Ironman,
+			HP:         sess.Player.HP,
+			MaxHP:      sess.Player.MaxHP,
+			InCombat:   sess.Combat != nil,
+			Status:     string(session.StatusActive),
+		},
+	}
+	if sess.Completed() {
+		env.State.Status = string(session.StatusCompleted)
+		if sess.Ending != nil && sess.Ending.Turn == sess.TurnCount {
+			env.Ending = sess.Ending
+		}
+	}
+	if loc, err := ws.GetLocation(sess.CurrentLocationID); err == nil {
+		env.State.LocationName = loc.Name
+		env.State.ThemeID = loc.ThemeID
+	}
+	return env
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -3,11 +3,13 @@ package narrative
 import (
 	"errors"
 	"fmt"
+	"llmrpg/internal/dice"    // For skill check rolls
+	"llmrpg/internal/effects" // For status effect rules (travel, roll bonuses)
+	"llmrpg/internal/events"  // For client-facing state change events
+	"llmrpg/internal/history" // For session history records
 	"llmrpg/internal/llm"     // For llm.LLMAction definition
 	"llmrpg/internal/session" // For session.GameSession definition
 	"llmrpg/internal/world"   // For world.WorldSystem interface
-	"strings"
-
 	// Import other system packages (like inventory, character) here when needed
 )
 
@@ -18,11 +20,50 @@ type ActionType string
 const (
 	// MVP Actions
 	UpdateLocation ActionType = "updateLocation"
-	AddItem        ActionType = "addItem"    // To be implemented with InventorySystem
-	RemoveItem     ActionType = "removeItem" // To be implemented with InventorySystem
-	ApplyEffect    ActionType = "applyEffect" // To be implemented with CharacterSystem/EffectSystem
+	AddItem        ActionType = "addItem"     // Gives the player items defined by the world
+	RemoveItem     ActionType = "removeItem"  // Takes items from the player
+	ApplyEffect    ActionType = "applyEffect" // Puts a status effect on the player (see the effects package)
+	SkillCheck     ActionType = "skillCheck"  // Rolls dice (optionally against a DC) via the dice package
+	SetFlag        ActionType = "setFlag"     // Sets or clears a session flag (endings may depend on flags)
+	Search         ActionType = "search"      // Rolls the current location's loot table (once per location)
+	Rest           ActionType = "rest"        // Passes in-story time to recover HP; may be interrupted by an encounter
+	Challenge      ActionType = "challenge"   // Lockpicking, hacking, ...: rolled by a registered resolver or played as a frontend minigame
+
+	RepairItem ActionType = "repairItem" // Restores a worn item's durability, consuming repair materials
+
+	UseAbility   ActionType = "useAbility"   // Player uses a known ability, paying its cost (see world.Ability)
+	LearnAbility ActionType = "learnAbility" // Player learns an ability
+
+	GainXP         ActionType = "gainXp"         // Awards experience for story milestones; may level the player up
+	AdjustKarma    ActionType = "adjustKarma"    // Shifts the player's karma for a moral choice (see session.GameSession.Karma)
+	RegisterEntity ActionType = "registerEntity" // Records an invented NPC, shop, artifact, ... so later turns stay consistent
+	SpreadRumor    ActionType = "spreadRumor"    // Starts word of a notable deed at the location; it spreads over in-story time
+
+	// Container actions (see world.Container)
+	TakeFromContainer ActionType = "takeFromContainer" // Moves items from a container at the location to the inventory
+	PutInContainer    ActionType = "putInContainer"    // Moves items from the inventory into a container at the location
+
+	Interact ActionType = "interact" // Does one of the location's interactions (see world.Interaction)
+
+	// Mount and vehicle actions (items with a mount; see world.Route)
+	AcquireMount ActionType = "acquireMount" // Gives the player a mount or vehicle
+	Board        ActionType = "board"        // Player mounts or boards one they have
+	Dismount     ActionType = "dismount"     // Player continues on foot
+
+	// Combat actions, resolved by the combat package's rules
+	StartCombat ActionType = "startCombat" // Starts a fight against the listed enemies
+	Attack      ActionType = "attack"      // Player attacks an enemy; enemies then take their turn
+	Defend      ActionType = "defend"      // Player guards for the round
+	Flee        ActionType = "flee"        // Player tries to escape the fight
+	UseItem     ActionType = "useItem"     // Player uses a consumable (healing works outside combat too)
 
-	// Add other action types later (e.g., initiateCombat, startDialogue)
+	// Stealth actions, tracked per scene (see session.StealthState)
+	Sneak       ActionType = "sneak"       // Stealth check to become hidden
+	SetStealth  ActionType = "setStealth"  // Narrator raises suspicion (suspected/detected)
+	SneakAttack ActionType = "sneakAttack" // Opening strike from hiding, with advantage and extra damage
+	Pickpocket  ActionType = "pickpocket"  // Sleight-of-hand check to steal an item while unnoticed
+
+	// Add other action types later (e.g., startDialogue)
 )
 
 // ExecutionResult could potentially hold more info about the outcome of an action
@@ -43,7 +84,18 @@ type ActionExecutor interface {
 // SimpleActionExecutor implements the execution logic using injected system dependencies.
 type SimpleActionExecutor struct {
 	WorldSystem world.WorldSystem
-	// Add InventorySystem inventory.System later
+	Policy      *ActionPolicy                // Which action types are legal where (nil = all known types)
+	Roller      *dice.Roller                 // Dice override, e.g. for scripted rolls (nil = each session's seeded dice, see session.GameSession.Roller)
+	Scenarios   map[string]*world.Scenario   // Guided openings; the active beat may restrict travel
+	Items       map[string]*world.Item       // Items the world defines; inventory actions only accept these
+	Bestiary    map[string]*world.Creature   // Creatures encounters are built from (empty = the LLM supplies enemy stats)
+	Abilities   map[string]*world.Ability    // Abilities the world defines; useAbility/learnAbility only accept these
+	Perks       map[string]*world.Perk       // Perk catalog offered on level-up (empty = levels bring no perk choice)
+	LootTables  map[string]*world.LootTable  // Shared loot tables locations may reference for searches
+	Rest        *RestPolicy                  // Rest duration and per-tag safety (nil = DefaultRestPolicy)
+	Survival    *world.SurvivalRules         // Optional survival ruleset (nil = survival mode off)
+	Challenges  map[string]*ChallengeKind    // Registered challenge kinds, by ID (nil = DefaultChallenges)
+	WorldEvents map[string]*world.WorldEvent // World events; active ones may close exits
 	// Add CharacterSystem character.System later
 }
 
@@ -59,7 +111,17 @@ func NewSimpleActionExecutor(ws world.WorldSystem /* Add other systems as params
 	}
 }
 
+// roller returns the dice for sess: the executor's override if set, otherwise the
+// session's seeded dice.
+func (e *SimpleActionExecutor) roller(sess *session.GameSession) *dice.Roller {
+	if e.Roller != nil {
+		return e.Roller
+	}
+	return sess.Roller()
+}
+
 // ExecuteActions processes actions returned by the LLM against the current game session.
+// Each action's result is appended to the session's LastTurnResults.
 func (e *SimpleActionExecutor) ExecuteActions(actions []llm.LLMAction, currentSession *session.GameSession) []error {
 	var executionErrors []error
 
@@ -75,23 +137,14 @@ func (e *SimpleActionExecutor) ExecuteActions(actions []llm.LLMAction, currentSe
 
 		fmt.Printf("Executor: Processing action type '%s'\n", actionType)
 
-		switch actionType {
-		case UpdateLocation:
-			err = e.handleUpdateLocation(action, currentSession)
-		case AddItem:
-			// Placeholder - Requires InventorySystem
-			err = fmt.Errorf("action type '%s' requires InventorySystem (not implemented yet)", actionType)
-			// err = e.handleAddItem(action, currentSession)
-		case RemoveItem:
-			// Placeholder - Requires InventorySystem
-			err = fmt.Errorf("action type '%s' requires InventorySystem (not implemented yet)", actionType)
-			// err = e.handleRemoveItem(action, currentSession)
-		case ApplyEffect:
-			// Placeholder - Requires Character/Effect System
-			err = fmt.Errorf("action type '%s' requires Character/EffectSystem (not implemented yet)", actionType)
-			// err = e.handleApplyEffect(action, currentSession)
-		default:
-			err = fmt.Errorf("unknown or unsupported action type received from LLM: '%s'", action.Type)
+		eventsBefore, rollsBefore := len(currentSession.LastTurnEvents), len(currentSession.LastTurnRolls)
+
+		// Reject known action types that aren't legal at the current location before dispatching.
+		currentLoc, _ := e.WorldSystem.GetLocation(currentSession.CurrentLocationID)
+		if isKnownActionType(actionType) && !e.Policy.IsAllowed(currentLoc, actionType) {
+			err = &ActionNotAllowedError{ActionType: actionType, LocationID: currentSession.CurrentLocationID, Allowed: e.Policy.Allowed(currentLoc)}
+		} else {
+			err = e.dispatch(actionType, action, currentSession)
 		}
 
 		// Collect errors. Decide if execution should stop on first error?
@@ -103,9 +156,10 @@ func (e *SimpleActionExecutor) ExecuteActions(actions []llm.LLMAction, currentSe
 			fmt.Printf("Executor Error: %v\n", wrappedErr) // Log error
 		} else {
 			// Log successful action execution to session history?
-            // Note: This assumes modification happens directly on the session pointer.
-			currentSession.AddRecentAction(fmt.Sprintf("System executed: %s", actionType))
+			// Note: This assumes modification happens directly on the session pointer.
+			currentSession.Record(history.ActorSystem, history.TypeAction, string(actionType))
 		}
+		currentSession.LastTurnResults = append(currentSession.LastTurnResults, actionResult(action, err, currentSession.LastTurnEvents[eventsBefore:], currentSession.LastTurnRolls[rollsBefore:]))
 	}
 
 	// Persist session changes after all actions? Or rely on caller?
@@ -115,6 +169,76 @@ func (e *SimpleActionExecutor) ExecuteActions(actions []llm.LLMAction, currentSe
 	return executionErrors // Return nil if no errors occurred
 }
 
+// dispatch routes a single action to its handler.
+func (e *SimpleActionExecutor) dispatch(actionType ActionType, action llm.LLMAction, currentSession *session.GameSession) error {
+	switch actionType {
+	case UpdateLocation:
+		return e.handleUpdateLocation(action, currentSession)
+	case AddItem:
+		return e.handleAddItem(action, currentSession)
+	case RemoveItem:
+		return e.handleRemoveItem(action, currentSession)
+	case ApplyEffect:
+		return e.handleApplyEffect(action, currentSession)
+	case SkillCheck:
+		return e.handleSkillCheck(action, currentSession)
+	case SetFlag:
+		return e.handleSetFlag(action, currentSession)
+	case StartCombat:
+		return e.handleStartCombat(action, currentSession)
+	case Attack:
+		return e.handleAttack(action, currentSession)
+	case Defend:
+		return e.handleDefend(action, currentSession)
+	case Flee:
+		return e.handleFlee(action, currentSession)
+	case UseItem:
+		return e.handleUseItem(action, currentSession)
+	case Search:
+		return e.handleSearch(action, currentSession)
+	case Rest:
+		return e.handleRest(action, currentSession)
+	case Challenge:
+		return e.handleChallenge(action, currentSession)
+	case RepairItem:
+		return e.handleRepairItem(action, currentSession)
+	case UseAbility:
+		return e.handleUseAbility(action, currentSession)
+	case LearnAbility:
+		return e.handleLearnAbility(action, currentSession)
+	case GainXP:
+		return e.handleGainXP(action, currentSession)
+	case AdjustKarma:
+		return e.handleAdjustKarma(action, currentSession)
+	case RegisterEntity:
+		return e.handleRegisterEntity(action, currentSession)
+	case SpreadRumor:
+		return e.handleSpreadRumor(action, currentSession)
+	case TakeFromContainer:
+		return e.handleTakeFromContainer(action, currentSession)
+	case PutInContainer:
+		return e.handlePutInContainer(action, currentSession)
+	case Interact:
+		return e.handleInteract(action, currentSession)
+	case AcquireMount:
+		return e.handleAcquireMount(action, currentSession)
+	case Board:
+		return e.handleBoard(action, currentSession)
+	case Dismount:
+		return e.handleDismount(action, currentSession)
+	case Sneak:
+		return e.handleSneak(action, currentSession)
+	case SetStealth:
+		return e.handleSetStealth(action, currentSession)
+	case SneakAttack:
+		return e.handleSneakAttack(action, currentSession)
+	case Pickpocket:
+		return e.handlePickpocket(action, currentSession)
+	default:
+		return fmt.Errorf("unknown or unsupported action type received from LLM: '%s'", action.Type)
+	}
+}
+
 // handleUpdateLocation processes the 'updateLocation' action.
 // It validates the target location and updates the session state.
 func (e *SimpleActionExecutor) handleUpdateLocation(action llm.LLMAction, currentSession *session.GameSession) error {
@@ -145,45 +269,153 @@ func (e *SimpleActionExecutor) handleUpdateLocation(action llm.LLMAction, curren
 	isAdj, err := e.WorldSystem.IsAdjacent(currentLocationID, targetLocationID)
 	if err != nil {
 		// Check if the error was due to non-existence vs other issues
-		if strings.Contains(err.Error(), "not found") {
-             return fmt.Errorf("validation failed - location does not exist: %w", err)
-        }
+		if errors.Is(err, world.ErrLocationNotFound) {
+			return fmt.Errorf("validation failed - location does not exist: %w", err)
+		}
 		return fmt.Errorf("error checking adjacency via WorldSystem: %w", err)
 	}
 
+	if !isAdj && currentSession.ExitOpen(currentLocationID, targetLocationID) {
+		isAdj = true // Opened by an interaction
+	}
+
 	if !isAdj {
 		// LLM suggested an invalid move according to world rules
-		return fmt.Errorf("validation failed - target location '%s' is not adjacent to current location '%s'", targetLocationID, currentLocationID)
+		return fmt.Errorf("validation failed - %w: target location '%s' is not adjacent to current location '%s'", world.ErrNotAdjacent, targetLocationID, currentLocationID)
+	}
+
+	// Active world events (a storm, a closed gate) may block the way
+	if ev := exitClosedBy(e.WorldEvents, currentSession, currentLocationID, targetLocationID); ev != nil {
+		return fmt.Errorf("validation failed - the way to '%s' is closed while '%s' lasts", targetLocationID, ev.Name)
+	}
+
+	// Scenario beats may keep the player within a few locations until the objective is done
+	if scenario, beat := activeBeat(e.Scenarios, currentSession); beat != nil && !beat.AllowsLocation(targetLocationID) {
+		return &ScenarioRestrictionError{ScenarioID: scenario.ID, BeatID: beat.ID, TargetID: targetLocationID, Allowed: beat.AllowedLocations}
+	}
+
+	// Some status effects (e.g. exhausted) keep the player where they are
+	if effectID := effects.TravelBlocker(currentSession.Player); effectID != "" {
+		return &EffectRestrictionError{EffectID: effectID, TargetID: targetLocationID}
+	}
+
+	// Routes may need a mount or vehicle, and take in-story time
+	hours, err := e.travelHours(currentSession, currentLocationID, targetLocationID)
+	if err != nil {
+		return err
 	}
 
 	// 3. Apply State Change
 	fmt.Printf("Executor: Move validated. Updating session location for player '%s' to '%s'\n", currentSession.Player.ID, targetLocationID)
 	currentSession.CurrentLocationID = targetLocationID
 
+	targetName := targetLocationID
+	if targetLoc, err := e.WorldSystem.GetLocation(targetLocationID); err == nil {
+		targetName = targetLoc.Name
+	}
+	currentSession.Emit(events.NewLocationChanged(currentSession.TurnCount, currentLocationID, targetLocationID, targetName))
+	setStealth(currentSession, "") // Stealth is per scene
+	if hours > 0 {
+		currentSession.GameHours += hours
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("The journey to %s takes %d hour(s).", targetName, hours))
+	}
+
 	// Potentially trigger other effects related to location change (e.g., clear temporary flags)
 
 	return nil // Success
 }
 
-// --- Placeholder handlers for future actions ---
+// handleSkillCheck processes the 'skillCheck' action: rolls 'notation' (default 1d20)
+// in the given 'mode', compares against 'dc' if present, and records the breakdown.
+func (e *SimpleActionExecutor) handleSkillCheck(action llm.LLMAction, currentSession *session.GameSession) error {
+	notation := "1d20"
+	if v, ok := action.Data["notation"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return errors.New("action data field 'notation' must be a string")
+		}
+		notation = s
+	}
+	expr, err := dice.Parse(notation)
+	if err != nil {
+		return err
+	}
+	expr.Modifier += checkBonus(currentSession.Player) // e.g. blessed, or perks
 
-// func (e *SimpleActionExecutor) handleAddItem(action llm.LLMAction, currentSession *session.GameSession) error {
-// 	// 1. Validate Data (itemId, count)
-// 	// 2. Call InventorySystem.AddItem(currentSession.Player.ID, itemId, count)
-// 	// 3. Handle errors from InventorySystem
-// 	return errors.New("handleAddItem not implemented")
-// }
+	mode := dice.Normal
+	if v, ok := action.Data["mode"]; ok {
+		s, _ := v.(string)
+		switch dice.Mode(s) {
+		case dice.Normal, dice.Advantage, dice.Disadvantage:
+			mode = dice.Mode(s)
+		default:
+			return fmt.Errorf("action data field 'mode' must be 'normal', 'advantage' or 'disadvantage', got %v", v)
+		}
+	}
 
-// func (e *SimpleActionExecutor) handleRemoveItem(action llm.LLMAction, currentSession *session.GameSession) error {
-// 	// 1. Validate Data (itemId, count)
-// 	// 2. Call InventorySystem.RemoveItem(currentSession.Player.ID, itemId, count)
-// 	// 3. Handle errors (e.g., item not found, insufficient count)
-// 	return errors.New("handleRemoveItem not implemented")
-// }
+	label, _ := action.Data["label"].(string)
+
+	var result dice.Result
+	if v, ok := action.Data["dc"]; ok {
+		dc, ok := v.(float64) // JSON numbers decode as float64
+		if !ok {
+			return errors.New("action data field 'dc' must be a number")
+		}
+		result = e.roller(currentSession).Check(expr, mode, int(dc), label)
+	} else {
+		result = e.roller(currentSession).Roll(expr, mode)
+		result.Label = label
+	}
 
-// func (e *SimpleActionExecutor) handleApplyEffect(action llm.LLMAction, currentSession *session.GameSession) error {
-// 	// 1. Validate Data (effectId, duration, description, target?)
-// 	// 2. Call CharacterSystem.ApplyEffect(currentSession.Player.ID, effectData)
-// 	// 3. Handle errors
-// 	return errors.New("handleApplyEffect not implemented")
-// }
\ No newline at end of file
+	currentSession.LastTurnRolls = append(currentSession.LastTurnRolls, result)
+	summary := fmt.Sprintf("%s rolled %s = %d", labelOr(label, "Roll"), result.Notation, result.Total)
+	if result.Success != nil {
+		outcome := "failure"
+		if *result.Success {
+			outcome = "success"
+		}
+		summary += fmt.Sprintf(" vs DC %d (%s)", result.Target, outcome)
+	}
+	fmt.Printf("Executor: %s\n", summary)
+	currentSession.Record(history.ActorSystem, history.TypeAction, summary)
+	return nil
+}
+
+// handleSetFlag processes the 'setFlag' action: sets 'flag' (or clears it when 'value' is false).
+// A choice flag may be tagged with 'karma', applied the first time the flag is set.
+func (e *SimpleActionExecutor) handleSetFlag(action llm.LLMAction, currentSession *session.GameSession) error {
+	flag, ok := action.Data["flag"].(string)
+	if !ok || flag == "" {
+		return errors.New("action data field 'flag' must be a non-empty string")
+	}
+	value := true
+	if v, ok := action.Data["value"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return errors.New("action data field 'value' must be a boolean")
+		}
+		value = b
+	}
+	karma := 0
+	if _, ok := action.Data["karma"]; ok {
+		n, err := karmaAmount(action, "karma")
+		if err != nil {
+			return err
+		}
+		karma = n
+	}
+	alreadySet := currentSession.Flags[flag]
+	currentSession.SetFlag(flag, value)
+	fmt.Printf("Executor: Flag '%s' set to %t\n", flag, value)
+	if value && !alreadySet && karma != 0 {
+		adjustKarma(currentSession, karma, flag)
+	}
+	return nil
+}
+
+func labelOr(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
@@ -0,0 +1,52 @@
+package narrative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"strings"
+)
+
+// narrateFailures rewrites response's narration after some of its actions failed
+// (executionErrors), so the failures happen in the story (a barred gate, an empty
+// pocket) instead of being reported as errors. Actions in the rewrite are ignored:
+// the state the failed actions left is what the narration must match.
+func (ne *NarrativeEngine) narrateFailures(ctx context.Context, currentSession *session.GameSession, playerInput string, response *llm.LLMResponse, executionErrors []error) error {
+	promptData, err := ne.buildPromptContext(currentSession) // State as the actions left it
+	if err != nil {
+		return err
+	}
+	promptData.PlayerInput = playerInput
+	promptData.SystemNotes = append(promptData.SystemNotes, failureNote(response.Narrative, executionErrors))
+
+	rewrite, err := ne.generate(ctx, currentSession, "failure", *promptData)
+	if err != nil {
+		return err
+	}
+	currentSession.Stats.AddUsage(rewrite.Usage)
+	rewrite = ne.moderateResponse(ctx, currentSession, promptData, rewrite)
+	if strings.TrimSpace(rewrite.Narrative) == "" {
+		return errors.New("the rewrite has no narrative")
+	}
+	response.Narrative = rewrite.Narrative
+	if len(rewrite.Suggestions) > 0 {
+		response.Suggestions = rewrite.Suggestions
+	}
+	return nil
+}
+
+// failureNote tells the narrator which parts of its narration didn't happen, and why.
+func failureNote(narrative string, executionErrors []error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your narration this turn was: %q. Some of what it described could not happen:", narrative)
+	for _, err := range executionErrors {
+		if cause := errors.Unwrap(err); cause != nil { // Drop the executor's "failed to execute action" wrapping
+			err = cause
+		}
+		fmt.Fprintf(&b, " %s.", strings.TrimSuffix(err.Error(), "."))
+	}
+	b.WriteString(" Rewrite the narration so those attempts fail within the story (for example, a gate is barred or an item isn't there), keeping everything else. Never mention errors, rules or the game system.")
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package narrative
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SanitizeInput cleans player-supplied text before it reaches the prompt or history:
+// control characters, invalid UTF-8 and invisible format characters (bidi overrides,
+// zero-width spaces) are dropped, and runs of whitespace, including newlines, collapse
+// to a single space. The engine applies it to every turn's input; handlers apply it
+// first so length limits count what the LLM will actually see.
+func SanitizeInput(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	pendingSpace := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			pendingSpace = b.Len() > 0 // No leading space
+			continue
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			continue
+		}
+		if pendingSpace {
+			b.WriteByte(' ')
+			pendingSpace = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
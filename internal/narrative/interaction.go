@@ -0,0 +1,90 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"strings"
+)
+
+// handleInteract processes the 'interact' action: does the current location's
+// 'interactionId', setting its flags and opening its exits.
+func (e *SimpleActionExecutor) handleInteract(action llm.LLMAction, currentSession *session.GameSession) error {
+	interactionID, ok := action.Data["interactionId"].(string)
+	if !ok || interactionID == "" {
+		return errors.New("action data field 'interactionId' must be a non-empty string")
+	}
+	if currentSession.Combat != nil {
+		return errors.New("the player can't do that during combat")
+	}
+	loc, err := e.WorldSystem.GetLocation(currentSession.CurrentLocationID)
+	if err != nil {
+		return err
+	}
+	in := loc.Interaction(interactionID)
+	if in == nil {
+		return fmt.Errorf("there is no interaction '%s' at '%s'", interactionID, loc.ID)
+	}
+	if in.Once && currentSession.InteractionUsed(loc.ID, in.ID) {
+		return fmt.Errorf("'%s' at '%s' has already been done", in.Name, loc.ID)
+	}
+
+	for _, flag := range in.Effects.SetFlags {
+		currentSession.SetFlag(flag, true)
+	}
+	var opened []string
+	for _, to := range in.Effects.OpenExits {
+		if currentSession.OpenExit(loc.ID, to) {
+			opened = append(opened, to)
+		}
+	}
+	currentSession.UseInteraction(loc.ID, in.ID)
+	fmt.Printf("Executor: Interaction '%s' done at '%s' (flags %v, opened exits %v)\n", in.ID, loc.ID, in.Effects.SetFlags, opened)
+
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s: %s.", currentSession.Player.Name, strings.TrimSuffix(in.Name, ".")))
+	for _, to := range opened {
+		name := to
+		if target, err := e.WorldSystem.GetLocation(to); err == nil {
+			name = target.Name
+		}
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("A way to %s is now open.", name))
+	}
+	currentSession.Emit(events.NewInteracted(currentSession.TurnCount, loc.ID, in.ID, in.Name, in.Effects.Event, opened))
+	return nil
+}
+
+// interactionsContext lists loc's interactions for the prompt, marking one-time
+// interactions already done.
+func interactionsContext(sess *session.GameSession, loc *world.LocationNode) []string {
+	lines := make([]string, 0, len(loc.Interactions))
+	for _, in := range loc.Interactions {
+		line := fmt.Sprintf("%s (%s)", in.ID, in.Name)
+		if in.Description != "" {
+			line += ": " + in.Description
+		}
+		if in.Once && sess.InteractionUsed(loc.ID, in.ID) {
+			line += " [done]"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// openedExits returns the locations reachable from locationID only through exits
+// interactions opened, skipping any that no longer exist or are regular exits anyway.
+func (ne *NarrativeEngine) openedExits(sess *session.GameSession, locationID string) []*world.LocationNode {
+	var nodes []*world.LocationNode
+	for _, id := range sess.OpenedExitsFrom(locationID) {
+		if adjacent, err := ne.WorldSystem.IsAdjacent(locationID, id); err != nil || adjacent {
+			continue
+		}
+		if node, err := ne.WorldSystem.GetLocation(id); err == nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,89 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"sort"
+)
+
+// handleAddItem processes the 'addItem' action: gives the player 'count' (default 1) of 'itemId'.
+func (e *SimpleActionExecutor) handleAddItem(action llm.LLMAction, currentSession *session.GameSession) error {
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	count, err := itemCount(action)
+	if err != nil {
+		return err
+	}
+	currentSession.Player.AddItem(item.ID, count)
+	fmt.Printf("Executor: Added %d x '%s' to inventory\n", count, item.ID)
+	currentSession.Emit(events.NewItemGained(currentSession.TurnCount, item.ID, count))
+	return nil
+}
+
+// handleRemoveItem processes the 'removeItem' action: takes 'count' (default 1) of 'itemId'.
+func (e *SimpleActionExecutor) handleRemoveItem(action llm.LLMAction, currentSession *session.GameSession) error {
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	count, err := itemCount(action)
+	if err != nil {
+		return err
+	}
+	if err := currentSession.Player.RemoveItem(item.ID, count); err != nil {
+		return err
+	}
+	fmt.Printf("Executor: Removed %d x '%s' from inventory\n", count, item.ID)
+	currentSession.Emit(events.NewItemLost(currentSession.TurnCount, item.ID, count))
+	dismountIfGone(currentSession, item.ID)
+	return nil
+}
+
+// lookupItem resolves the action's 'itemId' against the world's items.
+func (e *SimpleActionExecutor) lookupItem(action llm.LLMAction) (*world.Item, error) {
+	itemID, ok := action.Data["itemId"].(string)
+	if !ok || itemID == "" {
+		return nil, errors.New("action data field 'itemId' must be a non-empty string")
+	}
+	item, ok := e.Items[itemID]
+	if !ok {
+		return nil, fmt.Errorf("item '%s' is not defined in this world", itemID)
+	}
+	return item, nil
+}
+
+func itemCount(action llm.LLMAction) (int, error) {
+	v, ok := action.Data["count"]
+	if !ok {
+		return 1, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, errors.New("action data field 'count' must be a positive integer")
+	}
+	return int(n), nil
+}
+
+// inventoryContext lists what the player carries for the prompt, in item ID order.
+func inventoryContext(sess *session.GameSession, items map[string]*world.Item) []string {
+	ids := make([]string, 0, len(sess.Player.Inventory))
+	for id := range sess.Player.Inventory {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		name, gear := id, ""
+		if item, ok := items[id]; ok {
+			name, gear = item.Name, gearContext(sess, item, items)
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s) x%d%s", name, id, sess.Player.Inventory[id], gear))
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+)
+
+// MaxKarmaShift is the most one 'adjustKarma' action (or karma-tagged flag) may move karma.
+const MaxKarmaShift = 25
+
+// KarmaStanding names the reputation karma earns, e.g. "respected". Endings and
+// scenario beats can test karma thresholds directly (see world.KarmaRange).
+func KarmaStanding(karma int) string {
+	switch {
+	case karma <= -60:
+		return "villainous"
+	case karma <= -20:
+		return "distrusted"
+	case karma < 20:
+		return "neutral"
+	case karma < 60:
+		return "respected"
+	default:
+		return "heroic"
+	}
+}
+
+// adjustKarma shifts the session's karma by amount for reason, recording the change
+// and any new standing.
+func adjustKarma(sess *session.GameSession, amount int, reason string) {
+	before := KarmaStanding(sess.Karma)
+	applied := sess.AdjustKarma(amount)
+	if applied == 0 {
+		return
+	}
+	summary := fmt.Sprintf("Karma %+d (%s), now %d", applied, reason, sess.Karma)
+	if after := KarmaStanding(sess.Karma); after != before {
+		summary += fmt.Sprintf("; the player is now %s", after)
+	}
+	fmt.Printf("Executor: %s\n", summary)
+	sess.Record(history.ActorSystem, history.TypeAction, summary)
+	sess.Emit(events.NewKarmaChanged(sess.TurnCount, applied, sess.Karma, reason))
+}
+
+// karmaAmount reads the integer action data field 'field', which must lie within
+// ±MaxKarmaShift.
+func karmaAmount(action llm.LLMAction, field string) (int, error) {
+	n, ok := action.Data[field].(float64) // JSON numbers decode as float64
+	if !ok || n < -MaxKarmaShift || n > MaxKarmaShift || n != float64(int(n)) {
+		return 0, fmt.Errorf("action data field '%s' must be an integer from %d to %d", field, -MaxKarmaShift, MaxKarmaShift)
+	}
+	return int(n), nil
+}
+
+// handleAdjustKarma processes the 'adjustKarma' action: shifts karma by 'amount'
+// (negative for cruel or dishonest deeds) for 'reason'.
+func (e *SimpleActionExecutor) handleAdjustKarma(action llm.LLMAction, currentSession *session.GameSession) error {
+	amount, err := karmaAmount(action, "amount")
+	if err != nil {
+		return err
+	}
+	reason, _ := action.Data["reason"].(string)
+	if reason == "" {
+		reason = "story"
+	}
+	adjustKarma(currentSession, amount, reason)
+	if (amount >= rumorKarma || amount <= -rumorKarma) && reason != "story" {
+		currentSession.AddRumor(fmt.Sprintf("%s: %s", currentSession.Player.Name, reason), currentSession.CurrentLocationID) // Deeds like this get talked about
+	}
+	return nil
+}
+
+// karmaContext describes the player's karma for the prompt, e.g. "35 (respected)".
+func karmaContext(sess *session.GameSession) string {
+	return fmt.Sprintf("%d (%s)", sess.Karma, KarmaStanding(sess.Karma))
+}
@@ -0,0 +1,64 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"slices"
+	"strings"
+)
+
+// handleSearch processes the 'search' action: rolls the current location's loot table.
+// Each location can be searched once per session; later searches (and locations without
+// a table) turn up nothing.
+func (e *SimpleActionExecutor) handleSearch(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't search during combat")
+	}
+	locationID := currentSession.CurrentLocationID
+	loc, err := e.WorldSystem.GetLocation(locationID)
+	if err != nil {
+		return err
+	}
+	if loc.LootTable == "" || slices.Contains(currentSession.SearchedLocations, locationID) {
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Searched %s: found nothing.", loc.Name))
+		return nil
+	}
+	table, ok := e.LootTables[loc.LootTable]
+	if !ok {
+		return fmt.Errorf("location '%s' references non-existent loot table '%s'", locationID, loc.LootTable)
+	}
+	currentSession.SearchedLocations = append(currentSession.SearchedLocations, locationID)
+	e.rollLoot(currentSession, "location", locationID, loc.Name, table)
+	return nil
+}
+
+// rollLoot rolls table, adds the drops to the player's inventory and reports them as a
+// lootDropped event (plus itemGained per stack). source is "creature" or "location".
+func (e *SimpleActionExecutor) rollLoot(sess *session.GameSession, source, sourceID, sourceName string, table *world.LootTable) {
+	drops := table.Roll(e.roller(sess))
+	if len(drops) == 0 {
+		sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Loot from %s: nothing.", sourceName))
+		return
+	}
+	items := make([]map[string]interface{}, 0, len(drops))
+	found := make([]string, 0, len(drops))
+	for _, drop := range drops {
+		sess.Player.AddItem(drop.ItemID, drop.Count)
+		sess.Emit(events.NewItemGained(sess.TurnCount, drop.ItemID, drop.Count))
+		items = append(items, map[string]interface{}{"itemId": drop.ItemID, "count": drop.Count})
+		name := drop.ItemID
+		if item, ok := e.Items[drop.ItemID]; ok {
+			name = item.Name
+		}
+		found = append(found, fmt.Sprintf("%d x %s", drop.Count, name))
+	}
+	summary := fmt.Sprintf("Loot from %s: %s.", sourceName, strings.Join(found, ", "))
+	fmt.Printf("Executor: %s\n", summary)
+	sess.Record(history.ActorSystem, history.TypeAction, summary)
+	sess.Emit(events.NewLootDropped(sess.TurnCount, source, sourceID, items))
+}
@@ -0,0 +1,39 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"strings"
+)
+
+// DefaultLoreMentions is how many tagged lore documents a prompt includes when
+// NarrativeEngine.LoreMentions is unset.
+const DefaultLoreMentions = 2
+
+// mentionedLore adds the lore documents whose tags the scene mentions to promptData:
+// the player's input, where they are and who they are fighting, plus the current
+// location's ID and tags. It returns the IDs of the documents it included.
+func (ne *NarrativeEngine) mentionedLore(sess *session.GameSession, playerInput string, promptData *llm.PromptData) map[string]bool {
+	if ne.Lore.Len() == 0 {
+		return nil
+	}
+	scene := []string{promptData.LocationContext.CurrentLocationName, promptData.LocationContext.CurrentLocationDesc}
+	if promptData.Combat != nil {
+		scene = append(scene, promptData.Combat.Enemies...)
+	}
+	sceneTags := []string{sess.CurrentLocationID}
+	if loc, err := ne.WorldSystem.GetLocation(sess.CurrentLocationID); err == nil {
+		sceneTags = append(sceneTags, loc.Tags...)
+	}
+	limit := ne.LoreMentions
+	if limit <= 0 {
+		limit = DefaultLoreMentions
+	}
+	included := make(map[string]bool)
+	for _, doc := range ne.Lore.Mentioned(playerInput, strings.Join(scene, "\n"), sceneTags, limit) {
+		included[doc.ID] = true
+		promptData.LoreContext = append(promptData.LoreContext, fmt.Sprintf("%s: %s", doc.Title, doc.Excerpt()))
+	}
+	return included
+}
@@ -0,0 +1,109 @@
+package narrative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"llmrpg/internal/clock"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/moderation"
+	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
+)
+
+// ModerationPolicy runs player input and generated narrative through a moderator and
+// decides what happens to flagged text. Every check is recorded in Audit.
+type ModerationPolicy struct {
+	Moderator    moderation.Moderator
+	InputAction  moderation.Action
+	OutputAction moderation.Action
+	Audit        moderation.AuditLog // nil = standard logger
+}
+
+// ErrInputModerated is returned when moderation blocks a turn's input.
+var ErrInputModerated = errors.New("input rejected by content moderation")
+
+// moderatedNarrative replaces narration that moderation blocked.
+const moderatedNarrative = "The scene blurs for a moment, and the story finds another way forward. (This narration was withheld by content moderation.)"
+
+// moderate checks text at stage and records the verdict. flagged is false when the
+// moderator fails: moderation fails open, so a provider outage doesn't halt play.
+func (ne *NarrativeEngine) moderate(ctx context.Context, sess *session.GameSession, turn int, stage moderation.Stage, text string, action moderation.Action) (verdict moderation.Verdict, flagged bool) {
+	ctx, span := tracing.Start(ctx, "moderation.check", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("moderation.stage", string(stage))
+
+	contentRating := ne.contentRating(sess)
+	entry := moderation.AuditEntry{Time: clock.Now(), SessionID: sess.ID, Turn: turn, Stage: stage, Rating: string(contentRating), Variant: sess.PromptVariant, TextLength: len(text)}
+	verdict, err := ne.Moderation.Moderator.Moderate(ctx, text)
+	verdict = verdict.AtThreshold(contentRating.Profile().ModerationThreshold) // Stricter ratings flag lower scores
+	if err != nil {
+		span.RecordError(err)
+		fmt.Printf("Warning: Moderation failed for session '%s' (%s): %v\n", sess.ID, stage, err)
+		entry.Error = err.Error()
+	} else if verdict.Flagged {
+		entry.Action = action
+		span.SetAttr("moderation.flagged", true)
+		fmt.Printf("NarrativeEngine: %s for session %s flagged by moderation (%s), action: %s\n", stage, sess.ID, strings.Join(verdict.Categories, ", "), action)
+	}
+	entry.Verdict = verdict
+
+	audit := ne.Moderation.Audit
+	if audit == nil {
+		audit = moderation.LogAuditLog{}
+	}
+	audit.Record(entry)
+	return verdict, err == nil && verdict.Flagged
+}
+
+// moderateInput checks the player's input before the turn. It returns ErrInputModerated
+// if the input is blocked, or notes for the narrator if it should be softened.
+func (ne *NarrativeEngine) moderateInput(ctx context.Context, sess *session.GameSession, playerInput string) ([]string, error) {
+	if ne.Moderation == nil {
+		return nil, nil
+	}
+	action := ne.Moderation.InputAction
+	verdict, flagged := ne.moderate(ctx, sess, sess.TurnCount+1, moderation.StageInput, playerInput, action)
+	if !flagged {
+		return nil, nil
+	}
+	switch action {
+	case moderation.ActionBlock:
+		return nil, fmt.Errorf("session '%s': %w", sess.ID, ErrInputModerated)
+	case moderation.ActionSoften:
+		return []string{fmt.Sprintf("The player's input touches on content this game does not depict in detail (%s). Respond to their intent, but keep the narration restrained: fade out, imply or redirect rather than describing it.", strings.Join(verdict.Categories, ", "))}, nil
+	}
+	return nil, nil
+}
+
+// moderateResponse checks generated narrative. Flagged narration is regenerated once
+// with a toned-down instruction (soften) or replaced with a neutral narration without
+// actions (block, or when softening fails).
+func (ne *NarrativeEngine) moderateResponse(ctx context.Context, sess *session.GameSession, promptData *llm.PromptData, response *llm.LLMResponse) *llm.LLMResponse {
+	if ne.Moderation == nil {
+		return response
+	}
+	action := ne.Moderation.OutputAction
+	verdict, flagged := ne.moderate(ctx, sess, sess.TurnCount, moderation.StageOutput, response.Narrative, action)
+	if !flagged || action == moderation.ActionLog {
+		return response
+	}
+
+	if action == moderation.ActionSoften {
+		retryPrompt := *promptData
+		retryPrompt.SystemNotes = append(append([]string{}, promptData.SystemNotes...),
+			fmt.Sprintf("Your previous narration for this turn was flagged by content moderation (%s). Narrate the same outcome again in a restrained way that does not depict that content.", strings.Join(verdict.Categories, ", ")))
+		softened, err := ne.generate(ctx, sess, "soften", retryPrompt)
+		if err != nil {
+			fmt.Printf("Warning: Softening narration failed for session '%s': %v\n", sess.ID, err)
+		} else {
+			sess.Stats.AddUsage(softened.Usage)
+			if _, stillFlagged := ne.moderate(ctx, sess, sess.TurnCount, moderation.StageOutput, softened.Narrative, moderation.ActionBlock); !stillFlagged {
+				return softened
+			}
+		}
+	}
+	return &llm.LLMResponse{Narrative: moderatedNarrative}
+}
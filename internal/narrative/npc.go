@@ -0,0 +1,35 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/world"
+	"sort"
+)
+
+// npcsContext lists for the prompt the NPCs at locationID at hour of day (people) and
+// those who are here at other hours (away), with when they're next back.
+func npcsContext(npcs map[string]*world.NPC, locationID string, hour int) (people, away []string) {
+	ids := make([]string, 0, len(npcs))
+	for id := range npcs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		npc := npcs[id]
+		at, activity := npc.Whereabouts(hour)
+		switch {
+		case at == locationID:
+			line := fmt.Sprintf("%s (%s)", npc.Name, npc.ID)
+			if activity != "" {
+				line += ", " + activity
+			}
+			if npc.Description != "" {
+				line += ": " + npc.Description
+			}
+			people = append(people, line)
+		case npc.Frequents(locationID):
+			away = append(away, fmt.Sprintf("%s (%s), back at %02d:00", npc.Name, npc.ID, npc.NextAt(locationID, hour)))
+		}
+	}
+	return people, away
+}
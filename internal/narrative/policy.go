@@ -0,0 +1,66 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/world"
+)
+
+// ActionNotAllowedError is returned when the LLM requests an action type that
+// isn't legal in the current location/scene. The engine reacts by asking the
+// LLM to re-narrate the turn without it.
+type ActionNotAllowedError struct {
+	ActionType ActionType
+	LocationID string
+	Allowed    []ActionType
+}
+
+func (e *ActionNotAllowedError) Error() string {
+	return fmt.Sprintf("action type '%s' is not allowed at location '%s' (allowed: %v)", e.ActionType, e.LocationID, e.Allowed)
+}
+
+// ActionPolicy decides which action types are legal at a location.
+// Locations may declare their own allow-list; otherwise the world-wide Defaults apply.
+// An empty Defaults list means every known action type is allowed.
+type ActionPolicy struct {
+	Defaults []ActionType
+}
+
+// NewActionPolicy creates a policy with the given world-wide default allow-list.
+func NewActionPolicy(defaults []ActionType) *ActionPolicy {
+	return &ActionPolicy{Defaults: defaults}
+}
+
+// Allowed returns the action types legal at the given location.
+func (p *ActionPolicy) Allowed(loc *world.LocationNode) []ActionType {
+	if loc != nil && loc.AllowedActions != nil {
+		allowed := make([]ActionType, 0, len(loc.AllowedActions))
+		for _, a := range loc.AllowedActions {
+			allowed = append(allowed, ActionType(a))
+		}
+		return allowed
+	}
+	if p == nil || len(p.Defaults) == 0 {
+		return KnownActionTypes
+	}
+	return p.Defaults
+}
+
+// IsAllowed reports whether actionType may be executed at the given location.
+func (p *ActionPolicy) IsAllowed(loc *world.LocationNode, actionType ActionType) bool {
+	for _, a := range p.Allowed(loc) {
+		if a == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownActionType reports whether the executor has a handler for actionType.
+func isKnownActionType(actionType ActionType) bool {
+	for _, t := range KnownActionTypes {
+		if t == actionType {
+			return true
+		}
+	}
+	return false
+}
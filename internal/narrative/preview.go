@@ -0,0 +1,49 @@
+package narrative
+
+import (
+	"context"
+	"fmt"
+
+	"llmrpg/internal/llm"
+)
+
+// PromptPreview is the prompt a turn would send the narrator for an input.
+type PromptPreview struct {
+	Input    string              `json:"input"`    // The input as the turn would see it, after sanitizing
+	Turn     int                 `json:"turn"`     // The turn number the input would play
+	Messages []llm.PromptMessage `json:"messages"` // Exactly what the narrator's adapter would send
+	Context  llm.PromptData      `json:"context"`  // The structured context the messages were rendered from
+}
+
+// PreviewPrompt builds the narrator prompt for playerInput as the session's next turn
+// would, without calling the LLM or changing the session: the turn's start (effects,
+// world events, pending actions) plays out on a copy. Rolls made on the way are real
+// rolls, so a played turn may see different outcomes. Input moderation is skipped,
+// since it calls the moderation provider; the injection guard's note is included.
+func (ne *NarrativeEngine) PreviewPrompt(ctx context.Context, sessionID string, playerInput string) (*PromptPreview, error) {
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	trial, err := currentSession.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy session '%s' for a prompt preview: %w", sessionID, err)
+	}
+
+	playerInput = SanitizeInput(playerInput)
+	var inputNotes []string
+	if assessment, risky := ne.Guard.check(playerInput); risky {
+		inputNotes = append(inputNotes, injectionNote(assessment))
+	}
+	effectNotes, _ := ne.startTurn(ctx, trial, playerInput, nil, false)
+	promptData, err := ne.turnPrompt(ctx, trial, playerInput, inputNotes, effectNotes)
+	if err != nil {
+		return nil, err
+	}
+	return &PromptPreview{
+		Input:    playerInput,
+		Turn:     trial.TurnCount,
+		Messages: llm.PreviewPrompt(ne.LLMAdapter, ne.systemPrompt(trial), *promptData),
+		Context:  *promptData,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/effects"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// Progression tuning.
+const (
+	LevelHP    = 5   // Maximum hit points gained per level
+	MaxAwardXP = 100 // Most experience one 'gainXp' action may award
+)
+
+// XPForLevel is the total experience needed to advance past level: 50 for level 2,
+// 150 for level 3, 300 for level 4 and so on.
+func XPForLevel(level int) int {
+	return 25 * level * (level + 1)
+}
+
+// checkBonus is the player's modifier to skill checks, challenges and stealth rolls:
+// status effects (e.g. blessed) plus perks.
+func checkBonus(player *character.Character) int {
+	return effects.RollBonus(player) + player.Bonuses.Checks
+}
+
+// ErrNoPendingPerk is returned when a perk is chosen with no level-up waiting for one.
+var ErrNoPendingPerk = errors.New("no level-up is waiting for a perk choice")
+
+// ErrPerkUnavailable is returned when the chosen perk isn't one of the player's choices.
+var ErrPerkUnavailable = errors.New("perk is not available to the player")
+
+// gainXP awards amount experience to the player for reason and levels them up as many
+// times as it takes them past the thresholds. Each level-up raises maximum HP and, if
+// the world has perks left to choose, waits for a perk choice (see ChoosePerk).
+func (e *SimpleActionExecutor) gainXP(sess *session.GameSession, amount int, reason string) {
+	if amount <= 0 {
+		return
+	}
+	player := sess.Player
+	player.EnsureVitals()
+	player.XP += amount
+	sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s gains %d XP (%s).", player.Name, amount, reason))
+	sess.Emit(events.NewXPGained(sess.TurnCount, amount, player.XP, reason))
+	for player.XP >= XPForLevel(player.Level) {
+		player.Level++
+		player.MaxHP += LevelHP
+		player.HP += LevelHP
+		var choices []string
+		for _, p := range world.AvailablePerks(e.Perks, player.Level, player.Perks) {
+			choices = append(choices, p.ID)
+		}
+		if len(choices) > 0 {
+			player.PendingPerks++
+		}
+		summary := fmt.Sprintf("%s reaches level %d (max HP %d).", player.Name, player.Level, player.MaxHP)
+		fmt.Printf("Executor: %s\n", summary)
+		sess.Record(history.ActorSystem, history.TypeAction, summary)
+		sess.Emit(events.NewLevelUp(sess.TurnCount, player.Level, choices))
+	}
+}
+
+// handleGainXP processes the 'gainXp' action: awards 'amount' (1-MaxAwardXP) experience
+// for 'reason', e.g. a completed quest or a clever solution.
+func (e *SimpleActionExecutor) handleGainXP(action llm.LLMAction, currentSession *session.GameSession) error {
+	n, ok := action.Data["amount"].(float64) // JSON numbers decode as float64
+	if !ok || n < 1 || n > MaxAwardXP || n != float64(int(n)) {
+		return fmt.Errorf("action data field 'amount' must be an integer from 1 to %d", MaxAwardXP)
+	}
+	reason, _ := action.Data["reason"].(string)
+	if reason == "" {
+		reason = "story"
+	}
+	e.gainXP(currentSession, int(n), reason)
+	return nil
+}
+
+// ChoosePerk applies perkID, which must be one of the player's available perks, for a
+// pending level-up: its stat changes, bonuses and abilities.
+func ChoosePerk(sess *session.GameSession, perks map[string]*world.Perk, perkID string) error {
+	player := sess.Player
+	if player.PendingPerks < 1 {
+		return ErrNoPendingPerk
+	}
+	var perk *world.Perk
+	for _, p := range world.AvailablePerks(perks, player.Level, player.Perks) {
+		if p.ID == perkID {
+			perk = p
+		}
+	}
+	if perk == nil {
+		return fmt.Errorf("%w: '%s'", ErrPerkUnavailable, perkID)
+	}
+
+	player.EnsureVitals()
+	player.PendingPerks--
+	player.Perks = append(player.Perks, perk.ID)
+	player.MaxHP += perk.MaxHP
+	player.HP += perk.MaxHP
+	player.MaxMP += perk.MaxMP
+	player.MP += perk.MaxMP
+	player.Bonuses.Attack += perk.Attack
+	player.Bonuses.Armor += perk.Armor
+	player.Bonuses.Checks += perk.Checks
+	player.Bonuses.Initiative += perk.Initiative
+	for _, id := range perk.Abilities {
+		if !player.KnowsAbility(id) {
+			player.Abilities = append(player.Abilities, id)
+		}
+	}
+	sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s chooses the %s perk.", player.Name, perk.Name))
+	sess.Emit(events.NewPerkChosen(sess.TurnCount, perk.ID))
+	return nil
+}
+
+// perksContext describes the player's chosen perks for the prompt.
+func perksContext(player *character.Character, perks map[string]*world.Perk) []string {
+	lines := make([]string, 0, len(player.Perks))
+	for _, id := range player.Perks {
+		if p, ok := perks[id]; ok {
+			line := p.Name
+			if p.Description != "" {
+				line += ": " + p.Description
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
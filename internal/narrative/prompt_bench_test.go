@@ -0,0 +1,128 @@
+package narrative
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"llmrpg/internal/character"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// Benchmarks of building the narrator's prompt context for a turn, in a generated
+// grid world like the world package's benchmarks use:
+//
+//	go test ./internal/narrative -run '^$' -bench Prompt
+
+var benchLocations = flag.Int("bench.locations", 10000, "Locations in the generated benchmark world")
+
+func BenchmarkPromptContext(b *testing.B) {
+	benchmarkPrompt(b, false)
+}
+
+func BenchmarkPromptContextRumors(b *testing.B) {
+	benchmarkPrompt(b, true)
+}
+
+// benchmarkPrompt times building the prompt for a turn in the middle of the world, with
+// a full recent-events window and, with rumors, a rumor started at each end of the grid.
+func benchmarkPrompt(b *testing.B, rumors bool) {
+	n := *benchLocations
+	out := os.Stdout // World loading and the engine log progress; keep it for the results
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = out
+		devNull.Close()
+	}()
+
+	ws := world.NewInMemoryWorldSystem()
+	if err := ws.LoadWorldData(generateGrid(n)); err != nil {
+		b.Fatalf("Failed to load generated world: %v", err)
+	}
+	sessions := session.NewInMemorySessionManager()
+	engine, err := NewNarrativeEngine(ws, llm.NewMockAdapter(nil), NewSimpleActionExecutor(ws), sessions, "You are the narrator.")
+	if err != nil {
+		b.Fatal(err)
+	}
+	sess, err := sessions.CreateNewSession(character.NewCharacter("bench-player", "Ash", "", ""), gridLocationID(n/2))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for turn := 1; turn <= 10; turn++ {
+		sess.TurnCount = turn
+		sess.Record(history.ActorPlayer, history.TypeInput, "I look around.")
+		sess.Record(history.ActorSystem, history.TypeAction, "move")
+	}
+	if rumors {
+		for _, origin := range []string{gridLocationID(0), gridLocationID(n - 1)} {
+			sess.AddRumor("A stranger fought off a pack of wolves", origin)
+		}
+	}
+	sess.GameHours = session.MaxRumors * RumorHopHours // Old enough to have spread
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.PreviewPrompt(context.Background(), sess.ID, "I ask the locals for news."); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// generateGrid builds a square grid of n locations, each connected to its neighbours
+// in both directions, as location and theme file systems.
+func generateGrid(n int) (locFS, themeFS fstest.MapFS) {
+	locFS, themeFS = fstest.MapFS{}, fstest.MapFS{}
+	tags := []string{"town", "wilderness", "road", "interior", "water", "ruins"}
+	const themes = 8
+	for t := 0; t < themes; t++ {
+		id := fmt.Sprintf("theme_%d", t)
+		themeFS[id+".json"] = &fstest.MapFile{Data: mustJSON(world.ThemeDefinition{ID: id, Name: fmt.Sprintf("Theme %d", t)})}
+	}
+
+	width := int(math.Ceil(math.Sqrt(float64(n))))
+	for i := 0; i < n; i++ {
+		var adjacent []string
+		x, y := i%width, i/width
+		for _, neighbour := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			nx, ny := neighbour[0], neighbour[1]
+			if j := ny*width + nx; nx >= 0 && nx < width && ny >= 0 && j < n {
+				adjacent = append(adjacent, gridLocationID(j))
+			}
+		}
+		loc := world.LocationNode{
+			ID:          gridLocationID(i),
+			Name:        fmt.Sprintf("Location %d", i),
+			Description: fmt.Sprintf("Grid square %d,%d of a generated benchmark world.", x, y),
+			AdjacentIDs: adjacent,
+			Tags:        []string{tags[i%len(tags)], tags[(i/len(tags))%len(tags)]},
+			ThemeID:     fmt.Sprintf("theme_%d", i%themes),
+		}
+		locFS[loc.ID+".json"] = &fstest.MapFile{Data: mustJSON(loc)}
+	}
+	return locFS, themeFS
+}
+
+func gridLocationID(i int) string {
+	return fmt.Sprintf("loc_%05d", i)
+}
+
+func mustJSON(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,147 @@
+package narrative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
+	"strings"
+)
+
+// ErrCannotRegenerate is returned when a turn can't be played or narrated again: only
+// the session's latest turn can, while the server still holds its starting state, and
+// ironman sessions can't roll a turn back.
+var ErrCannotRegenerate = errors.New("the turn can't be regenerated")
+
+// ErrTurnNotFound is returned for a turn number the session hasn't reached.
+var ErrTurnNotFound = errors.New("no such turn")
+
+// retainedTurn is the state a session's latest turn started from, with what it was
+// played with, so the turn can be regenerated.
+type retainedTurn struct {
+	turn       int
+	checkpoint *session.Checkpoint
+	input      string
+	inputNotes []string
+	minigame   *bool
+	confirmed  bool           // Whether the turn applied the pending actions it started with
+	prompt     llm.PromptData // Context the turn was narrated from
+}
+
+// retainTurn keeps the starting state of the turn currentSession just played,
+// replacing the previous one.
+func (ne *NarrativeEngine) retainTurn(currentSession *session.GameSession, turn *retainedTurn) {
+	ne.retainedMu.Lock()
+	defer ne.retainedMu.Unlock()
+	if ne.retained == nil {
+		ne.retained = make(map[string]*retainedTurn)
+	}
+	turn.turn = currentSession.TurnCount
+	ne.retained[currentSession.ID] = turn
+}
+
+// regenerable returns the retained starting state of sess's turn, or ErrCannotRegenerate.
+// rollback says whether the turn will be rolled back, which ironman sessions forbid.
+func (ne *NarrativeEngine) regenerable(sess *session.GameSession, turn int, rollback bool) (*retainedTurn, error) {
+	if rollback {
+		if err := sess.CheckRewindable(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCannotRegenerate, err)
+		}
+	}
+	ne.retainedMu.Lock()
+	rt := ne.retained[sess.ID]
+	ne.retainedMu.Unlock()
+	if rt == nil || rt.turn != turn || turn != sess.TurnCount {
+		return nil, fmt.Errorf("session '%s' turn %d: %w (only the latest turn can be, until the server restarts)", sess.ID, turn, ErrCannotRegenerate)
+	}
+	return rt, nil
+}
+
+// regenerate rolls sess back to before its latest turn, keeping its reports, and plays
+// the turn again with the same input; note tells the narrator why. The caller must hold
+// the session's turn lock from the regenerable check on, so no turn lands in between.
+func (ne *NarrativeEngine) regenerate(ctx context.Context, sess *session.GameSession, rt *retainedTurn, note string) (*llm.LLMResponse, error) {
+	reports := sess.Reports
+	if err := sess.Restore(rt.checkpoint); err != nil {
+		return nil, err
+	}
+	sess.Reports = reports
+	notes := append(append([]string(nil), rt.inputNotes...), note)
+	return ne.runTurnLocked(ctx, sess, rt.input, notes, rt.minigame, rt.confirmed)
+}
+
+// RegenerateTurn plays turn n of the session again from its starting state. Without
+// confirm only the narration is rerolled: the actions already executed stand and the
+// narrator describes them anew. With confirm the turn is rolled back and replayed,
+// executing whatever actions the new response contains.
+func (ne *NarrativeEngine) RegenerateTurn(ctx context.Context, sessionID string, n int, confirm bool) (*llm.LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "narrative.regenerate", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("session.id", sessionID)
+
+	currentSession, err := ne.SessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve session '%s': %w", sessionID, err)
+	}
+	defer ne.lockTurn(sessionID)()
+	if n < 1 || n > currentSession.TurnCount {
+		return nil, fmt.Errorf("session '%s' turn %d: %w", sessionID, n, ErrTurnNotFound)
+	}
+	rt, err := ne.regenerable(currentSession, n, confirm)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *llm.LLMResponse
+	if confirm {
+		response, err = ne.regenerate(ctx, currentSession, rt, "The player asked for this turn to be played again. Write a fresh response to their input.")
+	} else {
+		response, err = ne.rerollNarration(ctx, currentSession, rt)
+	}
+	span.RecordError(err)
+	return response, err
+}
+
+// rerollNarration asks the narrator for new prose for sess's latest turn, from the
+// context the turn was narrated from. The turn's executed actions are passed along as
+// already resolved, and any actions in the new response are ignored; the narration
+// replaces the turn's narration in the session's history. Like regenerate, the caller
+// must hold the session's turn lock.
+func (ne *NarrativeEngine) rerollNarration(ctx context.Context, sess *session.GameSession, rt *retainedTurn) (*llm.LLMResponse, error) {
+	var resolved []string
+	for _, record := range sess.Memory {
+		if record.Turn == rt.turn && record.Type == history.TypeAction {
+			resolved = append(resolved, record.Summary)
+		}
+	}
+	promptData := rt.prompt
+	promptData.SystemNotes = append(append([]string(nil), rt.prompt.SystemNotes...), rerollNote(resolved))
+	response, err := ne.generate(ctx, sess, "reroll", promptData)
+	if err != nil {
+		return nil, fmt.Errorf("LLM adapter failed for session '%s': %w", sess.ID, err)
+	}
+	sess.Stats.AddUsage(response.Usage)
+	response = ne.moderateResponse(ctx, sess, &promptData, response)
+	response.Actions = nil // The turn's outcome is already settled
+
+	sess.ReplaceNarration(rt.turn, response.Narrative)
+	response.Rolls = sess.LastTurnRolls
+	response.Events = sess.LastTurnEvents
+	response.CombatLog = sess.LastTurnCombat
+	if err := ne.SessionManager.UpdateSession(sess); err != nil {
+		fmt.Printf("Warning: Failed to update session '%s' after rerolling narration: %v\n", sess.ID, err)
+	}
+	return response, nil
+}
+
+// rerollNote tells the narrator to rewrite the prose for outcomes already resolved.
+func rerollNote(resolved []string) string {
+	note := "The player asked for different narration of this turn. Write new prose for the same moment; don't return any actions."
+	if len(resolved) > 0 {
+		note += fmt.Sprintf(" The engine already resolved this turn as follows, so the narration must match: %s", strings.Join(resolved, "; "))
+	}
+	return note
+}
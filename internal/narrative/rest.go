@@ -0,0 +1,132 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/effects"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"strconv"
+	"strings"
+)
+
+// RestPolicy configures the 'rest' action.
+type RestPolicy struct {
+	Hours int // In-story hours a full rest takes
+	// TagSafety scales a location's encounter chance by tag, in percent: 0 makes
+	// resting there safe, 200 doubles the risk. The lowest value among the
+	// location's tags applies; untagged (or unlisted) locations use 100.
+	TagSafety map[string]int
+}
+
+// DefaultRestPolicy rests for 8 hours; taverns and barracks are safe and other
+// interiors halve the risk.
+func DefaultRestPolicy() *RestPolicy {
+	return &RestPolicy{Hours: 8, TagSafety: map[string]int{"tavern": 0, "barracks": 0, "interior": 50}}
+}
+
+// ParseRestSafety parses a "tag=percent,tag=percent" list for RestPolicy.TagSafety.
+func ParseRestSafety(spec string) (map[string]int, error) {
+	safety := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		tag, value, ok := strings.Cut(part, "=")
+		percent, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(tag) == "" || err != nil || percent < 0 {
+			return nil, fmt.Errorf("invalid rest safety entry '%s' (want tag=percent)", part)
+		}
+		safety[strings.TrimSpace(tag)] = percent
+	}
+	return safety, nil
+}
+
+// EncounterChance is the percent chance of a random encounter while resting at loc.
+func (p *RestPolicy) EncounterChance(loc *world.LocationNode) int {
+	if loc.Encounters == nil {
+		return 0
+	}
+	safety := 100
+	for _, tag := range loc.Tags {
+		if v, ok := p.TagSafety[tag]; ok && v < safety {
+			safety = v
+		}
+	}
+	return min(loc.Encounters.Chance*safety/100, 100)
+}
+
+func (e *SimpleActionExecutor) restPolicy() *RestPolicy {
+	if e.Rest == nil {
+		return DefaultRestPolicy()
+	}
+	return e.Rest
+}
+
+// handleRest processes the 'rest' action: the player rests for RestPolicy.Hours,
+// restoring all HP and clearing effects a rest cures (see effects.ClearRested). The
+// location's encounter table may interrupt the rest halfway with a fight instead.
+func (e *SimpleActionExecutor) handleRest(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't rest during combat")
+	}
+	loc, err := e.WorldSystem.GetLocation(currentSession.CurrentLocationID)
+	if err != nil {
+		return err
+	}
+	policy := e.restPolicy()
+	player := currentSession.Player
+	player.EnsureVitals()
+	drainForRest(currentSession, e.Survival) // Meals and lamp oil go while resting, interrupted or not
+
+	if enemies := e.restEncounter(currentSession, policy, loc); len(enemies) > 0 {
+		hours := policy.Hours / 2
+		currentSession.GameHours += hours
+		summary := fmt.Sprintf("%s's rest at %s is interrupted after %d hour(s).", player.Name, loc.Name, hours)
+		fmt.Printf("Executor: %s\n", summary)
+		currentSession.Record(history.ActorSystem, history.TypeAction, summary)
+		currentSession.Emit(events.NewRested(currentSession.TurnCount, hours, 0, true))
+		return e.beginCombat(currentSession, enemies)
+	}
+
+	currentSession.GameHours += policy.Hours
+	healed := player.Heal(player.MaxHP)
+	player.MP = player.MaxMP
+	summary := fmt.Sprintf("%s rests for %d hour(s) at %s and recovers %d HP (HP %d/%d).", player.Name, policy.Hours, loc.Name, healed, player.HP, player.MaxHP)
+	fmt.Printf("Executor: %s\n", summary)
+	currentSession.Record(history.ActorSystem, history.TypeAction, summary)
+	currentSession.Emit(events.NewRested(currentSession.TurnCount, policy.Hours, healed, false))
+	for _, effectID := range effects.ClearRested(player) {
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s is no longer %s.", player.Name, effectID))
+		currentSession.Emit(events.NewEffectExpired(currentSession.TurnCount, effectID))
+	}
+	return nil
+}
+
+// restEncounter rolls for a random encounter while resting at loc and returns the
+// enemies that turn up, if any.
+func (e *SimpleActionExecutor) restEncounter(sess *session.GameSession, policy *RestPolicy, loc *world.LocationNode) []combat.Combatant {
+	chance := policy.EncounterChance(loc)
+	if chance <= 0 || e.roller(sess).Roll(dice.Expression{Count: 1, Sides: 100}, dice.Normal).Total > chance {
+		return nil
+	}
+	entry := loc.Encounters.Pick(e.roller(sess))
+	if entry == nil {
+		return nil
+	}
+	creature, ok := e.Bestiary[entry.CreatureID]
+	if !ok {
+		fmt.Printf("Executor Warning: Encounter at '%s' references unknown creature '%s'\n", loc.ID, entry.CreatureID)
+		return nil
+	}
+	enemies := make([]combat.Combatant, 0, max(entry.Count, 1))
+	for range min(max(entry.Count, 1), maxEnemiesPerEntry) {
+		enemies = append(enemies, combat.FromCreature(creature))
+	}
+	return enemies
+}
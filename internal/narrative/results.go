@@ -0,0 +1,22 @@
+package narrative
+
+import (
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/llm"
+)
+
+// actionResult describes one executed action: err is its error (nil = it applied), and
+// changes and rolls are the events it emitted and the dice it rolled.
+func actionResult(action llm.LLMAction, err error, changes []events.Event, rolls []dice.Result) llm.ActionResult {
+	result := llm.ActionResult{
+		Type:    action.Type,
+		Success: err == nil,
+		Changes: append([]events.Event(nil), changes...),
+		Rolls:   append([]dice.Result(nil), rolls...),
+	}
+	if err != nil {
+		result.Message = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"strings"
+)
+
+// Rumors spread one exit further every RumorHopHours of in-story time and are no
+// longer talked about after RumorLifetimeHours.
+const (
+	RumorHopHours      = 6
+	RumorLifetimeHours = 7 * 24
+)
+
+// rumorKarma is the smallest karma shift for a deed worth talking about.
+const rumorKarma = 10
+
+// maxPromptRumors is the most rumors listed in a prompt, newest first.
+const maxPromptRumors = 5
+
+// handleSpreadRumor processes the 'spreadRumor' action: starts word of 'text' at the
+// current location.
+func (e *SimpleActionExecutor) handleSpreadRumor(action llm.LLMAction, currentSession *session.GameSession) error {
+	text, _ := action.Data["text"].(string)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return errors.New("action data field 'text' must be a non-empty string")
+	}
+	currentSession.AddRumor(text, currentSession.CurrentLocationID)
+	fmt.Printf("Executor: Rumor started at '%s': %s\n", currentSession.CurrentLocationID, text)
+	return nil
+}
+
+// rumorsContext lists for the prompt the rumors that have reached the session's
+// location by now, newest first, with where they came from and how long ago.
+func rumorsContext(ws world.WorldSystem, sess *session.GameSession) []string {
+	var lines []string
+	distances := make(map[string]map[string]int) // Origin -> location -> exits away
+	for i := len(sess.Rumors) - 1; i >= 0 && len(lines) < maxPromptRumors; i-- {
+		rumor := sess.Rumors[i]
+		age := sess.GameHours - rumor.Hour
+		if age > RumorLifetimeHours {
+			continue
+		}
+		if distances[rumor.LocationID] == nil {
+			// No rumor outlives RumorLifetimeHours, so none travels further than this
+			distances[rumor.LocationID] = world.DistancesWithin(ws, rumor.LocationID, RumorLifetimeHours/RumorHopHours)
+		}
+		hops, reachable := distances[rumor.LocationID][sess.CurrentLocationID]
+		if !reachable || age < hops*RumorHopHours {
+			continue
+		}
+		origin := "here"
+		if hops > 0 {
+			origin = rumor.LocationID
+			if loc, err := ws.GetLocation(rumor.LocationID); err == nil {
+				origin = loc.Name
+			}
+			origin = "at " + origin
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s, %s)", rumor.Text, origin, rumorAge(age)))
+	}
+	return lines
+}
+
+// rumorAge describes how long ago something happened, e.g. "2 days ago".
+func rumorAge(hours int) string {
+	switch {
+	case hours < 1:
+		return "just now"
+	case hours < 24:
+		return fmt.Sprintf("%d hour(s) ago", hours)
+	default:
+		return fmt.Sprintf("%d day(s) ago", hours/24)
+	}
+}
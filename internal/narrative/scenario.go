@@ -0,0 +1,70 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/events"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"strings"
+)
+
+// ScenarioRestrictionError is returned when the LLM moves the player outside the
+// locations the active scenario beat allows. Like ActionNotAllowedError, the engine
+// reacts by asking the LLM to re-narrate the turn.
+type ScenarioRestrictionError struct {
+	ScenarioID string
+	BeatID     string
+	TargetID   string
+	Allowed    []string
+}
+
+func (e *ScenarioRestrictionError) Error() string {
+	return fmt.Sprintf("scenario '%s' beat '%s' does not allow moving to '%s' (allowed: %v)", e.ScenarioID, e.BeatID, e.TargetID, e.Allowed)
+}
+
+// activeBeat returns the session's current scenario beat, or nil in free play.
+func activeBeat(scenarios map[string]*world.Scenario, sess *session.GameSession) (*world.Scenario, *world.Beat) {
+	if sess.ScenarioID == "" {
+		return nil, nil
+	}
+	scenario, ok := scenarios[sess.ScenarioID]
+	if !ok {
+		return nil, nil // Scenario removed since the session started; fall back to free play
+	}
+	beat := scenario.Beat(sess.ScenarioBeat)
+	if beat == nil {
+		return nil, nil
+	}
+	return scenario, beat
+}
+
+// scenarioNotes tells the narrator what the active beat requires.
+func scenarioNotes(scenario *world.Scenario, beat *world.Beat) []string {
+	notes := []string{fmt.Sprintf("The player is in the guided opening '%s'. Their current objective is: %s. Steer the story toward it without completing it for them.", scenario.Name, beat.Objective)}
+	if beat.Prompt != "" {
+		notes = append(notes, beat.Prompt)
+	}
+	if len(beat.AllowedLocations) > 0 {
+		notes = append(notes, fmt.Sprintf("Until the objective is complete, the player may only travel to: %s.", strings.Join(beat.AllowedLocations, ", ")))
+	}
+	return notes
+}
+
+// advanceScenario completes every consecutive beat whose condition now holds, emitting a
+// questUpdated event for each and one more when the scenario (and free play) is reached.
+func advanceScenario(scenarios map[string]*world.Scenario, sess *session.GameSession) {
+	turnEvents := sess.LastTurnEvents
+	for {
+		scenario, beat := activeBeat(scenarios, sess)
+		if beat == nil || !beat.Complete.Met(sess.CurrentLocationID, sess.Karma, turnEvents) {
+			return
+		}
+		sess.ScenarioBeat++
+		sess.Emit(events.NewQuestUpdated(sess.TurnCount, scenario.ID+"."+beat.ID, "completed", beat.Objective))
+		if next := scenario.Beat(sess.ScenarioBeat); next != nil {
+			sess.Emit(events.NewQuestUpdated(sess.TurnCount, scenario.ID+"."+next.ID, "active", next.Objective))
+		} else {
+			sess.Emit(events.NewQuestUpdated(sess.TurnCount, scenario.ID, "completed", scenario.Name))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package narrative
+
+import "llmrpg/internal/llm"
+
+// KnownActionTypes lists every action type the executor understands.
+var KnownActionTypes = []ActionType{UpdateLocation, AddItem, RemoveItem, ApplyEffect, SkillCheck, SetFlag, Search, Rest, Challenge, RepairItem, UseAbility, LearnAbility, GainXP, AdjustKarma, RegisterEntity, SpreadRumor, TakeFromContainer, PutInContainer, Interact, AcquireMount, Board, Dismount, StartCombat, Attack, Defend, Flee, UseItem, Sneak, SetStealth, SneakAttack, Pickpocket}
+
+// ActionResponseSchema builds the provider response schema, restricting action
+// types to KnownActionTypes and describing the parameters each action accepts.
+func ActionResponseSchema() *llm.JSONSchema {
+	actionTypes := make([]string, 0, len(KnownActionTypes))
+	for _, t := range KnownActionTypes {
+		actionTypes = append(actionTypes, string(t))
+	}
+
+	// Union of all action parameters; which ones apply depends on the action type.
+	actionData := &llm.JSONSchema{
+		Type:        "object",
+		Description: "Parameters for the action: updateLocation uses locationId; addItem/removeItem use itemId and count; applyEffect uses effectId, duration and description; skillCheck uses notation, dc, mode and label; setFlag uses flag, value and optionally karma; challenge uses kind and dc; repairItem uses itemId; useAbility uses abilityId and targetId; learnAbility uses abilityId; gainXp and adjustKarma use amount and reason; registerEntity uses name, kind and description; spreadRumor uses text; takeFromContainer and putInContainer use containerId, itemId and count; interact uses interactionId; acquireMount and board use itemId; startCombat uses enemies; attack and sneakAttack use targetId; useItem uses itemId and targetId; sneak uses dc; setStealth uses state; pickpocket uses itemId and dc; defend, flee, search, rest and dismount take no parameters",
+		Properties: map[string]*llm.JSONSchema{
+			"locationId":    {Type: "string"},
+			"itemId":        {Type: "string"},
+			"count":         {Type: "integer"},
+			"effectId":      {Type: "string"},
+			"duration":      {Type: "integer", Description: "Turns; omit for an effect that lasts until removed"},
+			"description":   {Type: "string"},
+			"notation":      {Type: "string", Description: "Dice notation such as 1d20+2"},
+			"dc":            {Type: "integer"},
+			"mode":          {Type: "string", Enum: []string{"normal", "advantage", "disadvantage"}},
+			"label":         {Type: "string"},
+			"flag":          {Type: "string"},
+			"value":         {Type: "boolean"},
+			"karma":         {Type: "integer", Description: "Karma shift for the choice the flag records, -25 to 25"},
+			"targetId":      {Type: "string", Description: "Enemy ID from the combat context"},
+			"state":         {Type: "string", Enum: []string{"suspected", "detected"}},
+			"kind":          {Type: "string", Description: "Challenge kind (e.g. lockpicking, hacking or persuasion), or for registerEntity one of: npc, place, shop, item, faction, creature, other"},
+			"containerId":   {Type: "string", Description: "Container ID from the location context"},
+			"interactionId": {Type: "string", Description: "Interaction ID from the location context"},
+			"abilityId":     {Type: "string", Description: "Ability ID from the player's ability list"},
+			"amount":        {Type: "integer"},
+			"reason":        {Type: "string"},
+			"name":          {Type: "string"},
+			"text":          {Type: "string", Description: "A rumor as locals would retell it"},
+			"enemies": {
+				Type: "array",
+				Items: &llm.JSONSchema{
+					Type: "object",
+					Properties: map[string]*llm.JSONSchema{
+						"creatureId":  {Type: "string", Description: "Bestiary creature ID; required when the context lists creatures"},
+						"count":       {Type: "integer"},
+						"name":        {Type: "string"},
+						"hp":          {Type: "integer"},
+						"armorClass":  {Type: "integer"},
+						"attackBonus": {Type: "integer"},
+						"damage":      {Type: "string", Description: "Dice notation such as 1d6+1"},
+					},
+				},
+			},
+		},
+	}
+
+	return llm.ResponseSchema(actionTypes, actionData)
+}
@@ -0,0 +1,186 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+)
+
+// Default difficulty classes for stealth checks, when the action gives no 'dc'.
+const (
+	DefaultSneakDC      = 12
+	DefaultPickpocketDC = 13
+)
+
+// StealthRequiredError is returned when the LLM uses an action that needs the player
+// unnoticed (sneakAttack, pickpocket) in the wrong stealth state. Like
+// ActionNotAllowedError, the engine reacts by asking the LLM to re-narrate the turn.
+type StealthRequiredError struct {
+	ActionType ActionType
+	State      session.StealthState
+}
+
+func (e *StealthRequiredError) Error() string {
+	return fmt.Sprintf("action type '%s' requires the player to be hidden (stealth: %s)", e.ActionType, stealthLabel(e.State))
+}
+
+func stealthLabel(state session.StealthState) string {
+	if state == "" {
+		return "not sneaking"
+	}
+	return string(state)
+}
+
+// setStealth moves the session to state, recording and reporting the change.
+func setStealth(sess *session.GameSession, state session.StealthState) {
+	if sess.Stealth == state {
+		return
+	}
+	from := sess.Stealth
+	sess.Stealth = state
+	fmt.Printf("Executor: Stealth %s -> %s\n", stealthLabel(from), stealthLabel(state))
+	if state != "" {
+		sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("Stealth: %s is now %s.", sess.Player.Name, state))
+	}
+	sess.Emit(events.NewStealthChanged(sess.TurnCount, string(from), string(state)))
+}
+
+// stealthCheck rolls a d20 check against 'dc' (default defaultDC) for a stealth action,
+// recording it like a skill check.
+func (e *SimpleActionExecutor) stealthCheck(action llm.LLMAction, sess *session.GameSession, defaultDC int, mode dice.Mode, label string) (bool, error) {
+	dc := defaultDC
+	if v, ok := action.Data["dc"]; ok {
+		n, ok := v.(float64) // JSON numbers decode as float64
+		if !ok {
+			return false, errors.New("action data field 'dc' must be a number")
+		}
+		dc = int(n)
+	}
+	expr := dice.Expression{Count: 1, Sides: 20, Modifier: checkBonus(sess.Player)}
+	result := e.roller(sess).Check(expr, mode, dc, label)
+	sess.LastTurnRolls = append(sess.LastTurnRolls, result)
+	outcome := "failure"
+	if *result.Success {
+		outcome = "success"
+	}
+	summary := fmt.Sprintf("%s rolled %s = %d vs DC %d (%s)", label, result.Notation, result.Total, dc, outcome)
+	fmt.Printf("Executor: %s\n", summary)
+	sess.Record(history.ActorSystem, history.TypeAction, summary)
+	return *result.Success, nil
+}
+
+// handleSneak processes the 'sneak' action: a stealth check (against 'dc', default
+// DefaultSneakDC; with disadvantage once detected). Success hides the player; failure
+// makes them suspected, or detected if they already were.
+func (e *SimpleActionExecutor) handleSneak(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't sneak during combat")
+	}
+	mode := dice.Normal
+	if currentSession.Stealth == session.StealthDetected {
+		mode = dice.Disadvantage
+	}
+	success, err := e.stealthCheck(action, currentSession, DefaultSneakDC, mode, "Stealth")
+	if err != nil {
+		return err
+	}
+	switch {
+	case success:
+		setStealth(currentSession, session.StealthHidden)
+	case currentSession.Stealth == session.StealthSuspected || currentSession.Stealth == session.StealthDetected:
+		setStealth(currentSession, session.StealthDetected)
+	default:
+		setStealth(currentSession, session.StealthSuspected)
+	}
+	return nil
+}
+
+// handleSetStealth processes the 'setStealth' action: the narrator raises suspicion
+// ('state' "suspected" or "detected", default detected), e.g. when the player knocks
+// something over. It can never hide the player; that takes a 'sneak' check.
+func (e *SimpleActionExecutor) handleSetStealth(action llm.LLMAction, currentSession *session.GameSession) error {
+	state := session.StealthDetected
+	if v, ok := action.Data["state"].(string); ok && v != "" {
+		state = session.StealthState(v)
+	}
+	switch state {
+	case session.StealthSuspected:
+		if currentSession.Stealth == session.StealthDetected {
+			return nil // Already worse
+		}
+	case session.StealthDetected:
+	default:
+		return fmt.Errorf("action data field 'state' must be 'suspected' or 'detected' (use 'sneak' to hide), got '%s'", state)
+	}
+	setStealth(currentSession, state)
+	return nil
+}
+
+// handleSneakAttack processes the 'sneakAttack' action: while hidden in a fight that
+// hasn't started its first round, the player strikes 'targetId' with advantage and
+// extra damage (see combat.Rules.SneakAttack). Afterwards the player is detected.
+func (e *SimpleActionExecutor) handleSneakAttack(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat == nil {
+		return errNotInCombat
+	}
+	if currentSession.Stealth != session.StealthHidden || currentSession.Combat.Round > 0 {
+		return &StealthRequiredError{ActionType: SneakAttack, State: currentSession.Stealth}
+	}
+	targetID, _ := action.Data["targetId"].(string)
+	entries, err := e.rules(currentSession).SneakAttack(currentSession.Combat, currentSession.Player, targetID)
+	if err != nil {
+		return err
+	}
+	e.logCombat(currentSession, entries)
+	return nil
+}
+
+// handlePickpocket processes the 'pickpocket' action: a sleight-of-hand check (against
+// 'dc', default DefaultPickpocketDC) to take 'itemId' from someone nearby. The player
+// must be hidden, or suspected at disadvantage. Failure gets them detected.
+func (e *SimpleActionExecutor) handlePickpocket(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't pickpocket during combat")
+	}
+	mode := dice.Normal
+	switch currentSession.Stealth {
+	case session.StealthHidden:
+	case session.StealthSuspected:
+		mode = dice.Disadvantage
+	default:
+		return &StealthRequiredError{ActionType: Pickpocket, State: currentSession.Stealth}
+	}
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return err
+	}
+	success, err := e.stealthCheck(action, currentSession, DefaultPickpocketDC, mode, "Pickpocket")
+	if err != nil {
+		return err
+	}
+	if !success {
+		setStealth(currentSession, session.StealthDetected)
+		return nil
+	}
+	currentSession.Player.AddItem(item.ID, 1)
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s pickpockets %s.", currentSession.Player.Name, item.Name))
+	currentSession.Emit(events.NewItemGained(currentSession.TurnCount, item.ID, 1))
+	return nil
+}
+
+// stealthContext describes the player's stealth state for the prompt ("" when not sneaking).
+func stealthContext(state session.StealthState) string {
+	switch state {
+	case session.StealthHidden:
+		return "hidden (unnoticed; sneakAttack and pickpocket are possible)"
+	case session.StealthSuspected:
+		return "suspected (someone is searching; pickpocket is risky, sneakAttack impossible)"
+	case session.StealthDetected:
+		return "detected (the player has been spotted)"
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// resourceLevel returns the session's level of res; resources start full.
+func resourceLevel(sess *session.GameSession, res *world.SurvivalResource) int {
+	if level, ok := sess.Resources[res.ID]; ok {
+		return level
+	}
+	return res.Max
+}
+
+func setResourceLevel(sess *session.GameSession, res *world.SurvivalResource, level int) {
+	if sess.Resources == nil {
+		sess.Resources = make(map[string]int)
+	}
+	sess.Resources[res.ID] = min(max(level, 0), res.Max)
+}
+
+// resolveSurvival is the start-of-turn step for worlds with survival rules: resources
+// drain (some only at locations with certain tags), depleted resources hurt the player
+// or apply their effect, and restored ones lift it again. It returns notes warning the
+// narrator about low or depleted resources.
+func resolveSurvival(sess *session.GameSession, rules *world.SurvivalRules, loc *world.LocationNode) []string {
+	if rules == nil {
+		return nil
+	}
+	var tags []string
+	if loc != nil {
+		tags = loc.Tags
+	}
+	var notes []string
+	player := sess.Player
+	for i := range rules.Resources {
+		res := &rules.Resources[i]
+		level := resourceLevel(sess, res)
+		if res.DrainsAt(tags) {
+			level = max(level-res.DrainPerTurn, 0)
+			setResourceLevel(sess, res, level)
+		}
+
+		if level > 0 {
+			if res.Depleted.Effect != "" && player.RemoveEffect(res.Depleted.Effect) {
+				sess.Emit(events.NewEffectExpired(sess.TurnCount, res.Depleted.Effect))
+			}
+			if warning := res.Warning(level); warning != "" {
+				notes = append(notes, fmt.Sprintf("%s is low (%d/%d): %s", res.Name, level, res.Max, warning))
+			}
+			continue
+		}
+
+		notes = append(notes, fmt.Sprintf("%s has run out: %s", res.Name, res.Depleted.Message))
+		if effectID := res.Depleted.Effect; effectID != "" && !player.HasEffect(effectID) {
+			player.AddEffect(character.Effect{ID: effectID, Description: res.Depleted.Message})
+			sess.Emit(events.NewEffectApplied(sess.TurnCount, effectID, 0, res.Depleted.Message))
+		}
+		if res.Depleted.Damage > 0 {
+			lost := player.TakeDamage(res.Depleted.Damage)
+			sess.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s loses %d HP for lack of %s (HP %d/%d).", player.Name, lost, res.Name, player.HP, player.MaxHP))
+			if note := succumb(sess, "lack of "+res.Name); note != "" {
+				return append(notes, note)
+			}
+		}
+	}
+	return notes
+}
+
+// drainForRest applies each resource's extra cost of resting.
+func drainForRest(sess *session.GameSession, rules *world.SurvivalRules) {
+	if rules == nil {
+		return
+	}
+	for i := range rules.Resources {
+		res := &rules.Resources[i]
+		if res.DrainPerRest > 0 {
+			setResourceLevel(sess, res, resourceLevel(sess, res)-res.DrainPerRest)
+		}
+	}
+}
+
+// restoreResources applies an item's survival restores and returns a summary, or "".
+func restoreResources(sess *session.GameSession, rules *world.SurvivalRules, item *world.Item) string {
+	if rules == nil {
+		return ""
+	}
+	summary := ""
+	for i := range rules.Resources {
+		res := &rules.Resources[i]
+		amount, ok := item.Restores[res.ID]
+		if !ok {
+			continue
+		}
+		setResourceLevel(sess, res, resourceLevel(sess, res)+amount)
+		if summary != "" {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%s %d/%d", res.Name, resourceLevel(sess, res), res.Max)
+	}
+	if summary == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s uses %s (%s).", sess.Player.Name, item.Name, summary)
+}
+
+// survivalContext lists resource levels for the prompt.
+func survivalContext(sess *session.GameSession, rules *world.SurvivalRules) []string {
+	if rules == nil {
+		return nil
+	}
+	lines := make([]string, 0, len(rules.Resources))
+	for i := range rules.Resources {
+		res := &rules.Resources[i]
+		lines = append(lines, fmt.Sprintf("%s %d/%d", res.Name, resourceLevel(sess, res), res.Max))
+	}
+	return lines
+}
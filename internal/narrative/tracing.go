@@ -0,0 +1,61 @@
+package narrative
+
+import (
+	"context"
+
+	"llmrpg/internal/clock"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
+)
+
+// generate calls the LLM adapter with the session's system prompt inside an
+// "llm.generate" span. purpose names the call (turn, renarrate, epilogue) so the
+// spans of one turn can be told apart; the adapter's HTTP request appears as a child
+// span, so the gap between the two is time spent queued for an LLM slot. In debug
+// mode the call is also added to the turn's call log.
+func (ne *NarrativeEngine) generate(ctx context.Context, sess *session.GameSession, purpose string, promptData llm.PromptData) (*llm.LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "llm.generate", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("llm.purpose", purpose)
+
+	started := clock.Now()
+	response, err := ne.LLMAdapter.GenerateResponse(ctx, ne.systemPrompt(sess), promptData)
+	llm.CallLogFrom(ctx).Record(purpose, clock.Now().Sub(started), response, err)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttr("llm.actions", len(response.Actions))
+	if response.Usage != nil {
+		span.SetAttr("llm.usage.prompt_tokens", response.Usage.PromptTokens)
+		span.SetAttr("llm.usage.completion_tokens", response.Usage.CompletionTokens)
+	}
+	return response, nil
+}
+
+// executeActions runs the actions the LLM proposed, holding high-impact ones for the
+// player's confirmation if the session asks for it (see holdActions). Held actions'
+// results follow those of the executed ones.
+func (ne *NarrativeEngine) executeActions(ctx context.Context, actions []llm.LLMAction, sess *session.GameSession) []error {
+	heldBefore := len(sess.PendingActions)
+	executionErrors := ne.applyActions(ctx, ne.holdActions(sess, actions), sess)
+	for _, held := range sess.PendingActions[heldBefore:] {
+		sess.LastTurnResults = append(sess.LastTurnResults, pendingResult(held))
+	}
+	return executionErrors
+}
+
+// applyActions runs the executor inside an "actions.execute" span.
+func (ne *NarrativeEngine) applyActions(ctx context.Context, actions []llm.LLMAction, sess *session.GameSession) []error {
+	_, span := tracing.Start(ctx, "actions.execute", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("actions.count", len(actions))
+
+	executionErrors := ne.ActionExecutor.ExecuteActions(actions, sess)
+	span.SetAttr("actions.errors", len(executionErrors))
+	if len(executionErrors) > 0 {
+		span.RecordError(executionErrors[0])
+	}
+	return executionErrors
+}
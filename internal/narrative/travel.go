@@ -0,0 +1,158 @@
+package narrative
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/character"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+)
+
+// RouteBlockedError is returned when the LLM moves the player along a route that needs
+// a mount or vehicle they aren't riding. Like EffectRestrictionError, the engine reacts
+// by asking the LLM to re-narrate the turn.
+type RouteBlockedError struct {
+	TargetID string
+	Requires string
+}
+
+func (e *RouteBlockedError) Error() string {
+	return fmt.Sprintf("the route to '%s' requires a %s", e.TargetID, e.Requires)
+}
+
+// currentMount returns the item the player is riding, or nil on foot.
+func (e *SimpleActionExecutor) currentMount(sess *session.GameSession) *world.Item {
+	if item, ok := e.Items[sess.Mount]; ok && item.Mount != nil {
+		return item
+	}
+	return nil
+}
+
+// travelHours checks the route from fromID to toID (if it has one) against the player's
+// mount and returns how many in-story hours the trip takes.
+func (e *SimpleActionExecutor) travelHours(sess *session.GameSession, fromID, toID string) (int, error) {
+	from, err := e.WorldSystem.GetLocation(fromID)
+	if err != nil {
+		return 0, err
+	}
+	route := from.Route(toID)
+	if route == nil {
+		return 0, nil
+	}
+	var mount *world.Mount
+	if item := e.currentMount(sess); item != nil {
+		mount = item.Mount
+	}
+	if route.Requires != "" && (mount == nil || mount.Kind != route.Requires) {
+		return 0, &RouteBlockedError{TargetID: toID, Requires: route.Requires}
+	}
+	return route.TravelHours(mount), nil
+}
+
+// setMount changes what the player is riding ("" = on foot), reporting the change.
+func setMount(sess *session.GameSession, itemID string) {
+	if sess.Mount == itemID {
+		return
+	}
+	from := sess.Mount
+	sess.Mount = itemID
+	fmt.Printf("Executor: Mount '%s' -> '%s'\n", from, itemID)
+	sess.Emit(events.NewMountChanged(sess.TurnCount, from, itemID))
+}
+
+// dismountIfGone puts the player on foot if they no longer have itemID and were riding it.
+func dismountIfGone(sess *session.GameSession, itemID string) {
+	if sess.Mount == itemID && sess.Player.ItemCount(itemID) == 0 {
+		setMount(sess, "")
+	}
+}
+
+// lookupMount resolves the action's 'itemId' to an item the player can board.
+func (e *SimpleActionExecutor) lookupMount(action llm.LLMAction) (*world.Item, error) {
+	item, err := e.lookupItem(action)
+	if err != nil {
+		return nil, err
+	}
+	if item.Mount == nil {
+		return nil, fmt.Errorf("item '%s' is not a mount or vehicle", item.ID)
+	}
+	return item, nil
+}
+
+// handleAcquireMount processes the 'acquireMount' action: the player gains the mount or
+// vehicle 'itemId' (bought, tamed, found). It goes into the inventory until boarded.
+func (e *SimpleActionExecutor) handleAcquireMount(action llm.LLMAction, currentSession *session.GameSession) error {
+	item, err := e.lookupMount(action)
+	if err != nil {
+		return err
+	}
+	currentSession.Player.AddItem(item.ID, 1)
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s acquires %s.", currentSession.Player.Name, item.Name))
+	currentSession.Emit(events.NewItemGained(currentSession.TurnCount, item.ID, 1))
+	return nil
+}
+
+// handleBoard processes the 'board' action: the player mounts or boards 'itemId', which
+// they must carry. Routes then take the mount's travel time and allow its kind.
+func (e *SimpleActionExecutor) handleBoard(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Combat != nil {
+		return errors.New("the player can't board a mount during combat")
+	}
+	item, err := e.lookupMount(action)
+	if err != nil {
+		return err
+	}
+	if currentSession.Player.ItemCount(item.ID) < 1 {
+		return fmt.Errorf("%w: the player doesn't have mount '%s'", character.ErrInsufficientItems, item.ID)
+	}
+	if currentSession.Mount != item.ID {
+		currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s boards %s.", currentSession.Player.Name, item.Name))
+	}
+	setMount(currentSession, item.ID)
+	return nil
+}
+
+// handleDismount processes the 'dismount' action: the player continues on foot.
+func (e *SimpleActionExecutor) handleDismount(action llm.LLMAction, currentSession *session.GameSession) error {
+	if currentSession.Mount == "" {
+		return errors.New("the player isn't riding anything")
+	}
+	name := currentSession.Mount
+	if item, ok := e.Items[name]; ok {
+		name = item.Name
+	}
+	currentSession.Record(history.ActorSystem, history.TypeAction, fmt.Sprintf("%s leaves %s.", currentSession.Player.Name, name))
+	setMount(currentSession, "")
+	return nil
+}
+
+// mountContext describes what the player is riding for the prompt ("" on foot).
+func mountContext(sess *session.GameSession, items map[string]*world.Item) string {
+	item, ok := items[sess.Mount]
+	if !ok || item.Mount == nil {
+		return ""
+	}
+	line := fmt.Sprintf("%s (%s, a %s)", item.Name, item.ID, item.Mount.Kind)
+	if item.Mount.Travel > 0 && item.Mount.Travel != 100 {
+		line += fmt.Sprintf(": journeys take %d%% of the time on foot", item.Mount.Travel)
+	}
+	return line
+}
+
+// routeContext describes an exit's route for the adjacent locations list ("" if none).
+func routeContext(route *world.Route) string {
+	if route == nil {
+		return ""
+	}
+	var line string
+	if route.Hours > 0 {
+		line = fmt.Sprintf(", %dh on foot", route.Hours)
+	}
+	if route.Requires != "" {
+		line += fmt.Sprintf(", requires a %s", route.Requires)
+	}
+	return line
+}
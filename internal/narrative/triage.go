@@ -0,0 +1,238 @@
+package narrative
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"llmrpg/internal/clock"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/session"
+	"llmrpg/internal/tracing"
+)
+
+// --- Input Triage ---
+// Many turns don't need the narrator: walking to the next location, asking where the
+// exits are, "thanks!". With triage on, a small, cheap model first labels the input,
+// and kinds with a mechanical answer are resolved by the engine from templates; every
+// other turn is narrated as usual. Triage only runs on plain turns: nothing pending,
+// no fight, no guided opening and no notes the narrator has to work into the story.
+
+// InputKind is triage's label for a player input.
+type InputKind string
+
+const (
+	InputMovement InputKind = "movement" // Going to one of the listed exits, nothing more
+	InputQuestion InputKind = "question" // Asking about the scene or their own state (where am I, where can I go)
+	InputCombat   InputKind = "combat"   // Attacking or otherwise starting or continuing a fight
+	InputChatter  InputKind = "chatter"  // Out-of-character remarks to the game, not to anyone in the story
+	InputOther    InputKind = "other"    // Anything else: narrate it
+)
+
+// DefaultTriageShortcuts are the kinds answered without the narrator by default.
+var DefaultTriageShortcuts = []InputKind{InputMovement, InputQuestion, InputChatter}
+
+// ParseInputKind parses an InputKind name.
+func ParseInputKind(s string) (InputKind, error) {
+	switch kind := InputKind(s); kind {
+	case InputMovement, InputQuestion, InputCombat, InputChatter, InputOther:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown input kind '%s' (expected movement, question, combat, chatter or other)", s)
+	}
+}
+
+// TriagePolicy classifies player input with a cheap model before the turn is narrated.
+type TriagePolicy struct {
+	Adapter   llm.Adapter // The triage model; keep it small, it runs on every plain turn
+	Shortcuts []InputKind // Kinds answered mechanically (nil = DefaultTriageShortcuts). Combat and other always go to the narrator
+}
+
+// shortcut reports whether kind is answered without the narrator.
+func (p *TriagePolicy) shortcut(kind InputKind) bool {
+	shortcuts := p.Shortcuts
+	if shortcuts == nil {
+		shortcuts = DefaultTriageShortcuts
+	}
+	return slices.Contains(shortcuts, kind)
+}
+
+// triagePrompt instructs the triage model. It replies in the narrator's response
+// format so the same adapters and response schema serve both.
+const triagePrompt = `You sort a text adventure player's input into one kind, so the game knows whether it needs a full narration. Reply with JSON: put only the kind in "narrative", leave "suggestions" empty, and return no actions except as described for movement.
+
+Kinds:
+- movement: the player only wants to go to one of the places listed under "Nearby". Return one updateLocation action with that place's ID as "locationId". If the destination isn't listed, or the player also does something else on the way, it is "other".
+- question: the player asks about their surroundings or their own state (where they are, where they can go, who is here, what they carry, how hurt they are), not about the world's lore or history, and not a question to a character.
+- combat: the player attacks, fights or threatens someone.
+- chatter: an out-of-character remark to the game itself (thanks, greetings to the game, "lol", "brb"), not something said to a character in the story.
+- other: anything else, and anything you're unsure about.`
+
+// triageRecentActions is how many recent records the triage model sees, so it can tell
+// a reply to a character from chatter.
+const triageRecentActions = 4
+
+// classifyInput asks the triage model what kind of input promptData's is. For
+// movement it also returns the destination the model picked ("" if none).
+func (p *TriagePolicy) classifyInput(ctx context.Context, promptData *llm.PromptData) (InputKind, string, *llm.TokenUsage, error) {
+	ctx, span := tracing.Start(ctx, "llm.triage", tracing.KindInternal)
+	defer span.End()
+
+	brief := llm.PromptData{
+		LocationContext: promptData.LocationContext,
+		PlayerInput:     promptData.PlayerInput,
+		ContentRating:   promptData.ContentRating,
+	}
+	recent := promptData.SessionContext.RecentActions
+	brief.SessionContext.RecentActions = recent[max(0, len(recent)-triageRecentActions):]
+	started := clock.Now()
+	response, err := p.Adapter.GenerateResponse(ctx, triagePrompt, brief)
+	llm.CallLogFrom(ctx).Record("triage", clock.Now().Sub(started), response, err)
+	if err != nil {
+		span.RecordError(err)
+		return "", "", nil, err
+	}
+
+	kind, err := ParseInputKind(strings.ToLower(strings.TrimSpace(response.Narrative)))
+	if err != nil {
+		kind = InputOther // A confused classifier means the narrator should handle it
+	}
+	span.SetAttr("triage.kind", string(kind))
+	var destination string
+	if kind == InputMovement {
+		for _, action := range response.Actions {
+			if ActionType(action.Type) == UpdateLocation {
+				destination, _ = action.Data["locationId"].(string)
+				break
+			}
+		}
+	}
+	return kind, destination, response.Usage, nil
+}
+
+// triageTurn classifies the turn's input and, if its kind has a mechanical answer,
+// resolves the turn without the narrator. It returns nil when the turn should be
+// narrated as usual, including when triage fails or the mechanical answer doesn't fit.
+func (ne *NarrativeEngine) triageTurn(ctx context.Context, sess *session.GameSession, promptData *llm.PromptData) *llm.LLMResponse {
+	kind, destination, usage, err := ne.Triage.classifyInput(ctx, promptData)
+	sess.Stats.AddUsage(usage)
+	if err != nil {
+		fmt.Printf("Warning: Input triage failed for session '%s', narrating the turn: %v\n", sess.ID, err)
+		return nil
+	}
+	fmt.Printf("NarrativeEngine: Triage labelled input for session %s as %s.\n", sess.ID, kind)
+	if !ne.Triage.shortcut(kind) {
+		return nil
+	}
+
+	var response *llm.LLMResponse
+	switch kind {
+	case InputMovement:
+		response = ne.mechanicalMove(ctx, sess, promptData, destination)
+	case InputQuestion:
+		response = &llm.LLMResponse{Narrative: ne.sceneSummary(sess), Suggestions: ne.exitSuggestions(sess)}
+	case InputChatter:
+		response = &llm.LLMResponse{Narrative: chatterNarrative, Suggestions: ne.exitSuggestions(sess)}
+	}
+	if response == nil {
+		return nil
+	}
+	response.Triage = string(kind)
+	sess.Stats.TriagedTurns++
+	return response
+}
+
+// chatterNarrative answers out-of-character remarks.
+const chatterNarrative = "(The story waits for you. Tell it what you do next.)"
+
+// mechanicalMove moves the player to destination, one of the exits in promptData, and
+// describes where they arrive. It returns nil, leaving the session as it was, if the
+// destination isn't an exit or the move is refused (a closed road, a missing mount).
+func (ne *NarrativeEngine) mechanicalMove(ctx context.Context, sess *session.GameSession, promptData *llm.PromptData, destination string) *llm.LLMResponse {
+	if destination == "" || !slices.Contains(promptData.LocationContext.AdjacentLocationIDs, destination) {
+		return nil
+	}
+	move := llm.LLMAction{Type: string(UpdateLocation), Data: map[string]interface{}{"locationId": destination}}
+	resultsBefore := len(sess.LastTurnResults)
+	if errs := ne.executeActions(ctx, []llm.LLMAction{move}, sess); len(errs) > 0 {
+		// A refused move changes nothing; the narrator plays the turn and tells of the refusal
+		fmt.Printf("NarrativeEngine: Mechanical move to '%s' for session %s was refused, narrating the turn: %v\n", destination, sess.ID, errs)
+		sess.LastTurnResults = sess.LastTurnResults[:resultsBefore]
+		return nil
+	}
+
+	arrived, err := ne.WorldSystem.GetLocation(destination)
+	if err != nil {
+		return nil
+	}
+	narrative := fmt.Sprintf("You make your way to %s.\n\n%s", arrived.Name, arrived.Description)
+	return &llm.LLMResponse{Narrative: narrative, Suggestions: ne.exitSuggestions(sess), Actions: []llm.LLMAction{move}}
+}
+
+// sceneSummary answers a question about the scene: where the player is, the ways on,
+// who is here, and the player's health and belongings.
+func (ne *NarrativeEngine) sceneSummary(sess *session.GameSession) string {
+	loc, err := ne.WorldSystem.GetLocation(sess.CurrentLocationID)
+	if err != nil {
+		return ""
+	}
+	lines := []string{fmt.Sprintf("You are at %s. %s", loc.Name, loc.Description)}
+	if exits := ne.exitNames(sess); len(exits) > 0 {
+		lines = append(lines, fmt.Sprintf("From here you can go to: %s.", strings.Join(exits, ", ")))
+	}
+	people, _ := npcsContext(ne.NPCs, loc.ID, sess.HourOfDay())
+	for i, line := range people {
+		people[i], _, _ = strings.Cut(line, " (") // Just the names
+	}
+	if len(people) > 0 {
+		lines = append(lines, fmt.Sprintf("Here with you: %s.", strings.Join(people, ", ")))
+	}
+	lines = append(lines, fmt.Sprintf("Health: %d/%d.", sess.Player.HP, sess.Player.MaxHP))
+	var carried []string
+	for _, line := range inventoryContext(sess, ne.Items) {
+		name, rest, _ := strings.Cut(line, " (")
+		_, count, _ := strings.Cut(rest, ") ")
+		carried = append(carried, name+" "+count)
+	}
+	if len(carried) > 0 {
+		lines = append(lines, fmt.Sprintf("You carry: %s.", strings.Join(carried, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exitNames lists the names of the places the player can go from the current location.
+func (ne *NarrativeEngine) exitNames(sess *session.GameSession) []string {
+	adjacent, err := ne.WorldSystem.GetAdjacentLocations(sess.CurrentLocationID)
+	if err != nil {
+		return nil
+	}
+	adjacent = append(adjacent, ne.openedExits(sess, sess.CurrentLocationID)...)
+	var names []string
+	for _, loc := range adjacent {
+		if loc != nil {
+			names = append(names, loc.Name)
+		}
+	}
+	return names
+}
+
+// exitSuggestions suggests going to each exit of the current location.
+func (ne *NarrativeEngine) exitSuggestions(sess *session.GameSession) []string {
+	var suggestions []string
+	for _, name := range ne.exitNames(sess) {
+		suggestions = append(suggestions, "Go to "+name)
+	}
+	return suggestions
+}
+
+// triageable reports whether a turn is plain enough for triage: no notes the narrator
+// must work in (effects, settled challenges or pending actions, guard or moderation
+// warnings), no fight and no active guided opening.
+func (ne *NarrativeEngine) triageable(sess *session.GameSession, notes int) bool {
+	if ne.Triage == nil || notes > 0 || sess.Combat != nil {
+		return false
+	}
+	_, beat := activeBeat(ne.Scenarios, sess)
+	return beat == nil
+}
@@ -0,0 +1,170 @@
+package narrative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmrpg/internal/clock"
+	"llmrpg/internal/events"
+	"llmrpg/internal/ids"
+	"llmrpg/internal/llm"
+	"sync"
+	"time"
+)
+
+// TurnStatus describes where an asynchronous turn is in its lifecycle.
+type TurnStatus string
+
+const (
+	TurnQueued     TurnStatus = "queued"
+	TurnProcessing TurnStatus = "processing"
+	TurnCompleted  TurnStatus = "completed"
+	TurnFailed     TurnStatus = "failed"
+)
+
+// AsyncTurn tracks a player turn that is processed in the background.
+type AsyncTurn struct {
+	ID            string           `json:"id"`
+	SessionID     string           `json:"sessionId"`
+	Status        TurnStatus       `json:"status"`
+	QueuePosition int              `json:"queuePosition"` // 0 once the turn holds an LLM slot
+	SubmittedAt   time.Time        `json:"submittedAt"`
+	CompletedAt   *time.Time       `json:"completedAt,omitempty"`
+	Response      *llm.LLMResponse `json:"response,omitempty"`
+	Error         string           `json:"error,omitempty"`
+}
+
+// ErrTurnInProgress is returned by Submit while the session already has a turn queued or
+// processing; a session's turns run one at a time.
+var ErrTurnInProgress = errors.New("a turn is already in progress for this session")
+
+// TurnTracker runs turns in the background and keeps their status for polling.
+type TurnTracker struct {
+	engine  *NarrativeEngine
+	timeout time.Duration
+	Clock   clock.Clock   // Submission and completion times
+	IDs     ids.Generator // Unique part of turn IDs
+	Events  *events.Bus   // Optional; a TurnReady event is published when each turn finishes
+
+	mu     sync.RWMutex
+	turns  map[string]*AsyncTurn
+	active map[string]string // Session ID -> ID of its queued or processing turn
+}
+
+// NewTurnTracker creates a tracker that processes turns with the given engine.
+// Each background turn is cancelled after timeout, and finished turns are forgotten
+// once they have been done for as long, so clients have that long to collect them.
+func NewTurnTracker(engine *NarrativeEngine, timeout time.Duration) *TurnTracker {
+	return &TurnTracker{
+		engine:  engine,
+		timeout: timeout,
+		Clock:   clock.Default,
+		IDs:     ids.Default,
+		turns:   make(map[string]*AsyncTurn),
+		active:  make(map[string]string),
+	}
+}
+
+// Submit queues a turn for processing and returns immediately. It returns
+// ErrTurnInProgress, naming the turn, if the session's previous turn hasn't finished.
+func (tt *TurnTracker) Submit(sessionID, playerInput string) (AsyncTurn, error) {
+	turn := &AsyncTurn{
+		ID:          "turn_" + tt.IDs.NewID(),
+		SessionID:   sessionID,
+		Status:      TurnQueued,
+		SubmittedAt: tt.Clock.Now(),
+	}
+
+	tt.mu.Lock()
+	tt.prune(turn.SubmittedAt)
+	if running, ok := tt.active[sessionID]; ok {
+		tt.mu.Unlock()
+		return AsyncTurn{}, fmt.Errorf("%w: %s", ErrTurnInProgress, running)
+	}
+	tt.turns[turn.ID] = turn
+	tt.active[sessionID] = turn.ID
+	snapshot := *turn
+	tt.mu.Unlock()
+
+	go tt.run(turn.ID, sessionID, playerInput)
+	return snapshot, nil
+}
+
+// prune forgets turns that finished more than tt.timeout before now. Callers hold tt.mu.
+func (tt *TurnTracker) prune(now time.Time) {
+	for id, turn := range tt.turns {
+		if turn.CompletedAt != nil && now.Sub(*turn.CompletedAt) > tt.timeout {
+			delete(tt.turns, id)
+		}
+	}
+}
+
+// Get returns a snapshot of a turn's current state.
+func (tt *TurnTracker) Get(turnID string) (AsyncTurn, error) {
+	tt.mu.RLock()
+	defer tt.mu.RUnlock()
+	turn, ok := tt.turns[turnID]
+	if !ok {
+		return AsyncTurn{}, fmt.Errorf("turn not found: %s", turnID)
+	}
+	return *turn, nil
+}
+
+func (tt *TurnTracker) run(turnID, sessionID, playerInput string) {
+	ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+	defer cancel()
+
+	// Keep queue position current while we wait for an LLM slot.
+	ctx = llm.WithQueueObserver(ctx, func(position int) {
+		tt.update(turnID, func(turn *AsyncTurn) {
+			turn.QueuePosition = position
+			if position == 0 {
+				turn.Status = TurnProcessing
+			}
+		})
+	})
+
+	response, err := tt.engine.ProcessPlayerInput(ctx, sessionID, playerInput)
+
+	var finished AsyncTurn
+	tt.update(turnID, func(turn *AsyncTurn) {
+		now := tt.Clock.Now()
+		turn.CompletedAt = &now
+		turn.QueuePosition = 0
+		if err != nil {
+			turn.Status = TurnFailed
+			turn.Error = err.Error()
+		} else {
+			turn.Status = TurnCompleted
+			turn.Response = response
+		}
+		finished = *turn
+		delete(tt.active, sessionID)
+	})
+	tt.publish(finished)
+}
+
+// publish announces a finished turn on the event bus, for players who stopped polling.
+func (tt *TurnTracker) publish(turn AsyncTurn) {
+	if tt.Events == nil || turn.CompletedAt == nil {
+		return
+	}
+	sess, err := tt.engine.SessionManager.GetSession(turn.SessionID)
+	if err != nil {
+		return
+	}
+	seconds := turn.CompletedAt.Sub(turn.SubmittedAt).Seconds()
+	tt.Events.Publish(events.Published{
+		SessionID: turn.SessionID,
+		PlayerID:  sess.PlayerID,
+		Event:     events.NewTurnReady(sess.TurnCount, turn.ID, string(turn.Status), seconds, turn.Error),
+	})
+}
+
+func (tt *TurnTracker) update(turnID string, fn func(turn *AsyncTurn)) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if turn, ok := tt.turns[turnID]; ok {
+		fn(turn)
+	}
+}
@@ -0,0 +1,57 @@
+package narrative
+
+import (
+	"hash/fnv"
+
+	"llmrpg/internal/session"
+)
+
+// --- Prompt Experiments ---
+// A world can try several narrator prompts side by side: each new session is assigned
+// one of the engine's PromptVariants, weighted, and keeps it for the whole playthrough.
+// The pick is a hash of the session ID, so assignment is spread like a random draw but
+// reproducible in deterministic mode. The variant is recorded on the session, so
+// moderation audit entries and analytics aggregates can be broken down by it.
+
+// PromptVariant is one arm of a system prompt experiment.
+type PromptVariant struct {
+	ID     string // Recorded on sessions, audit entries and analytics
+	Prompt string // The system prompt sessions in this variant are narrated with
+	Weight int    // Relative share of new sessions; must be positive
+}
+
+// AssignPromptVariant picks a variant for a new session and records it, returning its
+// ID ("" when the engine runs no experiment).
+func (ne *NarrativeEngine) AssignPromptVariant(sess *session.GameSession) string {
+	total := 0
+	for _, variant := range ne.PromptVariants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return ""
+	}
+	// Not the session's roller: assignment mustn't shift its rolls
+	h := fnv.New64a()
+	h.Write([]byte(sess.ID))
+	pick := int(h.Sum64() % uint64(total))
+	for _, variant := range ne.PromptVariants {
+		if pick -= variant.Weight; pick < 0 {
+			sess.PromptVariant = variant.ID
+			break
+		}
+	}
+	return sess.PromptVariant
+}
+
+// systemPrompt returns the prompt sess is narrated with: its variant's, or the
+// engine's SystemPrompt if it has none or the variant is no longer run.
+func (ne *NarrativeEngine) systemPrompt(sess *session.GameSession) string {
+	if sess.PromptVariant != "" {
+		for _, variant := range ne.PromptVariants {
+			if variant.ID == sess.PromptVariant {
+				return variant.Prompt
+			}
+		}
+	}
+	return ne.SystemPrompt
+}
@@ -0,0 +1,129 @@
+package narrative
+
+import (
+	"fmt"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/session"
+	"llmrpg/internal/world"
+	"sort"
+)
+
+// runWorldEvents starts the world events that came due since the scheduler last ran
+// (the clock may have jumped hours, e.g. during a rest) and reports which began or
+// ended. It returns notes telling the narrator about those at the player's location.
+func (ne *NarrativeEngine) runWorldEvents(sess *session.GameSession) []string {
+	if len(ne.WorldEvents) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(ne.WorldEvents))
+	for id := range ne.WorldEvents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	from, now := sess.WorldEventsHour, sess.GameHours
+	started := len(sess.WorldEvents)
+	for hour := from; hour <= now; hour++ {
+		clock := session.StartHour + hour
+		day, hourOfDay := clock/24+1, clock%24
+		for _, id := range ids {
+			ev := ne.WorldEvents[id]
+			if worldEventActiveAt(sess, id, hour) {
+				continue
+			}
+			t := ev.Trigger
+			due := t.Due(day, hourOfDay)
+			if t.Chance > 0 && hourOfDay == t.Hour {
+				due = sess.Roller().Roll(dice.Expression{Count: 1, Sides: 100}, dice.Normal).Total <= t.Chance
+			}
+			if due {
+				sess.WorldEvents = append(sess.WorldEvents, session.WorldEventRun{ID: id, Start: hour, End: hour + ev.Hours()})
+			}
+		}
+	}
+	for _, id := range ids { // Flag events start as soon as their flag is seen
+		ev := ne.WorldEvents[id]
+		if ev.Trigger.Flag != "" && sess.Flags[ev.Trigger.Flag] && !sess.WorldEventStarted(id) {
+			sess.WorldEvents = append(sess.WorldEvents, session.WorldEventRun{ID: id, Start: now, End: now + ev.Hours()})
+		}
+	}
+	sess.WorldEventsHour = now + 1
+
+	var notes []string
+	for i, run := range sess.WorldEvents {
+		ev, ok := ne.WorldEvents[run.ID]
+		if !ok {
+			continue
+		}
+		here := ev.Affects(sess.CurrentLocationID)
+		switch {
+		case i >= started && run.Active(now):
+			for _, flag := range ev.SetFlags {
+				sess.SetFlag(flag, true)
+			}
+			fmt.Printf("NarrativeEngine: World event '%s' started in session %s\n", ev.ID, sess.ID)
+			sess.Emit(events.NewWorldEventStarted(sess.TurnCount, ev.ID, ev.Name, ev.Locations))
+			if here {
+				notes = append(notes, fmt.Sprintf("Something is happening here as of now: %s. %s Work it into the scene.", ev.Name, ev.Description))
+			}
+		case i >= started: // Came and went while time passed
+			for _, flag := range ev.SetFlags {
+				sess.SetFlag(flag, true)
+			}
+		case run.End >= from && run.End <= now:
+			sess.Emit(events.NewWorldEventEnded(sess.TurnCount, ev.ID, ev.Name))
+			if here {
+				notes = append(notes, fmt.Sprintf("%s is over; describe the scene without it.", ev.Name))
+			}
+		}
+	}
+	return notes
+}
+
+// worldEventActiveAt reports whether an occurrence of the world event is under way at hour.
+func worldEventActiveAt(sess *session.GameSession, id string, hour int) bool {
+	for _, run := range sess.WorldEvents {
+		if run.ID == id && run.Active(hour) {
+			return true
+		}
+	}
+	return false
+}
+
+// activeWorldEvents returns the world events under way at locationID, in ID order.
+func activeWorldEvents(worldEvents map[string]*world.WorldEvent, sess *session.GameSession, locationID string) []*world.WorldEvent {
+	var active []*world.WorldEvent
+	for id, ev := range worldEvents {
+		if ev.Affects(locationID) && sess.WorldEventActive(id) {
+			active = append(active, ev)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active
+}
+
+// exitClosedBy returns the active world event closing the way from fromID to toID, or nil.
+func exitClosedBy(worldEvents map[string]*world.WorldEvent, sess *session.GameSession, fromID, toID string) *world.WorldEvent {
+	for _, ev := range activeWorldEvents(worldEvents, sess, fromID) {
+		for _, closed := range ev.ClosedExits {
+			if closed == toID {
+				return ev
+			}
+		}
+	}
+	return nil
+}
+
+// worldEventsContext describes the world events under way at locationID for the prompt.
+func worldEventsContext(worldEvents map[string]*world.WorldEvent, sess *session.GameSession, locationID string) []string {
+	var lines []string
+	for _, ev := range activeWorldEvents(worldEvents, sess, locationID) {
+		line := ev.Name
+		if ev.Description != "" {
+			line += ": " + ev.Description
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
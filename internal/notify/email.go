@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailAdapter sends notifications as plain-text email through an SMTP relay.
+type EmailAdapter struct {
+	Addr string    // SMTP server host:port
+	From string    // Sender address
+	Auth smtp.Auth // Optional, e.g. smtp.PlainAuth (which requires TLS unless the host is localhost)
+}
+
+func (a *EmailAdapter) Channel() Channel { return ChannelEmail }
+
+// Send mails n to the player's address. net/smtp can't be cancelled, so ctx is only
+// checked before sending.
+func (a *EmailAdapter) Send(ctx context.Context, prefs Preferences, n Notification) error {
+	if prefs.Email == "" {
+		return ErrNotConfigured
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", a.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", prefs.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	if err := smtp.SendMail(a.Addr, a.Auth, a.From, []string{prefs.Email}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package notify tells players about things that happened while they weren't watching,
+// such as a slow async turn finishing, by email or web push. It subscribes to the
+// event bus and sends each event a player opted into through every channel they
+// enabled.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"llmrpg/internal/events"
+)
+
+// Channel names a way of reaching a player.
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelWebPush Channel = "webpush"
+)
+
+// Notification is one message to a player.
+type Notification struct {
+	Kind      events.Type            `json:"kind"`
+	SessionID string                 `json:"sessionId"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	Data      map[string]interface{} `json:"data,omitempty"` // The event's data, for clients that act on it
+}
+
+// Adapter delivers notifications over one channel.
+type Adapter interface {
+	Channel() Channel
+	// Send delivers n to the player described by prefs. Adapters return
+	// ErrSubscriptionGone for a web push subscription the push service has dropped.
+	Send(ctx context.Context, prefs Preferences, n Notification) error
+}
+
+// ErrNotConfigured is returned by an adapter when the player has no address for its
+// channel (no email, no push subscription).
+var ErrNotConfigured = errors.New("player has no address for this channel")
+
+// ErrSubscriptionGone reports a web push subscription that expired or was revoked.
+type ErrSubscriptionGone struct {
+	Endpoint string
+}
+
+func (e *ErrSubscriptionGone) Error() string {
+	return fmt.Sprintf("push subscription is gone: %s", e.Endpoint)
+}
+
+// Kinds maps each event kind players can be notified of to its notification text.
+// DefaultKinds are on until a player chooses.
+var Kinds = map[events.Type]func(e events.Event) (title, body string){
+	events.TurnReady: func(e events.Event) (string, string) {
+		if e.Data["status"] == "failed" {
+			return "Your turn failed", "The narrator couldn't finish your turn. Open the game to try again."
+		}
+		return "Your turn is ready", "The narrator has answered. Open the game to see what happened."
+	},
+	events.SessionEnded: func(e events.Event) (string, string) {
+		return "Your story has ended", fmt.Sprintf("%v. Open the game to read the epilogue.", e.Data["name"])
+	},
+	events.LevelUp: func(e events.Event) (string, string) {
+		return "Level up!", fmt.Sprintf("You reached level %v.", e.Data["level"])
+	},
+	events.WorldEventStarted: func(e events.Event) (string, string) {
+		return "Something is happening", fmt.Sprintf("%v has begun in your world.", e.Data["name"])
+	},
+}
+
+// DefaultKinds are the kinds a player is notified of before choosing.
+var DefaultKinds = []events.Type{events.TurnReady}
+
+// Notifier turns bus events into notifications.
+type Notifier struct {
+	Adapters    map[Channel]Adapter
+	Preferences *PreferenceStore
+	SlowTurn    time.Duration // A TurnReady is only sent for turns that took at least this long
+	Timeout     time.Duration // Per delivery (0 = 30s)
+}
+
+// Handle is the bus subscriber: it sends p to its player in the background if they
+// want it.
+func (n *Notifier) Handle(p events.Published) {
+	if p.PlayerID == "" {
+		return // Notifications go to players, and this session has none
+	}
+	describe, ok := Kinds[p.Event.Type]
+	if !ok {
+		return
+	}
+	if p.Event.Type == events.TurnReady {
+		if seconds, _ := p.Event.Data["seconds"].(float64); seconds < n.SlowTurn.Seconds() {
+			return // The player was most likely still waiting for it
+		}
+	}
+	prefs, ok := n.Preferences.Get(p.PlayerID)
+	if !ok || !prefs.Wants(p.Event.Type) {
+		return
+	}
+	title, body := describe(p.Event)
+	note := Notification{Kind: p.Event.Type, SessionID: p.SessionID, Title: title, Body: body, Data: p.Event.Data}
+	go n.send(prefs, note)
+}
+
+// send delivers note through each of the player's channels.
+func (n *Notifier) send(prefs Preferences, note Notification) {
+	timeout := n.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	for _, channel := range prefs.Channels {
+		adapter, ok := n.Adapters[channel]
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := adapter.Send(ctx, prefs, note)
+		cancel()
+		if err == nil || errors.Is(err, ErrNotConfigured) {
+			continue
+		}
+		gone, failures := splitGone(err)
+		for _, endpoint := range gone {
+			n.Preferences.RemovePushSubscription(prefs.PlayerID, endpoint)
+			log.Printf("INFO [notify Player: %s]: Removed expired push subscription", prefs.PlayerID)
+		}
+		if len(failures) > 0 {
+			log.Printf("Warning: Failed to send %s notification to player %s: %v", channel, prefs.PlayerID, errors.Join(failures...))
+		}
+	}
+}
+
+// splitGone separates the subscriptions an adapter found gone from its other
+// failures; adapters that deliver to several addresses join their errors.
+func splitGone(err error) (gone []string, failures []error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var goneErr *ErrSubscriptionGone
+		if errors.As(err, &goneErr) {
+			gone = append(gone, goneErr.Endpoint)
+		} else {
+			failures = append(failures, err)
+		}
+	}
+	return gone, failures
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"llmrpg/internal/events"
+)
+
+// Preferences are how a player wants to be notified.
+type Preferences struct {
+	PlayerID          string             `json:"playerId"`
+	Channels          []Channel          `json:"channels"`                    // Enabled channels; empty = notifications off
+	Kinds             []events.Type      `json:"kinds,omitempty"`             // Event kinds to be told about (nil = DefaultKinds)
+	Email             string             `json:"email,omitempty"`             // For ChannelEmail
+	PushSubscriptions []PushSubscription `json:"pushSubscriptions,omitempty"` // For ChannelWebPush, one per browser or device
+}
+
+// PushSubscription is a browser's web push subscription, as PushSubscription.toJSON()
+// returns it.
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"` // The browser's public key, base64url
+		Auth   string `json:"auth"`   // Authentication secret, base64url
+	} `json:"keys"`
+}
+
+// Wants reports whether the player asked to be told about kind.
+func (p Preferences) Wants(kind events.Type) bool {
+	kinds := p.Kinds
+	if kinds == nil {
+		kinds = DefaultKinds
+	}
+	return len(p.Channels) > 0 && slices.Contains(kinds, kind)
+}
+
+// Validate checks the channels, kinds and addresses.
+func (p Preferences) Validate() error {
+	var problems []error
+	for _, channel := range p.Channels {
+		if channel != ChannelEmail && channel != ChannelWebPush {
+			problems = append(problems, fmt.Errorf("unknown channel '%s' (expected email or webpush)", channel))
+		}
+	}
+	for _, kind := range p.Kinds {
+		if _, ok := Kinds[kind]; !ok {
+			problems = append(problems, fmt.Errorf("players can't be notified of '%s'", kind))
+		}
+	}
+	if p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			problems = append(problems, fmt.Errorf("invalid email address '%s'", p.Email))
+		}
+	}
+	if slices.Contains(p.Channels, ChannelEmail) && p.Email == "" {
+		problems = append(problems, errors.New("the email channel needs an email address"))
+	}
+	for _, sub := range p.PushSubscriptions {
+		if u, err := url.Parse(sub.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			problems = append(problems, fmt.Errorf("push subscription endpoint '%s' must be an https URL", sub.Endpoint))
+		}
+		if sub.Keys.P256dh == "" || sub.Keys.Auth == "" {
+			problems = append(problems, fmt.Errorf("push subscription '%s' is missing its keys", sub.Endpoint))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// PreferenceStore holds every player's preferences, in memory and, when it has a path,
+// in a JSON file rewritten on every change.
+type PreferenceStore struct {
+	path string
+
+	mu    sync.RWMutex
+	prefs map[string]Preferences // By player ID
+}
+
+// NewPreferenceStore creates a store persisted at path ("" = memory only), loading
+// what the file already holds.
+func NewPreferenceStore(path string) (*PreferenceStore, error) {
+	ps := &PreferenceStore{path: path, prefs: make(map[string]Preferences)}
+	if path == "" {
+		return ps, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification preferences: %w", err)
+	}
+	if err := json.Unmarshal(data, &ps.prefs); err != nil {
+		return nil, fmt.Errorf("failed to parse notification preferences %s: %w", path, err)
+	}
+	return ps, nil
+}
+
+// Get returns a player's preferences.
+func (ps *PreferenceStore) Get(playerID string) (Preferences, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	prefs, ok := ps.prefs[playerID]
+	return clonePreferences(prefs), ok
+}
+
+// Put replaces a player's preferences after validating them.
+func (ps *PreferenceStore) Put(prefs Preferences) error {
+	if err := prefs.Validate(); err != nil {
+		return err
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.prefs[prefs.PlayerID] = clonePreferences(prefs)
+	return ps.save()
+}
+
+// RemovePushSubscription drops a subscription the push service reported gone.
+func (ps *PreferenceStore) RemovePushSubscription(playerID, endpoint string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	prefs, ok := ps.prefs[playerID]
+	if !ok {
+		return
+	}
+	prefs.PushSubscriptions = slices.DeleteFunc(slices.Clone(prefs.PushSubscriptions), func(sub PushSubscription) bool {
+		return sub.Endpoint == endpoint
+	})
+	ps.prefs[playerID] = prefs
+	if err := ps.save(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// save writes the file, if any, through a temporary file so a crash can't truncate it.
+// Callers hold ps.mu.
+func (ps *PreferenceStore) save() error {
+	if ps.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ps.prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ps.path), ".notify-prefs-*")
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ps.path); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+func clonePreferences(prefs Preferences) Preferences {
+	prefs.Channels = slices.Clone(prefs.Channels)
+	prefs.Kinds = slices.Clone(prefs.Kinds)
+	prefs.PushSubscriptions = slices.Clone(prefs.PushSubscriptions)
+	return prefs
+}
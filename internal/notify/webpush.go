@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebPushAdapter sends notifications to browsers through their push services, with
+// the payload encrypted for each subscription (RFC 8291) and the server identified by
+// its VAPID key (RFC 8292). Browsers receive the Notification as JSON in their
+// service worker's push event.
+type WebPushAdapter struct {
+	key     *ecdsa.PrivateKey
+	public  []byte // Uncompressed P-256 point, as browsers take it for applicationServerKey
+	subject string // Contact for push services, "mailto:..." or an https URL
+	client  *http.Client
+}
+
+// NewWebPushAdapter creates an adapter from a VAPID private key (the raw 32-byte
+// scalar, base64url, as web-push tools generate it) and a contact subject.
+func NewWebPushAdapter(privateKey, subject string, client *http.Client) (*WebPushAdapter, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(privateKey, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, fmt.Errorf("VAPID subject '%s' must be a mailto: or https: URL", subject)
+	}
+	public := ecdhKey.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(public[1:33]), Y: new(big.Int).SetBytes(public[33:])},
+		D:         new(big.Int).SetBytes(raw),
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &WebPushAdapter{key: key, public: public, subject: subject, client: client}, nil
+}
+
+func (a *WebPushAdapter) Channel() Channel { return ChannelWebPush }
+
+// PublicKey returns the VAPID public key, base64url, for clients to subscribe with.
+func (a *WebPushAdapter) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(a.public)
+}
+
+// Send pushes n to each of the player's subscriptions. Failures are joined;
+// subscriptions the push service dropped are reported as ErrSubscriptionGone.
+func (a *WebPushAdapter) Send(ctx context.Context, prefs Preferences, n Notification) error {
+	if len(prefs.PushSubscriptions) == 0 {
+		return ErrNotConfigured
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range prefs.PushSubscriptions {
+		if err := a.push(ctx, sub, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *WebPushAdapter) push(ctx context.Context, sub PushSubscription, payload []byte) error {
+	body, err := encryptPayload(sub, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt push for %s: %w", sub.Endpoint, err)
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+	token, err := a.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", "86400") // Keep undelivered notifications for a day
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, a.PublicKey()))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push to %s failed: %w", endpoint.Host, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return &ErrSubscriptionGone{Endpoint: sub.Endpoint}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push to %s returned %s: %s", endpoint.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// vapidToken signs the JWT that identifies this server to the push service at audience.
+func (a *WebPushAdapter) vapidToken(audience string) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": a.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64) // JWS ES256: r and s, 32 bytes each
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// pushRecordSize is the record size advertised in the aes128gcm header; payloads are
+// sent as a single record, so it only has to exceed them.
+const pushRecordSize = 4096
+
+// encryptPayload encrypts payload for a subscription as one aes128gcm record (RFC 8188),
+// keyed per RFC 8291 from an ephemeral ECDH exchange with the browser's key.
+func encryptPayload(sub PushSubscription, payload []byte) ([]byte, error) {
+	uaPublic, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.P256dh, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.Auth, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	if len(payload)+17+86 > pushRecordSize { // Delimiter, GCM tag and header must fit too
+		return nil, fmt.Errorf("payload is too large for web push (%d bytes)", len(payload))
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptRecord(uaKey, authSecret, asKey, salt, payload)
+}
+
+// encryptRecord does encryptPayload's work with a given sender key and salt.
+func encryptRecord(uaKey *ecdh.PublicKey, authSecret []byte, asKey *ecdh.PrivateKey, salt, payload []byte) ([]byte, error) {
+	uaPublic := uaKey.Bytes()
+	asPublic := asKey.PublicKey().Bytes()
+	sharedSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth secret, ECDH secret, "WebPush: info" || 0x00 || ua_public || as_public)
+	prkKey, err := hkdf.Extract(sha256.New, sharedSecret, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// Header: salt (16) || record size (4) || key ID length (1) || key ID (the sender's public key)
+	out := make([]byte, 0, 16+4+1+len(asPublic)+len(payload)+1+gcm.Overhead())
+	out = append(out, salt...)
+	out = binary.BigEndian.AppendUint32(out, pushRecordSize)
+	out = append(out, byte(len(asPublic)))
+	out = append(out, asPublic...)
+	plaintext := append(append([]byte{}, payload...), 0x02) // 0x02 marks the last (only) record
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
@@ -0,0 +1,99 @@
+// Package rating defines content ratings (E/T/M) and what each one means for the
+// narrator prompt, provider safety settings and moderation strictness.
+package rating
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rating is a content rating, from most to least restrictive: E, T, M.
+type Rating string
+
+const (
+	Everyone Rating = "E"
+	Teen     Rating = "T"
+	Mature   Rating = "M"
+)
+
+// Default is the rating used when neither the world nor the session declares one.
+const Default = Teen
+
+// Profile is what a rating enforces.
+type Profile struct {
+	Rating              Rating
+	Name                string
+	PromptConstraint    string  // Tells the narrator what it may depict
+	SafetyThreshold     string  // Provider safety filter level (Gemini HarmBlockThreshold)
+	ModerationThreshold float64 // Moderation category score at or above which text is flagged
+}
+
+var profiles = map[Rating]Profile{
+	Everyone: {
+		Rating:              Everyone,
+		Name:                "Everyone",
+		PromptConstraint:    "Suitable for all ages: no graphic violence, no blood or gore, no profanity, no sexual content, and no depiction of drugs or alcohol abuse. Conflict is resolved without lingering on harm.",
+		SafetyThreshold:     "BLOCK_LOW_AND_ABOVE",
+		ModerationThreshold: 0.2,
+	},
+	Teen: {
+		Rating:              Teen,
+		Name:                "Teen",
+		PromptConstraint:    "Suitable for teenagers: fantasy violence and peril are fine but not graphic or gory, mild language only, no sexual content.",
+		SafetyThreshold:     "BLOCK_MEDIUM_AND_ABOVE",
+		ModerationThreshold: 0.5,
+	},
+	Mature: {
+		Rating:              Mature,
+		Name:                "Mature",
+		PromptConstraint:    "For adults: intense violence, dark themes and strong language are allowed when the story calls for them, but no explicit sexual content and nothing gratuitous.",
+		SafetyThreshold:     "BLOCK_ONLY_HIGH",
+		ModerationThreshold: 0.8,
+	},
+}
+
+// Parse reads a rating from its letter or name ("T", "teen"). Empty input is an error.
+func Parse(s string) (Rating, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "e", "everyone":
+		return Everyone, nil
+	case "t", "teen":
+		return Teen, nil
+	case "m", "mature":
+		return Mature, nil
+	default:
+		return "", fmt.Errorf("unknown content rating '%s' (expected E, T or M)", s)
+	}
+}
+
+// Or returns r, or fallback if r is empty.
+func (r Rating) Or(fallback Rating) Rating {
+	if r == "" {
+		return fallback
+	}
+	return r
+}
+
+// Allows reports whether content rated other fits within r (e.g. M allows T).
+func (r Rating) Allows(other Rating) bool {
+	return level(other) <= level(r)
+}
+
+// Profile returns what r enforces; an empty or unknown rating gets the Default profile.
+func (r Rating) Profile() Profile {
+	if p, ok := profiles[r]; ok {
+		return p
+	}
+	return profiles[Default]
+}
+
+func level(r Rating) int {
+	switch r {
+	case Everyone:
+		return 0
+	case Teen:
+		return 1
+	default:
+		return 2
+	}
+}
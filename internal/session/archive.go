@@ -0,0 +1,177 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// --- Cold Storage ---
+// A long-running server would otherwise keep every session it ever created in
+// memory. With a ColdStore set, ArchiveIdle saves sessions that have been idle for a
+// while to it and drops them from memory, keeping only their Summary; the next
+// GetSession for one loads it back. Archived sessions still show up in player
+// listings, but not in GetAllSessionIDs, which lists the sessions in memory.
+// A session is saved from a snapshot taken under its TurnLock, so a turn in progress
+// is never written half-done, and is dropped only if nothing touched it since.
+
+// TurnLock returns the lock held while a turn changes the session. ArchiveIdle takes it
+// to snapshot the session, so callers that change a session outside of requests' normal
+// GetSession/UpdateSession flow (the narrative engine's turns) should hold it too.
+func (sm *InMemorySessionManager) TurnLock(sessionID string) *sync.Mutex {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	lock, ok := sm.turnLocks[sessionID]
+	if !ok {
+		lock = &sync.Mutex{}
+		sm.turnLocks[sessionID] = lock
+	}
+	return lock
+}
+
+// ArchiveIdle moves every session last active before idleFor ago to the ColdStore,
+// returning how many were archived. Sessions that fail to save stay in memory; the
+// first error is returned after the rest have been tried.
+func (sm *InMemorySessionManager) ArchiveIdle(idleFor time.Duration) (int, error) {
+	if sm.ColdStore == nil {
+		return 0, fmt.Errorf("no cold store configured")
+	}
+	cutoff := sm.Clock.Now().Add(-idleFor)
+	sm.mu.RLock()
+	var idle []*GameSession
+	for _, sess := range sm.sessions {
+		if sess.LastActive.Before(cutoff) {
+			idle = append(idle, sess)
+		}
+	}
+	sm.mu.RUnlock()
+
+	archived := 0
+	var firstErr error
+	for _, sess := range idle {
+		ok, err := sm.archive(sess, func(s *GameSession) bool { return s.LastActive.Before(cutoff) })
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to archive session %s: %w", sess.ID, err)
+			}
+			continue
+		}
+		if ok {
+			archived++
+		}
+	}
+	return archived, firstErr
+}
+
+// archive saves a snapshot of sess to the ColdStore and drops it from memory, keeping its
+// Summary. It reports false, and keeps the session, if a turn is in progress on it, if it
+// no longer satisfies eligible, or if it was touched while saving. It must be called
+// without sm.mu held.
+func (sm *InMemorySessionManager) archive(sess *GameSession, eligible func(*GameSession) bool) (bool, error) {
+	lock := sm.TurnLock(sess.ID)
+	if !lock.TryLock() {
+		return false, nil // Mid-turn, so not idle after all
+	}
+	sm.mu.RLock() // Also keeps GetSession from marking it active mid-snapshot
+	var snapshot *GameSession
+	var err error
+	lastActive := sess.LastActive
+	if sm.sessions[sess.ID] == sess && eligible(sess) {
+		snapshot, err = sess.Clone()
+	}
+	sm.mu.RUnlock()
+	lock.Unlock()
+	if snapshot == nil || err != nil {
+		return false, err
+	}
+
+	// Saved outside the locks: object storage is slow, and play shouldn't wait on it
+	if err := sm.ColdStore.SaveSession(snapshot); err != nil {
+		return false, err
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	// GetSession marks every access, so an unchanged LastActive means the snapshot is current
+	if sm.sessions[sess.ID] != sess || !sess.LastActive.Equal(lastActive) {
+		return false, nil
+	}
+	delete(sm.sessions, sess.ID)
+	delete(sm.turnLocks, sess.ID)
+	sm.archived[sess.ID] = snapshot.Summary()
+	sm.checkCapacity()
+	return true, nil
+}
+
+// AddArchivedSession registers a stored session as archived without keeping it in
+// memory, e.g. an idle one found in the store at startup. Like AddSession, it gives a
+// session saved under an old-format ID an opaque one and reports it as migrated.
+func (sm *InMemorySessionManager) AddArchivedSession(sess *GameSession) (migrated bool, err error) {
+	if sess == nil || sess.ID == "" {
+		return false, fmt.Errorf("cannot add nil session or session without ID")
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	id := sm.resolveID(sess.ID)
+	if _, exists := sm.sessions[id]; exists {
+		return false, fmt.Errorf("session %s already exists", sess.ID)
+	}
+	if _, exists := sm.archived[id]; exists {
+		return false, fmt.Errorf("session %s already exists", sess.ID)
+	}
+	migrated = sm.migrateLegacyIDs(sess)
+	if sess.LegacyID != "" {
+		if _, exists := sm.legacyIDs[sess.LegacyID]; exists {
+			return false, fmt.Errorf("session %s already exists", sess.LegacyID)
+		}
+		sm.legacyIDs[sess.LegacyID] = sess.ID
+	}
+	sm.archived[sess.ID] = sess.Summary()
+	return migrated, nil
+}
+
+// ArchivedSessions returns summaries of the sessions in cold storage, most recently
+// active first.
+func (sm *InMemorySessionManager) ArchivedSessions() []Summary {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	summaries := make([]Summary, 0, len(sm.archived))
+	for _, summary := range sm.archived {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].LastActive.After(summaries[j].LastActive) })
+	return summaries
+}
+
+// rehydrate loads an archived session back into memory. It returns nil, nil if id
+// isn't archived.
+func (sm *InMemorySessionManager) rehydrate(id string) (*GameSession, error) {
+	sm.mu.RLock()
+	_, archived := sm.archived[id]
+	sm.mu.RUnlock()
+	if !archived || sm.ColdStore == nil {
+		return nil, nil
+	}
+
+	sess, err := sm.ColdStore.LoadSession(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rehydrate archived session %s: %w", id, err)
+	}
+	sess.HistoryPolicy = sm.HistoryPolicy // Not persisted; follows the current deployment
+	sess.TrimHistory()
+	if err := sm.makeRoom(); err != nil {
+		return nil, fmt.Errorf("failed to rehydrate archived session %s: %w", id, err)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if current, ok := sm.sessions[id]; ok {
+		return current, nil // Another request rehydrated it first
+	}
+	delete(sm.archived, id)
+	sm.sessions[id] = sess
+	sm.checkCapacity()
+	fmt.Printf("Rehydrated archived session %s.\n", id)
+	return sess, nil
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AutosaveMode selects when sessions are written to the Store.
+type AutosaveMode string
+
+const (
+	AutosaveOff       AutosaveMode = "off"
+	AutosaveEveryTurn AutosaveMode = "every_turn"
+	AutosaveEveryN    AutosaveMode = "every_n"
+	AutosaveOnEvents  AutosaveMode = "events"
+)
+
+// AutosaveEvent is a significant moment that triggers a save in AutosaveOnEvents mode.
+type AutosaveEvent string
+
+const (
+	EventLocationChange AutosaveEvent = "locationChange"
+	EventCombatEnd      AutosaveEvent = "combatEnd"
+	EventSessionEnd     AutosaveEvent = "sessionEnd" // An ending was reached
+)
+
+// AutosavePolicy is configured per deployment.
+type AutosavePolicy struct {
+	Mode   AutosaveMode
+	EveryN int // Turns between saves in AutosaveEveryN mode
+}
+
+// ParseAutosaveMode validates a mode string from configuration.
+func ParseAutosaveMode(s string) (AutosaveMode, error) {
+	switch mode := AutosaveMode(strings.ToLower(s)); mode {
+	case AutosaveOff, AutosaveEveryTurn, AutosaveEveryN, AutosaveOnEvents:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown autosave mode '%s' (expected off, every_turn, every_n or events)", s)
+	}
+}
+
+// Autosaver applies an AutosavePolicy after each turn.
+type Autosaver struct {
+	store  Store
+	policy AutosavePolicy
+}
+
+// NewAutosaver creates an autosaver writing to store under policy.
+func NewAutosaver(store Store, policy AutosavePolicy) *Autosaver {
+	if policy.Mode == AutosaveEveryN && policy.EveryN <= 0 {
+		policy.EveryN = 5
+	}
+	return &Autosaver{store: store, policy: policy}
+}
+
+// AfterTurn saves the session if the policy calls for it, given the events
+// that happened this turn. It records the saved turn in LastAutosaveTurn.
+// Ironman sessions always autosave every turn: their single save is the only record.
+func (a *Autosaver) AfterTurn(sess *GameSession, events []AutosaveEvent) (bool, error) {
+	if !a.shouldSave(sess, events) {
+		return false, nil
+	}
+	previous := sess.LastAutosaveTurn
+	sess.LastAutosaveTurn = sess.TurnCount
+	if err := a.store.SaveSession(sess); err != nil {
+		sess.LastAutosaveTurn = previous
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *Autosaver) shouldSave(sess *GameSession, events []AutosaveEvent) bool {
+	if sess.Ironman {
+		return true
+	}
+	switch a.policy.Mode {
+	case AutosaveEveryTurn:
+		return true
+	case AutosaveEveryN:
+		return sess.TurnCount-sess.LastAutosaveTurn >= a.policy.EveryN
+	case AutosaveOnEvents:
+		return len(events) > 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,305 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- S3-Compatible Blob Store ---
+// Speaks the S3 REST API with SigV4 signing, which covers AWS S3, Google Cloud
+// Storage (interoperability endpoint with HMAC keys), MinIO, R2 and friends
+// without pulling in a cloud SDK.
+
+// BlobStoreConfig configures a BlobStore.
+type BlobStoreConfig struct {
+	Endpoint     string // e.g. "https://s3.us-east-1.amazonaws.com" or "https://storage.googleapis.com"
+	Region       string // Signing region; GCS accepts "auto"
+	Bucket       string
+	Prefix       string // Key prefix for snapshots, e.g. "sessions/"
+	AccessKey    string
+	SecretKey    string
+	PathStyle    bool   // Use endpoint/bucket/key instead of bucket.endpoint/key
+	StorageClass string // Optional, e.g. "STANDARD_IA" or "NEARLINE"
+	Tagging      string // Optional object tags ("k=v&k2=v2") for bucket lifecycle rules to match
+}
+
+// BlobStore persists gzip-compressed session snapshots in an object storage bucket.
+// Lifecycle (expiry, storage-class transitions) is left to bucket lifecycle rules,
+// which can target snapshots by Prefix or by the configured Tagging.
+type BlobStore struct {
+	cfg        BlobStoreConfig
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewBlobStore creates a blob store. A nil httpClient uses a default client.
+func NewBlobStore(cfg BlobStoreConfig, httpClient *http.Client) (*BlobStore, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("blob store requires an endpoint and a bucket")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("blob store requires an access key and a secret key")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &BlobStore{cfg: cfg, httpClient: httpClient, now: time.Now}, nil
+}
+
+// SaveSession uploads a compressed snapshot of the session.
+func (bs *BlobStore) SaveSession(sess *GameSession) error {
+	if sess == nil {
+		return fmt.Errorf("cannot save nil session")
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session %s: %w", sess.ID, err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress session %s: %w", sess.ID, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress session %s: %w", sess.ID, err)
+	}
+
+	headers := map[string]string{"Content-Type": "application/gzip"}
+	if bs.cfg.StorageClass != "" {
+		headers["X-Amz-Storage-Class"] = bs.cfg.StorageClass
+	}
+	if bs.cfg.Tagging != "" {
+		headers["X-Amz-Tagging"] = bs.cfg.Tagging
+	}
+
+	resp, err := bs.do(http.MethodPut, bs.key(sess.ID), nil, headers, compressed.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to upload session %s: %w", sess.ID, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// LoadSession downloads and decompresses a session snapshot.
+func (bs *BlobStore) LoadSession(sessionID string) (*GameSession, error) {
+	resp, err := bs.do(http.MethodGet, bs.key(sessionID), nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download session %s: %w", sessionID, err)
+	}
+	defer resp.Body.Close()
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress session %s: %w", sessionID, err)
+	}
+	defer zr.Close()
+
+	var sess GameSession
+	if err := json.NewDecoder(zr).Decode(&sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", sessionID, err)
+	}
+	return &sess, nil
+}
+
+// ListSessionIDs lists all snapshots under the prefix (following pagination).
+func (bs *BlobStore) ListSessionIDs() ([]string, error) {
+	var ids []string
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", bs.cfg.Prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := bs.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse session listing: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			name := strings.TrimPrefix(c.Key, bs.cfg.Prefix)
+			if strings.HasSuffix(name, ".json.gz") && !strings.Contains(name, "/") {
+				ids = append(ids, strings.TrimSuffix(name, ".json.gz"))
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return ids, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// DeleteSession removes a session snapshot.
+func (bs *BlobStore) DeleteSession(sessionID string) error {
+	resp, err := bs.do(http.MethodDelete, bs.key(sessionID), nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (bs *BlobStore) key(sessionID string) string {
+	return bs.cfg.Prefix + sessionID + ".json.gz"
+}
+
+// do sends a signed request and returns the response if the status is 2xx.
+func (bs *BlobStore) do(method, key string, query url.Values, headers map[string]string, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(bs.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob store endpoint: %w", err)
+	}
+	host := endpoint.Host
+	path := "/" + uriEncode(key, false)
+	if bs.cfg.PathStyle {
+		path = "/" + bs.cfg.Bucket + path
+	} else {
+		host = bs.cfg.Bucket + "." + host
+	}
+
+	reqURL := endpoint.Scheme + "://" + host + path
+	if len(query) > 0 {
+		reqURL += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	bs.sign(req, path, query, body)
+
+	resp, err := bs.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("blob store request failed: %s %s: status %s, body: %s", method, path, resp.Status, string(respBody))
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (bs *BlobStore) sign(req *http.Request, path string, query url.Values, body []byte) {
+	now := bs.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign host plus every header we set ourselves.
+	signed := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		signed[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + bs.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+bs.cfg.SecretKey), dateStamp)
+	key = hmacSHA256(key, bs.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		bs.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters (and '/' in paths).
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
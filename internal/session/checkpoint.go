@@ -0,0 +1,48 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Checkpoint is a copy of a session's state, taken so a turn that fails midway
+// (e.g. a panic in the executor) can be rolled back instead of leaving the session
+// half-updated. It uses the same JSON form as saved sessions.
+type Checkpoint struct {
+	data []byte
+}
+
+// Checkpoint captures the session's current state.
+func (sess *GameSession) Checkpoint() (*Checkpoint, error) {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint session %s: %w", sess.ID, err)
+	}
+	return &Checkpoint{data: data}, nil
+}
+
+// Restore resets the session in place to the checkpointed state, so pointers held
+// by the manager see the rollback. Fields not saved with the session (HistoryPolicy)
+// are kept.
+func (sess *GameSession) Restore(cp *Checkpoint) error {
+	var restored GameSession
+	if err := json.Unmarshal(cp.data, &restored); err != nil {
+		return fmt.Errorf("failed to restore session %s: %w", sess.ID, err)
+	}
+	restored.HistoryPolicy = sess.HistoryPolicy
+	*sess = restored
+	return nil
+}
+
+// Clone returns a deep copy of the session, for trying changes out without touching it.
+func (sess *GameSession) Clone() (*GameSession, error) {
+	cp, err := sess.Checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	clone := &GameSession{ID: sess.ID, HistoryPolicy: sess.HistoryPolicy}
+	if err := clone.Restore(cp); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
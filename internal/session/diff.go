@@ -0,0 +1,58 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FieldChange is one session JSON field that differs between two states.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"` // Missing = the field was unset
+	After  json.RawMessage `json:"after,omitempty"`  // Missing = the field was cleared
+}
+
+// diffIgnored lists fields that change on every access rather than with play.
+var diffIgnored = map[string]bool{"lastActive": true}
+
+// Diff returns the top-level session JSON fields that differ between before and
+// after, sorted by field name.
+func Diff(before, after *GameSession) ([]FieldChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for field, value := range afterFields {
+		if !diffIgnored[field] && !bytes.Equal(beforeFields[field], value) {
+			changes = append(changes, FieldChange{Field: field, Before: beforeFields[field], After: value})
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok && !diffIgnored[field] {
+			changes = append(changes, FieldChange{Field: field, Before: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// jsonFields splits the session's JSON form into its top-level fields.
+func jsonFields(sess *GameSession) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session %s: %w", sess.ID, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", sess.ID, err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,45 @@
+package session
+
+import "strings"
+
+// Entity is a name the narrator invented during play (an NPC, a shop, an artifact, ...),
+// remembered so later turns describe it consistently.
+type Entity struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind,omitempty"`        // e.g. "npc", "place", "item"; empty = only seen in narration
+	Description string `json:"description,omitempty"` // Established details (for names seen in narration, the sentence they appeared in)
+	LocationID  string `json:"locationId,omitempty"`  // Where the entity was first registered
+	Turn        int    `json:"turn"`                  // Turn the entity was first registered
+}
+
+// MaxEntities caps a session's entity registry; registering more drops the oldest.
+const MaxEntities = 100
+
+// Entity returns the registered entity named name (case-insensitive), or nil.
+func (sess *GameSession) Entity(name string) *Entity {
+	for i := range sess.Entities {
+		if strings.EqualFold(sess.Entities[i].Name, name) {
+			return &sess.Entities[i]
+		}
+	}
+	return nil
+}
+
+// RegisterEntity adds entity to the registry, or fills in the kind and description of
+// an entity already registered under the same name. It reports whether the entity is new.
+func (sess *GameSession) RegisterEntity(entity Entity) bool {
+	if existing := sess.Entity(entity.Name); existing != nil {
+		if entity.Kind != "" {
+			existing.Kind = entity.Kind
+		}
+		if entity.Description != "" && (entity.Kind != "" || existing.Description == "") {
+			existing.Description = entity.Description // Declared details win over narration excerpts
+		}
+		return false
+	}
+	sess.Entities = append(sess.Entities, entity)
+	if len(sess.Entities) > MaxEntities {
+		sess.Entities = sess.Entities[len(sess.Entities)-MaxEntities:]
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"slices"
+	"strings"
+)
+
+// InteractionKey identifies a location's interaction in GameSession.UsedInteractions.
+func InteractionKey(locationID, interactionID string) string {
+	return locationID + "/" + interactionID
+}
+
+// InteractionUsed reports whether the interaction at locationID has been done this playthrough.
+func (sess *GameSession) InteractionUsed(locationID, interactionID string) bool {
+	return slices.Contains(sess.UsedInteractions, InteractionKey(locationID, interactionID))
+}
+
+// UseInteraction records the interaction at locationID as done.
+func (sess *GameSession) UseInteraction(locationID, interactionID string) {
+	if !sess.InteractionUsed(locationID, interactionID) {
+		sess.UsedInteractions = append(sess.UsedInteractions, InteractionKey(locationID, interactionID))
+	}
+}
+
+// ExitOpen reports whether an interaction opened an exit between the two locations.
+// Opened exits work both ways.
+func (sess *GameSession) ExitOpen(fromID, toID string) bool {
+	return slices.Contains(sess.OpenedExits, fromID+"/"+toID) || slices.Contains(sess.OpenedExits, toID+"/"+fromID)
+}
+
+// OpenExit opens an exit between the two locations for the rest of the playthrough.
+// It reports whether the exit was closed before.
+func (sess *GameSession) OpenExit(fromID, toID string) bool {
+	if sess.ExitOpen(fromID, toID) {
+		return false
+	}
+	sess.OpenedExits = append(sess.OpenedExits, fromID+"/"+toID)
+	return true
+}
+
+// OpenedExitsFrom returns the locations reachable from locationID through opened exits.
+func (sess *GameSession) OpenedExitsFrom(locationID string) []string {
+	var ids []string
+	for _, exit := range sess.OpenedExits {
+		from, to, _ := strings.Cut(exit, "/")
+		switch locationID {
+		case from:
+			ids = append(ids, to)
+		case to:
+			ids = append(ids, from)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,35 @@
+package session
+
+import "strings"
+
+// IsLegacyID reports whether id is in the old name-and-timestamp form
+// ("session_<player>_<nanos>", "player_<name>_<nanos>") rather than an opaque ID.
+func IsLegacyID(id string) bool {
+	return strings.HasPrefix(id, "session_") || strings.HasPrefix(id, "player_")
+}
+
+// migrateLegacyIDs gives a session saved before opaque IDs a fresh session ID (and
+// player ID), keeping the old session ID in LegacyID so it still resolves.
+// Reports whether anything changed. Call with sm.mu held.
+func (sm *InMemorySessionManager) migrateLegacyIDs(sess *GameSession) bool {
+	migrated := false
+	if IsLegacyID(sess.ID) {
+		sess.LegacyID = sess.ID
+		sess.ID = sm.IDs.NewID()
+		migrated = true
+	}
+	if sess.Player != nil && IsLegacyID(sess.Player.ID) {
+		sess.Player.ID = sm.IDs.NewID()
+		migrated = true
+	}
+	return migrated
+}
+
+// resolveID maps an old-format session ID to the session's current ID.
+// Call with sm.mu held (read or write).
+func (sm *InMemorySessionManager) resolveID(sessionID string) string {
+	if current, ok := sm.legacyIDs[sessionID]; ok {
+		return current
+	}
+	return sessionID
+}
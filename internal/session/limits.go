@@ -0,0 +1,130 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// --- Session Caps ---
+// With MaxSessions set, the manager keeps at most that many sessions in memory: making
+// room for a new or rehydrated one evicts the least recently active session to the
+// ColdStore, exactly as ArchiveIdle would. Without a ColdStore there is nowhere to
+// evict to, and new sessions are refused with ErrSessionLimit instead. The cap is soft:
+// concurrent creations can overshoot it briefly, and the next one evicts the excess.
+
+// ErrSessionLimit is returned when the session cap is reached and no session can be evicted.
+var ErrSessionLimit = errors.New("session limit reached")
+
+// DefaultCapWarnPercent is the share of MaxSessions in use at which Usage reports the
+// manager as near capacity, when CapWarnPercent is unset.
+const DefaultCapWarnPercent = 80
+
+// Usage is a snapshot of the manager's memory use, for health checks and alerting.
+type Usage struct {
+	ActiveSessions   int  `json:"activeSessions"`        // Sessions held in memory
+	ArchivedSessions int  `json:"archivedSessions"`      // Sessions in cold storage
+	MaxSessions      int  `json:"maxSessions,omitempty"` // In-memory cap (0 = unlimited)
+	Evictions        int  `json:"evictions"`             // Sessions moved to cold storage to stay under the cap
+	Rejections       int  `json:"rejections"`            // Sessions refused because the cap was reached
+	HistoryRetained  int  `json:"historyRetained"`       // Memory entries each session keeps at most
+	NearCapacity     bool `json:"nearCapacity"`          // At or above CapWarnPercent of MaxSessions
+}
+
+// Usage reports how many sessions are in memory and in cold storage against the cap.
+func (sm *InMemorySessionManager) Usage() Usage {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return Usage{
+		ActiveSessions:   len(sm.sessions),
+		ArchivedSessions: len(sm.archived),
+		MaxSessions:      sm.MaxSessions,
+		Evictions:        sm.evictions,
+		Rejections:       sm.rejections,
+		HistoryRetained:  sm.HistoryPolicy.Normalized().MaxRetained,
+		NearCapacity:     sm.nearCapacity(),
+	}
+}
+
+// nearCapacity reports whether the sessions in memory have reached the warning
+// threshold. Callers must hold sm.mu.
+func (sm *InMemorySessionManager) nearCapacity() bool {
+	if sm.MaxSessions <= 0 {
+		return false
+	}
+	warn := sm.CapWarnPercent
+	if warn <= 0 || warn > 100 {
+		warn = DefaultCapWarnPercent
+	}
+	return len(sm.sessions)*100 >= sm.MaxSessions*warn
+}
+
+// checkCapacity logs a warning when the sessions in memory cross the warning threshold,
+// once per crossing. Callers must hold sm.mu for writing.
+func (sm *InMemorySessionManager) checkCapacity() {
+	near := sm.nearCapacity()
+	if near && !sm.capWarned {
+		log.Printf("Warning: %d of %d in-memory sessions in use; least recently active sessions will be evicted", len(sm.sessions), sm.MaxSessions)
+	}
+	sm.capWarned = near
+}
+
+// makeRoom evicts least recently active sessions to the ColdStore until there is room
+// for one more under MaxSessions. Sessions mid-turn or touched while being saved are
+// passed over for the next oldest; if none is left the cap is overshot, as it is by
+// concurrent creations. It must be called without sm.mu held.
+func (sm *InMemorySessionManager) makeRoom() error {
+	if sm.MaxSessions <= 0 {
+		return nil
+	}
+	passed := make(map[string]bool) // Sessions that couldn't be evicted this time
+	for {
+		sm.mu.RLock()
+		var oldest *GameSession
+		if len(sm.sessions) >= sm.MaxSessions {
+			for _, sess := range sm.sessions {
+				if !passed[sess.ID] && (oldest == nil || sess.LastActive.Before(oldest.LastActive)) {
+					oldest = sess
+				}
+			}
+		}
+		sm.mu.RUnlock()
+		if oldest == nil {
+			return nil
+		}
+		if sm.ColdStore == nil {
+			sm.mu.Lock()
+			sm.rejections++
+			sm.mu.Unlock()
+			return fmt.Errorf("%w (%d sessions in memory, no session store to evict to)", ErrSessionLimit, sm.MaxSessions)
+		}
+
+		evicted, err := sm.archive(oldest, func(*GameSession) bool { return len(sm.sessions) >= sm.MaxSessions })
+		sm.mu.Lock()
+		switch {
+		case err != nil:
+			sm.rejections++
+			sm.mu.Unlock()
+			return fmt.Errorf("%w: failed to evict session %s: %v", ErrSessionLimit, oldest.ID, err)
+		case evicted:
+			sm.evictions++
+			fmt.Printf("Evicted session %s to stay under the limit of %d in-memory sessions.\n", oldest.ID, sm.MaxSessions)
+		default:
+			passed[oldest.ID] = true
+		}
+		sm.mu.Unlock()
+	}
+}
+
+// TrimHistory drops the oldest entries of long-term memory and the recent-events
+// window beyond the session's history policy, e.g. for a save made under a more
+// generous policy than the current deployment's.
+func (sess *GameSession) TrimHistory() {
+	policy := sess.HistoryPolicy.Normalized()
+	if len(sess.Memory) > policy.MaxRetained {
+		sess.Memory = sess.Memory[len(sess.Memory)-policy.MaxRetained:]
+	}
+	if len(sess.RecentActions) > policy.RecentWindow {
+		sess.RecentActions = sess.RecentActions[len(sess.RecentActions)-policy.RecentWindow:]
+	}
+}
@@ -0,0 +1,27 @@
+package session
+
+import "llmrpg/internal/history"
+
+// PendingAction is a high-impact action the narrator proposed in a session with
+// ConfirmActions set. It isn't applied until the player confirms it.
+type PendingAction struct {
+	Type   string                 `json:"type"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Reason string                 `json:"reason,omitempty"` // What the action would do, e.g. "starts a fight"; empty for actions that follow from the one before
+	Turn   int                    `json:"turn"`             // Turn the action was proposed in
+}
+
+// Narration returns the narration recorded for turn, or "" if none is retained.
+func (sess *GameSession) Narration(turn int) string {
+	for i := len(sess.RecentActions) - 1; i >= 0; i-- {
+		if r := sess.RecentActions[i]; r.Turn == turn && r.Type == history.TypeNarration {
+			return r.Summary
+		}
+	}
+	for i := len(sess.Memory) - 1; i >= 0; i-- {
+		if r := sess.Memory[i]; r.Turn == turn && r.Type == history.TypeNarration {
+			return r.Summary
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"time"
+
+	"llmrpg/internal/clock"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+)
+
+// ReportReason says what a player found wrong with a turn.
+type ReportReason string
+
+const (
+	ReportInconsistent ReportReason = "inconsistent" // Contradicts earlier turns or the world
+	ReportBad          ReportReason = "bad"          // Low quality, off-topic or broken
+	ReportOther        ReportReason = "other"
+)
+
+// Valid reports whether r is a known reason.
+func (r ReportReason) Valid() bool {
+	return r == ReportInconsistent || r == ReportBad || r == ReportOther
+}
+
+// TurnReport is a player's report that a turn went wrong, stored with what the turn
+// recorded so it can be reviewed later.
+type TurnReport struct {
+	Turn        int                  `json:"turn"`
+	Reason      ReportReason         `json:"reason"`
+	Comment     string               `json:"comment,omitempty"`
+	ReportedAt  time.Time            `json:"reportedAt"`
+	Records     []history.TurnRecord `json:"records"`          // The turn's history: input, executed actions and narration
+	Rolls       []dice.Result        `json:"rolls,omitempty"`  // Only kept for the latest turn
+	Events      []events.Event       `json:"events,omitempty"` // Likewise
+	Regenerated bool                 `json:"regenerated"`      // The turn was rolled back and played again
+}
+
+// MaxReports caps the reports kept per session; the oldest are dropped.
+const MaxReports = 50
+
+// Report records a report about turn, capturing the turn's history (and for the latest
+// turn, its rolls and events) from the session.
+func (sess *GameSession) Report(turn int, reason ReportReason, comment string) TurnReport {
+	report := TurnReport{Turn: turn, Reason: reason, Comment: comment, ReportedAt: clock.Now()}
+	for _, record := range sess.Memory {
+		if record.Turn == turn {
+			report.Records = append(report.Records, record)
+		}
+	}
+	if turn == sess.TurnCount {
+		report.Rolls = sess.LastTurnRolls
+		report.Events = sess.LastTurnEvents
+	}
+	sess.Reports = append(sess.Reports, report)
+	if len(sess.Reports) > MaxReports {
+		sess.Reports = sess.Reports[len(sess.Reports)-MaxReports:]
+	}
+	return report
+}
@@ -0,0 +1,26 @@
+package session
+
+// MaxRumors bounds how many rumors a session keeps; the oldest are forgotten first.
+const MaxRumors = 20
+
+// Rumor is word of something that happened at a location. It spreads to other
+// locations as in-story time passes (see narrative.RumorHopHours).
+type Rumor struct {
+	Text       string `json:"text"`       // As locals would retell it, e.g. "Gh fought off two wolves"
+	LocationID string `json:"locationId"` // Where it happened
+	Hour       int    `json:"hour"`       // GameHours when it happened
+	Turn       int    `json:"turn"`
+}
+
+// AddRumor starts a rumor at locationID, unless the same one is already going around.
+func (sess *GameSession) AddRumor(text, locationID string) {
+	for _, r := range sess.Rumors {
+		if r.Text == text && r.LocationID == locationID {
+			return
+		}
+	}
+	sess.Rumors = append(sess.Rumors, Rumor{Text: text, LocationID: locationID, Hour: sess.GameHours, Turn: sess.TurnCount})
+	if len(sess.Rumors) > MaxRumors {
+		sess.Rumors = sess.Rumors[len(sess.Rumors)-MaxRumors:]
+	}
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"llmrpg/internal/dice"
+	"math/rand/v2"
+	"sync"
+)
+
+// seedSource, when set by SeedSessionsFrom, hands out session seeds in creation order.
+var seedSource struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// SeedSessionsFrom makes NewSeed derive every new session's seed from base, so the
+// Nth session created after startup always gets the same seed (deterministic mode).
+func SeedSessionsFrom(base uint64) {
+	seedSource.mu.Lock()
+	defer seedSource.mu.Unlock()
+	seedSource.rng = rand.New(rand.NewPCG(base, 0))
+}
+
+// NewSeed returns a non-zero session seed: random, or the next derived one after
+// SeedSessionsFrom.
+func NewSeed() uint64 {
+	seedSource.mu.Lock()
+	defer seedSource.mu.Unlock()
+	for {
+		var seed uint64
+		if seedSource.rng != nil {
+			seed = seedSource.rng.Uint64()
+		} else {
+			seed = rand.Uint64()
+		}
+		if seed != 0 {
+			return seed
+		}
+	}
+}
+
+// Roller returns the session's dice for the current turn. Every roll (checks, combat,
+// loot, encounters, world events) derives from Seed and TurnCount, so replaying the
+// same inputs against a session with the same seed rolls the same numbers. A session
+// without a seed (e.g. saved before seeds existed) is given one.
+func (sess *GameSession) Roller() *dice.Roller {
+	if sess.Seed == 0 {
+		sess.Seed = NewSeed()
+	}
+	if sess.roller == nil || sess.rollerTurn != sess.TurnCount || sess.rollerSeed != sess.Seed {
+		sess.roller = dice.NewRoller(rand.NewPCG(sess.Seed, uint64(sess.TurnCount)))
+		sess.rollerTurn, sess.rollerSeed = sess.TurnCount, sess.Seed
+	}
+	return sess.roller
+}
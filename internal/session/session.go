@@ -1,11 +1,17 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"llmrpg/internal/character" // Assuming 'llmrpg' is your go module name
-	"llmrpg/internal/world"
-	// We don't strictly need to import 'world' here, as we only store the ID,
-	// but the concept relies on the world package existing.
+	"llmrpg/internal/clock"
+	"llmrpg/internal/combat"
+	"llmrpg/internal/dice"
+	"llmrpg/internal/events"
+	"llmrpg/internal/history"
+	"llmrpg/internal/ids"
+	"llmrpg/internal/llm"
+	"llmrpg/internal/rating"
 	"sync"
 	"time"
 )
@@ -13,27 +19,177 @@ import (
 // GameSession holds the state for a single playthrough.
 // This is a simplified version for the initial MVP, focusing on Character and Location.
 type GameSession struct {
-	ID                string             `json:"id"`                  // Unique identifier for this session
-	Player            *character.Character `json:"character"`           // The player character for this session
-	CurrentLocationID string             `json:"currentLocationId"`   // ID of the player's current location in the world
-	CreatedAt         time.Time          `json:"createdAt"`           // When the session started
-	LastActive        time.Time          `json:"lastActive"`          // Last time session was accessed/updated
-	RecentActions     []string           `json:"recentActions"`       // Limited history for LLM context
-    CurrentLocation   *world.LocationNode `json:"currentLocation"` // <-- ADD THIS
+	ID                string                    `json:"id"`                          // Unique identifier for this session (opaque; see IsLegacyID)
+	LegacyID          string                    `json:"legacyId,omitempty"`          // Old-format ID the session was saved under before migration, still accepted by GetSession
+	Player            *character.Character      `json:"character"`                   // The player character for this session
+	AccessTokenHash   string                    `json:"accessTokenHash,omitempty"`   // SHA-256 of the secret clients must send with requests (see IssueAccessToken); deliberately in no state section
+	WorldID           string                    `json:"worldId,omitempty"`           // Hosted world the session plays in ("" = the server's default world)
+	PlayerID          string                    `json:"playerId,omitempty"`          // Stable identity of the human player (from auth or client-generated), for finding their sessions
+	CurrentLocationID string                    `json:"currentLocationId"`           // ID of the player's current location in the world
+	CreatedAt         time.Time                 `json:"createdAt"`                   // When the session started
+	LastActive        time.Time                 `json:"lastActive"`                  // Last time session was accessed/updated
+	RecentActions     []history.TurnRecord      `json:"recentActions"`               // Limited history window for LLM context
+	TurnCount         int                       `json:"turnCount"`                   // Number of player turns processed
+	Memory            []history.TurnRecord      `json:"memory,omitempty"`            // Long-term event log, searchable via /session/{id}/memory/search
+	HistoryPolicy     history.Policy            `json:"-"`                           // Window/retention policy, set by the manager
+	Seed              uint64                    `json:"seed,string"`                 // Source of every roll in the session (see Roller); same seed and inputs, same rolls
+	LastTurnRolls     []dice.Result             `json:"lastTurnRolls,omitempty"`     // Dice rolled during the most recent turn
+	LastTurnEvents    []events.Event            `json:"lastTurnEvents,omitempty"`    // Client events emitted during the most recent turn
+	LastTurnCombat    []combat.LogEntry         `json:"lastTurnCombat,omitempty"`    // Combat steps resolved during the most recent turn
+	LastTurnResults   []llm.ActionResult        `json:"lastTurnResults,omitempty"`   // What each action of the most recent turn did, in order
+	Combat            *combat.Encounter         `json:"combat,omitempty"`            // Fight in progress; nil outside combat
+	Stealth           StealthState              `json:"stealth,omitempty"`           // Whether the player is sneaking in the current scene; empty = not sneaking
+	Mount             string                    `json:"mount,omitempty"`             // Item ID of the mount or vehicle the player is riding; empty = on foot
+	PendingChallenge  *Challenge                `json:"pendingChallenge,omitempty"`  // Challenge waiting for the frontend's minigame result; nil = none
+	Ironman           bool                      `json:"ironman"`                     // Permadeath: no rewind, fork or save slots; single autosave only
+	ConfirmActions    bool                      `json:"confirmActions"`              // High-impact actions the narrator proposes wait for the player's confirmation
+	PendingActions    []PendingAction           `json:"pendingActions,omitempty"`    // Actions waiting for confirmation (see /session/{id}/actions/confirm)
+	LastAutosaveTurn  int                       `json:"lastAutosaveTurn"`            // Turn number of the most recent autosave (0 = never)
+	ScenarioID        string                    `json:"scenarioId,omitempty"`        // Guided opening being played (see world.Scenario); empty = free play
+	ScenarioBeat      int                       `json:"scenarioBeat,omitempty"`      // Index of the active beat; past the last beat the scenario is complete
+	ContentRating     rating.Rating             `json:"contentRating,omitempty"`     // Content rating chosen at creation (E/T/M); empty = the world's rating
+	PromptVariant     string                    `json:"promptVariant,omitempty"`     // System prompt variant assigned at creation for prompt experiments; empty = the world's prompt
+	Flags             map[string]bool           `json:"flags,omitempty"`             // Narrative flags set during play (see FlagDead)
+	Reports           []TurnReport              `json:"reports,omitempty"`           // Players' reports of turns that went wrong (see /session/{id}/report)
+	Entities          []Entity                  `json:"entities,omitempty"`          // Names invented during play, in registration order (see RegisterEntity)
+	Karma             int                       `json:"karma,omitempty"`             // Moral standing from -KarmaLimit (villainous) to KarmaLimit (heroic); see AdjustKarma
+	CompletedQuests   []string                  `json:"completedQuests,omitempty"`   // Quest IDs with a "completed" questUpdated event, in order
+	SearchedLocations []string                  `json:"searchedLocations,omitempty"` // Location IDs whose loot table has been rolled by a search
+	Containers        map[string]map[string]int `json:"containers,omitempty"`        // Contents of location containers the player changed, by ContainerKey (missing = as authored)
+	UsedInteractions  []string                  `json:"usedInteractions,omitempty"`  // Location interactions done at least once, by InteractionKey
+	OpenedExits       []string                  `json:"openedExits,omitempty"`       // Exits opened by interactions, as "fromId/toId" (see ExitOpen)
+	Rumors            []Rumor                   `json:"rumors,omitempty"`            // Word of the player's deeds spreading from where they happened, oldest first
+	WorldEvents       []WorldEventRun           `json:"worldEvents,omitempty"`       // World events that have started, oldest first (see world.WorldEvent)
+	WorldEventsHour   int                       `json:"worldEventsHour"`             // First GameHours the world event scheduler hasn't checked yet
+	GameHours         int                       `json:"gameHours"`                   // In-story hours elapsed since the session started (see Clock)
+	Resources         map[string]int            `json:"resources,omitempty"`         // Survival resource levels (worlds with survival rules; missing = full)
+	Status            Status                    `json:"status,omitempty"`            // Empty or "active" while playable; "completed" once an ending is reached
+	Ending            *EndingRecord             `json:"ending,omitempty"`            // Which ending was reached, with its epilogue
+	Stats             Stats                     `json:"stats"`                       // Playthrough statistics, see /session/{id}/stats
+
+	roller     *dice.Roller // Dice for the turn rollerTurn, from rollerSeed (see Roller)
+	rollerTurn int
+	rollerSeed uint64
 	// --- Fields deferred for later implementation based on design ---
 	// WorldState      WorldState     `json:"worldState"`        // More complex world state [cite: 161]
 	// CurrentScene    Scene          `json:"currentScene"`        // For scene management [cite: 156]
 	// SceneHistory    []SceneRecord  `json:"sceneHistory"`      // Longer-term history [cite: 163]
-	// Flags           map[string]bool `json:"flags"`             // Narrative flags specific to this session
 	// SaveSlot        string         `json:"saveSlot,omitempty"` // Identifier for persistence
 }
 
+// Status is the lifecycle state of a session.
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusCompleted Status = "completed" // An ending was reached; no further actions are accepted
+)
+
+// ContainerKey identifies a location's container in GameSession.Containers.
+func ContainerKey(locationID, containerID string) string {
+	return locationID + "/" + containerID
+}
+
+// StealthState is how well hidden the player is in the current scene (location).
+// It starts empty (not sneaking) in every new scene.
+type StealthState string
+
+const (
+	StealthHidden    StealthState = "hidden"    // Unnoticed
+	StealthSuspected StealthState = "suspected" // Someone is looking around
+	StealthDetected  StealthState = "detected"  // Spotted
+)
+
+// Challenge is a challenge (lockpicking, hacking, ...) the frontend resolves with a
+// minigame. It stays pending until the result is reported or the player moves on.
+type Challenge struct {
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	DC    int    `json:"dc"`
+	Turn  int    `json:"turn"` // Turn the challenge was started on
+}
+
+// FlagDead is the session flag marking the player character's death (checked by "death" endings).
+const FlagDead = "dead"
+
+// EndingRecord is the ending a completed session reached.
+type EndingRecord struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Epilogue string    `json:"epilogue"` // Closing narrative generated for the playthrough
+	Turn     int       `json:"turn"`
+	EndedAt  time.Time `json:"endedAt"`
+	Recap    Stats     `json:"recap"` // Playthrough statistics at the moment the story ended
+}
+
+// ErrSessionCompleted is returned when an action is submitted to a session that has reached an ending.
+var ErrSessionCompleted = errors.New("session has reached an ending and accepts no further actions")
+
+// Completed reports whether the session has reached an ending.
+func (sess *GameSession) Completed() bool {
+	return sess.Status == StatusCompleted
+}
+
+// SetFlag sets or clears a narrative flag.
+func (sess *GameSession) SetFlag(flag string, value bool) {
+	if !value {
+		delete(sess.Flags, flag)
+		return
+	}
+	if sess.Flags == nil {
+		sess.Flags = make(map[string]bool)
+	}
+	sess.Flags[flag] = true
+}
+
+// KarmaLimit bounds the session's karma in both directions.
+const KarmaLimit = 100
+
+// AdjustKarma shifts the session's karma by delta, clamped to ±KarmaLimit, and returns
+// the change actually applied.
+func (sess *GameSession) AdjustKarma(delta int) int {
+	before := sess.Karma
+	sess.Karma = max(-KarmaLimit, min(KarmaLimit, sess.Karma+delta))
+	return sess.Karma - before
+}
+
+// StartHour is the in-story hour of day a session begins at.
+const StartHour = 8
+
+// Clock formats the in-story time, e.g. "Day 2, 06:00".
+func (sess *GameSession) Clock() string {
+	hours := StartHour + sess.GameHours
+	return fmt.Sprintf("Day %d, %02d:00", hours/24+1, hours%24)
+}
+
+// HourOfDay is the in-story hour of the day (0-23).
+func (sess *GameSession) HourOfDay() int {
+	return (StartHour + sess.GameHours) % 24
+}
+
+// ErrSessionNotFound is returned by a Manager for an unknown session ID.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrIronmanSession is returned when a rewind, fork or save-slot operation is
+// attempted on a session created in ironman mode.
+var ErrIronmanSession = errors.New("session is in ironman mode: rewind, fork and save slots are disabled")
+
+// CheckRewindable returns ErrIronmanSession if the session forbids rewinding,
+// forking or manual save slots. Every such feature must call this first.
+func (sess *GameSession) CheckRewindable() error {
+	if sess.Ironman {
+		return fmt.Errorf("%w (session %s)", ErrIronmanSession, sess.ID)
+	}
+	return nil
+}
+
 // Manager defines the interface for managing game sessions.
 type Manager interface {
 	CreateNewSession(player *character.Character, startLocationID string) (*GameSession, error)
 	GetSession(sessionID string) (*GameSession, error)
 	GetAllSessionIDs() []string
-	UpdateSession(session *GameSession) error // For updating LastActive, etc.
+	ListPlayerSessions(playerID string) []Summary // Sessions recorded against a stable player identity
+	UpdateSession(session *GameSession) error     // For updating LastActive, etc.
 	// DeleteSession(sessionID string) error // Add later if needed
 	// SaveSession(sessionID string) error // Add later for persistence
 	// LoadSession(sessionID string) (*GameSession, error) // Add later for persistence
@@ -41,14 +197,33 @@ type Manager interface {
 
 // InMemorySessionManager stores active game sessions in memory.
 type InMemorySessionManager struct {
-	sessions map[string]*GameSession
-	mu       sync.RWMutex // Protects access to the sessions map
+	sessions       map[string]*GameSession
+	legacyIDs      map[string]string      // Old-format session ID -> current ID, for migrated saves
+	archived       map[string]Summary     // Sessions moved to ColdStore, by ID (see ArchiveIdle)
+	turnLocks      map[string]*sync.Mutex // Per-session turn locks, by ID (see TurnLock)
+	mu             sync.RWMutex           // Protects access to the sessions map
+	HistoryPolicy  history.Policy         // Applied to newly created sessions
+	Clock          clock.Clock            // Creation and last-active times
+	IDs            ids.Generator          // Unique part of new session IDs
+	ColdStore      Store                  // Optional; where ArchiveIdle moves idle sessions, loaded back on access
+	MaxSessions    int                    // Sessions kept in memory at most, evicting the least recently active (0 = unlimited; see limits.go)
+	CapWarnPercent int                    // Share of MaxSessions at which Usage reports NearCapacity (0 = DefaultCapWarnPercent)
+
+	evictions  int  // Sessions evicted to stay under MaxSessions
+	rejections int  // Sessions refused because MaxSessions was reached
+	capWarned  bool // Whether the near-capacity warning has been logged since usage last dropped below it
 }
 
 // NewInMemorySessionManager creates a new in-memory session manager.
 func NewInMemorySessionManager() *InMemorySessionManager {
 	return &InMemorySessionManager{
-		sessions: make(map[string]*GameSession),
+		sessions:      make(map[string]*GameSession),
+		legacyIDs:     make(map[string]string),
+		archived:      make(map[string]Summary),
+		turnLocks:     make(map[string]*sync.Mutex),
+		HistoryPolicy: history.DefaultPolicy(),
+		Clock:         clock.Default,
+		IDs:           ids.Default,
 	}
 }
 
@@ -63,52 +238,101 @@ func (sm *InMemorySessionManager) CreateNewSession(player *character.Character,
 	}
 	// In a real system, you might check if startLocationID is valid using WorldSystem here.
 
+	// Stay under MaxSessions, evicting the least recently active session if needed
+	if err := sm.makeRoom(); err != nil {
+		return nil, err
+	}
+
 	sm.mu.Lock() // Lock for writing
 	defer sm.mu.Unlock()
 
-	// Generate a unique session ID (simple approach for now)
-	// A robust solution might use UUIDs or database sequences.
-	newID := fmt.Sprintf("session_%s_%d", player.ID, time.Now().UnixNano())
+	// Generate an opaque session ID; the player's name lives on the character, not in the ID
+	newID := sm.IDs.NewID()
 
-	// Ensure ID uniqueness (highly unlikely collision with nanoseconds, but good practice)
+	// Ensure ID uniqueness (a UUID collision is practically impossible, but a bad generator isn't)
 	if _, exists := sm.sessions[newID]; exists {
-		// Handle collision (e.g., retry generation, return error)
-		return nil, fmt.Errorf("session ID collision detected (highly unlikely)")
+		return nil, fmt.Errorf("session ID collision detected: %s", newID)
 	}
+	now := sm.Clock.Now()
 
 	sess := &GameSession{
 		ID:                newID,
 		Player:            player,
 		CurrentLocationID: startLocationID,
-		CreatedAt:         time.Now(),
-		LastActive:        time.Now(),
-		RecentActions:     make([]string, 0, 5), // Initialize with capacity
+		CreatedAt:         now,
+		LastActive:        now,
+		RecentActions:     make([]history.TurnRecord, 0, sm.HistoryPolicy.Normalized().RecentWindow), // Initialize with capacity
+		HistoryPolicy:     sm.HistoryPolicy,
+		Stats:             Stats{LocationsVisited: []string{startLocationID}},
+		Seed:              NewSeed(),
+		Status:            StatusActive,
 	}
 
 	sm.sessions[newID] = sess
+	sm.checkCapacity()
 	fmt.Printf("Created new session: %s for player %s starting at %s\n", newID, player.Name, startLocationID)
 	return sess, nil
 }
 
+// AddSession registers an existing session (e.g. one restored from a Store). A session
+// saved under an old-format ID is given an opaque one (the old ID keeps resolving);
+// migrated reports whether that happened, so the caller can re-save it.
+func (sm *InMemorySessionManager) AddSession(sess *GameSession) (migrated bool, err error) {
+	if sess == nil || sess.ID == "" {
+		return false, fmt.Errorf("cannot add nil session or session without ID")
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, exists := sm.sessions[sm.resolveID(sess.ID)]; exists {
+		return false, fmt.Errorf("session %s already exists", sess.ID)
+	}
+	if _, exists := sm.archived[sm.resolveID(sess.ID)]; exists {
+		return false, fmt.Errorf("session %s already exists", sess.ID)
+	}
+	migrated = sm.migrateLegacyIDs(sess)
+	if sess.LegacyID != "" {
+		if _, exists := sm.legacyIDs[sess.LegacyID]; exists {
+			return false, fmt.Errorf("session %s already exists", sess.LegacyID)
+		}
+		sm.legacyIDs[sess.LegacyID] = sess.ID
+	}
+	sess.HistoryPolicy = sm.HistoryPolicy // Not persisted; follows the current deployment
+	sess.TrimHistory()
+	sm.sessions[sess.ID] = sess
+	sm.checkCapacity()
+	return migrated, nil
+}
+
 // GetSession retrieves a session by its ID. Updates LastActive time.
 func (sm *InMemorySessionManager) GetSession(sessionID string) (*GameSession, error) {
 	sm.mu.RLock() // Lock for reading initially
-	sess, ok := sm.sessions[sessionID]
+	id := sm.resolveID(sessionID)
+	sess, ok := sm.sessions[id]
 	sm.mu.RUnlock() // Unlock after reading
 
 	if !ok {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+		// Not in memory; it may be in cold storage
+		rehydrated, err := sm.rehydrate(id)
+		if err != nil {
+			return nil, err
+		}
+		if rehydrated == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+		}
+		sess = rehydrated
 	}
 
 	// Update LastActive time - requires a write lock temporarily
 	sm.mu.Lock()
-	sess.LastActive = time.Now()
+	sess.LastActive = sm.Clock.Now()
 	sm.mu.Unlock()
 
 	return sess, nil
 }
 
-// GetAllSessionIDs returns a slice of all active session IDs.
+// GetAllSessionIDs returns a slice of all active session IDs. Archived sessions are
+// not included (see ArchivedSessions).
 func (sm *InMemorySessionManager) GetAllSessionIDs() []string {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -138,7 +362,7 @@ func (sm *InMemorySessionManager) UpdateSession(session *GameSession) error {
 	}
 
 	// Update LastActive time
-	session.LastActive = time.Now()
+	session.LastActive = sm.Clock.Now()
 
 	// Replace the stored session pointer with the updated one?
 	// Or modify the existing one in place? Modifying in place is common if GetSession returns pointers.
@@ -151,18 +375,59 @@ func (sm *InMemorySessionManager) UpdateSession(session *GameSession) error {
 	return nil
 }
 
-// AddRecentAction adds an action summary to the session's history (limited size).
-func (sess *GameSession) AddRecentAction(actionSummary string) {
+// Record adds an entry to the session's history: always to long-term memory, and to the
+// recent-events window unless it's narration the policy keeps out of the window.
+func (sess *GameSession) Record(actor history.Actor, recordType history.RecordType, summary string) {
 	// Note: This method modifies the session directly. Ensure thread safety if sessions
 	// are accessed concurrently outside the manager's controlled methods.
 	// The SessionManager's methods provide safety for accessing the map, but not
 	// concurrent modifications *within* a single session object if pointers are shared.
 	// For simple sequential request handling, this is likely fine.
+	policy := sess.HistoryPolicy.Normalized()
+	record := history.TurnRecord{
+		Turn:      sess.TurnCount,
+		Actor:     actor,
+		Type:      recordType,
+		Summary:   summary,
+		Timestamp: clock.Now(),
+	}
+
+	sess.Memory = append(sess.Memory, record)
+	if len(sess.Memory) > policy.MaxRetained {
+		sess.Memory = sess.Memory[len(sess.Memory)-policy.MaxRetained:]
+	}
+
+	if recordType == history.TypeNarration && !policy.IncludeNarration {
+		return
+	}
+	sess.RecentActions = append(sess.RecentActions, record)
+	if len(sess.RecentActions) > policy.RecentWindow {
+		// Slice off the oldest entries
+		sess.RecentActions = sess.RecentActions[len(sess.RecentActions)-policy.RecentWindow:]
+	}
+}
 
-	const maxRecentActions = 5 // Keep the last 5 actions
-	sess.RecentActions = append(sess.RecentActions, actionSummary)
-	if len(sess.RecentActions) > maxRecentActions {
-		// Slice off the oldest element
-		sess.RecentActions = sess.RecentActions[len(sess.RecentActions)-maxRecentActions:]
+// ReplaceNarration replaces the narration recorded for turn (its first narration
+// entry) in memory and the recent-events window, e.g. after the prose was rerolled.
+func (sess *GameSession) ReplaceNarration(turn int, narrative string) {
+	for _, records := range [][]history.TurnRecord{sess.Memory, sess.RecentActions} {
+		for i := range records {
+			if records[i].Turn == turn && records[i].Type == history.TypeNarration {
+				records[i].Summary = narrative
+				break
+			}
+		}
 	}
-}
\ No newline at end of file
+}
+
+// Emit records a client event for the current turn. Completed quests are also
+// remembered in CompletedQuests, for ending conditions.
+func (sess *GameSession) Emit(event events.Event) {
+	sess.LastTurnEvents = append(sess.LastTurnEvents, event)
+	sess.Stats.observe(event)
+	if event.Type == events.QuestUpdated && event.Data["status"] == "completed" {
+		if questID, ok := event.Data["questId"].(string); ok {
+			sess.CompletedQuests = append(sess.CompletedQuests, questID)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"maps"
+	"slices"
+
+	"llmrpg/internal/events"
+	"llmrpg/internal/llm"
+)
+
+// Stats summarizes a playthrough. Everything except TurnsTaken and the token counts
+// is derived from the client events the session emits.
+type Stats struct {
+	TurnsTaken       int            `json:"turnsTaken"`
+	LocationsVisited []string       `json:"locationsVisited"` // Distinct location IDs, in the order first visited
+	ItemsGained      map[string]int `json:"itemsGained,omitempty"`
+	NPCsMet          []string       `json:"npcsMet,omitempty"` // Distinct NPC IDs, in the order first met
+	FightsWon        int            `json:"fightsWon,omitempty"`
+	TriagedTurns     int            `json:"triagedTurns,omitempty"` // Turns answered without the narrator (see narrative.TriagePolicy)
+	PromptTokens     int            `json:"promptTokens"`
+	CompletionTokens int            `json:"completionTokens"`
+	TotalTokens      int            `json:"totalTokens"`
+}
+
+// observe updates the stats from an emitted event.
+func (s *Stats) observe(event events.Event) {
+	switch event.Type {
+	case events.LocationChanged:
+		if id, ok := event.Data["toId"].(string); ok {
+			s.LocationsVisited = appendUnique(s.LocationsVisited, id)
+		}
+	case events.ItemGained:
+		id, _ := event.Data["itemId"].(string)
+		count, _ := event.Data["count"].(int)
+		if id != "" {
+			if s.ItemsGained == nil {
+				s.ItemsGained = make(map[string]int)
+			}
+			s.ItemsGained[id] += max(count, 1)
+		}
+	case events.CombatEnded:
+		if event.Data["outcome"] == "victory" {
+			s.FightsWon++
+		}
+	case events.NPCMet:
+		if id, ok := event.Data["npcId"].(string); ok {
+			s.NPCsMet = appendUnique(s.NPCsMet, id)
+		}
+	}
+}
+
+// Snapshot returns a copy that later turns won't modify.
+func (s Stats) Snapshot() Stats {
+	s.LocationsVisited = slices.Clone(s.LocationsVisited)
+	s.NPCsMet = slices.Clone(s.NPCsMet)
+	s.ItemsGained = maps.Clone(s.ItemsGained)
+	return s
+}
+
+// AddUsage counts the tokens an LLM call consumed on behalf of the session.
+func (s *Stats) AddUsage(usage *llm.TokenUsage) {
+	if usage == nil {
+		return
+	}
+	s.PromptTokens += usage.PromptTokens
+	s.CompletionTokens += usage.CompletionTokens
+	s.TotalTokens += usage.TotalTokens
+}
+
+func appendUnique(values []string, s string) []string {
+	if slices.Contains(values, s) {
+		return values
+	}
+	return append(values, s)
+}
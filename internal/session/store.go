@@ -0,0 +1,92 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store persists session snapshots outside the process.
+type Store interface {
+	SaveSession(sess *GameSession) error
+	LoadSession(sessionID string) (*GameSession, error)
+	ListSessionIDs() ([]string, error)
+}
+
+// FileStore keeps one JSON file per session in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store, creating dir if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create session store directory %s: %w", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// SaveSession writes the snapshot atomically (temp file + rename) so a crash
+// mid-write never leaves a truncated save behind.
+func (fs *FileStore) SaveSession(sess *GameSession) error {
+	if sess == nil {
+		return fmt.Errorf("cannot save nil session")
+	}
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session %s: %w", sess.ID, err)
+	}
+
+	path := fs.path(sess.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sess.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to finalize session %s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+// LoadSession reads a session snapshot by ID.
+func (fs *FileStore) LoadSession(sessionID string) (*GameSession, error) {
+	data, err := os.ReadFile(fs.path(sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", sessionID, err)
+	}
+	var sess GameSession
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", sessionID, err)
+	}
+	return &sess, nil
+}
+
+// ListSessionIDs returns the IDs of all stored sessions.
+func (fs *FileStore) ListSessionIDs() ([]string, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session store %s: %w", fs.dir, err)
+	}
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			ids = append(ids, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	return ids, nil
+}
+
+// DeleteSession removes a session snapshot. Deleting one that doesn't exist is not an error.
+func (fs *FileStore) DeleteSession(sessionID string) error {
+	if err := os.Remove(fs.path(sessionID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+func (fs *FileStore) path(sessionID string) string {
+	// Session IDs are generated server-side, but guard against path traversal anyway.
+	return filepath.Join(fs.dir, filepath.Base(sessionID)+".json")
+}
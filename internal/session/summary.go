@@ -0,0 +1,58 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary is a compact description of a session for listings.
+type Summary struct {
+	ID                string    `json:"id"`
+	PlayerID          string    `json:"playerId,omitempty"`
+	WorldID           string    `json:"worldId,omitempty"` // "" = the server's default world
+	CharacterName     string    `json:"characterName"`
+	CurrentLocationID string    `json:"currentLocationId"`
+	TurnCount         int       `json:"turnCount"`
+	Ironman           bool      `json:"ironman"`
+	CreatedAt         time.Time `json:"createdAt"`
+	LastActive        time.Time `json:"lastActive"`
+}
+
+// Summary returns the listing view of the session.
+func (sess *GameSession) Summary() Summary {
+	summary := Summary{
+		ID:                sess.ID,
+		PlayerID:          sess.PlayerID,
+		WorldID:           sess.WorldID,
+		CurrentLocationID: sess.CurrentLocationID,
+		TurnCount:         sess.TurnCount,
+		Ironman:           sess.Ironman,
+		CreatedAt:         sess.CreatedAt,
+		LastActive:        sess.LastActive,
+	}
+	if sess.Player != nil {
+		summary.CharacterName = sess.Player.Name
+	}
+	return summary
+}
+
+// ListPlayerSessions returns summaries of every session belonging to playerID, archived
+// ones included, most recently active first. Unlike GetSession, listing does not touch
+// LastActive or rehydrate archived sessions.
+func (sm *InMemorySessionManager) ListPlayerSessions(playerID string) []Summary {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	summaries := []Summary{}
+	for _, sess := range sm.sessions {
+		if playerID != "" && sess.PlayerID == playerID {
+			summaries = append(summaries, sess.Summary())
+		}
+	}
+	for _, summary := range sm.archived {
+		if playerID != "" && summary.PlayerID == playerID {
+			summaries = append(summaries, summary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].LastActive.After(summaries[j].LastActive) })
+	return summaries
+}
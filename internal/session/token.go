@@ -0,0 +1,36 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// IssueAccessToken gives the session a fresh secret and returns it. Only a hash is
+// kept on the session (and in saves), so the token must be handed to the client now;
+// it can't be recovered later. Issuing again revokes the previous token. Tokens are
+// 32 random bytes (base64url), drawn from crypto/rand rather than the ID generator,
+// which deterministic mode makes sequential.
+func (sess *GameSession) IssueAccessToken() string {
+	secret := make([]byte, 32)
+	rand.Read(secret) // Never returns an error; it crashes the program if the OS can't supply randomness
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	sess.AccessTokenHash = hashAccessToken(token)
+	return token
+}
+
+// CheckAccessToken reports whether token opens the session. A session without a token
+// (one saved before access tokens existed) opens for no one until it is issued one.
+func (sess *GameSession) CheckAccessToken(token string) bool {
+	if sess.AccessTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashAccessToken(token)), []byte(sess.AccessTokenHash)) == 1
+}
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
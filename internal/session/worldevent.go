@@ -0,0 +1,33 @@
+package session
+
+// WorldEventRun is one occurrence of a world event (see world.WorldEvent) in a session.
+type WorldEventRun struct {
+	ID    string `json:"id"`
+	Start int    `json:"start"` // GameHours it started at
+	End   int    `json:"end"`   // GameHours it ends at (exclusive)
+}
+
+// Active reports whether the occurrence is under way at the session's current time.
+func (r WorldEventRun) Active(gameHours int) bool {
+	return r.Start <= gameHours && gameHours < r.End
+}
+
+// WorldEventActive reports whether an occurrence of the world event is under way.
+func (sess *GameSession) WorldEventActive(id string) bool {
+	for _, run := range sess.WorldEvents {
+		if run.ID == id && run.Active(sess.GameHours) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorldEventStarted reports whether the world event has ever started in this session.
+func (sess *GameSession) WorldEventStarted(id string) bool {
+	for _, run := range sess.WorldEvents {
+		if run.ID == id {
+			return true
+		}
+	}
+	return false
+}
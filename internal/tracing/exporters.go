@@ -0,0 +1,258 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Log Exporter ---
+
+// LogExporter prints each finished span as one log line, for local debugging.
+type LogExporter struct{}
+
+func (LogExporter) Export(span SpanData) {
+	line := fmt.Sprintf("TRACE %s %s span=%s parent=%s %s", span.TraceID, span.Name, span.SpanID, span.ParentSpanID, span.End.Sub(span.Start).Round(time.Microsecond))
+	keys := make([]string, 0, len(span.Attributes))
+	for k := range span.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line += fmt.Sprintf(" %s=%v", k, span.Attributes[k])
+	}
+	if span.Err != "" {
+		line += fmt.Sprintf(" error=%q", span.Err)
+	}
+	log.Println(line)
+}
+
+func (LogExporter) Shutdown(ctx context.Context) error { return nil }
+
+// --- OTLP/HTTP Exporter ---
+
+// OTLPConfig configures an OTLPExporter.
+type OTLPConfig struct {
+	Endpoint      string            // Collector base URL, e.g. "http://localhost:4318"; spans go to Endpoint + "/v1/traces"
+	Headers       map[string]string // Extra request headers, e.g. for collector authentication
+	ServiceName   string            // Reported as the service.name resource attribute
+	BatchSize     int               // Spans per export request (default 256)
+	FlushInterval time.Duration     // Maximum time a span waits before export (default 5s)
+}
+
+// OTLPExporter batches spans and posts them to an OpenTelemetry collector in the
+// OTLP/HTTP JSON encoding. Spans are dropped (with a log line) if the queue is full
+// or the collector is unreachable; tracing never blocks a turn.
+type OTLPExporter struct {
+	cfg    OTLPConfig
+	client *http.Client
+	queue  chan SpanData
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewOTLPExporter starts an exporter that flushes in the background until Shutdown.
+func NewOTLPExporter(cfg OTLPConfig, client *http.Client) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("OTLP endpoint is required")
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "llmrpg"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 256
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second} // Not the traced LLM client, or exports would trace themselves
+	}
+	e := &OTLPExporter{
+		cfg:    cfg,
+		client: client,
+		queue:  make(chan SpanData, cfg.BatchSize*8),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func (e *OTLPExporter) Export(span SpanData) {
+	select {
+	case e.queue <- span:
+	default:
+		log.Printf("Warning: Trace queue full, dropping span '%s'", span.Name)
+	}
+}
+
+// Shutdown flushes queued spans and stops the background loop.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.queue) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.post(batch); err != nil {
+			log.Printf("Warning: Failed to export %d span(s): %v", len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *OTLPExporter) post(batch []SpanData) error {
+	body, err := json.Marshal(e.encode(batch))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.cfg.Endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP JSON payload (opentelemetry-proto ExportTraceServiceRequest). IDs are hex
+// strings and 64-bit integers are decimal strings, per the OTLP JSON mapping.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 unset, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func (e *OTLPExporter) encode(batch []SpanData) otlpRequest {
+	scope := otlpScopeSpans{}
+	scope.Scope.Name = "llmrpg"
+	for _, s := range batch {
+		span := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+		}
+		if s.Err != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Err}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]interface{}{"service.name": e.cfg.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+}
+
+func otlpAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]interface{}
+		switch v := v.(type) {
+		case string:
+			value = map[string]interface{}{"stringValue": v}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: value})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
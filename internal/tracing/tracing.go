@@ -0,0 +1,143 @@
+// Package tracing records OpenTelemetry-compatible spans for turn processing.
+//
+// Spans are exported over OTLP/HTTP (JSON encoding), which any OpenTelemetry
+// collector, Jaeger or Grafana Tempo accepts, without pulling in the OpenTelemetry
+// SDK. Tracing is off until SetExporter is called; Start then returns no-op spans.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Kind mirrors the OTLP span kind.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// SpanData is a finished span, as handed to an Exporter.
+type SpanData struct {
+	TraceID      string // 32 hex chars
+	SpanID       string // 16 hex chars
+	ParentSpanID string // Empty for root spans
+	Name         string
+	Kind         Kind
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{} // string, bool, int, int64 or float64 values
+	Err          string                 // Non-empty marks the span as failed
+}
+
+// Exporter receives finished spans. Implementations must be safe for concurrent use.
+type Exporter interface {
+	Export(span SpanData)
+	Shutdown(ctx context.Context) error
+}
+
+var exporter atomic.Pointer[Exporter]
+
+// SetExporter enables tracing (nil disables it).
+func SetExporter(e Exporter) {
+	if e == nil {
+		exporter.Store(nil)
+		return
+	}
+	exporter.Store(&e)
+}
+
+// Shutdown flushes and stops the configured exporter, if any.
+func Shutdown(ctx context.Context) error {
+	if e := exporter.Load(); e != nil {
+		return (*e).Shutdown(ctx)
+	}
+	return nil
+}
+
+// Span is an in-progress span. A nil *Span is a valid no-op span.
+type Span struct {
+	data     SpanData
+	exporter Exporter
+}
+
+type spanKey struct{}
+
+// Start begins a span named name as a child of the span in ctx (if any) and returns
+// a context carrying it. Call End on the span when the work finishes.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	e := exporter.Load()
+	if e == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		data: SpanData{
+			SpanID: randomHex(8),
+			Name:   name,
+			Kind:   kind,
+			Start:  time.Now(),
+		},
+		exporter: *e,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span carried by ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttr records a key/value attribute on the span.
+func (s *Span) SetAttr(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	s.data.Attributes[key] = value
+}
+
+// RecordError marks the span as failed. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.data.Err = err.Error()
+}
+
+// End finishes the span and hands it to the exporter.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.data.End = time.Now()
+	s.exporter.Export(s.data)
+}
+
+// TraceParent returns the W3C traceparent header value for the span, or "" for a no-op span.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.data.TraceID, s.data.SpanID)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf) // crypto/rand.Read never returns an error on supported platforms
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,88 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"llmrpg/internal/dice"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Abilities ---
+// Abilities are spells and techniques authored per world. Characters know a subset of
+// them (see StartingAbilities); using one costs mana or hit points and puts it on
+// cooldown for some turns.
+
+// Ability is a spell or technique a character can know.
+type Ability struct {
+	ID          string         `json:"id" yaml:"id"`
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Cost        AbilityCost    `json:"cost,omitempty" yaml:"cost,omitempty"`
+	Cooldown    int            `json:"cooldown,omitempty" yaml:"cooldown,omitempty"` // Turns before it can be used again (0 = every turn)
+	Heal        string         `json:"heal,omitempty" yaml:"heal,omitempty"`         // Hit points restored (dice notation)
+	Damage      string         `json:"damage,omitempty" yaml:"damage,omitempty"`     // Damage dealt to an enemy (dice notation; combat only)
+	Effect      *AbilityEffect `json:"effect,omitempty" yaml:"effect,omitempty"`     // Status effect put on the player
+	Starting    bool           `json:"starting,omitempty" yaml:"starting,omitempty"` // Every new character knows it
+	Classes     []string       `json:"classes,omitempty" yaml:"classes,omitempty"`   // New characters of these classes know it (case-insensitive)
+}
+
+// AbilityCost is what using an ability takes from the character.
+type AbilityCost struct {
+	MP int `json:"mp,omitempty" yaml:"mp,omitempty"`
+	HP int `json:"hp,omitempty" yaml:"hp,omitempty"`
+}
+
+// AbilityEffect is a status effect an ability applies (see the effects package).
+type AbilityEffect struct {
+	ID       string `json:"id" yaml:"id"`
+	Duration int    `json:"duration,omitempty" yaml:"duration,omitempty"` // Turns (0 = until removed)
+}
+
+// LoadAbilities reads every ability in fsys.
+func LoadAbilities(fsys fs.FS) (map[string]*Ability, error) {
+	return loadContentDir(fsys, "ability", AbilitySchema, func(a *Ability, fileID string) (string, error) {
+		if a.ID == "" {
+			a.ID = fileID
+		}
+		for field, notation := range map[string]string{"heal": a.Heal, "damage": a.Damage} {
+			if notation == "" {
+				continue
+			}
+			if _, err := dice.Parse(notation); err != nil {
+				return "", fmt.Errorf("ability '%s' %s: %w", a.ID, field, err)
+			}
+		}
+		if a.Heal == "" && a.Damage == "" && a.Effect == nil {
+			return "", fmt.Errorf("ability '%s' needs a heal, damage or effect", a.ID)
+		}
+		return a.ID, nil
+	})
+}
+
+// Abilities loads the archive's abilities. Archives without an abilities directory have none.
+func (a *Archive) Abilities() (map[string]*Ability, error) {
+	if _, err := fs.Stat(a.FS, ArchiveAbilitiesDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Ability{}, nil
+	}
+	abilityFS, err := fs.Sub(a.FS, ArchiveAbilitiesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveAbilitiesDir, err)
+	}
+	return LoadAbilities(abilityFS)
+}
+
+// StartingAbilities returns the IDs of the abilities a new character of class knows,
+// in ID order.
+func StartingAbilities(abilities map[string]*Ability, class string) []string {
+	var ids []string
+	for id, a := range abilities {
+		if a.Starting || slices.ContainsFunc(a.Classes, func(c string) bool { return strings.EqualFold(c, class) }) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,362 @@
+package world
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"llmrpg/internal/rating"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- World Archives (.llmworld) ---
+// A world archive is a zip file bundling everything needed to run a world:
+//
+//	manifest.json
+//	locations/*.json
+//	themes/*.json
+//	prompts/system_prompt.txt
+//	items/*.json   (optional; see Item)
+//	npcs/*.json    (optional; characters and their schedules, see NPC)
+//	events/*.json  (optional; scheduled and random world events, see WorldEvent)
+//	scenarios/*.json (optional; guided openings, see Scenario)
+//	endings/*.json   (optional; see Ending)
+//	bestiary/*.json  (optional; see Creature)
+//	loot/*.json      (optional; shared loot tables, see LootTable)
+//	abilities/*.json (optional; spells and techniques, see Ability)
+//	perks/*.json     (optional; level-up choices, see Perk)
+//	lore/*.md        (optional; articles loaded by the lore package)
+//	survival.json    (optional; survival ruleset, see SurvivalRules)
+//
+// Content files may also be written as .yaml/.yml.
+
+// ArchiveExtension is the file extension used for world archives.
+const ArchiveExtension = ".llmworld"
+
+// ArchiveFormatVersion is the manifest format version written by ExportArchive.
+const ArchiveFormatVersion = 1
+
+// Archive directory layout.
+const (
+	ArchiveManifestFile    = "manifest.json"
+	ArchiveLocationsDir    = "locations"
+	ArchiveThemesDir       = "themes"
+	ArchivePromptsDir      = "prompts"
+	ArchiveItemsDir        = "items"
+	ArchiveNPCsDir         = "npcs"
+	ArchiveWorldEventsDir  = "events"
+	ArchiveScenariosDir    = "scenarios"
+	ArchiveEndingsDir      = "endings"
+	ArchiveBestiaryDir     = "bestiary"
+	ArchiveLootDir         = "loot"
+	ArchiveAbilitiesDir    = "abilities"
+	ArchivePerksDir        = "perks"
+	ArchiveLoreDir         = "lore"
+	defaultArchivePromptAt = "prompts/system_prompt.txt"
+)
+
+// ArchiveSurvivalFiles are the names a survival ruleset may have, in lookup order.
+var ArchiveSurvivalFiles = []string{"survival.json", "survival.yaml", "survival.yml"}
+
+// Manifest describes a world archive.
+type Manifest struct {
+	FormatVersion   int             `json:"formatVersion"`
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Version         string          `json:"version,omitempty"`
+	Author          string          `json:"author,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	StartLocationID string          `json:"startLocationId"`
+	SystemPrompt    string          `json:"systemPrompt,omitempty"`   // Path inside the archive (default "prompts/system_prompt.txt")
+	ContentRating   string          `json:"contentRating,omitempty"`  // Highest content rating (E, T or M; default T)
+	LLM             *ModelSettings  `json:"llm,omitempty"`            // Narrator model for this world; nil uses the server's
+	PromptVariants  []PromptVariant `json:"promptVariants,omitempty"` // System prompt experiment; each new session is assigned one variant
+	HistoryFormat   string          `json:"historyFormat,omitempty"`  // Layout of recent events in prompts: inline, bullets, chat or digest (default: the server's)
+}
+
+// PromptVariant is one arm of a system prompt experiment: new sessions are assigned
+// a variant at random in proportion to its weight and are narrated with its prompt.
+// To compare against the world's own prompt, list it as a variant too.
+type PromptVariant struct {
+	ID           string `json:"id"`
+	SystemPrompt string `json:"systemPrompt"` // Path inside the archive
+	Weight       int    `json:"weight"`       // Relative share of new sessions; must be positive
+}
+
+// ModelSettings picks the narrator model and sampling parameters for a world.
+// Empty fields fall back to the server's LLM_PROVIDER / LLM_MODEL configuration.
+type ModelSettings struct {
+	Provider    string   `json:"provider,omitempty"` // gemini, openai or mock
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+	MaxTokens   *int     `json:"maxTokens,omitempty"`
+}
+
+// Problems lists everything wrong with the settings.
+func (s *ModelSettings) Problems() []string {
+	var problems []string
+	switch s.Provider {
+	case "", "gemini", "openai", "mock":
+	default:
+		problems = append(problems, fmt.Sprintf("llm.provider: unknown provider '%s' (known: gemini, openai, mock)", s.Provider))
+	}
+	if s.Temperature != nil && (*s.Temperature < 0 || *s.Temperature > 2) {
+		problems = append(problems, fmt.Sprintf("llm.temperature: %v is outside 0-2", *s.Temperature))
+	}
+	if s.TopP != nil && (*s.TopP < 0 || *s.TopP > 1) {
+		problems = append(problems, fmt.Sprintf("llm.topP: %v is outside 0-1", *s.TopP))
+	}
+	if s.MaxTokens != nil && *s.MaxTokens <= 0 {
+		problems = append(problems, fmt.Sprintf("llm.maxTokens: %d must be positive", *s.MaxTokens))
+	}
+	return problems
+}
+
+// Archive is an opened world archive.
+type Archive struct {
+	Manifest Manifest
+	FS       fs.FS // Archive contents, rooted at the archive top level
+}
+
+// OpenArchive reads a world archive from a zip file and parses its manifest.
+func OpenArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open world archive: %w", err)
+	}
+	return NewArchive(zr)
+}
+
+// NewArchive wraps an unpacked archive (e.g. a directory via os.DirFS) and parses its manifest.
+func NewArchive(fsys fs.FS) (*Archive, error) {
+	content, err := fs.ReadFile(fsys, ArchiveManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("world archive is missing %s: %w", ArchiveManifestFile, err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ArchiveManifestFile, err)
+	}
+	if manifest.SystemPrompt == "" {
+		manifest.SystemPrompt = defaultArchivePromptAt
+	}
+	return &Archive{Manifest: manifest, FS: fsys}, nil
+}
+
+// SystemPrompt returns the narrator prompt bundled with the archive.
+func (a *Archive) SystemPrompt() (string, error) {
+	content, err := fs.ReadFile(a.FS, a.Manifest.SystemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt %s: %w", a.Manifest.SystemPrompt, err)
+	}
+	return string(content), nil
+}
+
+// VariantPrompt returns the system prompt of one of the manifest's prompt variants.
+func (a *Archive) VariantPrompt(variant PromptVariant) (string, error) {
+	content, err := fs.ReadFile(a.FS, variant.SystemPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt %s of prompt variant '%s': %w", variant.SystemPrompt, variant.ID, err)
+	}
+	return string(content), nil
+}
+
+// LoadInto loads the archive's locations and themes into ws.
+func (a *Archive) LoadInto(ws WorldSystem) error {
+	locationFS, err := fs.Sub(a.FS, ArchiveLocationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to open %s in world archive: %w", ArchiveLocationsDir, err)
+	}
+	themeFS, err := fs.Sub(a.FS, ArchiveThemesDir)
+	if err != nil {
+		return fmt.Errorf("failed to open %s in world archive: %w", ArchiveThemesDir, err)
+	}
+	return ws.LoadWorldData(locationFS, themeFS)
+}
+
+// Scenarios loads the archive's scenarios, checking them against ws (the world loaded
+// from the archive). Archives without a scenarios directory have none.
+func (a *Archive) Scenarios(ws WorldSystem) (map[string]*Scenario, error) {
+	if _, err := fs.Stat(a.FS, ArchiveScenariosDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Scenario{}, nil
+	}
+	scenarioFS, err := fs.Sub(a.FS, ArchiveScenariosDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveScenariosDir, err)
+	}
+	return LoadScenarios(scenarioFS, ws)
+}
+
+// Validate checks the manifest, loads the world into a scratch WorldSystem (running
+// the usual theme/adjacency checks) and verifies the optional content directories.
+// It returns the scratch world so callers can inspect it.
+func (a *Archive) Validate() (*InMemoryWorldSystem, error) {
+	var problems []string
+	m := a.Manifest
+	if m.FormatVersion < 1 || m.FormatVersion > ArchiveFormatVersion {
+		problems = append(problems, fmt.Sprintf("unsupported formatVersion %d (supported: 1-%d)", m.FormatVersion, ArchiveFormatVersion))
+	}
+	if m.ID == "" {
+		problems = append(problems, "manifest is missing 'id'")
+	}
+	if m.Name == "" {
+		problems = append(problems, "manifest is missing 'name'")
+	}
+	if m.ContentRating != "" {
+		if _, err := rating.Parse(m.ContentRating); err != nil {
+			problems = append(problems, fmt.Sprintf("contentRating: %v", err))
+		}
+	}
+	if m.LLM != nil {
+		problems = append(problems, m.LLM.Problems()...)
+	}
+	switch m.HistoryFormat {
+	case "", "inline", "bullets", "chat", "digest":
+	default:
+		problems = append(problems, fmt.Sprintf("historyFormat: unknown format '%s' (known: inline, bullets, chat, digest)", m.HistoryFormat))
+	}
+
+	ws := NewInMemoryWorldSystem()
+	if err := a.LoadInto(ws); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if m.StartLocationID == "" {
+		problems = append(problems, "manifest is missing 'startLocationId'")
+	} else if _, err := ws.GetLocation(m.StartLocationID); err != nil {
+		problems = append(problems, fmt.Sprintf("startLocationId: %v", err))
+	}
+	if _, err := a.SystemPrompt(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	variantIDs := make(map[string]bool)
+	for i, variant := range m.PromptVariants {
+		switch {
+		case variant.ID == "":
+			problems = append(problems, fmt.Sprintf("promptVariants[%d]: missing 'id'", i))
+		case variantIDs[variant.ID]:
+			problems = append(problems, fmt.Sprintf("promptVariants[%d]: duplicate id '%s'", i, variant.ID))
+		}
+		variantIDs[variant.ID] = true
+		if variant.Weight <= 0 {
+			problems = append(problems, fmt.Sprintf("promptVariants[%d]: weight %d must be positive", i, variant.Weight))
+		}
+		if _, err := a.VariantPrompt(variant); err != nil {
+			problems = append(problems, fmt.Sprintf("promptVariants[%d]: %v", i, err))
+		}
+	}
+	if _, err := a.Scenarios(ws); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := a.Endings(ws); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if items, err := a.Items(); err != nil {
+		problems = append(problems, err.Error())
+	} else if lootTables, err := a.LootTables(items); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		if err := CheckLocationContainers(ws, items); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if bestiary, err := a.Bestiary(items, lootTables); err != nil {
+			problems = append(problems, err.Error())
+		} else if err := CheckLocationEncounters(ws, bestiary); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if err := CheckLocationLoot(ws, lootTables); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if _, err := a.Survival(items); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if abilities, err := a.Abilities(); err != nil {
+		problems = append(problems, err.Error())
+	} else if _, err := a.Perks(abilities); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := a.NPCs(ws); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := a.WorldEvents(ws); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid world archive '%s': %s", m.ID, strings.Join(problems, "; "))
+	}
+	return ws, nil
+}
+
+// ExportArchive writes the world currently loaded in ws as a world archive.
+func ExportArchive(w io.Writer, manifest Manifest, ws WorldSystem, systemPrompt string) error {
+	if manifest.FormatVersion == 0 {
+		manifest.FormatVersion = ArchiveFormatVersion
+	}
+	if manifest.SystemPrompt == "" {
+		manifest.SystemPrompt = defaultArchivePromptAt
+	}
+
+	zw := zip.NewWriter(w)
+	modified := time.Now()
+	writeFile := func(name string, data []byte) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", name, err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s to archive: %w", name, err)
+		}
+		return nil
+	}
+	writeJSON := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		return writeFile(name, data)
+	}
+
+	if err := writeJSON(ArchiveManifestFile, manifest); err != nil {
+		return err
+	}
+
+	themeIDs := ws.GetAllThemeIDs()
+	sort.Strings(themeIDs)
+	for _, id := range themeIDs {
+		theme, err := ws.GetTheme(id)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(path.Join(ArchiveThemesDir, id+".json"), theme); err != nil {
+			return err
+		}
+	}
+
+	locationIDs := ws.GetAllLocationIDs()
+	sort.Strings(locationIDs)
+	for _, id := range locationIDs {
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(path.Join(ArchiveLocationsDir, id+".json"), loc); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFile(manifest.SystemPrompt, []byte(systemPrompt)); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize world archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"llmrpg/internal/dice"
+)
+
+// --- Bestiary ---
+// Worlds author their creatures. When a world has a bestiary, combat encounters are
+// built from these definitions; the narrator only picks which creatures appear.
+
+// Creature is a bestiary entry.
+type Creature struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	HP          int               `json:"hp" yaml:"hp"`
+	ArmorClass  int               `json:"armorClass,omitempty" yaml:"armorClass,omitempty"`
+	AttackBonus int               `json:"attackBonus,omitempty" yaml:"attackBonus,omitempty"`
+	Damage      string            `json:"damage,omitempty" yaml:"damage,omitempty"`
+	Abilities   []CreatureAbility `json:"abilities,omitempty" yaml:"abilities,omitempty"`
+	Initiative  int               `json:"initiative,omitempty" yaml:"initiative,omitempty"` // Added to the creature's d20 initiative roll
+	Behavior    string            `json:"behavior,omitempty" yaml:"behavior,omitempty"`     // How it fights (Behavior*; default aggressive)
+	Loot        *LootTable        `json:"loot,omitempty" yaml:"loot,omitempty"`             // Dropped on defeat
+	LootTable   string            `json:"lootTable,omitempty" yaml:"lootTable,omitempty"`   // ID of a shared loot table, instead of Loot
+}
+
+// CreatureAbility is a special attack a creature uses instead of its basic attack.
+type CreatureAbility struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Damage      string `json:"damage" yaml:"damage"`
+	AttackBonus int    `json:"attackBonus,omitempty" yaml:"attackBonus,omitempty"` // Added to the creature's attack bonus
+	Chance      int    `json:"chance,omitempty" yaml:"chance,omitempty"`           // Percent per round (default 25)
+}
+
+// Creature behaviors: how an enemy picks its move each round.
+const (
+	BehaviorAggressive = "aggressive" // Always attacks
+	BehaviorDefensive  = "defensive"  // Below half HP, guards half the time instead of attacking
+	BehaviorCowardly   = "cowardly"   // Flees the fight once down to a third of its HP
+)
+
+// DefaultAbilityChance is how often (in percent) a creature uses an ability with no chance set.
+const DefaultAbilityChance = 25
+
+// LoadBestiary reads every creature in fsys, checking inline loot against items and
+// resolving lootTable references against lootTables.
+func LoadBestiary(fsys fs.FS, items map[string]*Item, lootTables map[string]*LootTable) (map[string]*Creature, error) {
+	return loadContentDir(fsys, "creature", CreatureSchema, func(c *Creature, fileID string) (string, error) {
+		if c.ID == "" {
+			c.ID = fileID
+		}
+		if c.HP < 1 {
+			return "", fmt.Errorf("creature '%s' hp must be at least 1", c.ID)
+		}
+		if c.Damage != "" {
+			if _, err := dice.Parse(c.Damage); err != nil {
+				return "", fmt.Errorf("creature '%s' damage: %w", c.ID, err)
+			}
+		}
+		switch c.Behavior {
+		case "", BehaviorAggressive, BehaviorDefensive, BehaviorCowardly:
+		default:
+			return "", fmt.Errorf("creature '%s' has unknown behavior '%s'", c.ID, c.Behavior)
+		}
+		for i, ability := range c.Abilities {
+			if _, err := dice.Parse(ability.Damage); err != nil {
+				return "", fmt.Errorf("creature '%s' abilities[%d] damage: %w", c.ID, i, err)
+			}
+			if ability.Chance < 0 || ability.Chance > 100 {
+				return "", fmt.Errorf("creature '%s' abilities[%d] chance must be between 0 and 100", c.ID, i)
+			}
+		}
+		if c.LootTable != "" {
+			if c.Loot != nil {
+				return "", fmt.Errorf("creature '%s' sets both loot and lootTable", c.ID)
+			}
+			table, ok := lootTables[c.LootTable]
+			if !ok {
+				return "", fmt.Errorf("creature '%s' references non-existent loot table '%s'", c.ID, c.LootTable)
+			}
+			c.Loot = table
+		} else if c.Loot != nil {
+			if err := c.Loot.validate(items); err != nil {
+				return "", fmt.Errorf("creature '%s' loot: %w", c.ID, err)
+			}
+		}
+		return c.ID, nil
+	})
+}
+
+// Bestiary loads the archive's creatures, checking loot against items and lootTables.
+// Archives without a bestiary directory have none.
+func (a *Archive) Bestiary(items map[string]*Item, lootTables map[string]*LootTable) (map[string]*Creature, error) {
+	if _, err := fs.Stat(a.FS, ArchiveBestiaryDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Creature{}, nil
+	}
+	bestiaryFS, err := fs.Sub(a.FS, ArchiveBestiaryDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveBestiaryDir, err)
+	}
+	return LoadBestiary(bestiaryFS, items, lootTables)
+}
@@ -0,0 +1,82 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// --- Containers ---
+// Locations may hold containers (a chest, a loose floorboard) with starting contents.
+// The world only defines what they hold when a playthrough starts; sessions keep their
+// own copy once the player takes from or puts into one.
+
+// Container is an item stash at a location.
+type Container struct {
+	ID          string         `json:"id" yaml:"id"`
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Items       map[string]int `json:"items,omitempty" yaml:"items,omitempty"` // Item ID -> count at the start of a playthrough
+}
+
+// Container returns loc's container with the given ID, or nil.
+func (loc *LocationNode) Container(id string) *Container {
+	for i := range loc.Containers {
+		if loc.Containers[i].ID == id {
+			return &loc.Containers[i]
+		}
+	}
+	return nil
+}
+
+// cloneContainers copies containers including their contents.
+func cloneContainers(containers []Container) []Container {
+	if containers == nil {
+		return nil
+	}
+	clone := make([]Container, len(containers))
+	for i, c := range containers {
+		clone[i] = c
+		clone[i].Items = maps.Clone(c.Items)
+	}
+	return clone
+}
+
+// checkContainers reports duplicate container IDs and non-positive counts at loc.
+func checkContainers(loc *LocationNode) error {
+	seen := make(map[string]bool, len(loc.Containers))
+	for _, c := range loc.Containers {
+		if c.ID == "" {
+			return fmt.Errorf("location '%s' has a container without an id", loc.ID)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("location '%s' has more than one container '%s'", loc.ID, c.ID)
+		}
+		seen[c.ID] = true
+		for itemID, count := range c.Items {
+			if count < 1 {
+				return fmt.Errorf("location '%s' container '%s' must hold a positive count of '%s'", loc.ID, c.ID, itemID)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckLocationContainers reports containers holding items that aren't in items.
+func CheckLocationContainers(ws WorldSystem, items map[string]*Item) error {
+	var problems []error
+	for _, id := range ws.GetAllLocationIDs() {
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			continue
+		}
+		for _, c := range loc.Containers {
+			for itemID := range c.Items {
+				if _, ok := items[itemID]; !ok {
+					problems = append(problems, fmt.Errorf("location '%s' container '%s' holds non-existent item '%s'", id, c.ID, itemID))
+				}
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
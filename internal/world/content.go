@@ -0,0 +1,130 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- Content File Decoding ---
+// World content (locations, themes, and later items/NPCs/quests) may be authored
+// as JSON or YAML. Both formats report problems as ContentErrors so authors get
+// the same file:line messages regardless of format.
+
+// ContentError points at a problem in a content file.
+type ContentError struct {
+	File    string
+	Line    int    // 1-based; 0 when unknown
+	Field   string // Dotted field path, when known
+	Message string
+}
+
+func (e *ContentError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ": field '%s'", e.Field)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// IsContentFile reports whether name has a supported content extension (.json, .yaml, .yml).
+func IsContentFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// contentID derives an ID from a content file name ("oakhaven_gate.yaml" -> "oakhaven_gate").
+func contentID(name string) string {
+	return strings.TrimSuffix(path.Base(name), path.Ext(name))
+}
+
+// DecodeContent unmarshals a JSON or YAML content file (chosen by extension) into v.
+// Errors are *ContentError values (joined when YAML reports several).
+func DecodeContent(name string, content []byte, v interface{}) error {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return decodeYAML(name, content, v)
+	default:
+		return decodeJSON(name, content, v)
+	}
+}
+
+func decodeJSON(name string, content []byte, v interface{}) error {
+	err := json.Unmarshal(content, v)
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &ContentError{File: name, Line: lineAt(content, syntaxErr.Offset), Message: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		return &ContentError{
+			File:    name,
+			Line:    lineAt(content, typeErr.Offset),
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}
+	default:
+		return &ContentError{File: name, Message: err.Error()}
+	}
+}
+
+// yamlLinePattern extracts the line number yaml.v3 embeds in its error text.
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+func decodeYAML(name string, content []byte, v interface{}) error {
+	err := yaml.Unmarshal(content, v)
+	if err == nil {
+		return nil
+	}
+	var messages []string
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	errs := make([]error, 0, len(messages))
+	for _, msg := range messages {
+		contentErr := &ContentError{File: name, Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			contentErr.Line, _ = strconv.Atoi(m[1])
+			contentErr.Message = m[2]
+		}
+		errs = append(errs, contentErr)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// lineAt converts a byte offset into a 1-based line number.
+func lineAt(content []byte, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
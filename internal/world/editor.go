@@ -0,0 +1,418 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- Runtime World Editing ---
+// Locations and themes can be created, updated and deleted while the server runs
+// (e.g. from a web-based world editor). Edits replace nodes rather than mutating
+// them, so reads already in progress keep a consistent view. When a ContentStore is
+// set, every edit is also written back to the content files.
+
+// Editor errors, for mapping to HTTP status codes.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrInUse       = errors.New("still referenced")
+	ErrInvalidEdit = errors.New("invalid edit")
+)
+
+// LinkMode controls how an edit maintains adjacency on neighbouring locations.
+type LinkMode string
+
+const (
+	LinkNone          LinkMode = "none"          // Only the edited location's adjacentIds change
+	LinkBidirectional LinkMode = "bidirectional" // Neighbours gain (or lose) the reverse edge too
+)
+
+// ParseLinkMode parses a LinkMode, defaulting to LinkNone for an empty string.
+func ParseLinkMode(s string) (LinkMode, error) {
+	switch LinkMode(s) {
+	case "", LinkNone:
+		return LinkNone, nil
+	case LinkBidirectional:
+		return LinkBidirectional, nil
+	}
+	return "", fmt.Errorf("%w: unknown link mode '%s' (expected none or bidirectional)", ErrInvalidEdit, s)
+}
+
+// Editor is implemented by world systems that support runtime edits.
+// Mutating calls return every location whose stored definition changed.
+type Editor interface {
+	PutLocation(loc LocationNode, mode LinkMode) ([]*LocationNode, error)
+	UpdateLocation(locationID string, mode LinkMode, update func(loc *LocationNode) error) ([]*LocationNode, error)
+	DeleteLocation(locationID string) ([]*LocationNode, error)
+	PutTheme(theme ThemeDefinition) error
+	DeleteTheme(themeID string) error
+}
+
+// ContentStore persists edited content. source is the path the item was loaded
+// from (empty for new items); implementations return the path they wrote to.
+type ContentStore interface {
+	SaveLocation(loc *LocationNode, source string) (string, error)
+	DeleteLocation(locationID, source string) error
+	SaveTheme(theme *ThemeDefinition, source string) (string, error)
+	DeleteTheme(themeID, source string) error
+}
+
+// contentIDPattern matches the IDs accepted by the content schemas.
+var contentIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// SetContentStore enables write-back of runtime edits. A nil store keeps edits in memory only.
+func (ws *InMemoryWorldSystem) SetContentStore(store ContentStore) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.store = store
+}
+
+// PutLocation creates or replaces a location. Its theme and adjacent locations must exist.
+// With LinkBidirectional, neighbours added to or removed from adjacentIds are updated to match.
+func (ws *InMemoryWorldSystem) PutLocation(loc LocationNode, mode LinkMode) ([]*LocationNode, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.putLocation(loc, mode)
+}
+
+// UpdateLocation changes an existing location in place of a caller mutating a node it
+// read: update is given a copy of the current definition, and the result is validated
+// and stored as by PutLocation. An error from update aborts the edit.
+func (ws *InMemoryWorldSystem) UpdateLocation(locationID string, mode LinkMode, update func(loc *LocationNode) error) ([]*LocationNode, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	current, ok := ws.locations[locationID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, locationID)
+	}
+	loc := current.Clone()
+	if err := update(loc); err != nil {
+		return nil, err
+	}
+	if loc.ID != locationID {
+		return nil, fmt.Errorf("%w: location '%s' cannot be renamed to '%s'", ErrInvalidEdit, locationID, loc.ID)
+	}
+	return ws.putLocation(*loc, mode)
+}
+
+// putLocation validates and stores loc. Callers hold ws.mu for writing.
+func (ws *InMemoryWorldSystem) putLocation(loc LocationNode, mode LinkMode) ([]*LocationNode, error) {
+	if !contentIDPattern.MatchString(loc.ID) {
+		return nil, fmt.Errorf("%w: invalid location ID '%s'", ErrInvalidEdit, loc.ID)
+	}
+	if loc.Name == "" || loc.Description == "" {
+		return nil, fmt.Errorf("%w: location '%s' needs a name and a description", ErrInvalidEdit, loc.ID)
+	}
+	if loc.ThemeID != "" {
+		if _, ok := ws.themes[loc.ThemeID]; !ok {
+			return nil, fmt.Errorf("%w: location '%s' references non-existent theme ID '%s'", ErrInvalidEdit, loc.ID, loc.ThemeID)
+		}
+	}
+	for _, adjID := range loc.AdjacentIDs {
+		if adjID == loc.ID {
+			return nil, fmt.Errorf("%w: location '%s' cannot be adjacent to itself", ErrInvalidEdit, loc.ID)
+		}
+		if _, ok := ws.locations[adjID]; !ok {
+			return nil, fmt.Errorf("%w: location '%s' references non-existent adjacent location ID '%s'", ErrInvalidEdit, loc.ID, adjID)
+		}
+	}
+
+	if err := checkRoutes(&loc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+	if err := checkContainers(&loc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+	if err := checkInteractions(&loc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+	if err := checkInteractionExits(&loc, func(id string) bool { _, ok := ws.locations[id]; return ok }); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+
+	updated := loc.Clone()
+	changed := []*LocationNode{updated}
+	if mode == LinkBidirectional {
+		var previous []string
+		if old, ok := ws.locations[loc.ID]; ok {
+			previous = old.AdjacentIDs
+		}
+		for _, adjID := range loc.AdjacentIDs {
+			if !containsString(previous, adjID) && !containsString(ws.locations[adjID].AdjacentIDs, loc.ID) {
+				neighbour := ws.locations[adjID].Clone()
+				neighbour.AdjacentIDs = append(neighbour.AdjacentIDs, loc.ID)
+				changed = append(changed, neighbour)
+			}
+		}
+		for _, adjID := range previous {
+			if neighbour, ok := ws.locations[adjID]; ok && !containsString(loc.AdjacentIDs, adjID) && containsString(neighbour.AdjacentIDs, loc.ID) {
+				neighbour = neighbour.Clone()
+				neighbour.AdjacentIDs = removeString(neighbour.AdjacentIDs, loc.ID)
+				neighbour.dropRoute(loc.ID)
+				changed = append(changed, neighbour)
+			}
+		}
+	}
+
+	if err := ws.saveLocations(changed); err != nil {
+		return nil, err
+	}
+	for _, l := range changed {
+		ws.locations[l.ID] = l
+	}
+	ws.reindex()
+	return cloneLocations(changed), nil
+}
+
+// DeleteLocation removes a location and every adjacency (and interaction exit) pointing at it.
+func (ws *InMemoryWorldSystem) DeleteLocation(locationID string) ([]*LocationNode, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.locations[locationID]; !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, locationID)
+	}
+
+	var changed []*LocationNode
+	for id, other := range ws.locations {
+		if id != locationID && (containsString(other.AdjacentIDs, locationID) || other.opensExitTo(locationID)) {
+			neighbour := other.Clone()
+			neighbour.AdjacentIDs = removeString(neighbour.AdjacentIDs, locationID)
+			neighbour.dropRoute(locationID)
+			neighbour.dropInteractionExits(locationID)
+			changed = append(changed, neighbour)
+		}
+	}
+
+	if err := ws.saveLocations(changed); err != nil {
+		return nil, err
+	}
+	if ws.store != nil {
+		if err := ws.store.DeleteLocation(locationID, ws.locationSources[locationID]); err != nil {
+			return nil, fmt.Errorf("failed to delete location '%s' from content store: %w", locationID, err)
+		}
+	}
+	for _, l := range changed {
+		ws.locations[l.ID] = l
+	}
+	delete(ws.locations, locationID)
+	delete(ws.locationSources, locationID)
+	ws.reindex()
+	return cloneLocations(changed), nil
+}
+
+// PutTheme creates or replaces a theme.
+func (ws *InMemoryWorldSystem) PutTheme(theme ThemeDefinition) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if !contentIDPattern.MatchString(theme.ID) {
+		return fmt.Errorf("%w: invalid theme ID '%s'", ErrInvalidEdit, theme.ID)
+	}
+	if ws.store != nil {
+		source, err := ws.store.SaveTheme(&theme, ws.themeSources[theme.ID])
+		if err != nil {
+			return fmt.Errorf("failed to save theme '%s' to content store: %w", theme.ID, err)
+		}
+		ws.themeSources[theme.ID] = source
+	}
+	ws.themes[theme.ID] = theme.Clone() // The caller keeps its palette map
+	return nil
+}
+
+// DeleteTheme removes a theme that no location references.
+func (ws *InMemoryWorldSystem) DeleteTheme(themeID string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.themes[themeID]; !ok {
+		return fmt.Errorf("%w: '%s'", ErrThemeNotFound, themeID)
+	}
+	for _, loc := range ws.locations {
+		if loc.ThemeID == themeID {
+			return fmt.Errorf("theme '%s' is used by location '%s': %w", themeID, loc.ID, ErrInUse)
+		}
+	}
+	if ws.store != nil {
+		if err := ws.store.DeleteTheme(themeID, ws.themeSources[themeID]); err != nil {
+			return fmt.Errorf("failed to delete theme '%s' from content store: %w", themeID, err)
+		}
+	}
+	delete(ws.themes, themeID)
+	delete(ws.themeSources, themeID)
+	return nil
+}
+
+// saveLocations writes locations to the content store, if any. Callers hold ws.mu.
+func (ws *InMemoryWorldSystem) saveLocations(locs []*LocationNode) error {
+	if ws.store == nil {
+		return nil
+	}
+	for _, loc := range locs {
+		source, err := ws.store.SaveLocation(loc, ws.locationSources[loc.ID])
+		if err != nil {
+			return fmt.Errorf("failed to save location '%s' to content store: %w", loc.ID, err)
+		}
+		ws.locationSources[loc.ID] = source
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the theme, for the same reason as LocationNode.Clone.
+func (theme *ThemeDefinition) Clone() *ThemeDefinition {
+	clone := *theme
+	clone.Palette = maps.Clone(theme.Palette)
+	if theme.Fonts != nil {
+		fonts := *theme.Fonts
+		clone.Fonts = &fonts
+	}
+	return &clone
+}
+
+// Clone returns a deep copy of the location. GetLocation and the other reads hand out
+// clones, so callers can't change the world by mutating what they were given; edits
+// go through the Editor, which stores clones of its own.
+func (loc *LocationNode) Clone() *LocationNode {
+	clone := *loc
+	clone.AdjacentIDs = slices.Clone(loc.AdjacentIDs)
+	clone.Tags = slices.Clone(loc.Tags)
+	clone.AllowedActions = slices.Clone(loc.AllowedActions) // Preserves nil ("world default") vs empty
+	clone.Routes = slices.Clone(loc.Routes)
+	clone.Containers = cloneContainers(loc.Containers)
+	clone.Interactions = cloneInteractions(loc.Interactions)
+	if loc.Encounters != nil {
+		encounters := *loc.Encounters
+		encounters.Entries = slices.Clone(loc.Encounters.Entries)
+		clone.Encounters = &encounters
+	}
+	if loc.Attributes != nil {
+		clone.Attributes = make(map[string]interface{}, len(loc.Attributes))
+		for k, v := range loc.Attributes {
+			clone.Attributes[k] = cloneAttribute(v)
+		}
+	}
+	return &clone
+}
+
+// cloneLocations clones every location in locs.
+func cloneLocations(locs []*LocationNode) []*LocationNode {
+	clones := make([]*LocationNode, len(locs))
+	for i, loc := range locs {
+		clones[i] = loc.Clone()
+	}
+	return clones
+}
+
+// cloneAttribute deep-copies an attribute value decoded from JSON or YAML.
+func cloneAttribute(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for k, inner := range v {
+			clone[k] = cloneAttribute(inner)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, inner := range v {
+			clone[i] = cloneAttribute(inner)
+		}
+		return clone
+	}
+	return v
+}
+
+func removeString(values []string, s string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// --- Directory Content Store ---
+
+// DirContentStore writes edited content back to the location and theme directories.
+// Existing files keep their path and format (JSON or YAML); new content is written as <id>.json.
+type DirContentStore struct {
+	LocationDir string
+	ThemeDir    string
+}
+
+// NewDirContentStore creates a store writing to the given directories.
+func NewDirContentStore(locationDir, themeDir string) *DirContentStore {
+	return &DirContentStore{LocationDir: locationDir, ThemeDir: themeDir}
+}
+
+// SaveLocation writes a location file.
+func (s *DirContentStore) SaveLocation(loc *LocationNode, source string) (string, error) {
+	return writeContentFile(s.LocationDir, loc.ID, source, loc)
+}
+
+// DeleteLocation removes a location file.
+func (s *DirContentStore) DeleteLocation(locationID, source string) error {
+	return removeContentFile(s.LocationDir, locationID, source)
+}
+
+// SaveTheme writes a theme file.
+func (s *DirContentStore) SaveTheme(theme *ThemeDefinition, source string) (string, error) {
+	return writeContentFile(s.ThemeDir, theme.ID, source, theme)
+}
+
+// DeleteTheme removes a theme file.
+func (s *DirContentStore) DeleteTheme(themeID, source string) error {
+	return removeContentFile(s.ThemeDir, themeID, source)
+}
+
+// writeContentFile atomically writes v to dir/source (or dir/<id>.json), in the format the extension implies.
+func writeContentFile(dir, id, source string, v interface{}) (string, error) {
+	if source == "" {
+		source = id + ".json"
+	}
+	var data []byte
+	var err error
+	switch strings.ToLower(path.Ext(source)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(v)
+	default:
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s: %w", source, err)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(source))
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	return source, nil
+}
+
+func removeContentFile(dir, id, source string) error {
+	if source == "" {
+		source = id + ".json"
+	}
+	err := os.Remove(filepath.Join(dir, filepath.FromSlash(source)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
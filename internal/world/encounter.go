@@ -0,0 +1,66 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"llmrpg/internal/dice"
+)
+
+// --- Encounters ---
+// Locations may carry an encounter table: the creatures that can turn up when the
+// player lets their guard down there (e.g. while resting).
+
+// EncounterTable is a location's chance of a random encounter and who shows up.
+type EncounterTable struct {
+	Chance  int              `json:"chance" yaml:"chance"` // Percent chance per roll, before rest safety is applied
+	Entries []EncounterEntry `json:"entries" yaml:"entries"`
+}
+
+// EncounterEntry is one weighted group of creatures in an EncounterTable.
+type EncounterEntry struct {
+	CreatureID string `json:"creatureId" yaml:"creatureId"`
+	Count      int    `json:"count,omitempty" yaml:"count,omitempty"`   // Default 1
+	Weight     int    `json:"weight,omitempty" yaml:"weight,omitempty"` // Default 1
+}
+
+// Pick chooses an entry by weight.
+func (t *EncounterTable) Pick(roller *dice.Roller) *EncounterEntry {
+	total := 0
+	for _, entry := range t.Entries {
+		total += max(entry.Weight, 1)
+	}
+	if total < 1 {
+		return nil
+	}
+	pick := roller.Roll(dice.Expression{Count: 1, Sides: total}, dice.Normal).Total
+	for i := range t.Entries {
+		if pick -= max(t.Entries[i].Weight, 1); pick <= 0 {
+			return &t.Entries[i]
+		}
+	}
+	return nil
+}
+
+// CheckLocationEncounters reports encounter tables with out-of-range values or
+// creatures missing from bestiary.
+func CheckLocationEncounters(ws WorldSystem, bestiary map[string]*Creature) error {
+	var problems []error
+	for _, id := range ws.GetAllLocationIDs() {
+		loc, err := ws.GetLocation(id)
+		if err != nil || loc.Encounters == nil {
+			continue
+		}
+		if loc.Encounters.Chance < 0 || loc.Encounters.Chance > 100 {
+			problems = append(problems, fmt.Errorf("location '%s' encounters.chance must be between 0 and 100", id))
+		}
+		for i, entry := range loc.Encounters.Entries {
+			if _, ok := bestiary[entry.CreatureID]; !ok {
+				problems = append(problems, fmt.Errorf("location '%s' encounters.entries[%d] references non-existent creature '%s'", id, i, entry.CreatureID))
+			}
+			if entry.Count < 0 || entry.Weight < 0 {
+				problems = append(problems, fmt.Errorf("location '%s' encounters.entries[%d] count and weight must not be negative", id, i))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
@@ -0,0 +1,117 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// --- Endings ---
+// Worlds declare how the story can end. After each turn the engine checks every
+// ending; when one is met the session enters its epilogue: the narrator writes a
+// closing narrative, the session is marked completed and further actions are refused.
+
+// Ending is a way the story can end.
+type Ending struct {
+	ID       string          `json:"id" yaml:"id"`
+	Name     string          `json:"name" yaml:"name"`
+	Epilogue string          `json:"epilogue,omitempty" yaml:"epilogue,omitempty"` // Narrator guidance for the closing narrative
+	Priority int             `json:"priority,omitempty" yaml:"priority,omitempty"` // Highest wins when several endings are met at once
+	When     EndingCondition `json:"when" yaml:"when"`
+}
+
+// EndingCondition describes when an ending is reached. Every condition that is set must hold.
+type EndingCondition struct {
+	ReachLocation  string      `json:"reachLocation,omitempty" yaml:"reachLocation,omitempty"`
+	QuestCompleted string      `json:"questCompleted,omitempty" yaml:"questCompleted,omitempty"`
+	Flags          []string    `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Death          bool        `json:"death,omitempty" yaml:"death,omitempty"`
+	Karma          *KarmaRange `json:"karma,omitempty" yaml:"karma,omitempty"`
+}
+
+// EndingState is the slice of session state ending conditions are checked against.
+type EndingState struct {
+	LocationID      string
+	CompletedQuests []string
+	Flags           map[string]bool
+	Dead            bool
+	Karma           int
+}
+
+// Met reports whether the condition holds in state.
+func (c EndingCondition) Met(state EndingState) bool {
+	if c.ReachLocation != "" && c.ReachLocation != state.LocationID {
+		return false
+	}
+	if c.QuestCompleted != "" && !containsString(state.CompletedQuests, c.QuestCompleted) {
+		return false
+	}
+	for _, flag := range c.Flags {
+		if !state.Flags[flag] {
+			return false
+		}
+	}
+	if c.Death && !state.Dead {
+		return false
+	}
+	return c.Karma.Contains(state.Karma)
+}
+
+func (c EndingCondition) isEmpty() bool {
+	return c.ReachLocation == "" && c.QuestCompleted == "" && len(c.Flags) == 0 && !c.Death && c.Karma == nil
+}
+
+// FirstMetEnding returns the highest-priority ending met in state (ties broken by ID), or nil.
+func FirstMetEnding(endings map[string]*Ending, state EndingState) *Ending {
+	var met []*Ending
+	for _, ending := range endings {
+		if ending.When.Met(state) {
+			met = append(met, ending)
+		}
+	}
+	if len(met) == 0 {
+		return nil
+	}
+	sort.Slice(met, func(i, j int) bool {
+		if met[i].Priority != met[j].Priority {
+			return met[i].Priority > met[j].Priority
+		}
+		return met[i].ID < met[j].ID
+	})
+	return met[0]
+}
+
+// LoadEndings reads every ending in fsys and checks its references against ws.
+func LoadEndings(fsys fs.FS, ws WorldSystem) (map[string]*Ending, error) {
+	return loadContentDir(fsys, "ending", EndingSchema, func(e *Ending, fileID string) (string, error) {
+		if e.ID == "" {
+			e.ID = fileID
+		}
+		if e.When.isEmpty() {
+			return "", fmt.Errorf("ending '%s' has no conditions and would end every session immediately", e.ID)
+		}
+		if err := e.When.Karma.check(); err != nil {
+			return "", fmt.Errorf("ending '%s' when.karma: %w", e.ID, err)
+		}
+		if e.When.ReachLocation != "" {
+			if _, err := ws.GetLocation(e.When.ReachLocation); err != nil {
+				return "", fmt.Errorf("ending '%s' when.reachLocation references non-existent location ID '%s'", e.ID, e.When.ReachLocation)
+			}
+		}
+		return e.ID, nil
+	})
+}
+
+// Endings loads the archive's endings, checking them against ws. Archives without an
+// endings directory have none.
+func (a *Archive) Endings(ws WorldSystem) (map[string]*Ending, error) {
+	if _, err := fs.Stat(a.FS, ArchiveEndingsDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Ending{}, nil
+	}
+	endingFS, err := fs.Sub(a.FS, ArchiveEndingsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveEndingsDir, err)
+	}
+	return LoadEndings(endingFS, ws)
+}
@@ -0,0 +1,201 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Adjacency Graph Export ---
+// Authors visualize connectivity with Graph (JSON) or Graph.DOT (Graphviz).
+// Regions come from a location's "region" attribute, when set.
+
+// GraphNode is a location in the exported graph.
+type GraphNode struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	ThemeID string   `json:"themeId,omitempty"`
+	Region  string   `json:"region,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// GraphEdge connects two locations. Bidirectional edges are listed once, with From < To.
+type GraphEdge struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Bidirectional bool   `json:"bidirectional"`
+}
+
+// Graph is the location adjacency graph plus connectivity diagnostics.
+type Graph struct {
+	Nodes       []GraphNode `json:"nodes"`
+	Edges       []GraphEdge `json:"edges"`
+	StartID     string      `json:"startId,omitempty"`
+	DeadEnds    []string    `json:"deadEnds"`    // Locations with no exits
+	Unreachable []string    `json:"unreachable"` // Locations that can't be reached from StartID
+}
+
+// BuildGraph exports the adjacency graph of ws. If startID is a known location,
+// Unreachable lists every location the player can never walk to from it.
+func BuildGraph(ws WorldSystem, startID string) *Graph {
+	ids := ws.GetAllLocationIDs()
+	sort.Strings(ids)
+
+	g := &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}, DeadEnds: []string{}, Unreachable: []string{}}
+	adjacency := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			continue
+		}
+		region, _ := loc.Attributes["region"].(string)
+		g.Nodes = append(g.Nodes, GraphNode{ID: loc.ID, Name: loc.Name, ThemeID: loc.ThemeID, Region: region, Tags: loc.Tags})
+		adjacency[loc.ID] = loc.AdjacentIDs
+		if len(loc.AdjacentIDs) == 0 {
+			g.DeadEnds = append(g.DeadEnds, loc.ID)
+		}
+	}
+
+	for _, from := range ids {
+		for _, to := range adjacency[from] {
+			back := containsString(adjacency[to], from)
+			if back && to < from {
+				continue // Already listed from the other side
+			}
+			g.Edges = append(g.Edges, GraphEdge{From: from, To: to, Bidirectional: back})
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	if _, ok := adjacency[startID]; ok {
+		g.StartID = startID
+		reached := map[string]bool{startID: true}
+		queue := []string{startID}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range adjacency[current] {
+				if !reached[next] {
+					reached[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		for _, id := range ids {
+			if !reached[id] {
+				g.Unreachable = append(g.Unreachable, id)
+			}
+		}
+	}
+	return g
+}
+
+// Distances returns how many exits away from fromID each location reachable from it
+// is (fromID itself is 0). Locations that can't be reached are missing.
+func Distances(ws WorldSystem, fromID string) map[string]int {
+	return DistancesWithin(ws, fromID, -1)
+}
+
+// DistancesWithin is Distances limited to locations at most maxHops exits away (a
+// negative maxHops is no limit). On large worlds it only visits the neighbourhood.
+func DistancesWithin(ws WorldSystem, fromID string, maxHops int) map[string]int {
+	distances := map[string]int{fromID: 0}
+	queue := []string{fromID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if maxHops >= 0 && distances[current] >= maxHops {
+			continue
+		}
+		adjacent, err := adjacentIDs(ws, current)
+		if err != nil {
+			continue
+		}
+		for _, next := range adjacent {
+			if _, seen := distances[next]; !seen {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances
+}
+
+// DOT renders the graph in Graphviz format. Locations are grouped into clusters by
+// region; dead ends are drawn red, unreachable locations dashed, the start doubled.
+func (g *Graph) DOT() string {
+	deadEnd := make(map[string]bool, len(g.DeadEnds))
+	for _, id := range g.DeadEnds {
+		deadEnd[id] = true
+	}
+	unreachable := make(map[string]bool, len(g.Unreachable))
+	for _, id := range g.Unreachable {
+		unreachable[id] = true
+	}
+
+	byRegion := make(map[string][]GraphNode)
+	var regions []string
+	for _, n := range g.Nodes {
+		if _, ok := byRegion[n.Region]; !ok {
+			regions = append(regions, n.Region)
+		}
+		byRegion[n.Region] = append(byRegion[n.Region], n)
+	}
+	sort.Strings(regions)
+
+	var b strings.Builder
+	b.WriteString("digraph world {\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for i, region := range regions {
+		indent := "  "
+		if region != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(region))
+			indent = "    "
+		}
+		for _, n := range byRegion[region] {
+			label := n.Name
+			if len(n.Tags) > 0 {
+				label += "\n[" + strings.Join(n.Tags, ", ") + "]"
+			}
+			attrs := []string{"label=" + dotQuote(label)}
+			if n.ThemeID != "" {
+				attrs = append(attrs, "tooltip="+dotQuote("theme: "+n.ThemeID))
+			}
+			if n.ID == g.StartID {
+				attrs = append(attrs, "peripheries=2")
+			}
+			if deadEnd[n.ID] {
+				attrs = append(attrs, "color=red")
+			}
+			if unreachable[n.ID] {
+				attrs = append(attrs, `style="rounded,dashed"`)
+			}
+			fmt.Fprintf(&b, "%s%s [%s];\n", indent, dotQuote(n.ID), strings.Join(attrs, ", "))
+		}
+		if region != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Bidirectional {
+			fmt.Fprintf(&b, "  %s -> %s [dir=both];\n", dotQuote(e.From), dotQuote(e.To))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a Graphviz ID or label.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,110 @@
+package world
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// --- Location Indexes ---
+// Large worlds answer adjacency and tag queries from indexes built when the world
+// loads, instead of resolving AdjacentIDs or scanning every location per call. Edits
+// replace location nodes (see editor.go), so every edit rebuilds the indexes to keep
+// them pointing at the current nodes; edits are rare next to reads.
+
+// locationIndex holds precomputed lookups over InMemoryWorldSystem.locations.
+type locationIndex struct {
+	adjacent map[string][]*LocationNode // Location ID -> its adjacent locations, in AdjacentIDs order
+	byTag    map[string][]*LocationNode // Tag -> locations carrying it, by ID
+}
+
+// reindex rebuilds the location indexes. Callers hold ws.mu for writing.
+func (ws *InMemoryWorldSystem) reindex() {
+	idx := locationIndex{
+		adjacent: make(map[string][]*LocationNode, len(ws.locations)),
+		byTag:    make(map[string][]*LocationNode),
+	}
+	for id, loc := range ws.locations {
+		adjacent := make([]*LocationNode, 0, len(loc.AdjacentIDs))
+		for _, adjID := range loc.AdjacentIDs {
+			if adj, ok := ws.locations[adjID]; ok { // Dangling IDs are reported by LoadWorldData
+				adjacent = append(adjacent, adj)
+			}
+		}
+		idx.adjacent[id] = adjacent
+		for _, tag := range loc.Tags {
+			idx.byTag[tag] = append(idx.byTag[tag], loc)
+		}
+	}
+	for _, locs := range idx.byTag {
+		sort.Slice(locs, func(i, j int) bool { return locs[i].ID < locs[j].ID })
+	}
+	ws.index = idx
+}
+
+// LocationsWithTag returns copies of the locations tagged tag, sorted by ID.
+func (ws *InMemoryWorldSystem) LocationsWithTag(tag string) []*LocationNode {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return cloneLocations(ws.index.byTag[tag])
+}
+
+// AdjacentIDs returns the IDs of the locations adjacent to locationID that exist, for
+// graph searches that don't need the locations themselves (and the copies
+// GetAdjacentLocations makes).
+func (ws *InMemoryWorldSystem) AdjacentIDs(locationID string) ([]string, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	if _, ok := ws.locations[locationID]; !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, locationID)
+	}
+	adjacent := ws.index.adjacent[locationID]
+	ids := make([]string, len(adjacent))
+	for i, loc := range adjacent {
+		ids[i] = loc.ID
+	}
+	return ids, nil
+}
+
+// adjacencyIndex is implemented by world systems that can list adjacent IDs cheaply.
+type adjacencyIndex interface {
+	AdjacentIDs(locationID string) ([]string, error)
+}
+
+// adjacentIDs returns the IDs of the existing locations adjacent to locationID.
+func adjacentIDs(ws WorldSystem, locationID string) ([]string, error) {
+	if indexed, ok := ws.(adjacencyIndex); ok {
+		return indexed.AdjacentIDs(locationID)
+	}
+	adjacent, err := ws.GetAdjacentLocations(locationID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(adjacent))
+	for i, loc := range adjacent {
+		ids[i] = loc.ID
+	}
+	return ids, nil
+}
+
+// TagIndex is implemented by world systems that index locations by tag.
+type TagIndex interface {
+	LocationsWithTag(tag string) []*LocationNode
+}
+
+// LocationsWithTag returns the locations of ws tagged tag, sorted by ID, from its tag
+// index when it has one.
+func LocationsWithTag(ws WorldSystem, tag string) []*LocationNode {
+	if indexed, ok := ws.(TagIndex); ok {
+		return indexed.LocationsWithTag(tag)
+	}
+	ids := ws.GetAllLocationIDs()
+	sort.Strings(ids)
+	var locs []*LocationNode
+	for _, id := range ids {
+		if loc, err := ws.GetLocation(id); err == nil && slices.Contains(loc.Tags, tag) {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
@@ -0,0 +1,104 @@
+package world
+
+import (
+	"fmt"
+	"slices"
+)
+
+// --- Interactions ---
+// Locations may declare things the player can do there (pull a lever, ring a bell, pray
+// at a shrine). The narrator picks one with the 'interact' action and the engine applies
+// its effects; the world only describes them, sessions record which have been used.
+
+// Interaction is a location-specific verb with fixed effects.
+type Interaction struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`                                   // What the player does, e.g. "Pull the rusty lever"
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"` // What happens, told to the narrator
+	Once        bool              `json:"once,omitempty" yaml:"once,omitempty"`               // Can only be done once per playthrough
+	Effects     InteractionEffect `json:"effects" yaml:"effects"`
+}
+
+// InteractionEffect is what an interaction changes.
+type InteractionEffect struct {
+	SetFlags  []string `json:"setFlags,omitempty" yaml:"setFlags,omitempty"`   // Narrative flags to set
+	OpenExits []string `json:"openExits,omitempty" yaml:"openExits,omitempty"` // Location IDs that become reachable from here (both ways) for the rest of the playthrough
+	Event     string   `json:"event,omitempty" yaml:"event,omitempty"`         // Name passed to frontends in the "interacted" event, e.g. "bell_rung"
+}
+
+// Interaction returns loc's interaction with the given ID, or nil.
+func (loc *LocationNode) Interaction(id string) *Interaction {
+	for i := range loc.Interactions {
+		if loc.Interactions[i].ID == id {
+			return &loc.Interactions[i]
+		}
+	}
+	return nil
+}
+
+// cloneInteractions copies interactions including their effects.
+func cloneInteractions(interactions []Interaction) []Interaction {
+	if interactions == nil {
+		return nil
+	}
+	clone := make([]Interaction, len(interactions))
+	for i, in := range interactions {
+		clone[i] = in
+		clone[i].Effects.SetFlags = slices.Clone(in.Effects.SetFlags)
+		clone[i].Effects.OpenExits = slices.Clone(in.Effects.OpenExits)
+	}
+	return clone
+}
+
+// checkInteractions reports duplicate or unnamed interactions at loc, and exits to
+// loc itself. Exit targets are checked against the world by checkInteractionExits.
+func checkInteractions(loc *LocationNode) error {
+	seen := make(map[string]bool, len(loc.Interactions))
+	for _, in := range loc.Interactions {
+		if in.ID == "" {
+			return fmt.Errorf("location '%s' has an interaction without an id", loc.ID)
+		}
+		if seen[in.ID] {
+			return fmt.Errorf("location '%s' has more than one interaction '%s'", loc.ID, in.ID)
+		}
+		seen[in.ID] = true
+		if in.Name == "" {
+			return fmt.Errorf("location '%s' interaction '%s' has no name", loc.ID, in.ID)
+		}
+		if slices.Contains(in.Effects.OpenExits, loc.ID) {
+			return fmt.Errorf("location '%s' interaction '%s' cannot open an exit to itself", loc.ID, in.ID)
+		}
+	}
+	return nil
+}
+
+// checkInteractionExits reports interactions at loc opening exits to locations that
+// don't exist (per exists).
+func checkInteractionExits(loc *LocationNode, exists func(id string) bool) error {
+	for _, in := range loc.Interactions {
+		for _, to := range in.Effects.OpenExits {
+			if !exists(to) {
+				return fmt.Errorf("location '%s' interaction '%s' opens an exit to non-existent location '%s'", loc.ID, in.ID, to)
+			}
+		}
+	}
+	return nil
+}
+
+// opensExitTo reports whether any of loc's interactions opens an exit to toID.
+func (loc *LocationNode) opensExitTo(toID string) bool {
+	for _, in := range loc.Interactions {
+		if slices.Contains(in.Effects.OpenExits, toID) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropInteractionExits removes toID from the exits loc's interactions open. Call it on
+// a clone (see LocationNode.Clone).
+func (loc *LocationNode) dropInteractionExits(toID string) {
+	for i := range loc.Interactions {
+		loc.Interactions[i].Effects.OpenExits = slices.DeleteFunc(loc.Interactions[i].Effects.OpenExits, func(id string) bool { return id == toID })
+	}
+}
@@ -0,0 +1,83 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"llmrpg/internal/dice"
+)
+
+// --- Items ---
+// Items are authored per world. The player's inventory holds item IDs, so actions
+// can only grant or consume items the world defines.
+
+// Item is something the player can carry.
+type Item struct {
+	ID          string         `json:"id" yaml:"id"`
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Heal        string         `json:"heal,omitempty" yaml:"heal,omitempty"`             // Hit points restored on use (dice notation)
+	Damage      string         `json:"damage,omitempty" yaml:"damage,omitempty"`         // Damage dealt to an enemy on use (dice notation)
+	Restores    map[string]int `json:"restores,omitempty" yaml:"restores,omitempty"`     // Survival resource ID -> amount restored on use (see SurvivalRules)
+	Mount       *Mount         `json:"mount,omitempty" yaml:"mount,omitempty"`           // Set for mounts and vehicles the player can board
+	Weapon      string         `json:"weapon,omitempty" yaml:"weapon,omitempty"`         // Damage the player deals fighting with it (dice notation)
+	Armor       int            `json:"armor,omitempty" yaml:"armor,omitempty"`           // Armor class bonus while carried
+	Durability  int            `json:"durability,omitempty" yaml:"durability,omitempty"` // Wear it takes before breaking (0 = never wears)
+	Repair      map[string]int `json:"repair,omitempty" yaml:"repair,omitempty"`         // Item ID -> count consumed by a repair (empty = can't be repaired)
+	Quest       bool           `json:"quest,omitempty" yaml:"quest,omitempty"`           // Needed for a quest; discarding it is confirmed in sessions that confirm high-impact actions
+}
+
+// Gear reports whether the item is a weapon or armor the player fights with.
+func (i *Item) Gear() bool {
+	return i.Weapon != "" || i.Armor > 0
+}
+
+// Usable reports whether the item does something when used (and is consumed).
+func (i *Item) Usable() bool {
+	return i.Heal != "" || i.Damage != "" || len(i.Restores) > 0
+}
+
+// LoadItems reads every item in fsys.
+func LoadItems(fsys fs.FS) (map[string]*Item, error) {
+	items, err := loadContentDir(fsys, "item", ItemSchema, func(item *Item, fileID string) (string, error) {
+		if item.ID == "" {
+			item.ID = fileID
+		}
+		for field, notation := range map[string]string{"heal": item.Heal, "damage": item.Damage, "weapon": item.Weapon} {
+			if notation == "" {
+				continue
+			}
+			if _, err := dice.Parse(notation); err != nil {
+				return "", fmt.Errorf("item '%s' %s: %w", item.ID, field, err)
+			}
+		}
+		if item.Mount != nil && (item.Mount.Kind == "" || item.Mount.Travel < 0) {
+			return "", fmt.Errorf("item '%s' mount needs a kind and a non-negative travel percentage", item.ID)
+		}
+		return item.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var problems []error
+	for _, item := range items {
+		for material := range item.Repair {
+			if _, ok := items[material]; !ok {
+				problems = append(problems, fmt.Errorf("item '%s' repair needs non-existent item '%s'", item.ID, material))
+			}
+		}
+	}
+	return items, errors.Join(problems...)
+}
+
+// Items loads the archive's items. Archives without an items directory have none.
+func (a *Archive) Items() (map[string]*Item, error) {
+	if _, err := fs.Stat(a.FS, ArchiveItemsDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Item{}, nil
+	}
+	itemFS, err := fs.Sub(a.FS, ArchiveItemsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveItemsDir, err)
+	}
+	return LoadItems(itemFS)
+}
@@ -0,0 +1,25 @@
+package world
+
+import "fmt"
+
+// KarmaRange is a condition on the player's karma (see session.GameSession.Karma).
+// Either bound may be omitted; both are inclusive.
+type KarmaRange struct {
+	Min *int `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *int `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Contains reports whether karma lies within the range. A nil range contains everything.
+func (r *KarmaRange) Contains(karma int) bool {
+	if r == nil {
+		return true
+	}
+	return (r.Min == nil || karma >= *r.Min) && (r.Max == nil || karma <= *r.Max)
+}
+
+func (r *KarmaRange) check() error {
+	if r != nil && r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return fmt.Errorf("karma range min %d is above max %d", *r.Min, *r.Max)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package world
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- Content Linting ---
+// Loading rejects content that can't work (unknown exits, missing themes). Lint flags
+// content that works but reads or plays badly: descriptions too short or too long,
+// untagged locations, dead ends, places players can't reach and exits with no way back.
+// Some findings have a mechanical fix (adding the missing way back); FixLint applies them.
+
+// LintRule names a lint check.
+type LintRule string
+
+const (
+	LintShortDescription LintRule = "short-description" // Fewer words than LintOptions.MinWords
+	LintLongDescription  LintRule = "long-description"  // More words than LintOptions.MaxWords
+	LintMissingTags      LintRule = "missing-tags"      // No tags, so tag-based content can't find the location
+	LintDeadEnd          LintRule = "dead-end"          // No exits at all
+	LintUnreachable      LintRule = "unreachable"       // Can't be walked to from the start location
+	LintOneWayExit       LintRule = "one-way-exit"      // An exit whose destination has no exit back (fixable)
+)
+
+// LintRules lists every rule, in report order.
+var LintRules = []LintRule{LintShortDescription, LintLongDescription, LintMissingTags, LintDeadEnd, LintUnreachable, LintOneWayExit}
+
+// ParseLintRule parses a LintRule name.
+func ParseLintRule(s string) (LintRule, error) {
+	if rule := LintRule(s); slices.Contains(LintRules, rule) {
+		return rule, nil
+	}
+	names := make([]string, len(LintRules))
+	for i, rule := range LintRules {
+		names[i] = string(rule)
+	}
+	return "", fmt.Errorf("unknown lint rule '%s' (known: %s)", s, strings.Join(names, ", "))
+}
+
+// Default description length limits, in words.
+const (
+	DefaultLintMinWords = 15
+	DefaultLintMaxWords = 120
+)
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	StartID  string     // Unreachable locations are those not reachable from here; "" skips the check
+	MinWords int        // Shortest acceptable description (0 = DefaultLintMinWords)
+	MaxWords int        // Longest acceptable description (0 = DefaultLintMaxWords)
+	Disable  []LintRule // Rules not to run, e.g. one-way-exit for a world full of intentional drops
+}
+
+// LintIssue is one finding.
+type LintIssue struct {
+	Rule       LintRule `json:"rule"`
+	LocationID string   `json:"locationId"`
+	Target     string   `json:"target,omitempty"` // The other location involved (the exit's destination, for one-way-exit)
+	Message    string   `json:"message"`
+	Fix        string   `json:"fix,omitempty"` // What FixLint would change; empty when the issue needs an author
+}
+
+// Lint checks every location in ws. Issues are ordered by location, then by rule.
+func Lint(ws WorldSystem, opts LintOptions) []LintIssue {
+	minWords, maxWords := opts.MinWords, opts.MaxWords
+	if minWords == 0 {
+		minWords = DefaultLintMinWords
+	}
+	if maxWords == 0 {
+		maxWords = DefaultLintMaxWords
+	}
+	enabled := func(rule LintRule) bool { return !slices.Contains(opts.Disable, rule) }
+
+	ids := ws.GetAllLocationIDs()
+	sort.Strings(ids)
+	locations := make(map[string]*LocationNode, len(ids))
+	for _, id := range ids {
+		if loc, err := ws.GetLocation(id); err == nil {
+			locations[id] = loc
+		}
+	}
+	var unreachable []string
+	if opts.StartID != "" && enabled(LintUnreachable) {
+		unreachable = BuildGraph(ws, opts.StartID).Unreachable
+	}
+
+	issues := []LintIssue{}
+	for _, id := range ids {
+		loc, ok := locations[id]
+		if !ok {
+			continue
+		}
+		words := len(strings.Fields(loc.Description))
+		if words < minWords && enabled(LintShortDescription) {
+			issues = append(issues, LintIssue{Rule: LintShortDescription, LocationID: id,
+				Message: fmt.Sprintf("description has %d words; aim for at least %d", words, minWords)})
+		}
+		if words > maxWords && enabled(LintLongDescription) {
+			issues = append(issues, LintIssue{Rule: LintLongDescription, LocationID: id,
+				Message: fmt.Sprintf("description has %d words; aim for at most %d", words, maxWords)})
+		}
+		if len(loc.Tags) == 0 && enabled(LintMissingTags) {
+			issues = append(issues, LintIssue{Rule: LintMissingTags, LocationID: id, Message: "location has no tags"})
+		}
+		if len(loc.AdjacentIDs) == 0 && enabled(LintDeadEnd) {
+			issues = append(issues, LintIssue{Rule: LintDeadEnd, LocationID: id, Message: "location has no exits; players who get here are stuck"})
+		}
+		if slices.Contains(unreachable, id) {
+			issues = append(issues, LintIssue{Rule: LintUnreachable, LocationID: id,
+				Message: fmt.Sprintf("location can't be reached from '%s'", opts.StartID)})
+		}
+		if enabled(LintOneWayExit) {
+			for _, to := range loc.AdjacentIDs {
+				dest, ok := locations[to]
+				if !ok || containsString(dest.AdjacentIDs, id) {
+					continue
+				}
+				issues = append(issues, LintIssue{Rule: LintOneWayExit, LocationID: id, Target: to,
+					Message: fmt.Sprintf("exit to '%s' has no way back", to),
+					Fix:     fmt.Sprintf("add '%s' to the adjacentIds of '%s'", id, to)})
+			}
+		}
+	}
+	return issues
+}
+
+// FixLint applies the mechanical fixes among issues through ed and returns every
+// location it changed. Issues without a fix are skipped. It stops at the first failed
+// edit, returning what was changed before it.
+func FixLint(ed Editor, issues []LintIssue) ([]*LocationNode, error) {
+	var changed []*LocationNode
+	for _, issue := range issues {
+		if issue.Rule != LintOneWayExit || issue.Fix == "" {
+			continue
+		}
+		updated, err := ed.UpdateLocation(issue.Target, LinkNone, func(loc *LocationNode) error {
+			if !containsString(loc.AdjacentIDs, issue.LocationID) {
+				loc.AdjacentIDs = append(loc.AdjacentIDs, issue.LocationID)
+			}
+			return nil
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to fix %s on '%s': %w", issue.Rule, issue.LocationID, err)
+		}
+		changed = append(changed, updated...)
+	}
+	return changed, nil
+}
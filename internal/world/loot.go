@@ -0,0 +1,133 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"llmrpg/internal/dice"
+)
+
+// --- Loot ---
+// Loot tables are weighted item drops. Creatures carry one (inline or by reference)
+// that is rolled when they are defeated; locations may reference one that is rolled
+// when the player searches them.
+
+// LootTable is a weighted table of item drops.
+type LootTable struct {
+	ID      string      `json:"id,omitempty" yaml:"id,omitempty"`       // Set for shared tables (loot/*.json); empty inline
+	Rolls   int         `json:"rolls,omitempty" yaml:"rolls,omitempty"` // Times the table is rolled (default 1)
+	Entries []LootEntry `json:"entries" yaml:"entries"`
+}
+
+// LootEntry is one row of a LootTable. An entry without an item drops nothing.
+type LootEntry struct {
+	Item   string `json:"item,omitempty" yaml:"item,omitempty"`
+	Weight int    `json:"weight" yaml:"weight"`
+	Count  int    `json:"count,omitempty" yaml:"count,omitempty"` // Default 1
+}
+
+// Drop is an item stack produced by rolling a LootTable.
+type Drop struct {
+	ItemID string `json:"itemId"`
+	Count  int    `json:"count"`
+}
+
+// Roll rolls the table Rolls times and returns the drops, one per item in the order
+// first rolled. Entries without an item contribute nothing.
+func (t *LootTable) Roll(roller *dice.Roller) []Drop {
+	total := 0
+	for _, entry := range t.Entries {
+		total += entry.Weight
+	}
+	if total < 1 {
+		return nil
+	}
+	rolls := t.Rolls
+	if rolls == 0 {
+		rolls = 1
+	}
+	var drops []Drop
+	index := make(map[string]int) // Item ID -> position in drops
+	for range rolls {
+		pick := roller.Roll(dice.Expression{Count: 1, Sides: total}, dice.Normal).Total
+		for _, entry := range t.Entries {
+			pick -= entry.Weight
+			if pick > 0 {
+				continue
+			}
+			if entry.Item != "" {
+				count := max(entry.Count, 1)
+				if i, ok := index[entry.Item]; ok {
+					drops[i].Count += count
+				} else {
+					index[entry.Item] = len(drops)
+					drops = append(drops, Drop{ItemID: entry.Item, Count: count})
+				}
+			}
+			break
+		}
+	}
+	return drops
+}
+
+// validate checks the table's weights and counts, and that every item exists in items.
+func (t *LootTable) validate(items map[string]*Item) error {
+	if t.Rolls < 0 {
+		return fmt.Errorf("rolls must not be negative")
+	}
+	for i, entry := range t.Entries {
+		if entry.Weight < 1 {
+			return fmt.Errorf("entries[%d].weight must be at least 1", i)
+		}
+		if entry.Count < 0 {
+			return fmt.Errorf("entries[%d].count must not be negative", i)
+		}
+		if entry.Item != "" {
+			if _, ok := items[entry.Item]; !ok {
+				return fmt.Errorf("entries[%d] references non-existent item ID '%s'", i, entry.Item)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadLootTables reads every shared loot table in fsys and checks its items against items.
+func LoadLootTables(fsys fs.FS, items map[string]*Item) (map[string]*LootTable, error) {
+	return loadContentDir(fsys, "loot table", LootTableSchema, func(t *LootTable, fileID string) (string, error) {
+		if t.ID == "" {
+			t.ID = fileID
+		}
+		if err := t.validate(items); err != nil {
+			return "", fmt.Errorf("loot table '%s': %w", t.ID, err)
+		}
+		return t.ID, nil
+	})
+}
+
+// CheckLocationLoot reports locations whose lootTable isn't one of lootTables.
+func CheckLocationLoot(ws WorldSystem, lootTables map[string]*LootTable) error {
+	var problems []error
+	for _, id := range ws.GetAllLocationIDs() {
+		loc, err := ws.GetLocation(id)
+		if err != nil || loc.LootTable == "" {
+			continue
+		}
+		if _, ok := lootTables[loc.LootTable]; !ok {
+			problems = append(problems, fmt.Errorf("location '%s' references non-existent loot table '%s'", id, loc.LootTable))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// LootTables loads the archive's shared loot tables, checking them against items.
+// Archives without a loot directory have none.
+func (a *Archive) LootTables(items map[string]*Item) (map[string]*LootTable, error) {
+	if _, err := fs.Stat(a.FS, ArchiveLootDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*LootTable{}, nil
+	}
+	lootFS, err := fs.Sub(a.FS, ArchiveLootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveLootDir, err)
+	}
+	return LoadLootTables(lootFS, items)
+}
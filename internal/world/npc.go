@@ -0,0 +1,114 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// --- NPCs ---
+// NPCs are the world's named characters. A schedule says where each one is at each
+// hour of the in-story day, so the innkeeper goes home at night and a quest giver may
+// be away when the player calls.
+
+// NPC is one of the world's characters.
+type NPC struct {
+	ID          string          `json:"id" yaml:"id"`
+	Name        string          `json:"name" yaml:"name"`
+	Description string          `json:"description,omitempty" yaml:"description,omitempty"` // Appearance and manner, told to the narrator
+	LocationID  string          `json:"locationId,omitempty" yaml:"locationId,omitempty"`   // Where the NPC is at hours no schedule entry covers ("" = away)
+	Schedule    []ScheduleEntry `json:"schedule,omitempty" yaml:"schedule,omitempty"`       // First matching entry wins
+}
+
+// ScheduleEntry places an NPC somewhere for part of the day.
+type ScheduleEntry struct {
+	From       int    `json:"from" yaml:"from"`                                 // Hour of day the entry starts (0-23)
+	To         int    `json:"to" yaml:"to"`                                     // Hour it ends, exclusive (1-24); earlier than From wraps past midnight
+	LocationID string `json:"locationId,omitempty" yaml:"locationId,omitempty"` // "" = away, somewhere the player can't go
+	Activity   string `json:"activity,omitempty" yaml:"activity,omitempty"`     // What the NPC is doing, e.g. "serving behind the bar"
+}
+
+// covers reports whether the entry applies at hour (0-23).
+func (e *ScheduleEntry) covers(hour int) bool {
+	if e.From < e.To {
+		return hour >= e.From && hour < e.To
+	}
+	return hour >= e.From || hour < e.To%24
+}
+
+// Whereabouts returns where the NPC is at hour of day (0-23) and what they're doing
+// there; locationID is "" while they're away.
+func (n *NPC) Whereabouts(hour int) (locationID, activity string) {
+	for i := range n.Schedule {
+		if n.Schedule[i].covers(hour) {
+			return n.Schedule[i].LocationID, n.Schedule[i].Activity
+		}
+	}
+	return n.LocationID, ""
+}
+
+// Frequents reports whether the NPC is at locationID at some hour of the day.
+func (n *NPC) Frequents(locationID string) bool {
+	for hour := range 24 {
+		if at, _ := n.Whereabouts(hour); at == locationID {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAt returns the next hour of day (0-23) after hour at which the NPC is at
+// locationID, or -1 if they never are.
+func (n *NPC) NextAt(locationID string, hour int) int {
+	for i := 1; i <= 24; i++ {
+		next := (hour + i) % 24
+		if at, _ := n.Whereabouts(next); at == locationID {
+			return next
+		}
+	}
+	return -1
+}
+
+// LoadNPCs reads every NPC in fsys and checks their schedules against ws.
+func LoadNPCs(fsys fs.FS, ws WorldSystem) (map[string]*NPC, error) {
+	return loadContentDir(fsys, "npc", NPCSchema, func(n *NPC, fileID string) (string, error) {
+		if n.ID == "" {
+			n.ID = fileID
+		}
+		return n.ID, n.check(ws)
+	})
+}
+
+// check reports schedule hours out of range and locations that don't exist in ws.
+func (n *NPC) check(ws WorldSystem) error {
+	var problems []error
+	checkLocation := func(id string) {
+		if id == "" {
+			return
+		}
+		if _, err := ws.GetLocation(id); err != nil {
+			problems = append(problems, fmt.Errorf("npc '%s': %w", n.ID, err))
+		}
+	}
+	checkLocation(n.LocationID)
+	for i, e := range n.Schedule {
+		if e.From < 0 || e.From > 23 || e.To < 1 || e.To > 24 || e.From == e.To {
+			problems = append(problems, fmt.Errorf("npc '%s' schedule entry %d must run from an hour 0-23 to a different hour 1-24", n.ID, i+1))
+		}
+		checkLocation(e.LocationID)
+	}
+	return errors.Join(problems...)
+}
+
+// NPCs loads the archive's characters, checking their schedules against ws. Archives
+// without an npcs directory have none.
+func (a *Archive) NPCs(ws WorldSystem) (map[string]*NPC, error) {
+	if _, err := fs.Stat(a.FS, ArchiveNPCsDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*NPC{}, nil
+	}
+	npcFS, err := fs.Sub(a.FS, ArchiveNPCsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveNPCsDir, err)
+	}
+	return LoadNPCs(npcFS, ws)
+}
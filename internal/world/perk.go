@@ -0,0 +1,86 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+)
+
+// --- Perks ---
+// Perks are permanent upgrades the player picks when they level up. Each one adjusts
+// stats used by checks and combat, or grants abilities.
+
+// Perk is one entry of the world's perk catalog.
+type Perk struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	MinLevel    int      `json:"minLevel,omitempty" yaml:"minLevel,omitempty"`     // Level the player must have reached (default any)
+	Requires    []string `json:"requires,omitempty" yaml:"requires,omitempty"`     // Perk IDs that must be chosen first
+	MaxHP       int      `json:"maxHp,omitempty" yaml:"maxHp,omitempty"`           // Added to maximum (and current) hit points
+	MaxMP       int      `json:"maxMp,omitempty" yaml:"maxMp,omitempty"`           // Added to maximum (and current) mana
+	Attack      int      `json:"attack,omitempty" yaml:"attack,omitempty"`         // Added to attack rolls
+	Armor       int      `json:"armor,omitempty" yaml:"armor,omitempty"`           // Added to armor class
+	Checks      int      `json:"checks,omitempty" yaml:"checks,omitempty"`         // Added to skill checks, challenges and stealth rolls
+	Initiative  int      `json:"initiative,omitempty" yaml:"initiative,omitempty"` // Added to initiative rolls
+	Abilities   []string `json:"abilities,omitempty" yaml:"abilities,omitempty"`   // Ability IDs the perk teaches
+}
+
+// LoadPerks reads every perk in fsys and checks the perks and abilities they reference.
+func LoadPerks(fsys fs.FS, abilities map[string]*Ability) (map[string]*Perk, error) {
+	perks, err := loadContentDir(fsys, "perk", PerkSchema, func(p *Perk, fileID string) (string, error) {
+		if p.ID == "" {
+			p.ID = fileID
+		}
+		for _, id := range p.Abilities {
+			if _, ok := abilities[id]; !ok {
+				return "", fmt.Errorf("perk '%s' teaches non-existent ability '%s'", p.ID, id)
+			}
+		}
+		return p.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var problems []error
+	for _, p := range perks {
+		for _, id := range p.Requires {
+			if _, ok := perks[id]; !ok || id == p.ID {
+				problems = append(problems, fmt.Errorf("perk '%s' requires unknown perk '%s'", p.ID, id))
+			}
+		}
+	}
+	return perks, errors.Join(problems...)
+}
+
+// Perks loads the archive's perk catalog, checking it against abilities. Archives
+// without a perks directory have none.
+func (a *Archive) Perks(abilities map[string]*Ability) (map[string]*Perk, error) {
+	if _, err := fs.Stat(a.FS, ArchivePerksDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*Perk{}, nil
+	}
+	perkFS, err := fs.Sub(a.FS, ArchivePerksDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchivePerksDir, err)
+	}
+	return LoadPerks(perkFS, abilities)
+}
+
+// AvailablePerks returns the perks a character at level who has chosen taken may pick,
+// in ID order.
+func AvailablePerks(perks map[string]*Perk, level int, taken []string) []*Perk {
+	var available []*Perk
+	for _, p := range perks {
+		if slices.Contains(taken, p.ID) || p.MinLevel > level {
+			continue
+		}
+		if slices.ContainsFunc(p.Requires, func(id string) bool { return !slices.Contains(taken, id) }) {
+			continue
+		}
+		available = append(available, p)
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
+	return available
+}
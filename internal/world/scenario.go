@@ -0,0 +1,160 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"llmrpg/internal/events"
+)
+
+// --- Scenarios ---
+// A scenario is a guided opening or tutorial: a sequence of beats the player must
+// complete in order (reach a location, obtain an item) before free play begins.
+// Each beat can inject narrator guidance and confine travel to a set of locations.
+
+// Scenario is a scripted sequence of beats.
+type Scenario struct {
+	ID              string `json:"id" yaml:"id"`
+	Name            string `json:"name" yaml:"name"`
+	Description     string `json:"description,omitempty" yaml:"description,omitempty"`
+	StartLocationID string `json:"startLocationId,omitempty" yaml:"startLocationId,omitempty"` // Default start for sessions playing this scenario
+	Beats           []Beat `json:"beats" yaml:"beats"`
+}
+
+// Beat is one required step of a scenario.
+type Beat struct {
+	ID               string        `json:"id" yaml:"id"`
+	Objective        string        `json:"objective" yaml:"objective"`
+	Prompt           string        `json:"prompt,omitempty" yaml:"prompt,omitempty"`                     // Narrator guidance while the beat is active
+	AllowedLocations []string      `json:"allowedLocations,omitempty" yaml:"allowedLocations,omitempty"` // Empty = travel unrestricted
+	Complete         BeatCondition `json:"complete" yaml:"complete"`
+}
+
+// BeatCondition is the completion check for a beat. Every condition that is set must hold.
+type BeatCondition struct {
+	ReachLocation string      `json:"reachLocation,omitempty" yaml:"reachLocation,omitempty"` // The player is at this location
+	ObtainItem    string      `json:"obtainItem,omitempty" yaml:"obtainItem,omitempty"`       // An itemGained event for this item ID occurred
+	Karma         *KarmaRange `json:"karma,omitempty" yaml:"karma,omitempty"`                 // The player's karma is within range
+}
+
+// Met reports whether the condition holds for a player at locationID with the given
+// karma after a turn that emitted turnEvents.
+func (c BeatCondition) Met(locationID string, karma int, turnEvents []events.Event) bool {
+	if c.ReachLocation != "" && c.ReachLocation != locationID {
+		return false
+	}
+	if !c.Karma.Contains(karma) {
+		return false
+	}
+	if c.ObtainItem != "" {
+		obtained := false
+		for _, e := range turnEvents {
+			if e.Type == events.ItemGained && e.Data["itemId"] == c.ObtainItem {
+				obtained = true
+				break
+			}
+		}
+		if !obtained {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsLocation reports whether the player may move to locationID while the beat is active.
+func (b *Beat) AllowsLocation(locationID string) bool {
+	return len(b.AllowedLocations) == 0 || containsString(b.AllowedLocations, locationID)
+}
+
+// Beat returns the beat at index, or nil once the scenario is finished.
+func (s *Scenario) Beat(index int) *Beat {
+	if index < 0 || index >= len(s.Beats) {
+		return nil
+	}
+	return &s.Beats[index]
+}
+
+// LoadScenarios reads every scenario in fsys and checks its references against ws.
+func LoadScenarios(fsys fs.FS, ws WorldSystem) (map[string]*Scenario, error) {
+	return loadContentDir(fsys, "scenario", ScenarioSchema, func(s *Scenario, fileID string) (string, error) {
+		if s.ID == "" {
+			s.ID = fileID
+		}
+		return s.ID, s.check(ws)
+	})
+}
+
+// loadContentDir decodes every content file in fsys against schema. prepare fills in
+// defaults (fileID is the ID implied by the file name), validates the item and returns its ID.
+func loadContentDir[T any](fsys fs.FS, kind string, schema *Schema, prepare func(item *T, fileID string) (string, error)) (map[string]*T, error) {
+	items := make(map[string]*T)
+	var loadErrors []error
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !IsContentFile(d.Name()) {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("failed to read %s file %s: %w", kind, p, err))
+			return nil
+		}
+		item := new(T)
+		if err := LoadContent(p, content, schema, item); err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("invalid %s: %w", kind, err))
+			return nil
+		}
+		id, err := prepare(item, contentID(d.Name()))
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+		if _, exists := items[id]; exists {
+			loadErrors = append(loadErrors, fmt.Errorf("duplicate %s ID '%s' found (from file %s)", kind, id, p))
+			return nil
+		}
+		items[id] = item
+		return nil
+	})
+	if err != nil {
+		loadErrors = append(loadErrors, fmt.Errorf("error walking %s directory: %w", kind, err))
+	}
+	if len(loadErrors) > 0 {
+		return nil, fmt.Errorf("errors during %s loading: %w", kind, errors.Join(loadErrors...))
+	}
+	return items, nil
+}
+
+// check verifies that every location a scenario references exists in ws.
+func (s *Scenario) check(ws WorldSystem) error {
+	var problems []error
+	exists := func(field, id string) {
+		if _, err := ws.GetLocation(id); err != nil {
+			problems = append(problems, fmt.Errorf("scenario '%s' %s references non-existent location ID '%s'", s.ID, field, id))
+		}
+	}
+	if s.StartLocationID != "" {
+		exists("startLocationId", s.StartLocationID)
+	}
+	for i, beat := range s.Beats {
+		for _, id := range beat.AllowedLocations {
+			exists(fmt.Sprintf("beats[%d].allowedLocations", i), id)
+		}
+		if id := beat.Complete.ReachLocation; id != "" {
+			exists(fmt.Sprintf("beats[%d].complete.reachLocation", i), id)
+			if !beat.AllowsLocation(id) {
+				problems = append(problems, fmt.Errorf("scenario '%s' beat '%s' can never complete: reachLocation '%s' is not in allowedLocations", s.ID, beat.ID, id))
+			}
+		}
+		if err := beat.Complete.Karma.check(); err != nil {
+			problems = append(problems, fmt.Errorf("scenario '%s' beat '%s' complete.karma: %w", s.ID, beat.ID, err))
+		}
+		if beat.Complete == (BeatCondition{}) {
+			problems = append(problems, fmt.Errorf("scenario '%s' beat '%s' has no completion condition", s.ID, beat.ID))
+		}
+	}
+	return errors.Join(problems...)
+}
@@ -0,0 +1,266 @@
+package world
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- Content Schemas ---
+// Content files are checked against JSON Schema definitions (schemas/*.schema.json)
+// before they are decoded, so authors see "file:line: field 'x': problem" instead of
+// a generic unmarshal error. Both JSON and YAML are validated via yaml.v3 nodes
+// (JSON is valid YAML), which carry line numbers.
+//
+// Only the subset of JSON Schema the content definitions use is supported:
+// type, required, properties, additionalProperties (bool or schema), items, enum, pattern,
+// minLength and minItems.
+
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// Schema is a JSON Schema definition for a content type.
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties,omitempty"` // false, or a schema for extra values
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+
+	pattern    *regexp.Regexp
+	closed     bool    // additionalProperties: false
+	additional *Schema // additionalProperties: {schema}
+}
+
+// Schemas for the built-in content types.
+var (
+	LocationSchema   = mustLoadSchema("location")
+	ThemeSchema      = mustLoadSchema("theme")
+	EntitySchema     = mustLoadSchema("entity") // Archive content without a dedicated schema
+	NPCSchema        = mustLoadSchema("npc")
+	WorldEventSchema = mustLoadSchema("worldevent")
+	ItemSchema       = mustLoadSchema("item")
+	AbilitySchema    = mustLoadSchema("ability")
+	PerkSchema       = mustLoadSchema("perk")
+	CreatureSchema   = mustLoadSchema("creature")
+	LootTableSchema  = mustLoadSchema("loot")
+	SurvivalSchema   = mustLoadSchema("survival")
+	ScenarioSchema   = mustLoadSchema("scenario")
+	EndingSchema     = mustLoadSchema("ending")
+)
+
+// mustLoadSchema reads an embedded schema; the files ship with the binary, so failure is a programming error.
+func mustLoadSchema(name string) *Schema {
+	content, err := schemaFiles.ReadFile("schemas/" + name + ".schema.json")
+	if err != nil {
+		panic(fmt.Sprintf("world: missing embedded schema %s: %v", name, err))
+	}
+	var schema Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		panic(fmt.Sprintf("world: invalid embedded schema %s: %v", name, err))
+	}
+	if err := schema.compile(); err != nil {
+		panic(fmt.Sprintf("world: invalid embedded schema %s: %v", name, err))
+	}
+	return &schema
+}
+
+// compile prepares patterns and additionalProperties throughout the schema.
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+	switch trimmed := strings.TrimSpace(string(s.AdditionalProperties)); trimmed {
+	case "", "true":
+	case "false":
+		s.closed = true
+	default:
+		s.additional = &Schema{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+			return fmt.Errorf("invalid additionalProperties: %w", err)
+		}
+		if err := s.additional.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// ValidateContent checks a JSON or YAML content file against schema.
+// Every violation is reported as a *ContentError (joined when there are several).
+func ValidateContent(name string, content []byte, schema *Schema) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		// Syntax errors are reported by the decoder, which knows the file format.
+		return DecodeContent(name, content, &struct{}{})
+	}
+	if len(doc.Content) == 0 {
+		return &ContentError{File: name, Message: "file is empty"}
+	}
+
+	var errs []error
+	schema.validate(doc.Content[0], "", func(node *yaml.Node, field, msg string) {
+		errs = append(errs, &ContentError{File: name, Line: node.Line, Field: field, Message: msg})
+	})
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// LoadContent validates a content file against schema (when non-nil) and decodes it into v.
+func LoadContent(name string, content []byte, schema *Schema, v interface{}) error {
+	if schema != nil {
+		if err := ValidateContent(name, content, schema); err != nil {
+			return err
+		}
+	}
+	return DecodeContent(name, content, v)
+}
+
+func (s *Schema) validate(node *yaml.Node, field string, report func(node *yaml.Node, field, msg string)) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	actual := nodeType(node)
+	if s.Type != "" && !typeMatches(s.Type, actual) {
+		report(node, field, fmt.Sprintf("expected %s, got %s", s.Type, actual))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := make(map[string]bool, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			seen[key.Value] = true
+			prop, known := s.Properties[key.Value]
+			if !known {
+				switch {
+				case s.closed:
+					report(key, joinField(field, key.Value), "unknown field"+suggestField(key.Value, s.Properties))
+				case s.additional != nil:
+					s.additional.validate(value, joinField(field, key.Value), report)
+				}
+				continue
+			}
+			prop.validate(value, joinField(field, key.Value), report)
+		}
+		for _, name := range s.Required {
+			if !seen[name] {
+				report(node, joinField(field, name), "missing required field")
+			}
+		}
+	case yaml.SequenceNode:
+		if s.MinItems > 0 && len(node.Content) < s.MinItems {
+			report(node, field, fmt.Sprintf("must have at least %d item(s)", s.MinItems))
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", field, i), report)
+			}
+		}
+	case yaml.ScalarNode:
+		if actual != "string" {
+			return
+		}
+		if s.MinLength > 0 && utf8.RuneCountInString(node.Value) < s.MinLength {
+			if s.MinLength == 1 {
+				report(node, field, "must not be empty")
+			} else {
+				report(node, field, fmt.Sprintf("must be at least %d characters", s.MinLength))
+			}
+		}
+		if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+			report(node, field, fmt.Sprintf("value %q does not match pattern %s", node.Value, s.Pattern))
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, node.Value) {
+			report(node, field, fmt.Sprintf("value %q must be one of: %s", node.Value, strings.Join(s.Enum, ", ")))
+		}
+	}
+}
+
+// nodeType maps a YAML node to its JSON Schema type name.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int":
+			return "integer"
+		case "!!float":
+			return "number"
+		case "!!bool":
+			return "boolean"
+		case "!!null":
+			return "null"
+		default:
+			return "string"
+		}
+	}
+	return "unknown"
+}
+
+func typeMatches(expected, actual string) bool {
+	return expected == actual || (expected == "number" && actual == "integer")
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// suggestField offers a known field name differing only in case (e.g. "adjacentIDs" -> "adjacentIds").
+func suggestField(name string, properties map[string]*Schema) string {
+	known := make([]string, 0, len(properties))
+	for k := range properties {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+	for _, k := range known {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprintf(" (did you mean '%s'?)", k)
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package world
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// --- World Statistics ---
+// A content audit for authors: how big the world is, how it's connected, and which
+// themes and images nothing uses.
+
+// WorldStats summarizes the loaded world.
+type WorldStats struct {
+	Locations       int            `json:"locations"`
+	Themes          int            `json:"themes"`
+	Edges           int            `json:"edges"`           // Exits, counting each direction separately
+	AverageDegree   float64        `json:"averageDegree"`   // Mean exits per location
+	ThemeUsage      map[string]int `json:"themeUsage"`      // Locations per theme ID, including themes nothing uses (0)
+	Unthemed        []string       `json:"unthemed"`        // Locations without a theme
+	OrphanedThemes  []string       `json:"orphanedThemes"`  // Themes no location uses
+	TagCounts       map[string]int `json:"tagCounts"`       // Locations per tag
+	Untagged        []string       `json:"untagged"`        // Locations without tags
+	MissingImages   []string       `json:"missingImages"`   // "location_id: image" for images not among the assets
+	OrphanedImages  []string       `json:"orphanedImages"`  // Image assets no location references
+	AssetsAvailable bool           `json:"assetsAvailable"` // False when no asset directory was given, so image checks were skipped
+}
+
+// imageExtensions are the asset file types counted as location art.
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true}
+
+// ComputeStats audits ws. assets, when non-nil, is the static asset tree location
+// ImageIDs are served from; without it the image checks are skipped.
+func ComputeStats(ws WorldSystem, assets fs.FS) *WorldStats {
+	ids := ws.GetAllLocationIDs()
+	sort.Strings(ids)
+	themeIDs := ws.GetAllThemeIDs()
+	sort.Strings(themeIDs)
+
+	stats := &WorldStats{
+		Themes:         len(themeIDs),
+		ThemeUsage:     make(map[string]int, len(themeIDs)),
+		Unthemed:       []string{},
+		OrphanedThemes: []string{},
+		TagCounts:      make(map[string]int),
+		Untagged:       []string{},
+		MissingImages:  []string{},
+		OrphanedImages: []string{},
+	}
+	for _, id := range themeIDs {
+		stats.ThemeUsage[id] = 0
+	}
+
+	referenced := make(map[string][]string) // Image path -> locations using it
+	for _, id := range ids {
+		loc, err := ws.GetLocation(id)
+		if err != nil {
+			continue
+		}
+		stats.Locations++
+		stats.Edges += len(loc.AdjacentIDs)
+		if loc.ThemeID == "" {
+			stats.Unthemed = append(stats.Unthemed, loc.ID)
+		} else {
+			stats.ThemeUsage[loc.ThemeID]++
+		}
+		if len(loc.Tags) == 0 {
+			stats.Untagged = append(stats.Untagged, loc.ID)
+		}
+		for _, tag := range loc.Tags {
+			stats.TagCounts[tag]++
+		}
+		if loc.ImageID != "" {
+			image := path.Clean(strings.TrimPrefix(loc.ImageID, "/"))
+			referenced[image] = append(referenced[image], loc.ID)
+		}
+	}
+	if stats.Locations > 0 {
+		stats.AverageDegree = float64(stats.Edges) / float64(stats.Locations)
+	}
+	for _, id := range themeIDs {
+		if stats.ThemeUsage[id] == 0 {
+			stats.OrphanedThemes = append(stats.OrphanedThemes, id)
+		}
+	}
+
+	if assets == nil {
+		return stats
+	}
+	stats.AssetsAvailable = true
+	present := make(map[string]bool)
+	fs.WalkDir(assets, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil // Skip unreadable entries rather than abandoning the audit
+		}
+		present[p] = true
+		if imageExtensions[strings.ToLower(path.Ext(p))] && referenced[p] == nil {
+			stats.OrphanedImages = append(stats.OrphanedImages, p)
+		}
+		return nil
+	})
+	for image, locs := range referenced {
+		if !present[image] {
+			for _, id := range locs {
+				stats.MissingImages = append(stats.MissingImages, id+": "+image)
+			}
+		}
+	}
+	sort.Strings(stats.OrphanedImages)
+	sort.Strings(stats.MissingImages)
+	return stats
+}
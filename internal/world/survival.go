@@ -0,0 +1,140 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+)
+
+// --- Survival ---
+// Worlds may opt into a survival ruleset: resources such as food, water and light
+// that run down every turn, warn the narrator as they get low and hurt the player
+// once exhausted. Items restore them (see Item.Restores). Every number is data.
+
+// SurvivalRules is a world's survival ruleset.
+type SurvivalRules struct {
+	Resources []SurvivalResource `json:"resources" yaml:"resources"`
+}
+
+// SurvivalResource is one tracked resource.
+type SurvivalResource struct {
+	ID           string              `json:"id" yaml:"id"`
+	Name         string              `json:"name" yaml:"name"`
+	Max          int                 `json:"max" yaml:"max"`                                       // Level at the start and the most items can restore
+	DrainPerTurn int                 `json:"drainPerTurn" yaml:"drainPerTurn"`                     // Lost at the start of every turn
+	DrainPerRest int                 `json:"drainPerRest,omitempty" yaml:"drainPerRest,omitempty"` // Extra loss for each rest
+	OnlyTags     []string            `json:"onlyTags,omitempty" yaml:"onlyTags,omitempty"`         // Drains per turn only at locations with one of these tags (e.g. light in "dark" places)
+	Warnings     []SurvivalThreshold `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Depleted     SurvivalConsequence `json:"depleted" yaml:"depleted"`
+}
+
+// SurvivalThreshold is a warning shown to the narrator while a resource is at or below At.
+// The lowest matching threshold applies.
+type SurvivalThreshold struct {
+	At      int    `json:"at" yaml:"at"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// SurvivalConsequence is what happens each turn a resource is at 0.
+type SurvivalConsequence struct {
+	Damage  int    `json:"damage,omitempty" yaml:"damage,omitempty"` // Hit points lost per turn
+	Effect  string `json:"effect,omitempty" yaml:"effect,omitempty"` // Status effect applied until the resource is restored
+	Message string `json:"message" yaml:"message"`                   // Told to the narrator
+}
+
+// Resource returns the resource with the given ID, or nil.
+func (r *SurvivalRules) Resource(id string) *SurvivalResource {
+	for i := range r.Resources {
+		if r.Resources[i].ID == id {
+			return &r.Resources[i]
+		}
+	}
+	return nil
+}
+
+// DrainsAt reports whether the resource drains per turn at a location with tags.
+func (res *SurvivalResource) DrainsAt(tags []string) bool {
+	if len(res.OnlyTags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if slices.Contains(res.OnlyTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Warning returns the message of the lowest threshold level is at or below, or "".
+func (res *SurvivalResource) Warning(level int) string {
+	message, lowest := "", 0
+	for _, w := range res.Warnings {
+		if level <= w.At && (message == "" || w.At < lowest) {
+			message, lowest = w.Message, w.At
+		}
+	}
+	return message
+}
+
+// validate checks the ruleset's numbers and that items only restore its resources.
+func (r *SurvivalRules) validate(items map[string]*Item) error {
+	var problems []error
+	seen := make(map[string]bool)
+	for i, res := range r.Resources {
+		if seen[res.ID] {
+			problems = append(problems, fmt.Errorf("resources[%d]: duplicate resource ID '%s'", i, res.ID))
+		}
+		seen[res.ID] = true
+		if res.Max < 1 || res.DrainPerTurn < 0 || res.DrainPerRest < 0 || res.Depleted.Damage < 0 {
+			problems = append(problems, fmt.Errorf("resource '%s': max must be at least 1 and drains and damage must not be negative", res.ID))
+		}
+	}
+	for id, item := range items {
+		for resID, amount := range item.Restores {
+			if !seen[resID] {
+				problems = append(problems, fmt.Errorf("item '%s' restores '%s', which is not a survival resource", id, resID))
+			} else if amount < 1 {
+				problems = append(problems, fmt.Errorf("item '%s' must restore at least 1 %s", id, resID))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// LoadSurvivalRules reads a survival ruleset from a JSON or YAML file.
+func LoadSurvivalRules(name string, content []byte, items map[string]*Item) (*SurvivalRules, error) {
+	rules := &SurvivalRules{}
+	if err := LoadContent(name, content, SurvivalSchema, rules); err != nil {
+		return nil, fmt.Errorf("invalid survival rules: %w", err)
+	}
+	if err := rules.validate(items); err != nil {
+		return nil, fmt.Errorf("invalid survival rules %s: %w", name, err)
+	}
+	return rules, nil
+}
+
+// LoadSurvivalRulesFile reads a survival ruleset from a file on disk.
+func LoadSurvivalRulesFile(path string, items map[string]*Item) (*SurvivalRules, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read survival rules: %w", err)
+	}
+	return LoadSurvivalRules(path, content, items)
+}
+
+// Survival loads the archive's survival ruleset, or returns nil if it has none.
+func (a *Archive) Survival(items map[string]*Item) (*SurvivalRules, error) {
+	for _, name := range ArchiveSurvivalFiles {
+		content, err := fs.ReadFile(a.FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in world archive: %w", name, err)
+		}
+		return LoadSurvivalRules(name, content, items)
+	}
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+package world
+
+import (
+	"fmt"
+	"slices"
+)
+
+// --- Travel ---
+// Routes annotate a location's exits with the in-story time they take and the mount
+// or vehicle they require. Mounts are items the player boards (a horse, a boat).
+
+// Route describes travel along one of a location's exits. Exits without a route take
+// no time and need no mount.
+type Route struct {
+	To       string `json:"to" yaml:"to"`                                 // Adjacent location ID
+	Hours    int    `json:"hours,omitempty" yaml:"hours,omitempty"`       // In-story hours on foot
+	Requires string `json:"requires,omitempty" yaml:"requires,omitempty"` // Mount kind needed, e.g. "boat"
+}
+
+// Mount makes an item something the player can board.
+type Mount struct {
+	Kind   string `json:"kind" yaml:"kind"`                         // Matched against Route.Requires, e.g. "horse" or "boat"
+	Travel int    `json:"travel,omitempty" yaml:"travel,omitempty"` // Percent of on-foot travel time (default 100)
+}
+
+// Route returns loc's route to toID, or nil if the exit has none.
+func (loc *LocationNode) Route(toID string) *Route {
+	for i := range loc.Routes {
+		if loc.Routes[i].To == toID {
+			return &loc.Routes[i]
+		}
+	}
+	return nil
+}
+
+// dropRoute removes loc's route to toID, e.g. when the exit is removed.
+func (loc *LocationNode) dropRoute(toID string) {
+	loc.Routes = slices.DeleteFunc(loc.Routes, func(r Route) bool { return r.To == toID })
+}
+
+// TravelHours is how long the route takes riding mount (nil = on foot). A ride that
+// takes any time at all takes at least an hour.
+func (r *Route) TravelHours(mount *Mount) int {
+	if mount == nil || mount.Travel == 0 || r.Hours == 0 {
+		return r.Hours
+	}
+	return max(r.Hours*mount.Travel/100, 1)
+}
+
+// checkRoutes reports routes that don't follow one of loc's exits.
+func checkRoutes(loc *LocationNode) error {
+	seen := make(map[string]bool, len(loc.Routes))
+	for _, route := range loc.Routes {
+		if !containsString(loc.AdjacentIDs, route.To) {
+			return fmt.Errorf("location '%s' has a route to '%s', which is not one of its adjacentIds", loc.ID, route.To)
+		}
+		if seen[route.To] {
+			return fmt.Errorf("location '%s' has more than one route to '%s'", loc.ID, route.To)
+		}
+		seen[route.To] = true
+		if route.Hours < 0 {
+			return fmt.Errorf("location '%s' route to '%s' hours must not be negative", loc.ID, route.To)
+		}
+	}
+	return nil
+}
@@ -1,83 +1,115 @@
 package world
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 )
 
 // LocationNode remains the same - it stores the ThemeID string
 type LocationNode struct {
-	ID             string                 `json:"id"`
-	Name           string                 `json:"name"`
-	Description    string                 `json:"description"`
-	AdjacentIDs    []string               `json:"adjacentIds,omitempty"`
-	Tags           []string               `json:"tags,omitempty"`
-	ImageID        string                 `json:"imageId,omitempty"`
-	ThemeID        string                 `json:"themeId,omitempty"` // This ID is sent to the frontend
-	Attributes     map[string]interface{} `json:"attributes,omitempty"`
+	ID             string                 `json:"id" yaml:"id"`
+	Name           string                 `json:"name" yaml:"name"`
+	Description    string                 `json:"description" yaml:"description"`
+	AdjacentIDs    []string               `json:"adjacentIds,omitempty" yaml:"adjacentIds,omitempty"`
+	Tags           []string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ImageID        string                 `json:"imageId,omitempty" yaml:"imageId,omitempty"`
+	ThemeID        string                 `json:"themeId,omitempty" yaml:"themeId,omitempty"` // This ID is sent to the frontend
+	Attributes     map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	AllowedActions []string               `json:"allowedActions,omitempty" yaml:"allowedActions,omitempty"` // Optional allow-list of action types legal here (nil = world default)
+	LootTable      string                 `json:"lootTable,omitempty" yaml:"lootTable,omitempty"`           // Loot table rolled when the player searches here (see LootTable)
+	Encounters     *EncounterTable        `json:"encounters,omitempty" yaml:"encounters,omitempty"`         // Random encounters, e.g. while resting here
+	Routes         []Route                `json:"routes,omitempty" yaml:"routes,omitempty"`                 // Travel time and required mounts for some exits (see Route)
+	Containers     []Container            `json:"containers,omitempty" yaml:"containers,omitempty"`         // Item stashes the player can take from and put into
+	Interactions   []Interaction          `json:"interactions,omitempty" yaml:"interactions,omitempty"`     // Location-specific things the player can do (see Interaction)
 }
 
-// ThemeDefinition can be simplified. Its primary purpose in the backend
-// is now potentially just validating that a theme ID exists.
-// We might not even need to store much beyond the ID itself.
-// Keeping Name for potential display/debugging. [Original definition: cite: 112-113]
+// ThemeDefinition identifies a theme and carries optional presentation metadata.
+// The backend doesn't render anything itself; it validates the metadata at load
+// (see schemas/theme.schema.json) so frontends have one authoritative source.
 type ThemeDefinition struct {
-	ID   string `json:"id"`   // Ensure JSON 'id' matches filename/key
-	Name string `json:"name"` // Optional: Useful for debugging/listing
-	// CSSClass string `json:"cssClass"` // REMOVED from backend responsibility
-	// Palette map[string]string `json:"palette,omitempty"` // REMOVED
+	ID             string            `json:"id" yaml:"id"`                                             // Ensure JSON 'id' matches filename/key
+	Name           string            `json:"name" yaml:"name"`                                         // Optional: Useful for debugging/listing
+	Palette        map[string]string `json:"palette,omitempty" yaml:"palette,omitempty"`               // Named colours, e.g. {"background": "#2b2118"}
+	AmbientAudioID string            `json:"ambientAudioId,omitempty" yaml:"ambientAudioId,omitempty"` // Looping background audio, served from /assets
+	Fonts          *ThemeFonts       `json:"fonts,omitempty" yaml:"fonts,omitempty"`                   // Font hints
+}
+
+// ThemeFonts are font-family hints for frontends (CSS font-family syntax).
+type ThemeFonts struct {
+	Heading string `json:"heading,omitempty" yaml:"heading,omitempty"`
+	Body    string `json:"body,omitempty" yaml:"body,omitempty"`
 }
 
 // WorldSystem interface remains largely the same, but GetTheme might be less critical
 // or just return the ThemeDefinition struct (which is now simpler).
 type WorldSystem interface {
-	LoadWorldData(locationDir, themeDir string) error
+	LoadWorldData(locationFS, themeFS fs.FS) error
 	GetLocation(locationID string) (*LocationNode, error)
 	GetTheme(themeID string) (*ThemeDefinition, error)
 	IsAdjacent(currentLocationID, targetLocationID string) (bool, error)
 	GetAllLocationIDs() []string
 	GetAllThemeIDs() []string
 	ValidateThemeExists(themeID string) bool
-    GetAdjacentLocations(locationID string) ([]*LocationNode, error) 
+	GetAdjacentLocations(locationID string) ([]*LocationNode, error)
 }
+
+// Lookup errors. Both also match ErrNotFound.
+var (
+	ErrLocationNotFound = fmt.Errorf("location %w", ErrNotFound)
+	ErrThemeNotFound    = fmt.Errorf("theme %w", ErrNotFound)
+)
+
+// ErrNotAdjacent is returned for a move between locations that aren't connected.
+var ErrNotAdjacent = errors.New("location is not adjacent")
+
 // InMemoryWorldSystem holds loaded world data.
 type InMemoryWorldSystem struct {
 	locations map[string]*LocationNode
 	themes    map[string]*ThemeDefinition // Stores the simplified ThemeDefinition
+	index     locationIndex               // Adjacency and tag lookups, rebuilt whenever locations change (see index.go)
 	mu        sync.RWMutex
+
+	// Editing support (see editor.go)
+	locationSources map[string]string // Location ID -> file path it was loaded from
+	themeSources    map[string]string // Theme ID -> file path it was loaded from
+	store           ContentStore      // Optional; persists runtime edits
 }
 
 // NewInMemoryWorldSystem creates a new, empty world system.
 func NewInMemoryWorldSystem() *InMemoryWorldSystem {
 	return &InMemoryWorldSystem{
-		locations: make(map[string]*LocationNode),
-		themes:    make(map[string]*ThemeDefinition),
+		locations:       make(map[string]*LocationNode),
+		themes:          make(map[string]*ThemeDefinition),
+		locationSources: make(map[string]string),
+		themeSources:    make(map[string]string),
 	}
 }
 
-// LoadWorldData reads location and theme definitions.
-func (ws *InMemoryWorldSystem) LoadWorldData(locationDir, themeDir string) error {
+// LoadWorldData reads location and theme definitions from file systems rooted at
+// the location and theme directories (e.g. os.DirFS, fs.Sub of an embed.FS, a zip.Reader,
+// or an fstest.MapFS), so the loading logic doesn't care where the data lives.
+func (ws *InMemoryWorldSystem) LoadWorldData(locationFS, themeFS fs.FS) error {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
 	ws.locations = make(map[string]*LocationNode)
 	ws.themes = make(map[string]*ThemeDefinition)
+	ws.locationSources = make(map[string]string)
+	ws.themeSources = make(map[string]string)
 
 	var loadErrors []error
 
 	// --- Load Themes First (so locations can reference them) ---
-	fmt.Printf("Loading themes from: %s\n", themeDir)
-	err := filepath.WalkDir(themeDir, func(path string, d fs.DirEntry, err error) error {
-		// ... (error handling as before) ...
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
-            fmt.Printf("  Processing theme file: %s\n", d.Name())
-			content, err := os.ReadFile(path)
+	fmt.Println("Loading themes...")
+	err := fs.WalkDir(themeFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err // Unreadable directory; reported below
+		}
+		if !d.IsDir() && IsContentFile(d.Name()) {
+			fmt.Printf("  Processing theme file: %s\n", d.Name())
+			content, err := fs.ReadFile(themeFS, path)
 			if err != nil {
 				loadErrors = append(loadErrors, fmt.Errorf("failed to read theme file %s: %w", d.Name(), err))
 				return nil
@@ -85,14 +117,14 @@ func (ws *InMemoryWorldSystem) LoadWorldData(locationDir, themeDir string) error
 			// ... (error handling) ...
 
 			var theme ThemeDefinition // Use the simplified struct
-			if err := json.Unmarshal(content, &theme); err != nil {
-                loadErrors = append(loadErrors, fmt.Errorf("failed to parse theme JSON %s: %w", d.Name(), err))
+			if err := LoadContent(path, content, ThemeSchema, &theme); err != nil {
+				loadErrors = append(loadErrors, fmt.Errorf("invalid theme: %w", err))
 				return nil
 			}
 
 			if theme.ID == "" {
-				theme.ID = strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
-                fmt.Printf("    Warning: Theme file %s missing 'id' field, using filename '%s' as ID.\n", d.Name(), theme.ID)
+				theme.ID = contentID(d.Name())
+				fmt.Printf("    Warning: Theme file %s missing 'id' field, using filename '%s' as ID.\n", d.Name(), theme.ID)
 			}
 
 			if _, exists := ws.themes[theme.ID]; exists {
@@ -100,22 +132,24 @@ func (ws *InMemoryWorldSystem) LoadWorldData(locationDir, themeDir string) error
 				return nil
 			}
 			ws.themes[theme.ID] = &theme // Store the simplified theme definition
-            fmt.Printf("    Loaded theme definition: %s (%s)\n", theme.Name, theme.ID)
+			ws.themeSources[theme.ID] = path
+			fmt.Printf("    Loaded theme definition: %s (%s)\n", theme.Name, theme.ID)
 		}
 		return nil
 	})
-    if err != nil {
-		loadErrors = append(loadErrors, fmt.Errorf("error walking theme directory %s: %w", themeDir, err))
+	if err != nil {
+		loadErrors = append(loadErrors, fmt.Errorf("error walking theme directory: %w", err))
 	}
 
-
 	// --- Load Locations ---
-	fmt.Printf("Loading locations from: %s\n", locationDir)
-	err = filepath.WalkDir(locationDir, func(path string, d fs.DirEntry, err error) error {
-		// ... (error handling as before) ...
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
-            fmt.Printf("  Processing location file: %s\n", d.Name())
-			content, err := os.ReadFile(path)
+	fmt.Println("Loading locations...")
+	err = fs.WalkDir(locationFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err // Unreadable directory; reported below
+		}
+		if !d.IsDir() && IsContentFile(d.Name()) {
+			fmt.Printf("  Processing location file: %s\n", d.Name())
+			content, err := fs.ReadFile(locationFS, path)
 			if err != nil {
 				loadErrors = append(loadErrors, fmt.Errorf("failed to read location file %s: %w", d.Name(), err))
 				return nil
@@ -123,41 +157,41 @@ func (ws *InMemoryWorldSystem) LoadWorldData(locationDir, themeDir string) error
 			// ... (error handling) ...
 
 			var loc LocationNode
-			if err := json.Unmarshal(content, &loc); err != nil {
-                loadErrors = append(loadErrors, fmt.Errorf("failed to parse location JSON %s: %w", d.Name(), err))
+			if err := LoadContent(path, content, LocationSchema, &loc); err != nil {
+				loadErrors = append(loadErrors, fmt.Errorf("invalid location: %w", err))
 				return nil
 			}
 
-            if loc.ID == "" {
-                loc.ID = strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
-                fmt.Printf("    Warning: Location file %s missing 'id' field, using filename '%s' as ID.\n", d.Name(), loc.ID)
-            }
+			if loc.ID == "" {
+				loc.ID = contentID(d.Name())
+				fmt.Printf("    Warning: Location file %s missing 'id' field, using filename '%s' as ID.\n", d.Name(), loc.ID)
+			}
 
 			if _, exists := ws.locations[loc.ID]; exists {
 				loadErrors = append(loadErrors, fmt.Errorf("duplicate location ID '%s' found (from file %s)", loc.ID, d.Name()))
 				return nil
 			}
 
-            // *** Validate ThemeID before adding location ***
-            if loc.ThemeID != "" {
-                if _, themeExists := ws.themes[loc.ThemeID]; !themeExists {
-                    loadErrors = append(loadErrors, fmt.Errorf("location '%s' (%s) references non-existent theme ID '%s'", loc.Name, loc.ID, loc.ThemeID))
-                    // Decide: skip location, use default theme, or allow load? Forcing validation is safer.
-                    return nil // Skip loading this location if theme invalid
-                }
-            } else {
-                 fmt.Printf("    Warning: Location '%s' (%s) has no ThemeID defined.\n", loc.Name, loc.ID)
-                 // Assign a default theme ID? Or allow empty?
-            }
-
+			// *** Validate ThemeID before adding location ***
+			if loc.ThemeID != "" {
+				if _, themeExists := ws.themes[loc.ThemeID]; !themeExists {
+					loadErrors = append(loadErrors, fmt.Errorf("location '%s' (%s) references non-existent theme ID '%s'", loc.Name, loc.ID, loc.ThemeID))
+					// Decide: skip location, use default theme, or allow load? Forcing validation is safer.
+					return nil // Skip loading this location if theme invalid
+				}
+			} else {
+				fmt.Printf("    Warning: Location '%s' (%s) has no ThemeID defined.\n", loc.Name, loc.ID)
+				// Assign a default theme ID? Or allow empty?
+			}
 
 			ws.locations[loc.ID] = &loc
-            fmt.Printf("    Loaded location: %s (%s) with Theme: '%s'\n", loc.Name, loc.ID, loc.ThemeID)
+			ws.locationSources[loc.ID] = path
+			fmt.Printf("    Loaded location: %s (%s) with Theme: '%s'\n", loc.Name, loc.ID, loc.ThemeID)
 		}
 		return nil
 	})
-    if err != nil {
-		loadErrors = append(loadErrors, fmt.Errorf("error walking location directory %s: %w", locationDir, err))
+	if err != nil {
+		loadErrors = append(loadErrors, fmt.Errorf("error walking location directory: %w", err))
 	}
 
 	// --- Post-Load Validation (Adjacency checks) ---
@@ -167,54 +201,70 @@ func (ws *InMemoryWorldSystem) LoadWorldData(locationDir, themeDir string) error
 				loadErrors = append(loadErrors, fmt.Errorf("location '%s' (%s) references non-existent adjacent location ID '%s'", loc.Name, loc.ID, adjID))
 			}
 		}
+		if err := checkRoutes(loc); err != nil {
+			loadErrors = append(loadErrors, err)
+		}
+		if err := checkContainers(loc); err != nil {
+			loadErrors = append(loadErrors, err)
+		}
+		if err := checkInteractions(loc); err != nil {
+			loadErrors = append(loadErrors, err)
+		}
+		if err := checkInteractionExits(loc, func(id string) bool { _, ok := ws.locations[id]; return ok }); err != nil {
+			loadErrors = append(loadErrors, err)
+		}
 	}
 
+	ws.reindex()
+
 	fmt.Printf("World data loading finished. Locations: %d, Themes: %d\n", len(ws.locations), len(ws.themes))
 
 	if len(loadErrors) > 0 {
-        // ... (error reporting as before) ...
-		return errors.New("errors during world data loading")
+		for _, loadErr := range loadErrors {
+			fmt.Printf("  Error: %v\n", loadErr)
+		}
+		return fmt.Errorf("errors during world data loading: %w", errors.Join(loadErrors...))
 	}
 
 	return nil
 }
 
-
-// GetLocation remains the same
+// GetLocation returns a copy of a location; changing it doesn't change the world
+// (use the Editor, e.g. UpdateLocation, for that).
 func (ws *InMemoryWorldSystem) GetLocation(locationID string) (*LocationNode, error) {
 	ws.mu.RLock()
 	defer ws.mu.RUnlock()
 	loc, ok := ws.locations[locationID]
 	if !ok {
-		return nil, fmt.Errorf("location with ID '%s' not found", locationID)
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, locationID)
 	}
-	return loc, nil
+	return loc.Clone(), nil
 }
 
-// GetTheme returns the simplified theme definition (mainly for backend use now).
+// GetTheme returns a copy of the simplified theme definition (mainly for backend use now).
 func (ws *InMemoryWorldSystem) GetTheme(themeID string) (*ThemeDefinition, error) {
 	ws.mu.RLock()
 	defer ws.mu.RUnlock()
 	theme, ok := ws.themes[themeID]
 	if !ok {
-		return nil, fmt.Errorf("theme definition with ID '%s' not found", themeID)
+		return nil, fmt.Errorf("%w: '%s'", ErrThemeNotFound, themeID)
 	}
-	return theme, nil
+	return theme.Clone(), nil
 }
 
 // IsAdjacent remains the same
 func (ws *InMemoryWorldSystem) IsAdjacent(currentLocationID, targetLocationID string) (bool, error) {
-    // ... (implementation as before) ...
+	// ... (implementation as before) ...
 	ws.mu.RLock()
 	defer ws.mu.RUnlock()
 
 	currentLoc, ok := ws.locations[currentLocationID]
 	if !ok {
-		return false, fmt.Errorf("current location with ID '%s' not found", currentLocationID)
+		return false, fmt.Errorf("current %w: '%s'", ErrLocationNotFound, currentLocationID)
 	}
 
 	if _, ok := ws.locations[targetLocationID]; !ok {
-		return false, fmt.Errorf("target location with ID '%s' not found", targetLocationID)
+		return false, fmt.Errorf("target %w: '%s'", ErrLocationNotFound, targetLocationID)
 	}
 
 	for _, adjID := range currentLoc.AdjacentIDs {
@@ -225,7 +275,6 @@ func (ws *InMemoryWorldSystem) IsAdjacent(currentLocationID, targetLocationID st
 	return false, nil
 }
 
-
 // GetAllLocationIDs remains the same
 func (ws *InMemoryWorldSystem) GetAllLocationIDs() []string {
 	// ... (implementation as before) ...
@@ -238,7 +287,6 @@ func (ws *InMemoryWorldSystem) GetAllLocationIDs() []string {
 	return ids
 }
 
-
 // GetAllThemeIDs remains the same
 func (ws *InMemoryWorldSystem) GetAllThemeIDs() []string {
 	// ... (implementation as before) ...
@@ -251,36 +299,21 @@ func (ws *InMemoryWorldSystem) GetAllThemeIDs() []string {
 	return ids
 }
 
-
 // ValidateThemeExists checks if a theme ID is known to the system.
 func (ws *InMemoryWorldSystem) ValidateThemeExists(themeID string) bool {
-    ws.mu.RLock()
-    defer ws.mu.RUnlock()
-    _, exists := ws.themes[themeID]
-    return exists
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	_, exists := ws.themes[themeID]
+	return exists
 }
 
+// GetAdjacentLocations returns copies of the locations adjacent to locationID, from
+// the adjacency index.
 func (ws *InMemoryWorldSystem) GetAdjacentLocations(locationID string) ([]*LocationNode, error) {
-	currentLoc, err := ws.GetLocation(locationID) // Use the interface method GetLocation
-	if err != nil {
-		return nil, err // Location doesn't exist
-	}
-
-	adjacent := []*LocationNode{}
-	ws.mu.RLock() // Lock for reading map
+	ws.mu.RLock()
 	defer ws.mu.RUnlock()
-
-	for _, adjID := range currentLoc.AdjacentIDs {
-		// Use internal map access here for efficiency since we have the lock,
-        // or call ws.GetLocation again (which handles locking itself).
-        // Calling GetLocation is cleaner but involves repeated locking. Let's use direct access.
-		if loc, ok := ws.locations[adjID]; ok {
-			adjacent = append(adjacent, loc)
-		} else {
-			// This case should ideally be caught during LoadWorldData validation
-			fmt.Printf("Warning: Adjacency check found reference to non-existent location ID '%s' from '%s'.\n", adjID, locationID)
-		}
+	if _, ok := ws.locations[locationID]; !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrLocationNotFound, locationID)
 	}
-	return adjacent, nil
+	return cloneLocations(ws.index.adjacent[locationID]), nil
 }
-
@@ -0,0 +1,207 @@
+package world
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// Benchmarks of the world queries a turn goes through, against a generated grid world
+// (see generateGrid), so results are comparable across machines and commits:
+//
+//	go test ./internal/world -run '^$' -bench .
+//	go test ./internal/world -run '^$' -bench Adjacent -bench.locations 50000
+
+var benchLocations = flag.Int("bench.locations", 10000, "Locations in the generated benchmark world")
+
+// benchThemeCount and benchTags are spread over generated locations round-robin.
+const benchThemeCount = 8
+
+var benchTags = []string{"town", "wilderness", "road", "interior", "water", "ruins"}
+
+var (
+	benchOnce             sync.Once
+	benchLocFS, benchThFS fstest.MapFS
+	benchWS               *InMemoryWorldSystem
+	benchIDs              []string // Formatted up front, so the loops only time the queries
+)
+
+// benchWorld generates and loads the benchmark world once per run.
+func benchWorld(b *testing.B) (*InMemoryWorldSystem, []string) {
+	benchOnce.Do(func() {
+		defer quiet(b)()
+		n := *benchLocations
+		benchLocFS, benchThFS = generateGrid(n)
+		benchWS = NewInMemoryWorldSystem()
+		if err := benchWS.LoadWorldData(benchLocFS, benchThFS); err != nil {
+			b.Fatalf("Failed to load generated world: %v", err)
+		}
+		benchIDs = make([]string, n)
+		for i := range benchIDs {
+			benchIDs[i] = benchLocationID(i)
+		}
+	})
+	if benchWS == nil {
+		b.Fatal("generated world failed to load")
+	}
+	return benchWS, benchIDs
+}
+
+// quiet sends stdout, where loading logs every file, to the null device until the
+// returned function is called.
+func quiet(b *testing.B) func() {
+	out := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = out
+		devNull.Close()
+	}
+}
+
+func BenchmarkLoadWorldData(b *testing.B) {
+	benchWorld(b)
+	defer quiet(b)()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewInMemoryWorldSystem().LoadWorldData(benchLocFS, benchThFS); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetLocation(b *testing.B) {
+	ws, ids := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ws.GetLocation(ids[i%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAdjacentLocations(b *testing.B) {
+	ws, ids := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ws.GetAdjacentLocations(ids[i%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAdjacentLocationsParallel(b *testing.B) {
+	ws, ids := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := ws.GetAdjacentLocations(ids[i%len(ids)]); err != nil {
+				b.Error(err) // Fatal must not be called from RunParallel's goroutines
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkIsAdjacent(b *testing.B) {
+	ws, ids := benchWorld(b)
+	n := len(ids)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ws.IsAdjacent(ids[n/2], ids[(n/2+1)%n]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLocationsWithTag(b *testing.B) {
+	ws, _ := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if locs := LocationsWithTag(ws, benchTags[i%len(benchTags)]); len(locs) == 0 {
+			b.Fatal("no tagged locations")
+		}
+	}
+}
+
+func BenchmarkDistances(b *testing.B) {
+	ws, ids := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if d := Distances(ws, ids[len(ids)/2]); len(d) == 0 {
+			b.Fatal("no distances")
+		}
+	}
+}
+
+func BenchmarkBuildGraph(b *testing.B) {
+	ws, ids := benchWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if g := BuildGraph(ws, ids[0]); len(g.Unreachable) != 0 {
+			b.Fatalf("%d unreachable locations", len(g.Unreachable))
+		}
+	}
+}
+
+// generateGrid builds a square grid of n locations, each connected to its neighbours
+// in both directions, as location and theme file systems.
+func generateGrid(n int) (locFS, themeFS fstest.MapFS) {
+	locFS, themeFS = fstest.MapFS{}, fstest.MapFS{}
+	for t := 0; t < benchThemeCount; t++ {
+		id := fmt.Sprintf("theme_%d", t)
+		themeFS[id+".json"] = &fstest.MapFile{Data: mustJSON(ThemeDefinition{ID: id, Name: fmt.Sprintf("Theme %d", t)})}
+	}
+
+	width := int(math.Ceil(math.Sqrt(float64(n))))
+	for i := 0; i < n; i++ {
+		var adjacent []string
+		x, y := i%width, i/width
+		for _, neighbour := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			nx, ny := neighbour[0], neighbour[1]
+			if j := ny*width + nx; nx >= 0 && nx < width && ny >= 0 && j < n {
+				adjacent = append(adjacent, benchLocationID(j))
+			}
+		}
+		loc := LocationNode{
+			ID:          benchLocationID(i),
+			Name:        fmt.Sprintf("Location %d", i),
+			Description: fmt.Sprintf("Grid square %d,%d of a generated benchmark world.", x, y),
+			AdjacentIDs: adjacent,
+			Tags:        []string{benchTags[i%len(benchTags)], benchTags[(i/len(benchTags))%len(benchTags)]},
+			ThemeID:     fmt.Sprintf("theme_%d", i%benchThemeCount),
+		}
+		locFS[loc.ID+".json"] = &fstest.MapFile{Data: mustJSON(loc)}
+	}
+	return locFS, themeFS
+}
+
+func benchLocationID(i int) string {
+	return fmt.Sprintf("loc_%05d", i)
+}
+
+func mustJSON(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
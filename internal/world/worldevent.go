@@ -0,0 +1,112 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// --- World Events ---
+// World events are happenings on the world's own schedule: a caravan arriving on day 3,
+// a festival every 7 days, a storm some days, a raid once a flag is set. Each session
+// runs the schedule against its own clock and flags, and while an event is active it
+// overlays the locations it affects (a note for the narrator, closed exits).
+
+// WorldEvent is an authored event and when it happens.
+type WorldEvent struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"` // Told to the narrator at affected locations while the event is active
+	Locations   []string          `json:"locations,omitempty" yaml:"locations,omitempty"`     // Affected location IDs (empty = everywhere)
+	Trigger     WorldEventTrigger `json:"trigger" yaml:"trigger"`
+	Duration    int               `json:"duration,omitempty" yaml:"duration,omitempty"`       // Hours the event lasts (default 24)
+	SetFlags    []string          `json:"setFlags,omitempty" yaml:"setFlags,omitempty"`       // Flags set when the event starts
+	ClosedExits []string          `json:"closedExits,omitempty" yaml:"closedExits,omitempty"` // Location IDs that can't be reached from affected locations while active
+}
+
+// WorldEventTrigger says when a world event starts. Exactly one of Day, Chance and Flag is set.
+type WorldEventTrigger struct {
+	Day    int    `json:"day,omitempty" yaml:"day,omitempty"`       // Day of the playthrough it starts on (1 = the first)
+	Every  int    `json:"every,omitempty" yaml:"every,omitempty"`   // With Day: repeats every this many days
+	Chance int    `json:"chance,omitempty" yaml:"chance,omitempty"` // Percent chance each day (random events)
+	Hour   int    `json:"hour,omitempty" yaml:"hour,omitempty"`     // With Day or Chance: hour of day it starts (0-23)
+	Flag   string `json:"flag,omitempty" yaml:"flag,omitempty"`     // Starts once, as soon as this session flag is set
+}
+
+// DefaultWorldEventDuration is how many hours a world event lasts unless it says otherwise.
+const DefaultWorldEventDuration = 24
+
+// Hours returns how long the event lasts.
+func (ev *WorldEvent) Hours() int {
+	if ev.Duration > 0 {
+		return ev.Duration
+	}
+	return DefaultWorldEventDuration
+}
+
+// Affects reports whether the event applies at locationID.
+func (ev *WorldEvent) Affects(locationID string) bool {
+	return len(ev.Locations) == 0 || containsString(ev.Locations, locationID)
+}
+
+// Due reports whether a scheduled (Day) event starts on day at hour of day. Chance and
+// Flag events are never due; the scheduler rolls or checks them itself.
+func (t *WorldEventTrigger) Due(day, hour int) bool {
+	if t.Day == 0 || hour != t.Hour || day < t.Day {
+		return false
+	}
+	if day == t.Day {
+		return true
+	}
+	return t.Every > 0 && (day-t.Day)%t.Every == 0
+}
+
+// LoadWorldEvents reads every world event in fsys and checks the locations they reference.
+func LoadWorldEvents(fsys fs.FS, ws WorldSystem) (map[string]*WorldEvent, error) {
+	return loadContentDir(fsys, "world event", WorldEventSchema, func(ev *WorldEvent, fileID string) (string, error) {
+		if ev.ID == "" {
+			ev.ID = fileID
+		}
+		return ev.ID, ev.check(ws)
+	})
+}
+
+// check reports an ambiguous or out-of-range trigger and locations that don't exist in ws.
+func (ev *WorldEvent) check(ws WorldSystem) error {
+	var problems []error
+	t := ev.Trigger
+	set := 0
+	for _, on := range []bool{t.Day > 0, t.Chance > 0, t.Flag != ""} {
+		if on {
+			set++
+		}
+	}
+	if set != 1 {
+		problems = append(problems, fmt.Errorf("world event '%s' trigger must set exactly one of day, chance and flag", ev.ID))
+	}
+	if t.Every < 0 || (t.Every > 0 && t.Day == 0) {
+		problems = append(problems, fmt.Errorf("world event '%s' trigger.every must be positive and used with day", ev.ID))
+	}
+	if t.Chance < 0 || t.Chance > 100 || t.Hour < 0 || t.Hour > 23 {
+		problems = append(problems, fmt.Errorf("world event '%s' trigger.chance must be 0-100 and trigger.hour 0-23", ev.ID))
+	}
+	for _, id := range append(append([]string{}, ev.Locations...), ev.ClosedExits...) {
+		if _, err := ws.GetLocation(id); err != nil {
+			problems = append(problems, fmt.Errorf("world event '%s': %w", ev.ID, err))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// WorldEvents loads the archive's world events, checking them against ws. Archives
+// without an events directory have none.
+func (a *Archive) WorldEvents(ws WorldSystem) (map[string]*WorldEvent, error) {
+	if _, err := fs.Stat(a.FS, ArchiveWorldEventsDir); errors.Is(err, fs.ErrNotExist) {
+		return map[string]*WorldEvent{}, nil
+	}
+	eventFS, err := fs.Sub(a.FS, ArchiveWorldEventsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in world archive: %w", ArchiveWorldEventsDir, err)
+	}
+	return LoadWorldEvents(eventFS, ws)
+}